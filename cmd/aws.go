@@ -33,6 +33,7 @@ func awsFetchCmdBuilder(cfg *iqshell.Config) *cobra.Command {
 	cmd.Flags().Int64VarP(&info.AwsBucketInfo.MaxKeys, "max-keys", "n", 1000, "list AWS bucket with numbers of keys returned each time limited by this number if set")
 	cmd.Flags().StringVarP(&info.AwsBucketInfo.CToken, "continuation-token", "m", "", "AWS list continuation token")
 	cmd.Flags().IntVarP(&info.BatchInfo.WorkerCount, "thread-count", "c", 20, "maximum of fetch thread")
+	setFlowCmdAdaptiveConcurrencyFlags(cmd, &info.BatchInfo.Info)
 
 	// 没有实际作用
 	cmd.Flags().StringVarP(&info.Host, "up-host", "u", "", "Qiniu fetch up host, deprecated")
@@ -44,6 +45,7 @@ func awsFetchCmdBuilder(cfg *iqshell.Config) *cobra.Command {
 	cmd.Flags().StringVarP(&info.BatchInfo.FailExportFilePath, "failure-list", "e", "", "error fetch key list")
 	cmd.Flags().BoolVarP(&info.BatchInfo.EnableRecord, "enable-record", "", false, "record work progress, and do from last progress while retry")
 	cmd.Flags().BoolVarP(&info.BatchInfo.RecordRedoWhileError, "record-redo-while-error", "", false, "when re-executing the command and checking the command task progress record, if a task has already been done and failed, the task will be re-executed. The default is false, and the task will not be re-executed when it detects that the task fails")
+	cmd.Flags().StringVarP(&info.BatchInfo.RetryFailedFrom, "retry-failed-from", "", "", "retry only the failed works of a previous run; the value is a failure list file exported by --failure-list or a job id (see: qshell job ls)")
 
 	return cmd
 }
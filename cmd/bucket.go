@@ -5,17 +5,23 @@ import (
 
 	"github.com/qiniu/qshell/v2/docs"
 	"github.com/qiniu/qshell/v2/iqshell"
+	accountOperations "github.com/qiniu/qshell/v2/iqshell/common/account/operations"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/storage/bucket/operations"
 )
 
 var domainsCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	var info = operations.ListDomainInfo{}
+	var accountsInfo = accountOperations.MultiAccountInfo{}
 	var cmd = &cobra.Command{
 		Use:   "domains <Bucket>",
 		Short: "Get all domains of the bucket",
 		Run: func(cmd *cobra.Command, args []string) {
 			cfg.CmdCfg.CmdId = docs.DomainsType
+			if accountsInfo.Enable() {
+				accountOperations.RunWithAccounts(cfg, accountsInfo)
+				return
+			}
 			if len(args) > 0 {
 				info.Bucket = args[0]
 			}
@@ -23,23 +29,30 @@ var domainsCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 		},
 	}
 	cmd.Flags().BoolVarP(&info.Detail, "detail", "", false, "print detail info for domain")
+	setMultiAccountFlags(cmd, &accountsInfo)
 	return cmd
 }
 
 var bucketCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	var info = operations.GetBucketInfo{}
+	var accountsInfo = accountOperations.MultiAccountInfo{}
 	var cmd = &cobra.Command{
 		Use:   "bucket <Bucket>",
 		Short: "Get bucket info",
 		Long:  `Get bucket info`,
 		Run: func(cmd *cobra.Command, args []string) {
 			cfg.CmdCfg.CmdId = docs.BucketType
+			if accountsInfo.Enable() {
+				accountOperations.RunWithAccounts(cfg, accountsInfo)
+				return
+			}
 			if len(args) > 0 {
 				info.Bucket = args[0]
 			}
 			operations.GetBucket(cfg, info)
 		},
 	}
+	setMultiAccountFlags(cmd, &accountsInfo)
 	return cmd
 }
 
@@ -109,12 +122,18 @@ var listBucketCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 
 var listBucketCmd2Builder = func(cfg *iqshell.Config) *cobra.Command {
 	var info = operations.ListInfo{}
+	var accountsInfo = accountOperations.MultiAccountInfo{}
 	var cmd = &cobra.Command{
 		Use:   "listbucket2 <Bucket>",
 		Short: "List all the files in the bucket",
 		Long:  "List all the files in the bucket to stdout if output file not specified. Each row of data information is displayed in the following order by default:\n Key\tFileSize\tHash\tPutTime\tMimeType\tFileType\tEndUser",
 		Run: func(cmd *cobra.Command, args []string) {
 			cfg.CmdCfg.CmdId = docs.ListBucket2Type
+			if accountsInfo.Enable() {
+				accountsInfo.OutputFile = info.SaveToFile
+				accountOperations.RunWithAccounts(cfg, accountsInfo)
+				return
+			}
 			if len(args) > 0 {
 				info.Bucket = args[0]
 			}
@@ -144,6 +163,7 @@ var listBucketCmd2Builder = func(cfg *iqshell.Config) *cobra.Command {
 	cmd.Flags().StringVarP(&info.MimeTypes, "mimetypes", "", "", "Specify mimetype, separated by comma, all files will be listed according to the prefix and then filtered.")
 	cmd.Flags().StringVarP(&info.MinFileSize, "min-file-size", "", "", "Specify min file size, all files will be listed according to the prefix and then filtered.")
 	cmd.Flags().StringVarP(&info.MaxFileSize, "max-file-size", "", "", "Specify max file size, all files will be listed according to the prefix and then filtered.")
+	cmd.Flags().StringVarP(&info.Filter, "filter", "", "", `filter expression evaluated per file, all files will be listed according to the prefix and then filtered. e.g. 'key =~ "^logs/2024-.*\.gz$" && size > 1MB && !(mime == "text/plain")'. fields: key, size, hash, md5, mime, type, status, putTime, endUser; operators: ==, !=, >, >=, <, <=, =~, !~, glob, &&, ||, !`)
 
 	cmd.Flags().BoolVarP(&info.AppendMode, "append", "a", false, "result append to file instead of overwriting")
 	cmd.Flags().BoolVarP(&info.Readable, "readable", "r", false, "present file size with human readable format")
@@ -152,9 +172,12 @@ var listBucketCmd2Builder = func(cfg *iqshell.Config) *cobra.Command {
 
 	cmd.Flags().IntVarP(&info.ApiLimit, "api-limit", "", 1000, "one enumeration will make multiple requests, and the maximum number of items returned for each request; in the range 1-1000.")
 	cmd.Flags().BoolVarP(&info.EnableRecord, "enable-record", "", false, "record the execution status of the listbucket2 command. When the listbucket2 command is executed next time, the marker will be automatically filled and the listbucket2 will continue. Enabling this option will automatically enable append (see the --append option for details). The id of the record is related to the bucket where the file is located, the prefix listed, and the path where the file is saved.")
+	cmd.Flags().IntVarP(&info.ShardConcurrency, "shard-concurrency", "", 0, "list the bucket in prefix shards concurrently when greater than 1. shards are discovered by --shard-delimiter and every shard keeps its own marker in the record, so an interrupted listing with --enable-record resumes every shard. the output is NOT sorted by key. ignored when --marker is set.")
+	cmd.Flags().StringVarP(&info.ShardDelimiter, "shard-delimiter", "", "/", "delimiter used to discover prefix shards when --shard-concurrency is greater than 1.")
 
 	cmd.Flags().StringVarP(&info.OutputFieldsSep, "output-fields-sep", "", data.DefaultLineSeparate, "Each line needs to display the delimiter of the file information.")
 	cmd.Flags().StringVarP(&info.ShowFields, "show-fields", "", "", "The file attributes to be displayed on each line, separated by commas. Optional range: Key, Hash, FileSize, PutTime, MimeType, FileType, EndUser.")
+	setMultiAccountFlags(cmd, &accountsInfo)
 
 	return cmd
 }
@@ -163,12 +186,45 @@ func init() {
 	registerLoader(bucketCmdLoader)
 }
 
+var bucketStatsCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.StatsInfo{}
+	var cmd = &cobra.Command{
+		Use:   "bucket-stats <Bucket>",
+		Short: "Aggregate the files of the bucket into a report by storage class, mime type, prefix, size and age",
+		Example: `qshell bucket-stats <Bucket> --prefix-depth 1 --top 20
+qshell bucket-stats <Bucket> --age-buckets 90 --format csv -o stats.csv
+qshell bucket-stats <Bucket> --from-list <ListBucketResultFile> --format json`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.BucketStatsType
+			if len(args) > 0 {
+				info.Bucket = args[0]
+			}
+			operations.Stats(cfg, info)
+		},
+	}
+	cmd.Flags().StringVarP(&info.Prefix, "prefix", "p", "", "only stat files with the prefix")
+	cmd.Flags().StringVarP(&info.Filter, "filter", "", "", "filter expression evaluated per file, same as the --filter of listbucket2")
+	cmd.Flags().StringVarP(&info.FromListFile, "from-list", "", "", "stat the output file of listbucket2 instead of listing the bucket")
+	cmd.Flags().StringVarP(&info.ItemSeparate, "sep", "F", "\t", "separator of fields in each line of the --from-list file")
+	cmd.Flags().IntVarP(&info.PrefixDepth, "prefix-depth", "", 1, "depth of prefixes to group files by, 0 means not to group by prefix")
+	cmd.Flags().StringVarP(&info.PrefixDelimiter, "prefix-delimiter", "", "/", "delimiter of prefixes")
+	cmd.Flags().IntVarP(&info.Top, "top", "", 20, "only show the top N prefixes and mime types by bytes, the rest are merged into (others); 0 means show all")
+	cmd.Flags().StringVarP(&info.SizeBuckets, "size-buckets", "", "1KB,64KB,1MB,16MB,128MB,1GB", "boundaries of the size histogram, separated by comma")
+	cmd.Flags().StringVarP(&info.AgeBuckets, "age-buckets", "", "7,30,90,180,365", "boundaries of the put time age histogram in days, separated by comma")
+	cmd.Flags().StringVarP(&info.Format, "format", "", "table", "format of the report, one of table, json and csv")
+	cmd.Flags().StringVarP(&info.SaveToFile, "outfile", "o", "", "save the report to file, by default the report is written to stdout")
+	cmd.Flags().IntVarP(&info.MaxRetry, "max-retry", "", 20, "max retries when listing the bucket fails, -1 means unlimited")
+	return cmd
+}
+
 func bucketCmdLoader(superCmd *cobra.Command, cfg *iqshell.Config) {
 	superCmd.AddCommand(
 		bucketCmdBuilder(cfg),
 		mkBucketCmdBuilder(cfg),
 		listBucketCmdBuilder(cfg),
 		listBucketCmd2Builder(cfg),
+		bucketStatsCmdBuilder(cfg),
 		domainsCmdBuilder(cfg),
 	)
 }
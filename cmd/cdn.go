@@ -3,6 +3,7 @@ package cmd
 import (
 	"github.com/qiniu/qshell/v2/docs"
 	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/cdn"
 	"github.com/qiniu/qshell/v2/iqshell/cdn/operations"
 	"github.com/spf13/cobra"
 )
@@ -23,6 +24,7 @@ var cdnPrefetchCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	cmd.Flags().StringVarP(&info.UrlListFile, "input-file", "i", "", "input file")
 	cmd.Flags().IntVar(&info.QpsLimit, "qps", 0, "qps limit for http call, default no limit")
 	cmd.Flags().IntVarP(&info.SizeLimit, "size", "s", 50, "max item-size pre commit, max is 50, default 50")
+	setCdnWaitFlags(cmd, &info.WaitInfo)
 
 	return cmd
 }
@@ -44,17 +46,146 @@ var cdnRefreshCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	cmd.Flags().StringVarP(&info.ItemListFile, "input-file", "i", "", "input file")
 	cmd.Flags().IntVar(&info.QpsLimit, "qps", 0, "qps limit for http call, default no limit")
 	cmd.Flags().IntVarP(&info.SizeLimit, "size", "s", 50, "max item-size pre commit, max is 50, default 50")
+	setCdnWaitFlags(cmd, &info.WaitInfo)
 
 	return cmd
 }
 
+var cdnStatusCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.StatusInfo{}
+	var cmd = &cobra.Command{
+		Use:   "cdnstatus <JobId>",
+		Short: "Query the status of the urls in a cdnrefresh or cdnprefetch job",
+		Long:  "Query the status of the urls in a cdnrefresh or cdnprefetch job, the job id is printed by cdnrefresh/cdnprefetch or listed by job ls",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.CdnStatusType
+			if len(args) > 0 {
+				info.JobId = args[0]
+			}
+			operations.Status(cfg, info)
+		},
+	}
+
+	setCdnWaitFlags(cmd, &info.WaitInfo)
+
+	return cmd
+}
+
+var cdnLogsCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.LogsInfo{}
+	var cmd = &cobra.Command{
+		Use:   "cdnlogs <Domain> [<Domain>...] --from <Day|Hour> [--to <Day|Hour>]",
+		Short: "List and download the hourly access log archives of cdn domains",
+		Long:  "List and download the hourly access log archives of cdn domains, the format of day is 2006-01-02 and the format of hour is 2006-01-02-15",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.CdnLogsType
+			info.Domains = args
+			operations.Logs(cfg, info)
+		},
+	}
+
+	cmd.Flags().StringVarP(&info.From, "from", "", "", "the start day or hour of the logs, such as 2006-01-02 or 2006-01-02-15")
+	cmd.Flags().StringVarP(&info.To, "to", "", "", "the end day or hour (inclusive) of the logs, default is the same as --from")
+	cmd.Flags().StringVarP(&info.DestDir, "dest-dir", "o", ".", "the dir to save the logs, the logs are saved in <DestDir>/<Domain>/")
+	cmd.Flags().BoolVarP(&info.ListOnly, "list", "", false, "only list the log files, don't download")
+	cmd.Flags().IntVarP(&info.WorkerCount, "worker", "c", 4, "the count of concurrent downloads")
+
+	return cmd
+}
+
+var cdnLogsReportCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.LogsReportInfo{}
+	var cmd = &cobra.Command{
+		Use:   "cdnlogs-report <LogFileOrDir> [<LogFileOrDir>...]",
+		Short: "Analyze the cdn access logs downloaded by cdnlogs",
+		Long:  "Analyze the cdn access logs downloaded by cdnlogs, report the top urls, status codes, requests and bytes by hour, top referers and top ips",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.CdnLogsReportType
+			info.Paths = args
+			operations.LogsReport(cfg, info)
+		},
+	}
+
+	cmd.Flags().IntVarP(&info.Top, "top", "n", 10, "the count of the top urls, referers and ips")
+	cmd.Flags().BoolVarP(&info.IpLocation, "ip-location", "", false, "query the location of the top ips")
+
+	return cmd
+}
+
+func setCdnWaitFlags(cmd *cobra.Command, info *operations.WaitInfo) {
+	cmd.Flags().BoolVarP(&info.Wait, "wait", "", false, "wait until all the urls are processed successfully or failed")
+	cmd.Flags().IntVarP(&info.WaitInterval, "wait-interval", "", 10, "the interval of the status query, unit: second")
+	cmd.Flags().IntVarP(&info.WaitTimeout, "wait-timeout", "", 0, "the max time to wait, 0 means no limit, unit: second")
+}
+
+func setCdnRefreshFlags(cmd *cobra.Command, info *operations.AutoRefreshInfo) {
+	cmd.Flags().StringVarP(&info.Domain, "cdn-refresh-domain", "", "", "refresh the cdn cache of the modified files on this domain, such as cdn.example.com or https://cdn.example.com; query the refresh status with cdnstatus")
+	cmd.Flags().IntVarP(&info.QpsLimit, "cdn-refresh-qps", "", 0, "the qps limit of the cdn refresh requests, 0 means no limit")
+}
+
+func setCdnTrafficFlags(cmd *cobra.Command, info *operations.TrafficInfo) {
+	cmd.Flags().StringVarP(&info.From, "from", "", "", "the start day of the query, such as 2006-01-02")
+	cmd.Flags().StringVarP(&info.To, "to", "", "", "the end day (inclusive) of the query, default is the same as --from")
+	cmd.Flags().StringVarP(&info.Granularity, "granularity", "g", "day", "the granularity of the query, one of 5min, hour and day")
+	cmd.Flags().StringVarP(&info.Format, "format", "", "table", "format of the report, one of table, json and csv")
+	cmd.Flags().StringVarP(&info.SaveToFile, "outfile", "o", "", "save the report to file, by default the report is written to stdout")
+}
+
 func init() {
 	registerLoader(cdnCmdLoader)
 }
 
+var cdnFluxCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.TrafficInfo{}
+	var cmd = &cobra.Command{
+		Use:   "cdnflux <Domain> [<Domain>...] --from <Day> [--to <Day>]",
+		Short: "Query the flux of cdn domains",
+		Long:  "Query the flux (unit: byte) of cdn domains by the granularity of 5min, hour or day, the format of day is 2006-01-02",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.CdnFluxType
+			info.Type = cdn.TrafficTypeFlux
+			info.Domains = args
+			operations.Traffic(cfg, info)
+		},
+	}
+
+	setCdnTrafficFlags(cmd, &info)
+
+	return cmd
+}
+
+var cdnBandwidthCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.TrafficInfo{}
+	var cmd = &cobra.Command{
+		Use:   "cdnbandwidth <Domain> [<Domain>...] --from <Day> [--to <Day>]",
+		Short: "Query the bandwidth of cdn domains",
+		Long:  "Query the bandwidth (unit: bps) of cdn domains by the granularity of 5min, hour or day, the format of day is 2006-01-02",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.CdnBandwidthType
+			info.Type = cdn.TrafficTypeBandwidth
+			info.Domains = args
+			operations.Traffic(cfg, info)
+		},
+	}
+
+	setCdnTrafficFlags(cmd, &info)
+
+	return cmd
+}
+
 func cdnCmdLoader(superCmd *cobra.Command, cfg *iqshell.Config) {
 	superCmd.AddCommand(
 		cdnPrefetchCmdBuilder(cfg),
 		cdnRefreshCmdBuilder(cfg),
+		cdnStatusCmdBuilder(cfg),
+		cdnLogsCmdBuilder(cfg),
+		cdnLogsReportCmdBuilder(cfg),
+		cdnFluxCmdBuilder(cfg),
+		cdnBandwidthCmdBuilder(cfg),
 	)
 }
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"github.com/qiniu/qshell/v2/docs"
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/cdn/operations"
+	"github.com/spf13/cobra"
+)
+
+var domainCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "domain",
+		Short: "Create, inspect, configure, enable and disable the domains of buckets",
+		Args:  cobra.MaximumNArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.DomainType
+			operations.Domain(cfg)
+		},
+	}
+	return cmd
+}
+
+var domainCreateCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.DomainCreateInfo{}
+	var cmd = &cobra.Command{
+		Use:   "create <Domain> <Bucket>",
+		Short: "Create a cdn domain whose origin is the bucket, or bind an origin domain to the bucket with --origin",
+		Example: `qshell domain create cdn.example.com mybucket
+qshell domain create cdn.example.com mybucket --cert-id 5f0c0a6e8d4e2a0001000000 --force-https
+qshell domain create src.example.com mybucket --origin`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.DomainType
+			if len(args) > 1 {
+				info.Domain = args[0]
+				info.Bucket = args[1]
+			}
+			operations.CreateDomain(cfg, info)
+		},
+	}
+	cmd.Flags().BoolVarP(&info.Origin, "origin", "", false, "bind an origin domain to the bucket instead of creating a cdn domain")
+	cmd.Flags().StringVarP(&info.Platform, "platform", "", "", "platform of the cdn domain, one of web, download and vod, default is web")
+	cmd.Flags().StringVarP(&info.GeoCover, "geo-cover", "", "", "geo cover of the cdn domain, one of china, foreign and global, default is china")
+	cmd.Flags().StringVarP(&info.CertId, "cert-id", "", "", "id of the certificate, the cdn domain is created as https domain when specified")
+	cmd.Flags().BoolVarP(&info.ForceHttps, "force-https", "", false, "redirect http requests to https, only for https domain")
+	cmd.Flags().StringVarP(&info.ConfigFile, "config", "", "", "json file of the cdn domain config, the format is the same as the file saved by domain show -o")
+	return cmd
+}
+
+var domainShowCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.DomainShowInfo{}
+	var cmd = &cobra.Command{
+		Use:   "show <Domain>",
+		Short: "Show the config of the cdn domain, such as cname, state, source, https, referer, ip acl and cache",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.DomainType
+			if len(args) > 0 {
+				info.Domain = args[0]
+			}
+			operations.ShowDomain(cfg, info)
+		},
+	}
+	cmd.Flags().StringVarP(&info.SaveToFile, "outfile", "o", "", "save the config to json file, which can be modified and used by domain update")
+	return cmd
+}
+
+var domainUpdateCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.DomainUpdateInfo{}
+	var cmd = &cobra.Command{
+		Use:   "update <Domain> <ConfigFile>",
+		Short: "Update the source, cache, referer, ipACL and https config of the cdn domain from json file",
+		Example: `qshell domain show cdn.example.com -o domain.json
+qshell domain update cdn.example.com domain.json -y`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.DomainType
+			if len(args) > 1 {
+				info.Domain = args[0]
+				info.ConfigFile = args[1]
+			}
+			operations.UpdateDomain(cfg, info)
+		},
+	}
+	cmd.Flags().BoolVarP(&info.Force, "force", "y", false, "update the config without confirmation")
+	return cmd
+}
+
+var domainOnlineCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.DomainStateInfo{}
+	var cmd = &cobra.Command{
+		Use:   "online <Domain>",
+		Short: "Enable the cdn domain",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.DomainType
+			if len(args) > 0 {
+				info.Domain = args[0]
+			}
+			operations.OnlineDomain(cfg, info)
+		},
+	}
+	return cmd
+}
+
+var domainOfflineCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.DomainStateInfo{}
+	var cmd = &cobra.Command{
+		Use:   "offline <Domain>",
+		Short: "Disable the cdn domain, the domain stops serving after disabled",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.DomainType
+			if len(args) > 0 {
+				info.Domain = args[0]
+			}
+			operations.OfflineDomain(cfg, info)
+		},
+	}
+	cmd.Flags().BoolVarP(&info.Force, "force", "y", false, "disable the domain without confirmation")
+	return cmd
+}
+
+func init() {
+	registerLoader(domainCmdLoader)
+}
+
+func domainCmdLoader(superCmd *cobra.Command, cfg *iqshell.Config) {
+	domainCmd := domainCmdBuilder(cfg)
+	domainCmd.AddCommand(
+		domainCreateCmdBuilder(cfg),  // 创建 CDN 域名或绑定源站域名
+		domainShowCmdBuilder(cfg),    // 查看 CDN 域名配置
+		domainUpdateCmdBuilder(cfg),  // 根据配置文件更新 CDN 域名配置
+		domainOnlineCmdBuilder(cfg),  // 启用 CDN 域名
+		domainOfflineCmdBuilder(cfg), // 停用 CDN 域名
+	)
+	superCmd.AddCommand(domainCmd)
+}
@@ -30,10 +30,12 @@ have already in local disk and need to skip download or not.`,
 	}
 	cmd.Flags().StringVarP(&info.SuccessExportFilePath, "success-list", "s", "", "specifies the file path where the successful file list is saved")
 	cmd.Flags().StringVarP(&info.FailExportFilePath, "failure-list", "e", "", "specifies the file path where the failure file list is saved")
+	cmd.Flags().StringVarP(&info.RetryFailedFrom, "retry-failed-from", "", "", "retry only the failed works of a previous run; the value is a failure list file exported by --failure-list or a job id (see: qshell job ls)")
 
 	cmd.Flags().IntVarP(&info.WorkerCount, "thread-count", "c", 5, "num of threads to download files")
 	cmd.Flags().IntVarP(&info.WorkerCount, "thread", "", 5, "num of threads to download files")
 	_ = cmd.Flags().MarkDeprecated("thread", "use --thread-count instead") // 废弃 thread-count
+	setFlowCmdAdaptiveConcurrencyFlags(cmd, &info.Info)
 
 	return cmd
 }
@@ -66,16 +68,19 @@ have already in local disk and need to skip download or not.`,
 	}
 	cmd.Flags().StringVarP(&info.SuccessExportFilePath, "success-list", "s", "", "specifies the file path where the successful file list is saved")
 	cmd.Flags().StringVarP(&info.FailExportFilePath, "failure-list", "e", "", "specifies the file path where the failure file list is saved")
+	cmd.Flags().StringVarP(&info.RetryFailedFrom, "retry-failed-from", "", "", "retry only the failed works of a previous run; the value is a failure list file exported by --failure-list or a job id (see: qshell job ls)")
 
 	cmd.Flags().IntVarP(&info.WorkerCount, "thread-count", "c", 5, "num of threads to download files")
 	cmd.Flags().IntVarP(&info.WorkerCount, "thread", "", 5, "num of threads to download files")
 	_ = cmd.Flags().MarkDeprecated("thread", "use --thread-count instead") // 废弃 thread-count
+	setFlowCmdAdaptiveConcurrencyFlags(cmd, &info.Info)
 
 	cmd.Flags().StringVarP(&info.DownloadCfg.DestDir, "dest-dir", "", "", "local storage path, full path. default current dir")
 	cmd.Flags().BoolVarP(&info.DownloadCfg.GetFileApi, "get-file-api", "", false, "public storage cloud not support, private storage cloud support when has getfile api.")
 	cmd.Flags().StringVarP(&info.DownloadCfg.Bucket, "bucket", "", "", "storage bucket")
 	cmd.Flags().StringVarP(&info.DownloadCfg.Prefix, "prefix", "", "", "only download files with the specified prefix")
 	cmd.Flags().StringVarP(&info.DownloadCfg.Suffixes, "suffixes", "", "", "only download files with the specified suffixes")
+	cmd.Flags().StringVarP(&info.DownloadCfg.Filter, "filter", "", "", `only download files matching the filter expression, e.g. 'key =~ "^logs/.*\.gz$" && size > 1MB'; the syntax is the same as the --filter option of listbucket2`)
 	cmd.Flags().StringVarP(&info.DownloadCfg.KeyFile, "key-file", "", "", "configure a file and specify the keys to be downloaded; if not configured, download all the files in the bucket")
 	cmd.Flags().StringVarP(&info.DownloadCfg.SavePathHandler, "save-path-handler", "", "", "specify a callback function; when constructing the save path of the file, this option is preferred for construction. If not configured, $dest_dir + $ file separator + $Key will be used for construction. This function is implemented through the template of the Go language. The func command is used for function verification. For the specific syntax, please refer to the description of the func command.")
 	cmd.Flags().BoolVarP(&info.DownloadCfg.CheckHash, "check-hash", "", false, "whether to verify the hash, if it is enabled, it may take a long time")
@@ -94,6 +99,9 @@ have already in local disk and need to skip download or not.`,
 	cmd.Flags().Int64VarP(&info.DownloadCfg.SliceFileSizeThreshold, "slice-file-size-threshold", "", 40*utils.MB, "file threshold for downloading slices. When slice downloading is enabled and the file size is greater than this threshold, slice downloading will be enabled; unit:B")
 	cmd.Flags().BoolVarP(&info.DownloadCfg.RemoveTempWhileError, "remove-temp-while-error", "", false, "when the download encounters an error, delete the previously downloaded part of the file cache")
 	cmd.Flags().StringVarP(&info.DownloadCfg.RecordRoot, "record-root", "", "", "path to save download record information, including log files and download progress files; the default is download directory")
+	cmd.Flags().BoolVarP(&info.DownloadCfg.DisableVerify, "disable-verify", "", false, "do not verify the downloaded files; by default every downloaded file is verified against the qetag of the object, and against its md5 when the server has recorded it, mismatched files are moved to the quarantine dir and the download fails")
+	cmd.Flags().StringVarP(&info.DownloadCfg.QuarantineDir, "quarantine-dir", "", "", "directory to move the files that fail verification to; the default is the quarantine dir in the job dir")
+	cmd.Flags().StringVarP(&info.DownloadCfg.VerifyExportFilePath, "verify-export", "", "", "specifies the file path where the verification report is saved, one line per verified file")
 
 	cmd.Flags().StringVarP(&LogLevel, "log-level", "", "debug", "download log output level, optional values are debug,info,warn and error")
 	cmd.Flags().StringVarP(&LogFile, "log-file", "", "", "the output file of the download log is output to the file specified by record_root by default, and the specific file path can be seen in the terminal output")
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"github.com/qiniu/qshell/v2/docs"
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/storage/bucket/operations"
+	"github.com/spf13/cobra"
+)
+
+var inventoryCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "inventory",
+		Short: "Create sorted snapshots of bucket files and diff between snapshots",
+		Args:  cobra.MaximumNArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.InventoryType
+			operations.Inventory(cfg)
+		},
+	}
+	return cmd
+}
+
+var inventorySnapshotCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.InventorySnapshotInfo{}
+	var cmd = &cobra.Command{
+		Use:   "snapshot <Bucket>",
+		Short: "Create a sorted snapshot of files with hash, size, put time and type",
+		Example: `qshell inventory snapshot <Bucket> -o bucket-20240101.inventory
+qshell inventory snapshot <Bucket> --from-list <ListBucketResultFile> -o bucket-20231201.inventory`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.InventoryType
+			if len(args) > 0 {
+				info.Bucket = args[0]
+			}
+			operations.InventorySnapshot(cfg, info)
+		},
+	}
+	cmd.Flags().StringVarP(&info.Prefix, "prefix", "p", "", "only snapshot files with the prefix")
+	cmd.Flags().StringVarP(&info.FromListFile, "from-list", "", "", "import the snapshot from the output file of listbucket2 instead of listing the bucket")
+	cmd.Flags().StringVarP(&info.ItemSeparate, "sep", "F", "\t", "separator of fields in each line of the --from-list file")
+	cmd.Flags().StringVarP(&info.SaveToFile, "outfile", "o", "", "snapshot file path, an existing file will be overwritten")
+	cmd.Flags().IntVarP(&info.MaxRetry, "max-retry", "", 20, "max retries when listing the bucket fails, -1 means unlimited")
+	return cmd
+}
+
+var inventoryDiffCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.InventoryDiffInfo{}
+	var cmd = &cobra.Command{
+		Use:     "diff <SnapshotA> <SnapshotB>",
+		Short:   "Report added, removed and modified files from SnapshotA to SnapshotB in jsonl",
+		Example: `qshell inventory diff bucket-20231201.inventory bucket-20240101.inventory -o diff.jsonl`,
+		Args:    cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.InventoryType
+			if len(args) > 1 {
+				info.OldSnapshot = args[0]
+				info.NewSnapshot = args[1]
+			}
+			operations.InventoryDiff(cfg, info)
+		},
+	}
+	cmd.Flags().StringVarP(&info.SaveToFile, "outfile", "o", "", "save the diff to file, by default the diff is written to stdout")
+	return cmd
+}
+
+func init() {
+	registerLoader(inventoryCmdLoader)
+}
+
+func inventoryCmdLoader(superCmd *cobra.Command, cfg *iqshell.Config) {
+	inventoryCmd := inventoryCmdBuilder(cfg)
+	inventoryCmd.AddCommand(
+		inventorySnapshotCmdBuilder(cfg), // 创建空间文件快照
+		inventoryDiffCmdBuilder(cfg),     // 比较两个快照
+	)
+	superCmd.AddCommand(inventoryCmd)
+}
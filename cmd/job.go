@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"github.com/qiniu/qshell/v2/docs"
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/job/operations"
+	"github.com/spf13/cobra"
+)
+
+var jobCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "job",
+		Short: "List, inspect, resume and remove persisted batch jobs",
+		Args:  cobra.MaximumNArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.JobType
+			operations.Job(cfg)
+		},
+	}
+	return cmd
+}
+
+var jobLsCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.ListInfo{}
+	var cmd = &cobra.Command{
+		Use:   "ls",
+		Short: "List batch jobs",
+		Args:  cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.JobType
+			operations.List(cfg, info)
+		},
+	}
+	cmd.Flags().BoolVarP(&info.AllUsers, "all-users", "", false, "list jobs of all local users, by default only list jobs of current user")
+	return cmd
+}
+
+var jobShowCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.ShowInfo{}
+	var cmd = &cobra.Command{
+		Use:   "show <JobId>",
+		Short: "Show job information and failed works in job records",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.JobType
+			if len(args) > 0 {
+				info.JobId = args[0]
+			}
+			operations.Show(cfg, info)
+		},
+	}
+	cmd.Flags().BoolVarP(&info.AllUsers, "all-users", "", false, "find job in jobs of all local users")
+	return cmd
+}
+
+var jobResumeCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.ResumeInfo{}
+	var cmd = &cobra.Command{
+		Use:   "resume <JobId>",
+		Short: "Resume job with its original arguments",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.JobType
+			if len(args) > 0 {
+				info.JobId = args[0]
+			}
+			operations.Resume(cfg, info)
+		},
+	}
+	cmd.Flags().BoolVarP(&info.AllUsers, "all-users", "", false, "find job in jobs of all local users")
+	return cmd
+}
+
+var jobRmCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.RemoveInfo{}
+	var cmd = &cobra.Command{
+		Use:   "rm [<JobId>...]",
+		Short: "Remove job data",
+		Example: `qshell job rm 0123456789abcdef0123456789abcdef
+qshell job rm --before 30 --all-users`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.JobType
+			info.JobIds = args
+			operations.Remove(cfg, info)
+		},
+	}
+	cmd.Flags().IntVarP(&info.BeforeDays, "before", "", 0, "remove jobs which are not active in the last <before> days")
+	cmd.Flags().BoolVarP(&info.AllUsers, "all-users", "", false, "remove jobs of all local users, by default only remove jobs of current user")
+	cmd.Flags().BoolVarP(&info.Force, "force", "y", false, "remove jobs without confirmation")
+	return cmd
+}
+
+func init() {
+	registerLoader(jobCmdLoader)
+}
+
+func jobCmdLoader(superCmd *cobra.Command, cfg *iqshell.Config) {
+	jobCmd := jobCmdBuilder(cfg)
+	jobCmd.AddCommand(
+		jobLsCmdBuilder(cfg),     // 列举 job
+		jobShowCmdBuilder(cfg),   // 查看 job 信息及失败的 work
+		jobResumeCmdBuilder(cfg), // 恢复执行 job
+		jobRmCmdBuilder(cfg),     // 删除 job
+	)
+	superCmd.AddCommand(jobCmd)
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"github.com/qiniu/qshell/v2/docs"
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/recorder/operations"
+	"github.com/spf13/cobra"
+)
+
+var recordCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "record",
+		Short: "Export and import job work records between record backends",
+		Args:  cobra.MaximumNArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.RecordType
+			operations.Record(cfg)
+		},
+	}
+	return cmd
+}
+
+var recordExportCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.ExportInfo{}
+	var cmd = &cobra.Command{
+		Use:   "export <RecordPath>",
+		Short: "Export job work records",
+		Example: `qshell record export ~/.qshell/users/<UserName>/batchdelete/<JobId>/.recorder -o records.jsonl
+qshell record export /mnt/shared/job/.recorder --backend shared-dir -o records.jsonl`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.RecordType
+			if len(args) > 0 {
+				info.RecordPath = args[0]
+			}
+			operations.Export(cfg, info)
+		},
+	}
+	cmd.Flags().StringVarP(&info.Backend, "backend", "", "leveldb", "backend of records, one of leveldb and shared-dir")
+	cmd.Flags().StringVarP(&info.SaveToFile, "outfile", "o", "", "export records to file, by default records are written to stdout")
+	return cmd
+}
+
+var recordImportCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.ImportInfo{}
+	var cmd = &cobra.Command{
+		Use:     "import <ImportFile> <RecordPath>",
+		Short:   "Import job work records exported by record export",
+		Example: `qshell record import records.jsonl /mnt/shared/job/.recorder --backend shared-dir`,
+		Args:    cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.RecordType
+			if len(args) > 1 {
+				info.ImportFile = args[0]
+				info.RecordPath = args[1]
+			}
+			operations.Import(cfg, info)
+		},
+	}
+	cmd.Flags().StringVarP(&info.Backend, "backend", "", "leveldb", "backend of records, one of leveldb and shared-dir")
+	return cmd
+}
+
+func init() {
+	registerLoader(recordCmdLoader)
+}
+
+func recordCmdLoader(superCmd *cobra.Command, cfg *iqshell.Config) {
+	recordCmd := recordCmdBuilder(cfg)
+	recordCmd.AddCommand(
+		recordExportCmdBuilder(cfg), // 导出 job 执行记录
+		recordImportCmdBuilder(cfg), // 导入 job 执行记录
+	)
+	superCmd.AddCommand(recordCmd)
+}
@@ -10,7 +10,9 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell"
 	"github.com/qiniu/qshell/v2/iqshell/common/config"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
 	"github.com/qiniu/qshell/v2/iqshell/common/version"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
 )
 
 const (
@@ -62,7 +64,13 @@ var rootCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	cmd.PersistentFlags().BoolVarP(&cfg.DDebugEnable, "ddebug", "D", false, "deep debug mode")
 	cmd.PersistentFlags().StringVarP(&cfg.ConfigFilePath, "config", "C", "", "set config file (default is $HOME/.qshell.json)")
 	cmd.PersistentFlags().BoolVarP(&cfg.Local, "local", "L", false, "use current directory qshell workspace (default is $HOME/.qshell)")
+	cmd.PersistentFlags().StringVarP(&cfg.Account, "account", "", "", "name of the local account used by this command instead of the current account, the current account is not changed")
+	cmd.PersistentFlags().StringVarP(&cfg.Profile, "profile", "", "", "name of the profile in credentials file ($HOME/.qshell/credentials) used by this command, see: qshell user --doc")
 	cmd.PersistentFlags().BoolVarP(&cfg.Document, "doc", "", false, "document of command")
+	cmd.PersistentFlags().StringVarP(&cfg.RecordBackend, "record-backend", "", "", "backend of job work records used to resume job, one of leveldb and shared-dir (default is leveldb)")
+	cmd.PersistentFlags().StringVarP(&cfg.RecordDir, "record-dir", "", "", "directory to save job work records when record backend is shared-dir, it can be a directory shared by multiple hosts")
+	cmd.PersistentFlags().StringVarP(&cfg.BandwidthLimit, "bandwidth-limit", "", "", "client-side bandwidth limit shared by all uploads and downloads, such as 50MB/s; it can be scheduled by time of day, such as 10MB/s@09:00-18:00,50MB/s")
+	cmd.PersistentFlags().StringVarP(&cfg.Output, "output", "", "", "output format of command result, one of text, json and jsonl. In json and jsonl mode, stdout only outputs machine-readable records and logs are written to stderr")
 	return cmd
 }
 
@@ -85,6 +93,8 @@ func Execute() {
 		_, _ = fmt.Fprintf(os.Stderr, "%v\n", err)
 		data.SetCmdStatusError()
 	}
+	output.Flush()
+	workspace.EndJob()
 
 	if !data.IsTestMode() && data.GetCmdStatus() != data.StatusOK {
 		os.Exit(data.GetCmdStatus())
@@ -5,16 +5,22 @@ import (
 
 	"github.com/qiniu/qshell/v2/docs"
 	"github.com/qiniu/qshell/v2/iqshell"
+	accountOperations "github.com/qiniu/qshell/v2/iqshell/common/account/operations"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object/operations"
 )
 
 var statCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	var info = operations.StatusInfo{}
+	var accountsInfo = accountOperations.MultiAccountInfo{}
 	var cmd = &cobra.Command{
 		Use:   "stat <Bucket> <Key>",
 		Short: "Get the basic info of a remote file",
 		Run: func(cmd *cobra.Command, args []string) {
 			cfg.CmdCfg.CmdId = docs.StatType
+			if accountsInfo.Enable() {
+				accountOperations.RunWithAccounts(cfg, accountsInfo)
+				return
+			}
 			if len(args) > 0 {
 				info.Bucket = args[0]
 			}
@@ -24,6 +30,7 @@ var statCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 			operations.Status(cfg, info)
 		},
 	}
+	setMultiAccountFlags(cmd, &accountsInfo)
 	return cmd
 }
 
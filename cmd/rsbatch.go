@@ -5,6 +5,8 @@ import (
 
 	"github.com/qiniu/qshell/v2/docs"
 	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object/operations"
 )
@@ -27,12 +29,14 @@ var batchStatCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	setBatchCmdWorkerCountFlags(cmd, &info.BatchInfo)
 	setBatchCmdMinWorkerCountFlags(cmd, &info.BatchInfo)
 	setBatchCmdWorkerCountIncreasePeriodFlags(cmd, &info.BatchInfo)
+	setBatchCmdAdaptiveConcurrencyFlags(cmd, &info.BatchInfo)
 	setBatchCmdSuccessExportFileFlags(cmd, &info.BatchInfo)
 	setBatchCmdFailExportFileFlags(cmd, &info.BatchInfo)
 	setBatchCmdResultExportFileFlags(cmd, &info.BatchInfo)
 	setBatchCmdForceFlags(cmd, &info.BatchInfo)
 	setBatchCmdEnableRecordFlags(cmd, &info.BatchInfo)
 	setBatchCmdRecordRedoWhileErrorFlags(cmd, &info.BatchInfo)
+	setBatchCmdRetryFailedFromFlags(cmd, &info.BatchInfo)
 	return cmd
 }
 
@@ -54,15 +58,41 @@ var batchForbiddenCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	setBatchCmdWorkerCountFlags(cmd, &info.BatchInfo)
 	setBatchCmdMinWorkerCountFlags(cmd, &info.BatchInfo)
 	setBatchCmdWorkerCountIncreasePeriodFlags(cmd, &info.BatchInfo)
+	setBatchCmdAdaptiveConcurrencyFlags(cmd, &info.BatchInfo)
 	setBatchCmdSuccessExportFileFlags(cmd, &info.BatchInfo)
 	setBatchCmdFailExportFileFlags(cmd, &info.BatchInfo)
 	setBatchCmdForceFlags(cmd, &info.BatchInfo)
 	setBatchCmdEnableRecordFlags(cmd, &info.BatchInfo)
 	setBatchCmdRecordRedoWhileErrorFlags(cmd, &info.BatchInfo)
+	setBatchCmdRetryFailedFromFlags(cmd, &info.BatchInfo)
+	setBatchCmdDryRunFlags(cmd, &info.BatchInfo)
 	cmd.Flags().BoolVarP(&info.UnForbidden, "reverse", "r", false, "unforbidden object in qiniu bucket")
 	return cmd
 }
 
+var batchApplyCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.BatchApplyInfo{}
+	var cmd = &cobra.Command{
+		Use:   "batchapply <PlanFile>",
+		Short: "Apply the plan file generated by batch command with --dry-run",
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.BatchApplyType
+			if len(args) > 0 {
+				info.PlanFile = args[0]
+			}
+			operations.BatchApply(cfg, info)
+		},
+	}
+	setBatchCmdWorkerCountFlags(cmd, &info.BatchInfo)
+	setBatchCmdMinWorkerCountFlags(cmd, &info.BatchInfo)
+	setBatchCmdWorkerCountIncreasePeriodFlags(cmd, &info.BatchInfo)
+	setBatchCmdAdaptiveConcurrencyFlags(cmd, &info.BatchInfo)
+	setBatchCmdSuccessExportFileFlags(cmd, &info.BatchInfo)
+	setBatchCmdFailExportFileFlags(cmd, &info.BatchInfo)
+	setBatchCmdForceFlags(cmd, &info.BatchInfo)
+	return cmd
+}
+
 var batchDeleteCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	var info = operations.BatchDeleteInfo{}
 	var cmd = &cobra.Command{
@@ -78,6 +108,7 @@ var batchDeleteCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 		},
 	}
 	setBatchCmdDefaultFlags(cmd, &info.BatchInfo)
+	setCdnRefreshFlags(cmd, &info.CdnRefresh)
 	return cmd
 }
 
@@ -135,6 +166,13 @@ var batchRestoreArCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 		},
 	}
 	setBatchCmdDefaultFlags(cmd, &info.BatchInfo)
+	cmd.Flags().BoolVarP(&info.Wait, "wait", "", false, "wait until the files are restored, the restore status is queried by batch stat periodically")
+	cmd.Flags().IntVarP(&info.WaitInterval, "wait-interval", "", 30, "the interval of the first restore status query, doubled after each query, unit: second")
+	cmd.Flags().IntVarP(&info.WaitMaxInterval, "wait-max-interval", "", 600, "the max interval of the restore status query, unit: second")
+	cmd.Flags().IntVarP(&info.WaitTimeout, "wait-timeout", "", 0, "the max time to wait, 0 means no limit, unit: second")
+	cmd.Flags().StringVarP(&info.ReadyListFile, "ready-list", "", "", "export the restored files to this file, in the format of listbucket2 output which can be used as the key file of qdownload2")
+	cmd.Flags().StringVarP(&info.DownloadConfig, "download-config", "", "", "the qdownload2 config file, download the files as soon as they are restored, implies --wait")
+	cmd.Flags().IntVarP(&info.DownloadWorker, "download-worker", "", 5, "the count of concurrent downloads when --download-config is set")
 	return cmd
 }
 
@@ -184,6 +222,34 @@ Lifecycle value must great than or equal to -1, unit: day.
 	return cmd
 }
 
+var lifecyclePlanCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.LifecyclePlanInfo{}
+	var cmd = &cobra.Command{
+		Use:   "lifecycle-plan <Bucket> --rule <Rule> [--rule <Rule>...] --output-dir <OutputDir>",
+		Short: "Simulate lifecycle rules against a listing and generate batchchlifecycle input files",
+		Long: `Simulate lifecycle rules against a listing of the bucket and generate batchchlifecycle input files with an estimated storage cost delta.
+Rule format: <Prefix>:<Action>=<Days>[,<Action>=<Days>...], action is one of ia, archive_ir, archive, deep_archive and delete, days count from the put time.
+The rule with the longest matched prefix is used, and only transitions to a colder storage class than the current one are planned.`,
+		Example: `qshell lifecycle-plan <Bucket> --rule 'logs/:ia=30,archive=180' --output-dir ./plan
+qshell lifecycle-plan <Bucket> --rule 'logs/:ia=30,archive=180' --rule ':ia=90' --from-list <ListBucketResultFile> --output-dir ./plan`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.LifecyclePlanType
+			if len(args) > 0 {
+				info.Bucket = args[0]
+			}
+			operations.LifecyclePlan(cfg, info)
+		},
+	}
+	cmd.Flags().StringArrayVarP(&info.Rules, "rule", "", nil, "lifecycle rule, can be set multiple times, format: <Prefix>:<Action>=<Days>[,<Action>=<Days>...]")
+	cmd.Flags().StringVarP(&info.OutputDir, "output-dir", "o", "", "directory to save the batchchlifecycle input files, the files which already satisfy the rules and the summary")
+	cmd.Flags().StringVarP(&info.Prices, "price", "", "", "storage price of each class used to estimate the cost, unit: CNY/GB/month, format: <Class>=<Price>[,<Class>=<Price>...], class is one of standard, ia, archive_ir, archive and deep_archive. default: "+object.DefaultLifecyclePrices)
+	cmd.Flags().StringVarP(&info.FromListFile, "from-list", "", "", "plan the output file of listbucket2 instead of listing the bucket")
+	cmd.Flags().StringVarP(&info.ItemSeparate, "sep", "F", "\t", "separator of fields in each line of the --from-list file")
+	cmd.Flags().IntVarP(&info.MaxRetry, "max-retry", "", 20, "max retries when listing the bucket fails, -1 means unlimited")
+	return cmd
+}
+
 var batchMoveCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	var info = operations.BatchMoveInfo{}
 	var cmd = &cobra.Command{
@@ -203,6 +269,7 @@ var batchMoveCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	}
 	setBatchCmdDefaultFlags(cmd, &info.BatchInfo)
 	setBatchCmdOverwriteFlags(cmd, &info.BatchInfo)
+	setCdnRefreshFlags(cmd, &info.CdnRefresh)
 	return cmd
 }
 
@@ -222,6 +289,7 @@ var batchRenameCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	}
 	setBatchCmdDefaultFlags(cmd, &info.BatchInfo)
 	setBatchCmdOverwriteFlags(cmd, &info.BatchInfo)
+	setCdnRefreshFlags(cmd, &info.CdnRefresh)
 	return cmd
 }
 
@@ -244,6 +312,7 @@ var batchCopyCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	}
 	setBatchCmdDefaultFlags(cmd, &info.BatchInfo)
 	setBatchCmdOverwriteFlags(cmd, &info.BatchInfo)
+	setCdnRefreshFlags(cmd, &info.CdnRefresh)
 	return cmd
 }
 
@@ -263,6 +332,7 @@ var batchSignCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	setBatchCmdResultExportFileFlags(cmd, &info.BatchInfo)
 	setBatchCmdEnableRecordFlags(cmd, &info.BatchInfo)
 	setBatchCmdRecordRedoWhileErrorFlags(cmd, &info.BatchInfo)
+	setBatchCmdRetryFailedFromFlags(cmd, &info.BatchInfo)
 	cmd.Flags().StringVarP(&info.Deadline, "deadline", "e", "3600", "deadline in seconds, default 3600")
 	return cmd
 }
@@ -289,26 +359,66 @@ var batchFetchCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	setBatchCmdInputFileFlags(cmd, &info.BatchInfo)
 	setBatchCmdEnableRecordFlags(cmd, &info.BatchInfo)
 	setBatchCmdRecordRedoWhileErrorFlags(cmd, &info.BatchInfo)
+	setBatchCmdRetryFailedFromFlags(cmd, &info.BatchInfo)
 	setBatchCmdSuccessExportFileFlags(cmd, &info.BatchInfo)
 	setBatchCmdFailExportFileFlags(cmd, &info.BatchInfo)
 	setBatchCmdItemSeparateFlags(cmd, &info.BatchInfo)
 	setBatchCmdForceFlags(cmd, &info.BatchInfo)
 	cmd.Flags().IntVarP(&info.BatchInfo.WorkerCount, "worker", "c", 1, "worker count")
+	setBatchCmdAdaptiveConcurrencyFlags(cmd, &info.BatchInfo)
 	cmd.Flags().StringVarP(&upHost, "up-host", "u", "", "fetch uphost")
 	return cmd
 }
 
+var crossCopyCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.CrossCopyInfo{}
+	var cmd = &cobra.Command{
+		Use:   "crosscopy <SrcAccount>:<SrcBucket>[/<SrcPrefix>] <DstAccount>:<DstBucket>[/<DstPrefix>]",
+		Short: "Copy files across accounts and regions, the src files are fetched by the dst account with signed urls and verified by hash",
+		Example: `copy all files under logs/ of bucket src-bucket of account alice to backup/logs/ of bucket dst-bucket of account bob
+	qshell crosscopy alice:src-bucket/logs/ bob:dst-bucket/backup/logs/`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.CrossCopyType
+			if len(args) > 0 {
+				info.Src = args[0]
+			}
+			if len(args) > 1 {
+				info.Dst = args[1]
+			}
+			operations.CrossCopy(cfg, info)
+		},
+	}
+	cmd.Flags().StringVarP(&info.SrcDomain, "src-domain", "", "", "the domain used to download the src files, default is the domain bound to the src bucket")
+	cmd.Flags().StringVarP(&info.FromListFile, "from-list", "", "", "only copy the files in this listbucket2 output file instead of listing the src bucket")
+	cmd.Flags().IntVarP(&info.UrlExpires, "url-expires", "", 3600, "the expiration of the signed src url, unit: second")
+	cmd.Flags().BoolVarP(&info.Async, "async", "", false, "use async fetch, suitable for large files")
+	cmd.Flags().BoolVarP(&info.Overwrite, "overwrite", "", false, "overwrite the dst file if it exists and its hash is different from the src file")
+	cmd.Flags().IntVarP(&info.MaxRetry, "max-retry", "", 20, "max retries when listing the src bucket error, -1 means unlimited")
+	setBatchCmdEnableRecordFlags(cmd, &info.BatchInfo)
+	setBatchCmdRecordRedoWhileErrorFlags(cmd, &info.BatchInfo)
+	setBatchCmdSuccessExportFileFlags(cmd, &info.BatchInfo)
+	setBatchCmdFailExportFileFlags(cmd, &info.BatchInfo)
+	setBatchCmdItemSeparateFlags(cmd, &info.BatchInfo)
+	setBatchCmdForceFlags(cmd, &info.BatchInfo)
+	cmd.Flags().IntVarP(&info.BatchInfo.WorkerCount, "worker", "c", 4, "worker count")
+	setBatchCmdAdaptiveConcurrencyFlags(cmd, &info.BatchInfo)
+	return cmd
+}
+
 func setBatchCmdDefaultFlags(cmd *cobra.Command, info *batch.Info) {
 	setBatchCmdInputFileFlags(cmd, info)
 	setBatchCmdWorkerCountFlags(cmd, info)
 	setBatchCmdMinWorkerCountFlags(cmd, info)
 	setBatchCmdWorkerCountIncreasePeriodFlags(cmd, info)
+	setBatchCmdAdaptiveConcurrencyFlags(cmd, info)
 	setBatchCmdEnableRecordFlags(cmd, info)
 	setBatchCmdRecordRedoWhileErrorFlags(cmd, info)
+	setBatchCmdRetryFailedFromFlags(cmd, info)
 	setBatchCmdSuccessExportFileFlags(cmd, info)
 	setBatchCmdFailExportFileFlags(cmd, info)
 	setBatchCmdItemSeparateFlags(cmd, info)
 	setBatchCmdForceFlags(cmd, info)
+	setBatchCmdDryRunFlags(cmd, info)
 }
 func setBatchCmdInputFileFlags(cmd *cobra.Command, info *batch.Info) {
 	cmd.Flags().StringVarP(&info.InputFile, "input-file", "i", "", "input file, read from stdin if not set")
@@ -325,6 +435,13 @@ func setBatchCmdMinWorkerCountFlags(cmd *cobra.Command, info *batch.Info) {
 func setBatchCmdWorkerCountIncreasePeriodFlags(cmd *cobra.Command, info *batch.Info) {
 	cmd.Flags().IntVarP(&info.WorkerCountIncreasePeriod, "worker-count-increase-period", "", 60, "worker count increase period. when the worker count is too big, an overrun error will be triggered. In order to alleviate this problem, qshell will automatically reduce the worker count. In order to complete the operation as quickly as possible, qshell will periodically increase the worker count. unit: second")
 }
+func setBatchCmdAdaptiveConcurrencyFlags(cmd *cobra.Command, info *batch.Info) {
+	setFlowCmdAdaptiveConcurrencyFlags(cmd, &info.Info)
+}
+func setFlowCmdAdaptiveConcurrencyFlags(cmd *cobra.Command, info *flow.Info) {
+	cmd.Flags().BoolVarP(&info.AdaptiveConcurrency, "adaptive-concurrency", "", false, "adaptive concurrency mode, the worker count and the number of works per batch will be adjusted by latency, throughput and error types (AIMD) between min worker count and max worker count")
+	cmd.Flags().IntVarP(&info.MaxWorkerCount, "max-worker", "", 0, "max worker count in adaptive concurrency mode, default is 4 times the worker count")
+}
 func setBatchCmdItemSeparateFlags(cmd *cobra.Command, info *batch.Info) {
 	cmd.Flags().StringVarP(&info.ItemSeparate, "sep", "F", "\t", "Separator used for split line fields, default is \\t (tab)")
 }
@@ -334,6 +451,9 @@ func setBatchCmdEnableRecordFlags(cmd *cobra.Command, info *batch.Info) {
 func setBatchCmdRecordRedoWhileErrorFlags(cmd *cobra.Command, info *batch.Info) {
 	cmd.Flags().BoolVarP(&info.RecordRedoWhileError, "record-redo-while-error", "", false, "when re-executing the command and checking the command task progress record, if a task has already been done and failed, the task will be re-executed. The default is false, and the task will not be re-executed when it detects that the task fails")
 }
+func setBatchCmdRetryFailedFromFlags(cmd *cobra.Command, info *batch.Info) {
+	cmd.Flags().StringVarP(&info.RetryFailedFrom, "retry-failed-from", "", "", "retry only the failed works of a previous run; the value is a failure list file exported by --failure-list or a job id (see: qshell job ls)")
+}
 func setBatchCmdSuccessExportFileFlags(cmd *cobra.Command, info *batch.Info) {
 	cmd.Flags().StringVarP(&info.SuccessExportFilePath, "success-list", "s", "", "specifies the file path where the successful file list is saved")
 }
@@ -344,6 +464,10 @@ func setBatchCmdOverwriteFlags(cmd *cobra.Command, info *batch.Info) {
 	cmd.Flags().BoolVarP(&info.Overwrite, "overwrite", "w", false, "overwrite mode")
 	_ = cmd.Flags().MarkShorthandDeprecated("overwrite", "deprecated and use --overwrite instead")
 }
+func setBatchCmdDryRunFlags(cmd *cobra.Command, info *batch.Info) {
+	cmd.Flags().BoolVarP(&info.DryRun, "dry-run", "", false, "only stat the objects and generate a plan file that shows what would change and what would fail, no object will be modified")
+	cmd.Flags().StringVarP(&info.PlanFile, "plan-file", "", "", "specifies the file path where the plan is saved in dry-run mode, default is plan.jsonl in the job dir")
+}
 func setBatchCmdResultExportFileFlags(cmd *cobra.Command, info *batch.Info) {
 	cmd.Flags().StringVarP(&info.ResultExportFilePath, "outfile", "o", "", "specifies the file path where the results is saved")
 }
@@ -361,11 +485,14 @@ func rsBatchCmdLoader(superCmd *cobra.Command, cfg *iqshell.Config) {
 		batchRenameCmdBuilder(cfg),
 		batchDeleteCmdBuilder(cfg),
 		batchChangeLifecycleCmdBuilder(cfg),
+		lifecyclePlanCmdBuilder(cfg),
 		batchDeleteAfterCmdBuilder(cfg),
 		batchChangeMimeCmdBuilder(cfg),
 		batchChangeTypeCmdBuilder(cfg),
 		batchRestoreArCmdBuilder(cfg),
+		batchApplyCmdBuilder(cfg),
 		batchSignCmdBuilder(cfg),
 		batchFetchCmdBuilder(cfg),
+		crossCopyCmdBuilder(cfg),
 	)
 }
@@ -1,8 +1,6 @@
 package cmd
 
 import (
-	"strings"
-
 	"github.com/qiniu/qshell/v2/docs"
 	"github.com/qiniu/qshell/v2/iqshell"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object/operations"
@@ -17,15 +15,7 @@ var createShareCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			cfg.CmdCfg.CmdId = docs.CreateShareType
 			if len(args) > 0 {
-				url := args[0]
-				url = strings.TrimPrefix(url, "kodo://")
-				parts := strings.SplitN(url, "/", 2)
-				if len(parts) > 0 {
-					info.Bucket = parts[0]
-				}
-				if len(parts) > 1 {
-					info.Prefix = parts[1]
-				}
+				info.Bucket, info.Prefix = parseKodoUrl(args[0])
 				info.Permission = "READONLY"
 			}
 			operations.CreateShare(cfg, info)
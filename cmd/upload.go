@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"strings"
+
 	"github.com/spf13/cobra"
 
 	"github.com/qiniu/qshell/v2/docs"
@@ -10,6 +12,8 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/storage/object/upload/operations"
 )
 
+const kodoUrlScheme = "kodo://"
+
 var uploadCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	info := operations.BatchUploadInfo{}
 	cmd := &cobra.Command{
@@ -32,11 +36,14 @@ var uploadCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	cmd.Flags().StringVarP(&info.FailExportFilePath, "failure-list", "e", "", "specifies the file path where the failure file list is saved")
 	cmd.Flags().StringVarP(&info.FailExportFilePath, "failure-list-old", "f", "", "specifies the file path where the failure file list is saved, deprecated")
 	_ = cmd.Flags().MarkDeprecated("failure-list-old", "use --failure-list instead")
+	cmd.Flags().StringVarP(&info.RetryFailedFrom, "retry-failed-from", "", "", "retry only the failed works of a previous run; the value is a failure list file exported by --failure-list or a job id (see: qshell job ls)")
 
 	cmd.Flags().StringVarP(&info.OverwriteExportFilePath, "overwrite-list", "w", "", "specifies the file path where the overwrite file list is saved")
 	cmd.Flags().IntVarP(&info.Info.WorkerCount, "worker", "c", 1, "worker count")
+	setFlowCmdAdaptiveConcurrencyFlags(cmd, &info.Info)
 	cmd.Flags().StringVarP(&info.CallbackUrl, "callback-urls", "l", "", "upload callback urls, separated by comma")
 	cmd.Flags().StringVarP(&info.CallbackHost, "callback-host", "T", "", "upload callback host")
+	setCdnRefreshFlags(cmd, &info.CdnRefresh)
 	return cmd
 }
 
@@ -66,8 +73,10 @@ var upload2CmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	}
 	cmd.Flags().StringVarP(&info.SuccessExportFilePath, "success-list", "s", "", "upload success file list")
 	cmd.Flags().StringVarP(&info.FailExportFilePath, "failure-list", "e", "", "upload failure file list")
+	cmd.Flags().StringVarP(&info.RetryFailedFrom, "retry-failed-from", "", "", "retry only the failed works of a previous run; the value is a failure list file exported by --failure-list or a job id (see: qshell job ls)")
 	cmd.Flags().StringVarP(&info.OverwriteExportFilePath, "overwrite-list", "w", "", "upload success (overwrite) file list")
 	cmd.Flags().IntVar(&info.Info.WorkerCount, "thread-count", 1, "multiple thread count")
+	setFlowCmdAdaptiveConcurrencyFlags(cmd, &info.Info)
 	cmd.Flags().IntVar(&info.UploadConfig.WorkerCount, "worker-count", 3, "the number of concurrently uploaded parts of a single file in resumable upload")
 	cmd.Flags().BoolVar(&info.UploadConfig.SequentialReadFile, "sequential-read-file", false, "File reading is sequential and does not involve skipping; when enabled, the uploading fragment data will be loaded into the memory. This option may increase file upload speed for mounted network filesystems.")
 
@@ -123,16 +132,39 @@ The default value is set to 0.If the uploader specifies MimeType (except applica
 	3. Detect content.
 Set to a value of -1 and use this value regardless of what value is specified on the uploader.`)
 	cmd.Flags().Uint64VarP(&info.TrafficLimit, "traffic-limit", "", 0, "Upload request single link speed limit to control client bandwidth usage. The speed limit value range is 819200 ~ 838860800, and the unit is bit/s.")
+	cmd.Flags().StringVarP(&info.CdnRefreshDomain, "cdn-refresh-domain", "", "", "refresh the cdn cache of the overwritten files on this domain, such as cdn.example.com or https://cdn.example.com; query the refresh status with cdnstatus")
+	cmd.Flags().IntVarP(&info.CdnRefreshQps, "cdn-refresh-qps", "", 0, "the qps limit of the cdn refresh requests, 0 means no limit")
 	return cmd
 }
 
 var syncCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	info := operations.SyncInfo{}
+	dirSyncInfo := operations.DirSyncInfo{}
 	cmd := &cobra.Command{
 		Use:   "sync <SrcResUrl> <Buckets> [-k <Key>]",
-		Short: "Sync big file to qiniu bucket",
+		Short: "Sync big file to qiniu bucket, or sync local dir with bucket by: sync <LocalDir> kodo://<Bucket>/<Prefix>",
 		Run: func(cmd *cobra.Command, args []string) {
 			cfg.CmdCfg.CmdId = docs.SyncType
+			if len(args) > 1 && (isKodoUrl(args[0]) || isKodoUrl(args[1])) {
+				// 本地文件夹和空间同步，kodo url 所在的位置为同步的目标端
+				if isKodoUrl(args[1]) {
+					dirSyncInfo.Direction = operations.DirSyncDirectionUpload
+					dirSyncInfo.LocalDir = args[0]
+					dirSyncInfo.Bucket, dirSyncInfo.Prefix = parseKodoUrl(args[1])
+				} else {
+					dirSyncInfo.Direction = operations.DirSyncDirectionDownload
+					dirSyncInfo.LocalDir = args[1]
+					dirSyncInfo.Bucket, dirSyncInfo.Prefix = parseKodoUrl(args[0])
+				}
+				dirSyncInfo.FileType = info.FileType
+				dirSyncInfo.UpHost = info.UpHost
+				dirSyncInfo.UseResumeV2 = info.UseResumeV2
+				dirSyncInfo.ChunkSize = info.ChunkSize
+				dirSyncInfo.CdnRefresh = info.CdnRefresh
+				operations.DirSync(cfg, dirSyncInfo)
+				return
+			}
+
 			info.DisableResume = true
 			if len(args) > 0 {
 				info.FilePath = args[0]
@@ -154,6 +186,19 @@ var syncCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	_ = cmd.Flags().MarkDeprecated("storage", "use --file-type instead") // 废弃 storage
 
 	cmd.Flags().BoolVarP(&info.Overwrite, "overwrite", "", false, "overwrite the file of same key in bucket")
+	setCdnRefreshFlags(cmd, &info.CdnRefresh)
+
+	// 本地文件夹和空间同步
+	cmd.Flags().BoolVarP(&dirSyncInfo.Delete, "delete", "", false, "sync local dir with bucket: delete the files that only exist in the destination")
+	cmd.Flags().BoolVarP(&dirSyncInfo.CheckHash, "check-hash", "", false, "sync local dir with bucket: compare qetag when file size is the same, otherwise compare local modify time and server put time")
+	cmd.Flags().StringVarP(&dirSyncInfo.Filter, "filter", "", "", "sync local dir with bucket: only sync files matching the filter expression, the syntax is the same as the --filter option of listbucket2; only key, size and putTime (modify time) are available for local files")
+	cmd.Flags().StringVarP(&dirSyncInfo.Domain, "domain", "", "", "sync local dir with bucket: domain used to download files")
+	cmd.Flags().BoolVarP(&dirSyncInfo.IsPublic, "public", "", false, "sync local dir with bucket: the bucket is public, download without signature")
+	cmd.Flags().BoolVarP(&dirSyncInfo.UseGetFileApi, "get-file-api", "", false, "sync local dir with bucket: download with get file api, used in private cloud")
+	cmd.Flags().IntVarP(&dirSyncInfo.WorkerCount, "thread-count", "c", 5, "sync local dir with bucket: num of threads to sync files")
+	cmd.Flags().BoolVarP(&dirSyncInfo.Force, "force", "y", false, "sync local dir with bucket: force mode, without verification code when --delete is set")
+	cmd.Flags().StringVarP(&dirSyncInfo.SuccessExportFilePath, "success-list", "", "", "sync local dir with bucket: specifies the file path where the successful operation list is saved")
+	cmd.Flags().StringVarP(&dirSyncInfo.FailExportFilePath, "failure-list", "", "", "sync local dir with bucket: specifies the file path where the failure operation list is saved")
 
 	cmd.Flags().StringVarP(&info.Policy.EndUser, "end-user", "", "", "Owner identification")
 	cmd.Flags().StringVarP(&info.Policy.CallbackURL, "callback-urls", "l", "", "upload callback urls, separated by comma")
@@ -302,3 +347,20 @@ func uploadCmdLoader(superCmd *cobra.Command, cfg *iqshell.Config) {
 		resumeUploadCmdBuilder(cfg),
 	)
 }
+
+func isKodoUrl(url string) bool {
+	return strings.HasPrefix(url, kodoUrlScheme)
+}
+
+// parseKodoUrl 解析 [kodo://]<Bucket>/<Prefix>
+func parseKodoUrl(url string) (bucket string, prefix string) {
+	url = strings.TrimPrefix(url, kodoUrlScheme)
+	parts := strings.SplitN(url, "/", 2)
+	if len(parts) > 0 {
+		bucket = parts[0]
+	}
+	if len(parts) > 1 {
+		prefix = parts[1]
+	}
+	return
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"github.com/qiniu/qshell/v2/docs"
 	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/account"
 	"github.com/qiniu/qshell/v2/iqshell/common/account/operations"
 	"github.com/spf13/cobra"
 )
@@ -183,6 +184,52 @@ var userRmCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	return cmd
 }
 
+// 开启口令保护模式
+var userEncryptCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.EncryptInfo{}
+	var cmd = &cobra.Command{
+		Use:   "encrypt",
+		Short: "Protect secret keys of local users with a master passphrase",
+		Long: `Protect secret keys of local users with a master passphrase. Secret keys are encrypted by AES-GCM with a key derived from the passphrase by scrypt.
+All users saved in local db and current user are migrated, run it again to migrate users not migrated. The passphrase can be set by environment variable QSHELL_PASSPHRASE.`,
+		Example: `qshell user encrypt --session-timeout 30m`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.User
+			operations.Encrypt(cfg, info)
+		},
+	}
+	cmd.Flags().DurationVarP(&info.SessionTimeout, "session-timeout", "", account.DefaultSessionTimeout, "the passphrase entered in terminal is cached for this duration so it needn't be entered for every command, 0 means no cache")
+	return cmd
+}
+
+// 关闭口令保护模式
+var userDecryptCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:     "decrypt",
+		Short:   "Disable master passphrase protection of local users",
+		Example: `qshell user decrypt`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.User
+			operations.Decrypt(cfg, operations.DecryptInfo{})
+		},
+	}
+	return cmd
+}
+
+// 清除口令缓存
+var userLockCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:     "lock",
+		Short:   "Clear the cached master passphrase, the passphrase will be required by the next command",
+		Example: `qshell user lock`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.User
+			operations.Lock(cfg, operations.LockInfo{})
+		},
+	}
+	return cmd
+}
+
 func init() {
 	registerLoader(userCmdLoader)
 }
@@ -197,6 +244,9 @@ func userCmdLoader(superCmd *cobra.Command, cfg *iqshell.Config) {
 		userLsCmdBuilder(cfg),      // 列举所有用户信息
 		userLookupCmdBuilder(cfg),  // 查看某个用户的信息
 		userCurrentCmdBuilder(cfg), // 查看当前用户信息
+		userEncryptCmdBuilder(cfg), // 开启口令保护模式
+		userDecryptCmdBuilder(cfg), // 关闭口令保护模式
+		userLockCmdBuilder(cfg),    // 清除口令缓存
 	)
 
 	superCmd.AddCommand(
@@ -204,3 +254,10 @@ func userCmdLoader(superCmd *cobra.Command, cfg *iqshell.Config) {
 		userCmd,
 	)
 }
+
+// setMultiAccountFlags 使用多个本地账户分别执行命令的选项，用于只读命令
+func setMultiAccountFlags(cmd *cobra.Command, info *operations.MultiAccountInfo) {
+	cmd.Flags().BoolVarP(&info.AllAccounts, "all-accounts", "", false, "execute the command with every account in local db, results of all accounts are aggregated with an account column")
+	cmd.Flags().StringVarP(&info.Accounts, "accounts", "", "", "execute the command with the accounts in local db, separated by comma, such as a,b,c; results of all accounts are aggregated with an account column")
+	cmd.Flags().IntVarP(&info.Worker, "accounts-worker", "", 4, "count of accounts executing the command concurrently when --all-accounts or --accounts is set")
+}
@@ -3,9 +3,12 @@
 package cmd
 
 import (
-	"github.com/qiniu/qshell/v2/cmd_test/test"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/qiniu/qshell/v2/cmd_test/test"
 )
 
 func TestSyncV1(t *testing.T) {
@@ -131,6 +134,55 @@ func TestSyncNoBucket(t *testing.T) {
 	}
 }
 
+func TestSyncDir(t *testing.T) {
+	fileContent := "sync dir test"
+	filePath, err := test.CreateFileWithContent("sync_dir_test.txt", fileContent)
+	if err != nil {
+		t.Fatal("create file error:", err)
+	}
+	localDir := filepath.Dir(filePath)
+	prefix := "sync_dir/"
+
+	// 本地 => 空间
+	result, errs := test.RunCmdWithError("sync", localDir, "kodo://"+test.Bucket+"/"+prefix, "-d")
+	if len(errs) > 0 {
+		t.Fail()
+	}
+	if !strings.Contains(result, "Sync Success, upload") {
+		t.Fatal(result)
+	}
+
+	// 空间 => 本地
+	rootPath, err := test.RootPath()
+	if err != nil {
+		t.Fatal("get root path error:", err)
+	}
+	downloadDir := filepath.Join(rootPath, "sync_dir")
+	defer os.RemoveAll(downloadDir)
+	_, errs = test.RunCmdWithError("sync", "kodo://"+test.Bucket+"/"+prefix, downloadDir, "--check-hash")
+	if len(errs) > 0 {
+		t.Fail()
+	}
+	if test.FileContent(filepath.Join(downloadDir, "sync_dir_test.txt")) != fileContent {
+		t.Fatal("download file content error")
+	}
+	test.RunCmdWithError("delete", test.Bucket, prefix+"sync_dir_test.txt")
+}
+
+func TestSyncDirNoLocalDir(t *testing.T) {
+	_, errs := test.RunCmdWithError("sync", "/sync_dir_not_exist", "kodo://"+test.Bucket+"/sync_dir/")
+	if !strings.Contains(errs, "is not exist") {
+		t.Fail()
+	}
+}
+
+func TestSyncDirNoBucket(t *testing.T) {
+	_, errs := test.RunCmdWithError("sync", ".", "kodo://")
+	if !strings.Contains(errs, "Bucket can't be empty") {
+		t.Fail()
+	}
+}
+
 func TestSyncDocument(t *testing.T) {
 	test.TestDocument("sync", t)
 }
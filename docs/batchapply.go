@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed batchapply.md
+var batchApplyDocument string
+
+const BatchApplyType = "batchapply"
+
+func init() {
+	addCmdDocumentInfo(BatchApplyType, batchApplyDocument)
+}
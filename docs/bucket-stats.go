@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed bucket-stats.md
+var bucketStatsDocument string
+
+const BucketStatsType = "bucket-stats"
+
+func init() {
+	addCmdDocumentInfo(BucketStatsType, bucketStatsDocument)
+}
@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed cdnbandwidth.md
+var cdnBandwidthDocument string
+
+const CdnBandwidthType = "cdnbandwidth"
+
+func init() {
+	addCmdDocumentInfo(CdnBandwidthType, cdnBandwidthDocument)
+}
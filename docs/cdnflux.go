@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed cdnflux.md
+var cdnFluxDocument string
+
+const CdnFluxType = "cdnflux"
+
+func init() {
+	addCmdDocumentInfo(CdnFluxType, cdnFluxDocument)
+}
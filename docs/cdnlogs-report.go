@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed cdnlogs-report.md
+var cdnLogsReportDocument string
+
+const CdnLogsReportType = "cdnlogs-report"
+
+func init() {
+	addCmdDocumentInfo(CdnLogsReportType, cdnLogsReportDocument)
+}
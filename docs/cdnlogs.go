@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed cdnlogs.md
+var cdnLogsDocument string
+
+const CdnLogsType = "cdnlogs"
+
+func init() {
+	addCmdDocumentInfo(CdnLogsType, cdnLogsDocument)
+}
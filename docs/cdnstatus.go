@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed cdnstatus.md
+var cdnStatusDocument string
+
+const CdnStatusType = "cdnstatus"
+
+func init() {
+	addCmdDocumentInfo(CdnStatusType, cdnStatusDocument)
+}
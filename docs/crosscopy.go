@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed crosscopy.md
+var crossCopyDocument string
+
+const CrossCopyType = "crosscopy"
+
+func init() {
+	addCmdDocumentInfo(CrossCopyType, crossCopyDocument)
+}
@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed domain.md
+var domainDocument string
+
+const DomainType = "domain"
+
+func init() {
+	addCmdDocumentInfo(DomainType, domainDocument)
+}
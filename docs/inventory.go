@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed inventory.md
+var inventoryDocument string
+
+const InventoryType = "inventory"
+
+func init() {
+	addCmdDocumentInfo(InventoryType, inventoryDocument)
+}
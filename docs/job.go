@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed job.md
+var jobDocument string
+
+const JobType = "job"
+
+func init() {
+	addCmdDocumentInfo(JobType, jobDocument)
+}
@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed lifecycle-plan.md
+var lifecyclePlanDocument string
+
+const LifecyclePlanType = "lifecycle-plan"
+
+func init() {
+	addCmdDocumentInfo(LifecyclePlanType, lifecyclePlanDocument)
+}
@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed record.md
+var recordDocument string
+
+const RecordType = "record"
+
+func init() {
+	addCmdDocumentInfo(RecordType, recordDocument)
+}
@@ -1,6 +1,7 @@
 package aws
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/s3"
@@ -75,25 +76,35 @@ func Fetch(cfg *iqshell.Config, info FetchInfo) {
 		return
 	}
 
-	fetchInfoChan := make(chan flow.Work, info.BatchInfo.WorkerCount)
+	fetchInfoChan := make(chan *object.FetchApiInfo, info.BatchInfo.WorkerCount)
+	fetchObject := func(svc *s3.S3, key string) {
+		req, _ := svc.GetObjectRequest(&s3.GetObjectInput{
+			Bucket: aws.String(info.AwsBucketInfo.Bucket),
+			Key:    aws.String(key),
+		})
+		if downloadUrl, e := req.Presign(5 * 3600 * time.Second); e == nil {
+			fetchInfoChan <- &object.FetchApiInfo{
+				Bucket:  info.QiniuBucket,
+				Key:     key,
+				FromUrl: downloadUrl,
+			}
+			log.DebugF("get object:%s\n%s", key, downloadUrl)
+		} else {
+			data.SetCmdStatusError()
+			log.ErrorF("fetch([%s:%s]) create download url error: %v", info.AwsBucketInfo.Bucket, key, e)
+		}
+	}
 	// 生产者
 	go func() {
-		if e := listBucket(info.AwsBucketInfo, func(svc *s3.S3, obj *s3.Object) {
-			req, _ := svc.GetObjectRequest(&s3.GetObjectInput{
-				Bucket: aws.String(info.AwsBucketInfo.Bucket),
-				Key:    obj.Key,
-			})
-			if downloadUrl, e := req.Presign(5 * 3600 * time.Second); e == nil {
-				fetchInfoChan <- &object.FetchApiInfo{
-					Bucket:  info.QiniuBucket,
-					Key:     *obj.Key,
-					FromUrl: downloadUrl,
-				}
-				log.DebugF("get object:%s\t%d\t%s\t%s\n%s", *obj.Key, *obj.Size, *obj.ETag, *obj.LastModified, downloadUrl)
-			} else {
+		if len(info.BatchInfo.RetryFailedFrom) > 0 {
+			// 下载链接有时效，重试时根据失败的 key 重新生成下载链接
+			if e := retryFailedObjects(info, fetchObject); e != nil {
+				log.Error(e)
 				data.SetCmdStatusError()
-				log.ErrorF("fetch([%s:%s]) create download url error: %v", info.AwsBucketInfo.Bucket, *obj.Key, e)
 			}
+		} else if e := listBucket(info.AwsBucketInfo, func(svc *s3.S3, obj *s3.Object) {
+			log.DebugF("list object:%s\t%d\t%s\t%s", *obj.Key, *obj.Size, *obj.ETag, *obj.LastModified)
+			fetchObject(svc, *obj.Key)
 		}); e != nil {
 			log.Error(e)
 			data.SetCmdStatusError()
@@ -111,7 +122,7 @@ func Fetch(cfg *iqshell.Config, info FetchInfo) {
 	metric := &batch.Metric{}
 	metric.Start()
 	flow.New(info.BatchInfo.Info).
-		WorkProviderWithChan(fetchInfoChan).
+		WorkProvider(&fetchWorkProvider{works: fetchInfoChan}).
 		WorkerProvider(flow.NewWorkerProvider(func() (flow.Worker, *data.CodeError) {
 			return flow.NewSimpleWorker(func(workInfo *flow.WorkInfo) (flow.Result, *data.CodeError) {
 				in := workInfo.Work.(*object.FetchApiInfo)
@@ -180,6 +191,7 @@ func Fetch(cfg *iqshell.Config, info FetchInfo) {
 			exporter.Success().ExportF("%s\t%s", in.FromUrl, in.Bucket)
 			log.InfoF("AWS Fetch Success, '%s' => [%s:%s]", in.FromUrl, in.Bucket, in.Key)
 		}).
+		OnConcurrencyChange(metric.OnConcurrencyChange).
 		OnWorkFail(func(workInfo *flow.WorkInfo, err *data.CodeError) {
 			metric.AddCurrentCount(1)
 			metric.AddFailureCount(1)
@@ -220,3 +232,49 @@ func Fetch(cfg *iqshell.Config, info FetchInfo) {
 		data.SetCmdStatusError()
 	}
 }
+
+// fetchWorkProvider work 数据为 FetchApiInfo 的 json，失败重试时从中解析 key
+type fetchWorkProvider struct {
+	works <-chan *object.FetchApiInfo
+}
+
+func (p *fetchWorkProvider) WorkTotalCount() int64 {
+	return flow.UnknownWorkCount
+}
+
+func (p *fetchWorkProvider) Provide() (hasMore bool, work *flow.WorkInfo, err *data.CodeError) {
+	for w := range p.works {
+		d, e := json.Marshal(w)
+		if e != nil {
+			return true, &flow.WorkInfo{Work: w}, data.NewEmptyError().AppendDescF("marshal work:%s error:%v", w.Key, e)
+		}
+		return true, &flow.WorkInfo{
+			Data: string(d),
+			Work: w,
+		}, nil
+	}
+	return false, &flow.WorkInfo{}, nil
+}
+
+func retryFailedObjects(info FetchInfo, fetchObject func(svc *s3.S3, key string)) *data.CodeError {
+	lines, err := flow.LoadRetryFailedLines(info.BatchInfo.RetryFailedFrom)
+	if err != nil {
+		return err
+	}
+
+	svc, err := newS3Service(info.AwsBucketInfo)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range lines {
+		work := &object.FetchApiInfo{}
+		if e := json.Unmarshal([]byte(line), work); e != nil || len(work.Key) == 0 {
+			data.SetCmdStatusError()
+			log.ErrorF("retry failed, can't get key from:%s", line)
+			continue
+		}
+		fetchObject(svc, work.Key)
+	}
+	return nil
+}
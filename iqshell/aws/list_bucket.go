@@ -63,20 +63,26 @@ func ListBucket(cfg *iqshell.Config, info ListBucketInfo) {
 	}
 }
 
-func listBucket(info ListBucketInfo, objectHandler func(s3 *s3.S3, object *s3.Object)) *data.CodeError {
-	if objectHandler == nil {
-		return nil
-	}
-
+func newS3Service(info ListBucketInfo) (*s3.S3, *data.CodeError) {
 	// AWS related code
 	s3session, err := session.NewSession()
 	if err != nil {
-		return data.NewEmptyError().AppendDescF("create AWS session error:%v", err)
+		return nil, data.NewEmptyError().AppendDescF("create AWS session error:%v", err)
 	}
 	s3session.Config.WithRegion(info.Region)
 	s3session.Config.WithCredentials(credentials.NewStaticCredentials(info.Id, info.SecretKey, ""))
+	return s3.New(s3session), nil
+}
 
-	svc := s3.New(s3session)
+func listBucket(info ListBucketInfo, objectHandler func(s3 *s3.S3, object *s3.Object)) *data.CodeError {
+	if objectHandler == nil {
+		return nil
+	}
+
+	svc, err := newS3Service(info)
+	if err != nil {
+		return err
+	}
 	input := &s3.ListObjectsV2Input{
 		Bucket:  aws.String(info.Bucket),
 		Prefix:  aws.String(info.Prefix),
@@ -1,11 +1,14 @@
 package cdn
 
 import (
+	"time"
+
 	"github.com/qiniu/go-sdk/v7/auth/qbox"
 	"github.com/qiniu/go-sdk/v7/cdn"
 
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
 	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
 )
 
@@ -28,35 +31,73 @@ func getCdnManager() (cdnManager *cdn.CdnManager, err *data.CodeError) {
 	return
 }
 
-func Prefetch(urls []string) *data.CodeError {
+// Prefetch 预取文件，返回预取任务信息，可通过 QueryPrefetch 查询预取进度
+func Prefetch(urls []string) (*Task, *data.CodeError) {
 	cdnManager, err := getCdnManager()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if resp, e := cdnManager.PrefetchUrls(urls); e != nil {
-		return data.NewEmptyError().AppendDescF("CDN prefetch error:%v", e)
+	resp, e := cdnManager.PrefetchUrls(urls)
+	if e != nil {
+		return nil, data.NewEmptyError().AppendDescF("CDN prefetch error:%v", e)
 	} else if resp.Code != 200 {
-		return data.NewEmptyError().AppendDescF("CDN prefetch Code: %d, Error: %s", resp.Code, resp.Error)
-	} else {
-		log.InfoF("CDN prefetch Code: %d, FlowInfo: %s", resp.Code, resp.Error)
+		return nil, data.NewEmptyError().AppendDescF("CDN prefetch Code: %d, Error: %s", resp.Code, resp.Error)
+	}
+
+	output.Result(resp)
+	log.InfoF("CDN prefetch Code: %d, FlowInfo: %s, RequestId: %s", resp.Code, resp.Error, resp.RequestID)
+	return &Task{
+		RequestId:   resp.RequestID,
+		Type:        TaskTypePrefetch,
+		Urls:        urls,
+		InvalidUrls: resp.InvalidUrls,
+		SubmitTime:  time.Now().Unix(),
+	}, nil
+}
+
+// Refresh 刷新文件或目录，urls 和 dirs 仅能有一个不为空；返回刷新任务信息，可通过 QueryRefresh 查询刷新进度
+func Refresh(urls []string, dirs []string) (*Task, *data.CodeError) {
+	task, resp, err := refresh(urls, dirs)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	output.Result(resp)
+	return task, nil
 }
 
-func Refresh(urls []string, dirs []string) *data.CodeError {
+// RefreshUrls 刷新文件，不输出结构化结果，供上传、批量修改等命令附带的自动刷新使用
+func RefreshUrls(urls []string) (*Task, *data.CodeError) {
+	task, _, err := refresh(urls, nil)
+	return task, err
+}
+
+func refresh(urls []string, dirs []string) (*Task, *cdn.RefreshResp, *data.CodeError) {
 	cdnManager, err := getCdnManager()
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	log.DebugF("cdnRefresh, url size: %d, dir size: %d", len(urls), len(dirs))
-	if resp, e := cdnManager.RefreshUrlsAndDirs(urls, dirs); e != nil {
-		return data.NewEmptyError().AppendDescF("CDN refresh error:%v", err)
+	resp, e := cdnManager.RefreshUrlsAndDirs(urls, dirs)
+	if e != nil {
+		return nil, nil, data.NewEmptyError().AppendDescF("CDN refresh error:%v", e)
 	} else if resp.Code != 200 {
-		return data.NewEmptyError().AppendDescF("CDN refresh Code: %d, Error: %s", resp.Code, resp.Error)
-	} else {
-		log.InfoF("CDN refresh Code: %d, FlowInfo: %s", resp.Code, resp.Error)
+		return nil, nil, data.NewEmptyError().AppendDescF("CDN refresh Code: %d, Error: %s", resp.Code, resp.Error)
+	}
+
+	log.InfoF("CDN refresh Code: %d, FlowInfo: %s, RequestId: %s", resp.Code, resp.Error, resp.RequestID)
+	task := &Task{
+		RequestId:   resp.RequestID,
+		Type:        TaskTypeRefresh,
+		Urls:        urls,
+		InvalidUrls: resp.InvalidUrls,
+		SubmitTime:  time.Now().Unix(),
+	}
+	if len(dirs) > 0 {
+		task.IsDir = true
+		task.Urls = dirs
+		task.InvalidUrls = resp.InvalidDirs
 	}
-	return nil
+	return task, &resp, nil
 }
@@ -0,0 +1,263 @@
+package cdn
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+
+	"github.com/qiniu/go-sdk/v7/auth"
+	"github.com/qiniu/go-sdk/v7/client"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+)
+
+const (
+	// 域名管理接口的默认 host，配置了 hosts.api 时使用配置的 host
+	domainApiDefaultHost = "api.qiniu.com"
+)
+
+const (
+	DomainPlatformWeb      = "web"
+	DomainPlatformDownload = "download"
+	DomainPlatformVod      = "vod"
+
+	DomainGeoCoverChina   = "china"
+	DomainGeoCoverForeign = "foreign"
+	DomainGeoCoverGlobal  = "global"
+
+	DomainProtocolHttp  = "http"
+	DomainProtocolHttps = "https"
+
+	DomainSourceTypeQiniuBucket = "qiniuBucket"
+
+	DomainOperatingStateOnline  = "online"
+	DomainOperatingStateOffline = "offline"
+)
+
+// DomainSource 回源配置
+type DomainSource struct {
+	SourceType        string                 `json:"sourceType"` // qiniuBucket / domain / ip / advanced
+	SourceHost        string                 `json:"sourceHost,omitempty"`
+	SourceIPs         []string               `json:"sourceIPs,omitempty"`
+	SourceDomain      string                 `json:"sourceDomain,omitempty"`
+	SourceQiniuBucket string                 `json:"sourceQiniuBucket,omitempty"`
+	SourceURLScheme   string                 `json:"sourceURLScheme,omitempty"`
+	AdvancedSources   []DomainAdvancedSource `json:"advancedSources,omitempty"`
+	TestURLPath       string                 `json:"testURLPath,omitempty"`
+}
+
+type DomainAdvancedSource struct {
+	Addr   string `json:"addr"`
+	Weight int    `json:"weight"`
+	Backup bool   `json:"backup"`
+}
+
+// DomainCache 缓存配置
+type DomainCache struct {
+	CacheControls []DomainCacheControl `json:"cacheControls"`
+	IgnoreParam   bool                 `json:"ignoreParam"`
+}
+
+type DomainCacheControl struct {
+	Time     int    `json:"time"`
+	TimeUnit int    `json:"timeunit"` // 0:秒 1:分 2:时 3:天 4:周 5:月 6:年
+	Type     string `json:"type"`     // all / path / suffix / follow
+	Rule     string `json:"rule"`
+}
+
+// DomainReferer Referer 防盗链配置
+type DomainReferer struct {
+	RefererType   string   `json:"refererType"` // 空：关闭 / black / white
+	RefererValues []string `json:"refererValues"`
+	NullReferer   bool     `json:"nullReferer"`
+}
+
+// DomainIpACL IP 黑白名单配置
+type DomainIpACL struct {
+	IpACLType   string   `json:"ipACLType"` // 空：关闭 / black / white
+	IpACLValues []string `json:"ipACLValues"`
+}
+
+// DomainHttps HTTPS 配置
+type DomainHttps struct {
+	CertId      string `json:"certId"`
+	ForceHttps  bool   `json:"forceHttps"`
+	Http2Enable bool   `json:"http2Enable"`
+}
+
+// DomainConfig CDN 域名的配置；更新配置时仅更新不为空的 Source、Cache、Referer、IpACL 及 Https
+type DomainConfig struct {
+	Name               string         `json:"name,omitempty"`
+	Type               string         `json:"type,omitempty"`
+	CName              string         `json:"cname,omitempty"`
+	Platform           string         `json:"platform,omitempty"`
+	GeoCover           string         `json:"geoCover,omitempty"`
+	Protocol           string         `json:"protocol,omitempty"`
+	OperatingState     string         `json:"operatingState,omitempty"`
+	OperatingStateDesc string         `json:"operatingStateDesc,omitempty"`
+	CreateAt           string         `json:"createAt,omitempty"`
+	ModifyAt           string         `json:"modifyAt,omitempty"`
+	Source             *DomainSource  `json:"source,omitempty"`
+	Cache              *DomainCache   `json:"cache,omitempty"`
+	Referer            *DomainReferer `json:"referer,omitempty"`
+	IpACL              *DomainIpACL   `json:"ipACL,omitempty"`
+	Https              *DomainHttps   `json:"https,omitempty"`
+}
+
+// LoadDomainConfig 从 json 文件加载域名配置，文件格式同 domain show -o 保存的配置文件
+func LoadDomainConfig(path string) (*DomainConfig, *data.CodeError) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, data.NewEmptyError().AppendDescF("read domain config file:%s error:%v", path, err)
+	}
+	cfg := &DomainConfig{}
+	if err = json.Unmarshal(content, cfg); err != nil {
+		return nil, data.NewEmptyError().AppendDescF("parse domain config file:%s error:%v", path, err)
+	}
+	return cfg, nil
+}
+
+// domainRequest 一次域名管理接口请求
+type domainRequest struct {
+	Name   string // 请求的名称，用于日志
+	Method string
+	Path   string
+	Body   interface{}
+}
+
+func domainPath(domain string) string {
+	return "/domain/" + domain
+}
+
+// domainUpdateRequests 更新域名配置需要的请求，与当前配置相同的配置项不更新；
+// Https 未指定证书时不更新，在域名当前为 http 协议时升级为 https，否则修改证书配置
+func domainUpdateRequests(current *DomainConfig, update *DomainConfig) []domainRequest {
+	path := domainPath(current.Name)
+	requests := make([]domainRequest, 0)
+	if update.Source != nil && !reflect.DeepEqual(update.Source, current.Source) {
+		requests = append(requests, domainRequest{Name: "source", Method: http.MethodPut, Path: path + "/source",
+			Body: map[string]interface{}{"source": update.Source}})
+	}
+	if update.Cache != nil && !reflect.DeepEqual(update.Cache, current.Cache) {
+		requests = append(requests, domainRequest{Name: "cache", Method: http.MethodPut, Path: path + "/cache",
+			Body: map[string]interface{}{"cache": update.Cache}})
+	}
+	if update.Referer != nil && !reflect.DeepEqual(update.Referer, current.Referer) {
+		requests = append(requests, domainRequest{Name: "referer", Method: http.MethodPut, Path: path + "/referer",
+			Body: map[string]interface{}{"referer": update.Referer}})
+	}
+	if update.IpACL != nil && !reflect.DeepEqual(update.IpACL, current.IpACL) {
+		requests = append(requests, domainRequest{Name: "ipACL", Method: http.MethodPut, Path: path + "/ipacl",
+			Body: map[string]interface{}{"ipACL": update.IpACL}})
+	}
+	if update.Https != nil && len(update.Https.CertId) > 0 && !reflect.DeepEqual(update.Https, current.Https) {
+		if current.Protocol == DomainProtocolHttps {
+			requests = append(requests, domainRequest{Name: "https", Method: http.MethodPut, Path: path + "/httpsconf",
+				Body: update.Https})
+		} else {
+			requests = append(requests, domainRequest{Name: "https", Method: http.MethodPut, Path: path + "/sslize",
+				Body: update.Https})
+		}
+	}
+	return requests
+}
+
+// DomainChangedSections 与当前配置相比需要更新的配置项
+func DomainChangedSections(current *DomainConfig, update *DomainConfig) []string {
+	sections := make([]string, 0)
+	for _, request := range domainUpdateRequests(current, update) {
+		sections = append(sections, request.Name)
+	}
+	return sections
+}
+
+func callDomainApi(request domainRequest, ret interface{}) *data.CodeError {
+	mac, err := workspace.GetMac()
+	if err != nil {
+		return err
+	}
+
+	host := workspace.GetConfig().Hosts.GetOneApi()
+	if len(host) == 0 {
+		host = domainApiDefaultHost
+	}
+	reqUrl := utils.Endpoint(workspace.GetConfig().IsUseHttps(), host) + request.Path
+	log.DebugF("domain %s request: %s %s", request.Name, request.Method, reqUrl)
+
+	var e error
+	if request.Body == nil {
+		e = client.DefaultClient.CredentialedCall(workspace.GetContext(), mac, auth.TokenQBox, ret, request.Method, reqUrl, nil)
+	} else {
+		e = client.DefaultClient.CredentialedCallWithJson(workspace.GetContext(), mac, auth.TokenQBox, ret, request.Method, reqUrl, nil, request.Body)
+	}
+	if e == nil {
+		return nil
+	}
+	if info, ok := e.(*client.ErrorInfo); ok {
+		return data.NewError(info.Code, fmt.Sprintf("domain %s error:%s", request.Name, info.Err))
+	}
+	return data.NewEmptyError().AppendDescF("domain %s error:%v", request.Name, e)
+}
+
+// GetDomain 获取 CDN 域名的配置
+func GetDomain(domain string) (*DomainConfig, *data.CodeError) {
+	cfg := &DomainConfig{}
+	if err := callDomainApi(domainRequest{Name: "get", Method: http.MethodGet, Path: domainPath(domain)}, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// CreateDomain 创建 CDN 域名，cfg 中需指定回源配置
+func CreateDomain(domain string, cfg *DomainConfig) *data.CodeError {
+	return callDomainApi(domainRequest{Name: "create", Method: http.MethodPost, Path: domainPath(domain), Body: cfg}, nil)
+}
+
+// OnlineDomain 启用 CDN 域名
+func OnlineDomain(domain string) *data.CodeError {
+	return callDomainApi(domainRequest{Name: "online", Method: http.MethodPost, Path: domainPath(domain) + "/online"}, nil)
+}
+
+// OfflineDomain 停用 CDN 域名，停用后域名不再提供服务
+func OfflineDomain(domain string) *data.CodeError {
+	return callDomainApi(domainRequest{Name: "offline", Method: http.MethodPost, Path: domainPath(domain) + "/offline"}, nil)
+}
+
+// UpdateDomain 更新 CDN 域名的配置，按 Source、Cache、Referer、IpACL、Https 的顺序依次更新，返回已更新的配置项；
+// 某项更新失败时不再更新后面的配置项
+func UpdateDomain(current *DomainConfig, update *DomainConfig) ([]string, *data.CodeError) {
+	updated := make([]string, 0)
+	for _, request := range domainUpdateRequests(current, update) {
+		if err := callDomainApi(request, nil); err != nil {
+			return updated, err
+		}
+		updated = append(updated, request.Name)
+	}
+	return updated, nil
+}
+
+// BindOriginDomain 为存储空间绑定源站域名
+func BindOriginDomain(domain string, bucket string) *data.CodeError {
+	mac, err := workspace.GetMac()
+	if err != nil {
+		return err
+	}
+
+	cfg := workspace.GetConfig()
+	reqUrl := fmt.Sprintf("%s/publish/%s/from/%s", utils.Endpoint(cfg.IsUseHttps(), cfg.Hosts.GetOneUc()),
+		base64.URLEncoding.EncodeToString([]byte(domain)), bucket)
+	log.DebugF("bind origin domain request: POST %s", reqUrl)
+	if e := client.DefaultClient.CredentialedCall(workspace.GetContext(), mac, auth.TokenQiniu, nil, http.MethodPost, reqUrl, nil); e != nil {
+		if info, ok := e.(*client.ErrorInfo); ok {
+			return data.NewError(info.Code, "bind origin domain error:"+info.Err)
+		}
+		return data.NewEmptyError().AppendDescF("bind origin domain error:%v", e)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package cdn
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDomainUpdateRequests(t *testing.T) {
+	current := &DomainConfig{Name: "cdn.example.com", Protocol: DomainProtocolHttp}
+	update := &DomainConfig{
+		Referer: &DomainReferer{RefererType: "white", RefererValues: []string{"*.example.com"}},
+		Https:   &DomainHttps{CertId: "cert"},
+	}
+	requests := domainUpdateRequests(current, update)
+	if len(requests) != 2 {
+		t.Fatalf("requests count invalid:%+v", requests)
+	}
+	if requests[0].Path != "/domain/cdn.example.com/referer" || requests[0].Body.(map[string]interface{})["referer"] != update.Referer {
+		t.Fatalf("referer request invalid:%+v", requests[0])
+	}
+	if requests[1].Path != "/domain/cdn.example.com/sslize" {
+		t.Fatalf("http domain should be sslized:%+v", requests[1])
+	}
+
+	current.Protocol = DomainProtocolHttps
+	if requests = domainUpdateRequests(current, update); requests[1].Path != "/domain/cdn.example.com/httpsconf" {
+		t.Fatalf("https domain should update https conf:%+v", requests[1])
+	}
+	if requests = domainUpdateRequests(current, &DomainConfig{}); len(requests) != 0 {
+		t.Fatalf("empty update should have no request:%+v", requests)
+	}
+
+	// 与当前配置相同的配置项及未指定证书的 https 不更新
+	current.Referer = &DomainReferer{RefererType: "white", RefererValues: []string{"*.example.com"}}
+	update.Https = &DomainHttps{ForceHttps: true}
+	if sections := DomainChangedSections(current, update); len(sections) != 0 {
+		t.Fatalf("unchanged config should not be updated:%v", sections)
+	}
+}
+
+func TestLoadDomainConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "domain.json")
+	content := `{"name":"cdn.example.com","protocol":"http","ipACL":{"ipACLType":"black","ipACLValues":["1.1.1.1"]},
+"cache":{"cacheControls":[{"time":1,"timeunit":3,"type":"suffix","rule":".jpg"}],"ignoreParam":true}}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := LoadDomainConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "cdn.example.com" || c.Source != nil || c.Referer != nil || c.IpACL == nil || c.IpACL.IpACLValues[0] != "1.1.1.1" {
+		t.Fatalf("config invalid:%+v", c)
+	}
+	if c.Cache == nil || !c.Cache.IgnoreParam || c.Cache.CacheControls[0].TimeUnit != 3 {
+		t.Fatalf("cache invalid:%+v", c.Cache)
+	}
+
+	if e := os.WriteFile(path, []byte("{"), 0644); e != nil {
+		t.Fatal(e)
+	}
+	if _, err := LoadDomainConfig(path); err == nil {
+		t.Fatal("invalid config file should fail")
+	}
+}
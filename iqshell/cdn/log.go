@@ -0,0 +1,139 @@
+package cdn
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/client"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+)
+
+const (
+	LogDayLayout  = "2006-01-02"
+	LogHourLayout = "2006-01-02-15"
+
+	logDownloadRetryCount    = 3 // 日志下载失败后的重试次数
+	logDownloadRetryInterval = time.Second
+)
+
+// LogLocation CDN 日志文件名中的小时及日志列表的日期均为北京时间（+0800），与本地时区无关
+var LogLocation = time.FixedZone("CST", 8*3600)
+
+// 日志文件名中的时间，如：cdn.example.com_2024-01-02-15_part-00000.gz
+var logHourRegexp = regexp.MustCompile(`_(\d{4}-\d{2}-\d{2}-\d{2})_`)
+
+// LogFile CDN 日志文件信息，日志按小时归档，一个小时可能有多个分片
+type LogFile struct {
+	Domain       string `json:"domain"`
+	Name         string `json:"name"`
+	Size         int64  `json:"size"`
+	ModifiedTime int64  `json:"mtime"`
+	Url          string `json:"url"`
+}
+
+// Hour 日志文件对应的小时，文件名中不包含时间时返回零值
+func (f *LogFile) Hour() time.Time {
+	matches := logHourRegexp.FindStringSubmatch(f.Name)
+	if len(matches) < 2 {
+		return time.Time{}
+	}
+	t, err := time.ParseInLocation(LogHourLayout, matches[1], LogLocation)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// ListLogs 获取 domains 在 day（格式：2006-01-02）的日志文件列表，按域名及文件名排序
+func ListLogs(day string, domains []string) ([]*LogFile, *data.CodeError) {
+	cdnManager, err := getCdnManager()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, e := cdnManager.GetCdnLogList(day, domains)
+	if e != nil {
+		return nil, data.NewEmptyError().AppendDescF("CDN log list error:%v", e)
+	}
+
+	files := make([]*LogFile, 0)
+	for domain, infos := range resp.Data {
+		for _, info := range infos {
+			files = append(files, &LogFile{
+				Domain:       domain,
+				Name:         info.Name,
+				Size:         info.Size,
+				ModifiedTime: info.ModifiedTime,
+				Url:          info.URL,
+			})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].Domain != files[j].Domain {
+			return files[i].Domain < files[j].Domain
+		}
+		return files[i].Name < files[j].Name
+	})
+	return files, nil
+}
+
+// DownloadLog 下载日志文件至 toFile，先下载至临时文件，下载完成且大小一致后再重命名；
+// 网络错误、服务端错误及大小不一致时重试，4xx 错误（如：下载链接过期）不重试
+func DownloadLog(file *LogFile, toFile string) *data.CodeError {
+	if err := os.MkdirAll(filepath.Dir(toFile), os.ModePerm); err != nil {
+		return data.NewEmptyError().AppendDescF("create dir for %s error:%v", toFile, err)
+	}
+
+	var err *data.CodeError
+	for i := 0; i <= logDownloadRetryCount; i++ {
+		if i > 0 {
+			log.DebugF("download %s error:%v, retry:%d", file.Name, err, i)
+			time.Sleep(logDownloadRetryInterval * time.Duration(i))
+		}
+		if err = downloadLog(file, toFile); err == nil || (err.Code >= 400 && err.Code < 500) {
+			break
+		}
+	}
+	return err
+}
+
+func downloadLog(file *LogFile, toFile string) *data.CodeError {
+	resp, err := client.DefaultStorageClient().Get(file.Url)
+	if err != nil {
+		return data.NewEmptyError().AppendDescF("download %s error:%v", file.Name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return data.NewError(resp.StatusCode, "").AppendDescF("download %s error, status:%s", file.Name, resp.Status)
+	}
+
+	tempFile := toFile + ".tmp"
+	f, err := os.Create(tempFile)
+	if err != nil {
+		return data.NewEmptyError().AppendDescF("create temp file %s error:%v", tempFile, err)
+	}
+	size, err := io.Copy(f, resp.Body)
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err == nil && file.Size > 0 && size != file.Size {
+		err = data.NewEmptyError().AppendDescF("size doesn't match, download:%d but except:%d", size, file.Size)
+	}
+	if err != nil {
+		if rErr := os.Remove(tempFile); rErr != nil {
+			log.WarningF("remove temp file %s error:%v", tempFile, rErr)
+		}
+		return data.NewEmptyError().AppendDescF("download %s error:%v", file.Name, err)
+	}
+
+	if err = os.Rename(tempFile, toFile); err != nil {
+		return data.NewEmptyError().AppendDescF("rename temp file %s error:%v", tempFile, err)
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+package cdn
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+const logTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+// LogEntry CDN 日志中的一条访问记录，格式如下：
+// <Ip> <Hit> <ResponseTime> [<Time>] "<Method> <Url> <Protocol>" <Status> <Bytes> "<Referer>" "<UserAgent>"
+type LogEntry struct {
+	Ip        string
+	Time      time.Time
+	Method    string
+	Url       string // 不包含 query
+	Status    int
+	Bytes     int64
+	Referer   string
+	UserAgent string
+}
+
+type logField struct {
+	value string
+	quote byte // 字段的包裹符号：[ 或 "，无包裹时为 0
+}
+
+// splitLogLine 按空格分割日志行，[] 及 "" 包裹的内容作为一个字段
+func splitLogLine(line string) []logField {
+	fields := make([]logField, 0, 12)
+	for i := 0; i < len(line); {
+		if line[i] == ' ' {
+			i++
+			continue
+		}
+
+		var end byte = ' '
+		quote := line[i]
+		switch quote {
+		case '[':
+			end = ']'
+		case '"':
+			end = '"'
+		default:
+			quote = 0
+		}
+		if quote != 0 {
+			i++
+		}
+
+		j := strings.IndexByte(line[i:], end)
+		if j < 0 {
+			j = len(line) - i
+		}
+		fields = append(fields, logField{value: line[i : i+j], quote: quote})
+		i += j + 1
+	}
+	return fields
+}
+
+// ParseLogLine 解析一行 CDN 日志
+func ParseLogLine(line string) (*LogEntry, *data.CodeError) {
+	fields := splitLogLine(line)
+	timeIndex := -1
+	for i, f := range fields {
+		if f.quote == '[' {
+			timeIndex = i
+			break
+		}
+	}
+	if timeIndex < 1 || len(fields) < timeIndex+4 || fields[timeIndex+1].quote != '"' {
+		return nil, data.NewEmptyError().AppendDescF("invalid cdn log line:%s", line)
+	}
+
+	t, err := time.Parse(logTimeLayout, fields[timeIndex].value)
+	if err != nil {
+		return nil, data.NewEmptyError().AppendDescF("invalid time of cdn log line:%s", line)
+	}
+	request := strings.Fields(fields[timeIndex+1].value)
+	if len(request) < 2 {
+		return nil, data.NewEmptyError().AppendDescF("invalid request of cdn log line:%s", line)
+	}
+	status, err := strconv.Atoi(fields[timeIndex+2].value)
+	if err != nil {
+		return nil, data.NewEmptyError().AppendDescF("invalid status of cdn log line:%s", line)
+	}
+	bytes, err := strconv.ParseInt(fields[timeIndex+3].value, 10, 64)
+	if err != nil {
+		bytes = 0
+	}
+
+	entry := &LogEntry{
+		Ip:     fields[0].value,
+		Time:   t,
+		Method: request[0],
+		Url:    request[1],
+		Status: status,
+		Bytes:  bytes,
+	}
+	if index := strings.IndexByte(entry.Url, '?'); index >= 0 {
+		entry.Url = entry.Url[:index]
+	}
+	if len(fields) > timeIndex+4 {
+		entry.Referer = fields[timeIndex+4].value
+	}
+	if len(fields) > timeIndex+5 {
+		entry.UserAgent = fields[timeIndex+5].value
+	}
+	return entry, nil
+}
+
+// LogStat 请求数及流量
+type LogStat struct {
+	Requests int64 `json:"requests"`
+	Bytes    int64 `json:"bytes"`
+}
+
+func (s *LogStat) add(bytes int64) {
+	s.Requests++
+	s.Bytes += bytes
+}
+
+// LogReportItem 报告中的一项统计，Name 为 url、状态码、小时、referer 或 ip
+type LogReportItem struct {
+	Name     string `json:"name"`
+	Location string `json:"location,omitempty"` // ip 所在地，仅 ip 统计且开启查询时有值
+	LogStat
+}
+
+// LogReportResult CDN 日志分析报告
+type LogReportResult struct {
+	TotalRequests int64            `json:"total_requests"`
+	TotalBytes    int64            `json:"total_bytes"`
+	InvalidLines  int64            `json:"invalid_lines"`
+	TopUrls       []*LogReportItem `json:"top_urls"`
+	StatusCodes   []*LogReportItem `json:"status_codes"`
+	Hours         []*LogReportItem `json:"hours"`
+	TopReferers   []*LogReportItem `json:"top_referers"`
+	TopIps        []*LogReportItem `json:"top_ips"`
+}
+
+// LogReport 统计 CDN 日志，非并发安全
+type LogReport struct {
+	total        LogStat
+	invalidLines int64
+	urls         map[string]*LogStat
+	statusCodes  map[string]*LogStat
+	hours        map[string]*LogStat
+	referers     map[string]*LogStat
+	ips          map[string]*LogStat
+}
+
+func NewLogReport() *LogReport {
+	return &LogReport{
+		urls:        make(map[string]*LogStat),
+		statusCodes: make(map[string]*LogStat),
+		hours:       make(map[string]*LogStat),
+		referers:    make(map[string]*LogStat),
+		ips:         make(map[string]*LogStat),
+	}
+}
+
+func addLogStat(stats map[string]*LogStat, name string, bytes int64) {
+	s, ok := stats[name]
+	if !ok {
+		s = &LogStat{}
+		stats[name] = s
+	}
+	s.add(bytes)
+}
+
+// Add 添加一条访问记录，小时按日志中记录的时区统计
+func (r *LogReport) Add(entry *LogEntry) {
+	r.total.add(entry.Bytes)
+	addLogStat(r.urls, entry.Url, entry.Bytes)
+	addLogStat(r.statusCodes, strconv.Itoa(entry.Status), entry.Bytes)
+	addLogStat(r.hours, entry.Time.Format("2006-01-02 15:00"), entry.Bytes)
+	addLogStat(r.referers, entry.Referer, entry.Bytes)
+	addLogStat(r.ips, entry.Ip, entry.Bytes)
+}
+
+// AddLine 解析并添加一行日志，空行忽略，无法解析的行计入 InvalidLines
+func (r *LogReport) AddLine(line string) {
+	if len(strings.TrimSpace(line)) == 0 {
+		return
+	}
+	if entry, err := ParseLogLine(line); err != nil {
+		r.invalidLines++
+	} else {
+		r.Add(entry)
+	}
+}
+
+// AddFile 解析并添加日志文件中的所有记录，支持 gzip 压缩的文件
+func (r *LogReport) AddFile(path string) *data.CodeError {
+	f, err := os.Open(path)
+	if err != nil {
+		return data.NewEmptyError().AppendDescF("open cdn log file:%s error:%v", path, err)
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	var lineReader io.Reader = reader
+	if header, _ := reader.Peek(2); len(header) == 2 && header[0] == 0x1f && header[1] == 0x8b {
+		gzipReader, gErr := gzip.NewReader(reader)
+		if gErr != nil {
+			return data.NewEmptyError().AppendDescF("read gzip cdn log file:%s error:%v", path, gErr)
+		}
+		defer gzipReader.Close()
+		lineReader = gzipReader
+	}
+
+	scanner := bufio.NewScanner(lineReader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		r.AddLine(scanner.Text())
+	}
+	if sErr := scanner.Err(); sErr != nil {
+		return data.NewEmptyError().AppendDescF("read cdn log file:%s error:%v", path, sErr)
+	}
+	return nil
+}
+
+// Result 生成报告，top 为 url、referer 及 ip 统计的最大条数，小于 1 时不限制
+func (r *LogReport) Result(top int) *LogReportResult {
+	byName := func(items []*LogReportItem) []*LogReportItem {
+		sort.Slice(items, func(i, j int) bool {
+			return items[i].Name < items[j].Name
+		})
+		return items
+	}
+	return &LogReportResult{
+		TotalRequests: r.total.Requests,
+		TotalBytes:    r.total.Bytes,
+		InvalidLines:  r.invalidLines,
+		TopUrls:       topLogReportItems(r.urls, top),
+		StatusCodes:   byName(topLogReportItems(r.statusCodes, 0)),
+		Hours:         byName(topLogReportItems(r.hours, 0)),
+		TopReferers:   topLogReportItems(r.referers, top),
+		TopIps:        topLogReportItems(r.ips, top),
+	}
+}
+
+// topLogReportItems 按请求数、流量降序排列，取前 top 个
+func topLogReportItems(stats map[string]*LogStat, top int) []*LogReportItem {
+	items := make([]*LogReportItem, 0, len(stats))
+	for name, s := range stats {
+		items = append(items, &LogReportItem{
+			Name:    name,
+			LogStat: *s,
+		})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Requests != items[j].Requests {
+			return items[i].Requests > items[j].Requests
+		}
+		if items[i].Bytes != items[j].Bytes {
+			return items[i].Bytes > items[j].Bytes
+		}
+		return items[i].Name < items[j].Name
+	})
+	if top > 0 && len(items) > top {
+		items = items[:top]
+	}
+	return items
+}
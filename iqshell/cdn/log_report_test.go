@@ -0,0 +1,107 @@
+package cdn
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var testLogLines = []string{
+	`1.1.1.1 HIT 10 [02/Jan/2024:15:04:05 +0800] "GET http://cdn.example.com/a.jpg?v=1 HTTP/1.1" 200 100 "-" "curl/7.64.1"`,
+	`1.1.1.1 MISS 20 [02/Jan/2024:15:10:00 +0800] "GET http://cdn.example.com/a.jpg HTTP/1.1" 200 100 "https://www.example.com/" "Mozilla/5.0 (Macintosh; Intel Mac OS X)"`,
+	`2.2.2.2 HIT 5 [02/Jan/2024:16:00:00 +0800] "GET http://cdn.example.com/b.jpg HTTP/1.1" 404 10 "-" "curl/7.64.1"`,
+	``,
+	`invalid line`,
+}
+
+func TestParseLogLine(t *testing.T) {
+	entry, err := ParseLogLine(testLogLines[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Ip != "1.1.1.1" || entry.Method != "GET" || entry.Url != "http://cdn.example.com/a.jpg" ||
+		entry.Status != 200 || entry.Bytes != 100 || entry.Referer != "https://www.example.com/" ||
+		entry.UserAgent != "Mozilla/5.0 (Macintosh; Intel Mac OS X)" {
+		t.Fatalf("entry invalid:%+v", entry)
+	}
+	if entry.Time.Format("2006-01-02 15:04:05") != "2024-01-02 15:10:00" {
+		t.Fatalf("time invalid:%v", entry.Time)
+	}
+
+	entry, err = ParseLogLine(testLogLines[0])
+	if err != nil || entry.Url != "http://cdn.example.com/a.jpg" {
+		t.Fatalf("url query should be removed:%+v, %v", entry, err)
+	}
+
+	for _, line := range []string{
+		"invalid line",
+		`1.1.1.1 [02/Jan/2024:15:04:05 +0800] "GET" 200 100`,
+		`1.1.1.1 [02/Jan/2024 15:04:05] "GET /a.jpg HTTP/1.1" 200 100`,
+		`1.1.1.1 [02/Jan/2024:15:04:05 +0800] "GET /a.jpg HTTP/1.1" ok 100`,
+	} {
+		if _, err := ParseLogLine(line); err == nil {
+			t.Fatalf("line should be invalid:%s", line)
+		}
+	}
+}
+
+func TestLogReport(t *testing.T) {
+	report := NewLogReport()
+	for _, line := range testLogLines {
+		report.AddLine(line)
+	}
+
+	result := report.Result(1)
+	if result.TotalRequests != 3 || result.TotalBytes != 210 || result.InvalidLines != 1 {
+		t.Fatalf("total invalid:%+v", result)
+	}
+	if len(result.TopUrls) != 1 || result.TopUrls[0].Name != "http://cdn.example.com/a.jpg" || result.TopUrls[0].Requests != 2 {
+		t.Fatalf("top urls invalid:%+v", result.TopUrls)
+	}
+	if len(result.StatusCodes) != 2 || result.StatusCodes[0].Name != "200" || result.StatusCodes[1].Bytes != 10 {
+		t.Fatalf("status codes invalid:%+v", result.StatusCodes)
+	}
+	if len(result.Hours) != 2 || result.Hours[0].Name != "2024-01-02 15:00" || result.Hours[0].Bytes != 200 {
+		t.Fatalf("hours invalid:%+v", result.Hours)
+	}
+	if len(result.TopReferers) != 1 || result.TopReferers[0].Name != "-" {
+		t.Fatalf("top referers invalid:%+v", result.TopReferers)
+	}
+	if len(result.TopIps) != 1 || result.TopIps[0].Name != "1.1.1.1" {
+		t.Fatalf("top ips invalid:%+v", result.TopIps)
+	}
+	if result = report.Result(0); len(result.TopIps) != 2 {
+		t.Fatalf("top ips without limit invalid:%+v", result.TopIps)
+	}
+}
+
+func TestLogReportAddGzipFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cdn.example.com_2024-01-02-15_part-00000.gz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := gzip.NewWriter(f)
+	for _, line := range testLogLines {
+		_, _ = w.Write([]byte(line + "\n"))
+	}
+	_ = w.Close()
+	_ = f.Close()
+
+	report := NewLogReport()
+	if err := report.AddFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if result := report.Result(10); result.TotalRequests != 3 || result.InvalidLines != 1 {
+		t.Fatalf("gzip file report invalid:%+v", result)
+	}
+
+	file := &LogFile{Name: filepath.Base(path)}
+	if file.Hour().Format(LogHourLayout) != "2024-01-02-15" {
+		t.Fatalf("log file hour invalid:%v", file.Hour())
+	}
+	if file = (&LogFile{Name: "a.gz"}); !file.Hour().IsZero() {
+		t.Fatalf("log file without hour should be zero:%v", file.Hour())
+	}
+}
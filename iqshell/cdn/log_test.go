@@ -0,0 +1,59 @@
+package cdn
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLogFileHour(t *testing.T) {
+	oldLocal := time.Local
+	time.Local = time.UTC
+	defer func() {
+		time.Local = oldLocal
+	}()
+
+	f := &LogFile{Name: "cdn.example.com_2024-01-02-15_part-00000.gz"}
+	want := time.Date(2024, 1, 2, 7, 0, 0, 0, time.UTC)
+	if hour := f.Hour(); !hour.Equal(want) {
+		t.Fatalf("hour should be parsed in +0800, want:%s, got:%s", want, hour)
+	}
+
+	f = &LogFile{Name: "cdn.example.com.gz"}
+	if hour := f.Hour(); !hour.IsZero() {
+		t.Fatalf("hour should be zero, got:%s", hour)
+	}
+}
+
+func TestDownloadLogRetry(t *testing.T) {
+	var requestCount int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/retry":
+			if atomic.AddInt32(&requestCount, 1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte("log"))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	toFile := filepath.Join(t.TempDir(), "cdn.example.com", "a.gz")
+	if err := DownloadLog(&LogFile{Name: "a.gz", Size: 3, Url: server.URL + "/retry"}, toFile); err != nil {
+		t.Fatal("download log error:", err)
+	}
+	if content, _ := os.ReadFile(toFile); string(content) != "log" || requestCount != 2 {
+		t.Fatalf("download log should retry, content:%s request count:%d", content, requestCount)
+	}
+
+	if err := DownloadLog(&LogFile{Name: "b.gz", Url: server.URL + "/expired"}, toFile+".b"); err == nil || err.Code != http.StatusForbidden {
+		t.Fatalf("download log should fail without retry, error:%v", err)
+	}
+}
@@ -0,0 +1,150 @@
+package operations
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/qiniu/qshell/v2/iqshell/cdn"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/job"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/download"
+)
+
+// AutoRefreshInfo 上传、批量修改及同步后自动刷新被修改文件的 CDN 缓存
+type AutoRefreshInfo struct {
+	Domain   string // CDN 域名，为空时不刷新；可以包含 http:// 或 https://，不包含时使用 http
+	QpsLimit int    // 刷新请求的 qps 限制
+}
+
+func (info *AutoRefreshInfo) Enable() bool {
+	return len(info.Domain) > 0
+}
+
+func (info *AutoRefreshInfo) Check() *data.CodeError {
+	if !info.Enable() {
+		return nil
+	}
+	domain := strings.TrimSuffix(strings.TrimSpace(info.Domain), "/")
+	domain = strings.TrimPrefix(strings.TrimPrefix(domain, "https://"), "http://")
+	if len(domain) == 0 || strings.Contains(domain, "/") {
+		return alert.Error("cdn refresh domain should be a domain, such as cdn.example.com or https://cdn.example.com", "")
+	}
+	if info.QpsLimit < 0 {
+		return alert.Error("cdn refresh qps can't be negative", "")
+	}
+	return nil
+}
+
+// AutoRefresher 收集被修改的 key，按 cdn.BatchRefreshUrlsAllowMax 分批提交刷新；
+// 刷新任务记录在当前命令的 job 目录中，可通过 cdnstatus 查询，不输出结构化结果。未开启时为 nil，所有方法均可安全调用
+type AutoRefresher struct {
+	info     AutoRefreshInfo
+	recorder *taskRecorder
+	lock     sync.Mutex
+	urls     []string
+	count    int
+}
+
+// NewAutoRefresher 创建自动刷新器，需在工作区加载之后调用；未配置 CDN 域名时返回 nil
+func NewAutoRefresher(info AutoRefreshInfo) *AutoRefresher {
+	if !info.Enable() {
+		return nil
+	}
+
+	createQpsLimitIfNeeded(info.QpsLimit)
+	return &AutoRefresher{
+		info:     info,
+		recorder: newTaskRecorder(),
+		urls:     make([]string, 0, cdn.BatchRefreshUrlsAllowMax),
+	}
+}
+
+// AddKeys 添加被修改的 key，满一批时提交刷新
+func (r *AutoRefresher) AddKeys(keys ...string) {
+	if r == nil {
+		return
+	}
+
+	batches := make([][]string, 0)
+	r.lock.Lock()
+	for _, key := range keys {
+		if len(key) == 0 {
+			continue
+		}
+		r.urls = append(r.urls, autoRefreshUrl(r.info.Domain, key))
+		if len(r.urls) >= cdn.BatchRefreshUrlsAllowMax {
+			batches = append(batches, r.takeUrls())
+		}
+	}
+	r.lock.Unlock()
+
+	for _, urls := range batches {
+		r.submit(urls)
+	}
+}
+
+// Close 提交剩余的 key 并输出刷新任务的 JobId，需在所有 AddKeys 调用结束后调用
+func (r *AutoRefresher) Close() {
+	if r == nil {
+		return
+	}
+
+	r.lock.Lock()
+	urls := r.takeUrls()
+	r.lock.Unlock()
+	r.submit(urls)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if len(r.recorder.tasks) == 0 {
+		return
+	}
+	if jobId := r.recorder.jobId(); job.IsJobId(jobId) {
+		log.AlertF("CDN refresh: %d urls submitted in %d requests, query the status with: qshell cdnstatus %s",
+			r.count, len(r.recorder.tasks), jobId)
+	} else {
+		requestIds := make([]string, 0, len(r.recorder.tasks))
+		for _, task := range r.recorder.tasks {
+			requestIds = append(requestIds, task.RequestId)
+		}
+		log.AlertF("CDN refresh: %d urls submitted, RequestId: %s", r.count, strings.Join(requestIds, ","))
+	}
+}
+
+// takeUrls 取出待提交的 url，调用时需持有 r.lock
+func (r *AutoRefresher) takeUrls() []string {
+	urls := r.urls
+	r.urls = make([]string, 0, cdn.BatchRefreshUrlsAllowMax)
+	return urls
+}
+
+// submit 提交刷新；QPS 等待及刷新请求不持有 r.lock，不会阻塞其他 AddKeys 的调用
+func (r *AutoRefresher) submit(urls []string) {
+	if len(urls) == 0 {
+		return
+	}
+
+	waiterIfNeeded()
+	task, err := cdn.RefreshUrls(urls)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("CDN refresh error:%v, urls:%s", err, strings.Join(urls, ","))
+	} else {
+		r.count += len(urls)
+		r.recorder.add(task)
+	}
+}
+
+// autoRefreshUrl key 对应的 CDN 外链；CDN 按访问路径缓存，key 中的 / 不转义
+func autoRefreshUrl(domain, key string) string {
+	url := download.PublicUrl(download.UrlApiInfo{
+		BucketDomain: domain,
+		Key:          key,
+	})
+	return strings.ReplaceAll(url, "%2F", "/")
+}
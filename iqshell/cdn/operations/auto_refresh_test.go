@@ -0,0 +1,48 @@
+package operations
+
+import (
+	"testing"
+)
+
+func TestAutoRefreshInfoCheck(t *testing.T) {
+	for _, c := range []struct {
+		info  AutoRefreshInfo
+		valid bool
+	}{
+		{info: AutoRefreshInfo{}, valid: true},
+		{info: AutoRefreshInfo{Domain: "cdn.example.com"}, valid: true},
+		{info: AutoRefreshInfo{Domain: "https://cdn.example.com/"}, valid: true},
+		{info: AutoRefreshInfo{Domain: "cdn.example.com", QpsLimit: 10}, valid: true},
+		{info: AutoRefreshInfo{Domain: "https://"}, valid: false},
+		{info: AutoRefreshInfo{Domain: "cdn.example.com/a"}, valid: false},
+		{info: AutoRefreshInfo{Domain: "cdn.example.com", QpsLimit: -1}, valid: false},
+	} {
+		if err := c.info.Check(); (err == nil) != c.valid {
+			t.Fatalf("check %+v, expect valid:%v, error:%v", c.info, c.valid, err)
+		}
+	}
+}
+
+func TestAutoRefreshUrl(t *testing.T) {
+	for _, c := range []struct {
+		domain string
+		key    string
+		url    string
+	}{
+		{domain: "cdn.example.com", key: "a.jpg", url: "http://cdn.example.com/a.jpg"},
+		{domain: "https://cdn.example.com", key: "dir/a b.jpg", url: "https://cdn.example.com/dir/a%20b.jpg"},
+	} {
+		if url := autoRefreshUrl(c.domain, c.key); url != c.url {
+			t.Fatalf("url of %s/%s, expect:%s, but:%s", c.domain, c.key, c.url, url)
+		}
+	}
+}
+
+func TestNilAutoRefresher(t *testing.T) {
+	refresher := NewAutoRefresher(AutoRefreshInfo{})
+	if refresher != nil {
+		t.Fatal("auto refresher should be nil without domain")
+	}
+	refresher.AddKeys("a.jpg")
+	refresher.Close()
+}
@@ -0,0 +1,365 @@
+package operations
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/cdn"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
+)
+
+// Domain 【domain】无子命令时仅加载，--doc 时展示文档
+func Domain(cfg *iqshell.Config) {
+	iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{})
+}
+
+type DomainCreateInfo struct {
+	Domain     string // 域名 【必选】
+	Bucket     string // 绑定的存储空间，CDN 域名回源至此空间 【必选】
+	Origin     bool   // 创建源站域名，否则创建 CDN 域名 【可选】
+	Platform   string // CDN 域名的使用场景：web / download / vod，默认：web 【可选】
+	GeoCover   string // CDN 域名的覆盖范围：china / foreign / global，默认：china 【可选】
+	CertId     string // 证书 id，指定时创建 https 域名 【可选】
+	ForceHttps bool   // 强制 https 访问，仅 https 域名有效 【可选】
+	ConfigFile string // CDN 域名的配置文件，格式同 domain show 保存的配置文件，回源配置以 Bucket 为准 【可选】
+
+	config *cdn.DomainConfig
+}
+
+func (info *DomainCreateInfo) Check() *data.CodeError {
+	if len(info.Domain) == 0 {
+		return alert.CannotEmptyError("Domain", "")
+	}
+	if len(info.Bucket) == 0 {
+		return alert.CannotEmptyError("Bucket", "")
+	}
+	if info.Origin {
+		return nil
+	}
+
+	info.config = &cdn.DomainConfig{}
+	if len(info.ConfigFile) > 0 {
+		if c, err := cdn.LoadDomainConfig(info.ConfigFile); err != nil {
+			return err
+		} else {
+			info.config = c
+		}
+	}
+
+	c := info.config
+	c.Name, c.CName, c.OperatingState, c.OperatingStateDesc, c.CreateAt, c.ModifyAt = "", "", "", "", "", ""
+	if len(c.Type) == 0 {
+		c.Type = "normal"
+	}
+	if len(info.Platform) > 0 {
+		c.Platform = info.Platform
+	} else if len(c.Platform) == 0 {
+		c.Platform = cdn.DomainPlatformWeb
+	}
+	if len(info.GeoCover) > 0 {
+		c.GeoCover = info.GeoCover
+	} else if len(c.GeoCover) == 0 {
+		c.GeoCover = cdn.DomainGeoCoverChina
+	}
+	if len(info.CertId) > 0 {
+		c.Https = &cdn.DomainHttps{CertId: info.CertId, ForceHttps: info.ForceHttps}
+	} else if info.ForceHttps {
+		if c.Https == nil || len(c.Https.CertId) == 0 {
+			return alert.Error("force-https should be used with cert-id", "")
+		}
+		c.Https.ForceHttps = true
+	}
+	if c.Https != nil && len(c.Https.CertId) > 0 {
+		c.Protocol = cdn.DomainProtocolHttps
+	} else {
+		c.Protocol = cdn.DomainProtocolHttp
+		c.Https = nil
+	}
+	c.Source = &cdn.DomainSource{
+		SourceType:        cdn.DomainSourceTypeQiniuBucket,
+		SourceQiniuBucket: info.Bucket,
+	}
+
+	switch c.Platform {
+	case cdn.DomainPlatformWeb, cdn.DomainPlatformDownload, cdn.DomainPlatformVod:
+	default:
+		return data.NewEmptyError().AppendDescF("platform:%s not support, should be one of %s, %s and %s",
+			c.Platform, cdn.DomainPlatformWeb, cdn.DomainPlatformDownload, cdn.DomainPlatformVod)
+	}
+	switch c.GeoCover {
+	case cdn.DomainGeoCoverChina, cdn.DomainGeoCoverForeign, cdn.DomainGeoCoverGlobal:
+	default:
+		return data.NewEmptyError().AppendDescF("geo-cover:%s not support, should be one of %s, %s and %s",
+			c.GeoCover, cdn.DomainGeoCoverChina, cdn.DomainGeoCoverForeign, cdn.DomainGeoCoverGlobal)
+	}
+	return nil
+}
+
+// CreateDomain 【domain create】创建绑定存储空间的 CDN 域名或源站域名
+func CreateDomain(cfg *iqshell.Config, info DomainCreateInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	if info.Origin {
+		if err := cdn.BindOriginDomain(info.Domain, info.Bucket); err != nil {
+			data.SetCmdStatusError()
+			log.ErrorF("Create origin domain:%s of bucket:%s error:%v", info.Domain, info.Bucket, err)
+			return
+		}
+		output.Result(map[string]string{"domain": info.Domain, "bucket": info.Bucket, "type": "origin"})
+		log.AlertF("Create origin domain:%s of bucket:%s success", info.Domain, info.Bucket)
+		return
+	}
+
+	if err := cdn.CreateDomain(info.Domain, info.config); err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("Create cdn domain:%s of bucket:%s error:%v", info.Domain, info.Bucket, err)
+		return
+	}
+	info.config.Name = info.Domain
+	output.Result(info.config)
+	log.AlertF("Create cdn domain:%s of bucket:%s success, the domain is being deployed, "+
+		"see the cname and state with: qshell domain show %s", info.Domain, info.Bucket, info.Domain)
+}
+
+type DomainShowInfo struct {
+	Domain     string // 域名 【必选】
+	SaveToFile string // 配置保存为 json 文件，可修改后用于 domain update 【可选】
+}
+
+func (info *DomainShowInfo) Check() *data.CodeError {
+	if len(info.Domain) == 0 {
+		return alert.CannotEmptyError("Domain", "")
+	}
+	return nil
+}
+
+// ShowDomain 【domain show】展示 CDN 域名的配置
+func ShowDomain(cfg *iqshell.Config, info DomainShowInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	c, err := cdn.GetDomain(info.Domain)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("Get cdn domain:%s error:%v", info.Domain, err)
+		return
+	}
+	output.Result(c)
+	if len(info.SaveToFile) == 0 {
+		printDomainConfig(c)
+		return
+	}
+
+	content, e := json.MarshalIndent(c, "", "  ")
+	if e == nil {
+		e = os.WriteFile(info.SaveToFile, content, 0644)
+	}
+	if e != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("Save config of cdn domain:%s to %s error:%v", info.Domain, info.SaveToFile, e)
+		return
+	}
+	log.AlertF("Save config of cdn domain:%s to %s", info.Domain, info.SaveToFile)
+}
+
+func printDomainConfig(c *cdn.DomainConfig) {
+	log.AlertF("%-12s%s", "Name:", c.Name)
+	log.AlertF("%-12s%s", "CName:", c.CName)
+	log.AlertF("%-12s%s", "Type:", c.Type)
+	log.AlertF("%-12s%s", "Platform:", c.Platform)
+	log.AlertF("%-12s%s", "GeoCover:", c.GeoCover)
+	log.AlertF("%-12s%s", "Protocol:", c.Protocol)
+	if len(c.OperatingStateDesc) > 0 {
+		log.AlertF("%-12s%s (%s)", "State:", c.OperatingState, c.OperatingStateDesc)
+	} else {
+		log.AlertF("%-12s%s", "State:", c.OperatingState)
+	}
+	log.AlertF("%-12s%s", "CreateAt:", c.CreateAt)
+	log.AlertF("%-12s%s", "ModifyAt:", c.ModifyAt)
+
+	if s := c.Source; s != nil {
+		values := []string{s.SourceType}
+		if len(s.SourceQiniuBucket) > 0 {
+			values = append(values, "bucket:"+s.SourceQiniuBucket)
+		}
+		if len(s.SourceDomain) > 0 {
+			values = append(values, "domain:"+s.SourceDomain)
+		}
+		if len(s.SourceIPs) > 0 {
+			values = append(values, "ips:"+strings.Join(s.SourceIPs, ","))
+		}
+		for _, a := range s.AdvancedSources {
+			values = append(values, fmt.Sprintf("addr:%s(weight:%d backup:%t)", a.Addr, a.Weight, a.Backup))
+		}
+		if len(s.SourceHost) > 0 {
+			values = append(values, "host:"+s.SourceHost)
+		}
+		if len(s.SourceURLScheme) > 0 {
+			values = append(values, "scheme:"+s.SourceURLScheme)
+		}
+		log.AlertF("%-12s%s", "Source:", strings.Join(values, " "))
+	}
+	if h := c.Https; h != nil && len(h.CertId) > 0 {
+		log.AlertF("%-12scert:%s forceHttps:%t http2:%t", "Https:", h.CertId, h.ForceHttps, h.Http2Enable)
+	} else {
+		log.AlertF("%-12s%s", "Https:", "off")
+	}
+	if r := c.Referer; r != nil && len(r.RefererType) > 0 {
+		log.AlertF("%-12s%s %s nullReferer:%t", "Referer:", r.RefererType, strings.Join(r.RefererValues, ","), r.NullReferer)
+	} else {
+		log.AlertF("%-12s%s", "Referer:", "off")
+	}
+	if a := c.IpACL; a != nil && len(a.IpACLType) > 0 {
+		log.AlertF("%-12s%s %s", "IpACL:", a.IpACLType, strings.Join(a.IpACLValues, ","))
+	} else {
+		log.AlertF("%-12s%s", "IpACL:", "off")
+	}
+	if cache := c.Cache; cache != nil {
+		log.AlertF("%-12signoreParam:%t", "Cache:", cache.IgnoreParam)
+		for _, cc := range cache.CacheControls {
+			log.AlertF("%-12s%-8s%-30s%d%s", "", cc.Type, cc.Rule, cc.Time, domainCacheTimeUnit(cc.TimeUnit))
+		}
+	}
+}
+
+func domainCacheTimeUnit(unit int) string {
+	units := []string{"s", "m", "h", "d", "w", "M", "y"}
+	if unit < 0 || unit >= len(units) {
+		return ""
+	}
+	return units[unit]
+}
+
+type DomainStateInfo struct {
+	Domain string // 域名 【必选】
+	Force  bool   // 停用时不需要确认 【可选】
+}
+
+func (info *DomainStateInfo) Check() *data.CodeError {
+	if len(info.Domain) == 0 {
+		return alert.CannotEmptyError("Domain", "")
+	}
+	return nil
+}
+
+// OnlineDomain 【domain online】启用 CDN 域名
+func OnlineDomain(cfg *iqshell.Config, info DomainStateInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	if err := cdn.OnlineDomain(info.Domain); err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("Online cdn domain:%s error:%v", info.Domain, err)
+		return
+	}
+	output.Result(map[string]string{"domain": info.Domain, "state": cdn.DomainOperatingStateOnline})
+	log.AlertF("Online cdn domain:%s success", info.Domain)
+}
+
+// OfflineDomain 【domain offline】停用 CDN 域名，停用后域名不再提供服务
+func OfflineDomain(cfg *iqshell.Config, info DomainStateInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	log.AlertF("cdn domain:%s will be offline and stop serving", info.Domain)
+	if !info.Force && !flow.UserCodeVerification() {
+		return
+	}
+
+	if err := cdn.OfflineDomain(info.Domain); err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("Offline cdn domain:%s error:%v", info.Domain, err)
+		return
+	}
+	output.Result(map[string]string{"domain": info.Domain, "state": cdn.DomainOperatingStateOffline})
+	log.AlertF("Offline cdn domain:%s success", info.Domain)
+}
+
+type DomainUpdateInfo struct {
+	Domain     string // 域名 【必选】
+	ConfigFile string // 配置文件，格式同 domain show 保存的配置文件，仅更新文件中包含的 source、cache、referer、ipACL 及 https 【必选】
+	Force      bool   // 更新时不需要确认 【可选】
+
+	config *cdn.DomainConfig
+}
+
+func (info *DomainUpdateInfo) Check() *data.CodeError {
+	if len(info.Domain) == 0 {
+		return alert.CannotEmptyError("Domain", "")
+	}
+	if len(info.ConfigFile) == 0 {
+		return alert.CannotEmptyError("ConfigFile", "")
+	}
+
+	c, err := cdn.LoadDomainConfig(info.ConfigFile)
+	if err != nil {
+		return err
+	}
+	if c.Source == nil && c.Cache == nil && c.Referer == nil && c.IpACL == nil && c.Https == nil {
+		return alert.Error("config file should contain at least one of source, cache, referer, ipACL and https", "")
+	}
+	if len(c.Name) > 0 && c.Name != info.Domain {
+		return data.NewEmptyError().AppendDescF("the name:%s in config file is not the domain:%s", c.Name, info.Domain)
+	}
+	info.config = c
+	return nil
+}
+
+// UpdateDomain 【domain update】根据配置文件更新 CDN 域名的配置
+func UpdateDomain(cfg *iqshell.Config, info DomainUpdateInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	current, err := cdn.GetDomain(info.Domain)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("Get cdn domain:%s error:%v", info.Domain, err)
+		return
+	}
+	if len(current.Name) == 0 {
+		current.Name = info.Domain
+	}
+
+	sections := cdn.DomainChangedSections(current, info.config)
+	if len(sections) == 0 {
+		output.Result(map[string]interface{}{"domain": info.Domain, "updated": sections})
+		log.AlertF("config of cdn domain:%s is not changed", info.Domain)
+		return
+	}
+	log.AlertF("%s of cdn domain:%s will be updated", strings.Join(sections, ", "), info.Domain)
+	if !info.Force && !flow.UserCodeVerification() {
+		return
+	}
+
+	updated, err := cdn.UpdateDomain(current, info.config)
+	output.Result(map[string]interface{}{"domain": info.Domain, "updated": updated})
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("Update cdn domain:%s error:%v, updated:[%s]", info.Domain, err, strings.Join(updated, ", "))
+		return
+	}
+	log.AlertF("Update %s of cdn domain:%s success", strings.Join(updated, ", "), info.Domain)
+}
@@ -0,0 +1,193 @@
+package operations
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/cdn"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
+)
+
+type LogsInfo struct {
+	flow.Info
+
+	Domains  []string // 日志所属的域名
+	From     string   // 开始时间，格式：2006-01-02 或 2006-01-02-15
+	To       string   // 结束时间（包含），格式同 From，为空时同 From
+	DestDir  string   // 日志保存的文件夹，日志保存在 <DestDir>/<Domain>/ 下
+	ListOnly bool     // 仅列举日志文件，不下载
+
+	fromHour time.Time
+	toHour   time.Time
+}
+
+func (info *LogsInfo) Check() *data.CodeError {
+	if len(info.Domains) == 0 {
+		return alert.CannotEmptyError("Domain", "")
+	}
+	if len(info.From) == 0 {
+		return alert.CannotEmptyError("from", "")
+	}
+	if len(info.To) == 0 {
+		info.To = info.From
+	}
+
+	var err *data.CodeError
+	if info.fromHour, err = parseLogHour(info.From, false); err != nil {
+		return err
+	}
+	if info.toHour, err = parseLogHour(info.To, true); err != nil {
+		return err
+	}
+	if info.toHour.Before(info.fromHour) {
+		return alert.Error("to should not be earlier than from", "")
+	}
+
+	if len(info.DestDir) == 0 {
+		info.DestDir = "."
+	}
+	info.Force = true
+	return info.Info.Check()
+}
+
+// parseLogHour 解析日期或小时（北京时间），为日期时开始时间取当天的第一个小时，结束时间取当天的最后一个小时
+func parseLogHour(value string, isEnd bool) (time.Time, *data.CodeError) {
+	if t, err := time.ParseInLocation(cdn.LogHourLayout, value, cdn.LogLocation); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(cdn.LogDayLayout, value, cdn.LogLocation)
+	if err != nil {
+		return t, alert.Error("time:"+value+" is invalid, the format should be "+cdn.LogDayLayout+" or "+cdn.LogHourLayout, "")
+	}
+	if isEnd {
+		t = t.Add(23 * time.Hour)
+	}
+	return t, nil
+}
+
+// listLogs 列举时间范围内的日志文件，按天请求日志列表后按文件名中的小时过滤
+func (info *LogsInfo) listLogs() ([]*cdn.LogFile, *data.CodeError) {
+	files := make([]*cdn.LogFile, 0)
+	fromDay := time.Date(info.fromHour.Year(), info.fromHour.Month(), info.fromHour.Day(), 0, 0, 0, 0, cdn.LogLocation)
+	for day := fromDay; !day.After(info.toHour); day = day.AddDate(0, 0, 1) {
+		dayFiles, err := cdn.ListLogs(day.Format(cdn.LogDayLayout), info.Domains)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range dayFiles {
+			hour := f.Hour()
+			if hour.IsZero() || (!hour.Before(info.fromHour) && !hour.After(info.toHour)) {
+				files = append(files, f)
+			}
+		}
+	}
+	return files, nil
+}
+
+type logWork struct {
+	File   *cdn.LogFile
+	ToFile string
+}
+
+func (w *logWork) WorkId() string {
+	return w.ToFile
+}
+
+type logResult struct {
+	ToFile string
+}
+
+func (r *logResult) IsValid() bool {
+	return len(r.ToFile) > 0
+}
+
+// Logs 【cdnlogs】列举、下载 CDN 域名的访问日志
+func Logs(cfg *iqshell.Config, info LogsInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	files, err := info.listLogs()
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("CDN logs error:%v", err)
+		return
+	}
+
+	if info.ListOnly {
+		log.AlertF("%-30s\t%-50s\t%12s\t%-19s", "Domain", "Name", "Size", "ModifiedTime")
+		for _, f := range files {
+			output.Result(f)
+			log.AlertF("%-30s\t%-50s\t%12d\t%-19s", f.Domain, f.Name, f.Size,
+				time.Unix(f.ModifiedTime, 0).Format("2006-01-02 15:04:05"))
+		}
+		return
+	}
+
+	works := make([]flow.Work, 0, len(files))
+	for _, f := range files {
+		works = append(works, &logWork{
+			File:   f,
+			ToFile: filepath.Join(info.DestDir, f.Domain, f.Name),
+		})
+	}
+
+	var successCount, skipCount, failureCount int64
+	flow.New(info.Info).
+		WorkProviderWithArray(works).
+		WorkerProvider(flow.NewWorkerProvider(func() (flow.Worker, *data.CodeError) {
+			return flow.NewSimpleWorker(func(workInfo *flow.WorkInfo) (flow.Result, *data.CodeError) {
+				w, _ := workInfo.Work.(*logWork)
+				if e := cdn.DownloadLog(w.File, w.ToFile); e != nil {
+					return nil, e
+				}
+				return &logResult{ToFile: w.ToFile}, nil
+			}), nil
+		})).
+		DoWorkListMaxCount(1).
+		DoWorkListMinCount(1).
+		ShouldSkip(func(workInfo *flow.WorkInfo) (skip bool, cause *data.CodeError) {
+			// 已下载的日志不再下载
+			w, _ := workInfo.Work.(*logWork)
+			if stat, e := os.Stat(w.ToFile); e == nil && stat.Size() == w.File.Size {
+				return true, data.NewEmptyError().AppendDesc("already downloaded")
+			}
+			return false, nil
+		}).
+		OnWorkSkip(func(workInfo *flow.WorkInfo, result flow.Result, err *data.CodeError) {
+			atomic.AddInt64(&skipCount, 1)
+			w, _ := workInfo.Work.(*logWork)
+			log.InfoF("Skip %s because:%v", w.ToFile, err)
+		}).
+		OnWorkSuccess(func(workInfo *flow.WorkInfo, result flow.Result) {
+			atomic.AddInt64(&successCount, 1)
+			w, _ := workInfo.Work.(*logWork)
+			output.Result(w.File)
+			log.InfoF("Download Success, %s => %s", w.File.Name, w.ToFile)
+		}).
+		OnWorkFail(func(workInfo *flow.WorkInfo, err *data.CodeError) {
+			atomic.AddInt64(&failureCount, 1)
+			data.SetCmdStatusError()
+			w, _ := workInfo.Work.(*logWork)
+			log.ErrorF("Download Failed, %s => %s, error:%v", w.File.Name, w.ToFile, err)
+		}).Build().Start()
+
+	log.Alert("--------------- CDN Logs ----------------")
+	log.AlertF("%20s%10d", "Total:", len(files))
+	log.AlertF("%20s%10d", "Downloaded:", successCount)
+	log.AlertF("%20s%10d", "Skipped:", skipCount)
+	log.AlertF("%20s%10d", "Failure:", failureCount)
+	if destDir, e := filepath.Abs(info.DestDir); e == nil {
+		log.AlertF("%20s%s", "Dest Dir:", destDir)
+	}
+	log.Alert("-----------------------------------------")
+}
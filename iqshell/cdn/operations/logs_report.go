@@ -0,0 +1,116 @@
+package operations
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/cdn"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils/ip"
+)
+
+type LogsReportInfo struct {
+	Paths      []string // 日志文件或文件夹，文件夹中的所有日志文件均会被分析
+	Top        int      // url、referer 及 ip 统计的条数
+	IpLocation bool     // 是否查询 top ip 的所在地
+}
+
+func (info *LogsReportInfo) Check() *data.CodeError {
+	if len(info.Paths) == 0 {
+		return alert.CannotEmptyError("LogFile", "")
+	}
+	if info.Top < 1 {
+		info.Top = 10
+	}
+	return nil
+}
+
+// logFiles 需要分析的日志文件，忽略隐藏文件及未下载完成的临时文件
+func (info *LogsReportInfo) logFiles() ([]string, *data.CodeError) {
+	files := make([]string, 0)
+	for _, path := range info.Paths {
+		err := filepath.Walk(path, func(p string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if fi.IsDir() || strings.HasPrefix(fi.Name(), ".") || strings.HasSuffix(fi.Name(), ".tmp") {
+				return nil
+			}
+			files = append(files, p)
+			return nil
+		})
+		if err != nil {
+			return nil, data.NewEmptyError().AppendDescF("read cdn log path:%s error:%v", path, err)
+		}
+	}
+	return files, nil
+}
+
+// LogsReport 【cdnlogs-report】分析 cdnlogs 下载的日志，统计 top url、状态码、每小时的请求数及流量、top referer 及 top ip
+func LogsReport(cfg *iqshell.Config, info LogsReportInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	files, err := info.logFiles()
+	if err != nil {
+		data.SetCmdStatusError()
+		log.Error(err)
+		return
+	}
+
+	report := cdn.NewLogReport()
+	for _, f := range files {
+		log.DebugF("analyze cdn log file:%s", f)
+		if e := report.AddFile(f); e != nil {
+			data.SetCmdStatusError()
+			log.Error(e)
+		}
+	}
+
+	result := report.Result(info.Top)
+	if info.IpLocation {
+		parser := ip.DefaultParser()
+		for _, item := range result.TopIps {
+			if r, e := parser.Parse(item.Name); e != nil {
+				log.WarningF("query location of ip:%s error:%v", item.Name, e)
+			} else if l, ok := r.(ip.LocationResult); ok {
+				item.Location = l.Location()
+			}
+		}
+	}
+
+	output.Result(result)
+	printLogReport(len(files), result)
+}
+
+func printLogReport(fileCount int, result *cdn.LogReportResult) {
+	log.AlertF("Files: %d, Requests: %d, Bytes: %d(%s), Invalid Lines: %d", fileCount,
+		result.TotalRequests, result.TotalBytes, utils.FormatFileSize(result.TotalBytes), result.InvalidLines)
+	printLogReportItems("Top Urls", "Url", result.TopUrls)
+	printLogReportItems("Status Codes", "Status", result.StatusCodes)
+	printLogReportItems("Hours", "Hour", result.Hours)
+	printLogReportItems("Top Referers", "Referer", result.TopReferers)
+	printLogReportItems("Top Ips", "Ip", result.TopIps)
+}
+
+func printLogReportItems(title string, name string, items []*cdn.LogReportItem) {
+	log.Alert("")
+	log.AlertF("--------------- %s ----------------", title)
+	log.AlertF("%10s\t%12s\t%-10s\t%s", "Requests", "Bytes", "Size", name)
+	for _, item := range items {
+		if len(item.Location) > 0 {
+			log.AlertF("%10d\t%12d\t%-10s\t%s (%s)", item.Requests, item.Bytes, utils.FormatFileSize(item.Bytes), item.Name, item.Location)
+		} else {
+			log.AlertF("%10d\t%12d\t%-10s\t%s", item.Requests, item.Bytes, utils.FormatFileSize(item.Bytes), item.Name)
+		}
+	}
+}
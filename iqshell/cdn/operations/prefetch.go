@@ -1,26 +1,38 @@
 package operations
 
 import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
 	"github.com/qiniu/qshell/v2/iqshell"
 	"github.com/qiniu/qshell/v2/iqshell/cdn"
 	"github.com/qiniu/qshell/v2/iqshell/common/alert"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/flow"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
-	"strings"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
 )
 
 type PrefetchInfo struct {
 	UrlListFile string // url 信息文件
 	SizeLimit   int    // 每次刷新最大 size 限制
 	QpsLimit    int    // qps 限制
+	WaitInfo
 }
 
 func (info *PrefetchInfo) Check() *data.CodeError {
-	return nil
+	return info.WaitInfo.Check()
 }
 
 func Prefetch(cfg *iqshell.Config, info PrefetchInfo) {
+	// 每次执行均为新的预取任务
+	cfg.JobPathBuilder = func(cmdPath string) string {
+		jobId := utils.Md5Hex(fmt.Sprintf("%s:%s:%d", cfg.CmdCfg.CmdId, info.UrlListFile, time.Now().UnixNano()))
+		return filepath.Join(cmdPath, jobId)
+	}
+
 	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
 		Checker: &info,
 	}); !shouldContinue {
@@ -50,6 +62,7 @@ func Prefetch(cfg *iqshell.Config, info PrefetchInfo) {
 
 	createQpsLimitIfNeeded(info.QpsLimit)
 
+	recorder := newTaskRecorder()
 	urlsToPrefetch := make([]string, 0, 50)
 	for {
 		hasMore, workInfo, pErr := workProvider.Provide()
@@ -67,27 +80,26 @@ func Prefetch(cfg *iqshell.Config, info PrefetchInfo) {
 
 		if len(urlsToPrefetch) == cdn.BatchPrefetchAllowMax ||
 			(info.SizeLimit > 0 && len(urlsToPrefetch) >= info.SizeLimit) {
-			prefetchWithQps(urlsToPrefetch)
+			prefetchWithQps(recorder, urlsToPrefetch)
 			urlsToPrefetch = make([]string, 0, 50)
 		}
 	}
 
 	if len(urlsToPrefetch) > 0 {
-		prefetchWithQps(urlsToPrefetch)
+		prefetchWithQps(recorder, urlsToPrefetch)
 	}
+
+	recorder.end(info.WaitInfo)
 }
 
-func prefetchWithQps(urlsToPrefetch []string) {
+func prefetchWithQps(recorder *taskRecorder, urlsToPrefetch []string) {
 
 	waiterIfNeeded()
 
 	log.DebugF("cdnPrefetch, url size: %d", len(urlsToPrefetch))
 	if len(urlsToPrefetch) > 0 {
-		err := cdn.Prefetch(urlsToPrefetch)
-		if err != nil {
-			log.Error(err)
-			data.SetCmdStatusError()
-		}
+		task, err := cdn.Prefetch(urlsToPrefetch)
+		recorder.record(task, len(urlsToPrefetch), err)
 	}
 }
 
@@ -1,13 +1,18 @@
 package operations
 
 import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
 	"github.com/qiniu/qshell/v2/iqshell"
 	"github.com/qiniu/qshell/v2/iqshell/cdn"
 	"github.com/qiniu/qshell/v2/iqshell/common/alert"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/flow"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
-	"strings"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
 )
 
 type RefreshInfo struct {
@@ -15,14 +20,20 @@ type RefreshInfo struct {
 	IsDir        bool
 	SizeLimit    int
 	QpsLimit     int
+	WaitInfo
 }
 
 func (info *RefreshInfo) Check() *data.CodeError {
-	return nil
+	return info.WaitInfo.Check()
 }
 
 // Refresh 【cdnrefresh】刷新所有CDN节点
 func Refresh(cfg *iqshell.Config, info RefreshInfo) {
+	// 每次执行均为新的刷新任务
+	cfg.JobPathBuilder = func(cmdPath string) string {
+		jobId := utils.Md5Hex(fmt.Sprintf("%s:%s:%d", cfg.CmdCfg.CmdId, info.ItemListFile, time.Now().UnixNano()))
+		return filepath.Join(cmdPath, jobId)
+	}
 
 	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
 		Checker: &info,
@@ -53,6 +64,7 @@ func Refresh(cfg *iqshell.Config, info RefreshInfo) {
 
 	createQpsLimitIfNeeded(info.QpsLimit)
 
+	recorder := newTaskRecorder()
 	itemsToRefresh := make([]string, 0, 50)
 	for {
 		hasMore, workInfo, pErr := workProvider.Provide()
@@ -67,39 +79,41 @@ func Refresh(cfg *iqshell.Config, info RefreshInfo) {
 
 		w, _ := workInfo.Work.(*refreshWork)
 		itemsToRefresh = append(itemsToRefresh, w.Url)
-		if refreshWithQps(info, itemsToRefresh, false) {
+		if refreshWithQps(info, recorder, itemsToRefresh, false) {
 			itemsToRefresh = make([]string, 0, 50)
 		}
 	}
 
 	//check final items
 	if len(itemsToRefresh) > 0 {
-		refreshWithQps(info, itemsToRefresh, true)
+		refreshWithQps(info, recorder, itemsToRefresh, true)
 	}
+
+	recorder.end(info.WaitInfo)
 }
 
-func refreshWithQps(info RefreshInfo, items []string, force bool) (isRefresh bool) {
+func refreshWithQps(info RefreshInfo, recorder *taskRecorder, items []string, force bool) (isRefresh bool) {
+	var task *cdn.Task
 	var err *data.CodeError
 
 	if info.IsDir {
 		if force || len(items) == cdn.BatchRefreshDirsAllowMax ||
 			(info.SizeLimit > 0 && len(items) >= info.SizeLimit) {
 			waiterIfNeeded()
-			err = cdn.Refresh(nil, items)
+			task, err = cdn.Refresh(nil, items)
 			isRefresh = true
 		}
 	} else {
 		if force || len(items) == cdn.BatchRefreshUrlsAllowMax ||
 			(info.SizeLimit > 0 && len(items) >= info.SizeLimit) {
 			waiterIfNeeded()
-			err = cdn.Refresh(items, nil)
+			task, err = cdn.Refresh(items, nil)
 			isRefresh = true
 		}
 	}
 
-	if err != nil {
-		log.Error(err)
-		data.SetCmdStatusError()
+	if isRefresh {
+		recorder.record(task, len(items), err)
 	}
 	return
 }
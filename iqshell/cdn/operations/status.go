@@ -0,0 +1,58 @@
+package operations
+
+import (
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/cdn"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/job"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+)
+
+type StatusInfo struct {
+	JobId string
+	WaitInfo
+}
+
+func (info *StatusInfo) Check() *data.CodeError {
+	if len(info.JobId) == 0 {
+		return alert.CannotEmptyError("JobId", "")
+	}
+	return info.WaitInfo.Check()
+}
+
+// Status 【cdnstatus】查询 job 中记录的刷新、预取任务中每个 url 的处理结果
+func Status(cfg *iqshell.Config, info StatusInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	j, err := job.FindJob(job.ListJobs([]string{workspace.GetUserDir()}), info.JobId)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("CDN status error:%v", err)
+		return
+	}
+
+	tasks, err := cdn.LoadTasks(j.Dir)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("CDN status, job:%s has no cdn refresh or prefetch task, %v", j.Id, err)
+		return
+	}
+
+	var results []*cdn.UrlResult
+	if info.Wait {
+		results = waitTasks(info.WaitInfo, tasks)
+	} else {
+		results, err = queryTaskResults(tasks)
+		if err != nil {
+			data.SetCmdStatusError()
+			log.ErrorF("CDN status error:%v", err)
+		}
+	}
+	printTaskResults(results)
+}
@@ -0,0 +1,61 @@
+package operations
+
+import (
+	"path/filepath"
+
+	"github.com/qiniu/qshell/v2/iqshell/cdn"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+)
+
+// taskRecorder 将刷新、预取的任务记录到 job 目录中，用于 cdnstatus 查询及 --wait 等待
+type taskRecorder struct {
+	jobDir string
+	tasks  []*cdn.Task
+}
+
+func newTaskRecorder() *taskRecorder {
+	return &taskRecorder{
+		jobDir: workspace.GetJobDir(),
+		tasks:  make([]*cdn.Task, 0),
+	}
+}
+
+// record 记录一次提交的结果，urlCount 为提交的 url 数
+func (r *taskRecorder) record(task *cdn.Task, urlCount int, err *data.CodeError) {
+	if err != nil {
+		workspace.AddJobFailureCount(int64(urlCount))
+		log.Error(err)
+		data.SetCmdStatusError()
+		return
+	}
+
+	workspace.AddJobSuccessCount(int64(urlCount))
+	r.add(task)
+}
+
+// add 记录提交成功的任务
+func (r *taskRecorder) add(task *cdn.Task) {
+	r.tasks = append(r.tasks, task)
+	if sErr := cdn.SaveTask(r.jobDir, task); sErr != nil {
+		log.WarningF("save cdn task:%s error:%v", task.RequestId, sErr)
+	}
+}
+
+func (r *taskRecorder) jobId() string {
+	return filepath.Base(r.jobDir)
+}
+
+// end 提交结束，输出 JobId，配置了 --wait 时等待所有任务处理结束
+func (r *taskRecorder) end(info WaitInfo) {
+	if len(r.tasks) == 0 {
+		return
+	}
+
+	jobId := r.jobId()
+	log.AlertF("Job Id: %s, %d requests submitted, query the status with: qshell cdnstatus %s", jobId, len(r.tasks), jobId)
+	if info.Wait {
+		logFailedResults(waitTasks(info, r.tasks))
+	}
+}
@@ -0,0 +1,185 @@
+package operations
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/cdn"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+)
+
+const (
+	TrafficFormatTable = "table"
+	TrafficFormatJson  = "json"
+	TrafficFormatCsv   = "csv"
+)
+
+type TrafficInfo struct {
+	Type        string   // 统计类型：cdn.TrafficTypeFlux / cdn.TrafficTypeBandwidth，由命令指定
+	Domains     []string // 统计的域名 【必选】
+	From        string   // 开始日期，格式：2006-01-02 【必选】
+	To          string   // 结束日期（包含），格式同 From，为空时同 From 【可选】
+	Granularity string   // 统计粒度：5min / hour / day，默认：day 【可选】
+	Format      string   // 输出格式：table / json / csv 【可选】
+	SaveToFile  string   // 报告保存路径，为空时输出至标准输出 【可选】
+}
+
+func (info *TrafficInfo) Check() *data.CodeError {
+	if len(info.Domains) == 0 {
+		return alert.CannotEmptyError("Domain", "")
+	}
+	if len(info.From) == 0 {
+		return alert.CannotEmptyError("from", "")
+	}
+	if len(info.To) == 0 {
+		info.To = info.From
+	}
+
+	from, err := time.Parse(cdn.TrafficDateLayout, info.From)
+	if err != nil {
+		return alert.Error("from:"+info.From+" is invalid, the format should be "+cdn.TrafficDateLayout, "")
+	}
+	to, err := time.Parse(cdn.TrafficDateLayout, info.To)
+	if err != nil {
+		return alert.Error("to:"+info.To+" is invalid, the format should be "+cdn.TrafficDateLayout, "")
+	}
+	if to.Before(from) {
+		return alert.Error("to should not be earlier than from", "")
+	}
+
+	switch info.Granularity {
+	case "":
+		info.Granularity = cdn.TrafficGranularityDay
+	case cdn.TrafficGranularity5Min, cdn.TrafficGranularityHour, cdn.TrafficGranularityDay:
+	default:
+		return data.NewEmptyError().AppendDescF("granularity:%s not support, should be one of %s, %s and %s",
+			info.Granularity, cdn.TrafficGranularity5Min, cdn.TrafficGranularityHour, cdn.TrafficGranularityDay)
+	}
+
+	switch info.Format {
+	case "":
+		info.Format = TrafficFormatTable
+	case TrafficFormatTable, TrafficFormatJson, TrafficFormatCsv:
+	default:
+		return data.NewEmptyError().AppendDescF("format:%s not support, should be one of %s, %s and %s",
+			info.Format, TrafficFormatTable, TrafficFormatJson, TrafficFormatCsv)
+	}
+	return nil
+}
+
+// Traffic 【cdnflux】【cdnbandwidth】查询域名的流量或带宽
+func Traffic(cfg *iqshell.Config, info TrafficInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	report, err := cdn.QueryTraffic(info.Type, info.From, info.To, info.Granularity, info.Domains)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("CDN %s error:%v", info.Type, err)
+		return
+	}
+	output.Result(report)
+
+	// 结构化输出时，标准输出仅输出 Record
+	if len(info.SaveToFile) == 0 && output.IsStructured() {
+		return
+	}
+
+	var out io.Writer = data.Stdout()
+	if len(info.SaveToFile) > 0 {
+		file, e := os.Create(info.SaveToFile)
+		if e != nil {
+			data.SetCmdStatusError()
+			log.ErrorF("CDN %s: create file:%s error:%v", info.Type, info.SaveToFile, e)
+			return
+		}
+		defer file.Close()
+		out = file
+	}
+
+	switch info.Format {
+	case TrafficFormatJson:
+		err = writeTrafficJson(out, report)
+	case TrafficFormatCsv:
+		err = writeTrafficCsv(out, report)
+	default:
+		err = writeTrafficTable(out, report)
+	}
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("CDN %s: write report error:%v", info.Type, err)
+		return
+	}
+	if len(info.SaveToFile) > 0 {
+		log.AlertF("CDN %s of %d domains from %s to %s saved to %s", info.Type, len(info.Domains), info.From, info.To, info.SaveToFile)
+	}
+}
+
+func writeTrafficJson(out io.Writer, report *cdn.TrafficReport) *data.CodeError {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return data.ConvertError(encoder.Encode(report))
+}
+
+// writeTrafficCsv 仅输出各时间点的数据，便于其他工具导入
+func writeTrafficCsv(out io.Writer, report *cdn.TrafficReport) *data.CodeError {
+	writer := csv.NewWriter(out)
+	_ = writer.Write([]string{"time", "domain", "china", "oversea", "total"})
+	for _, p := range report.Points {
+		_ = writer.Write([]string{p.Time, p.Domain, strconv.FormatInt(p.China, 10),
+			strconv.FormatInt(p.Oversea, 10), strconv.FormatInt(p.Total, 10)})
+	}
+	writer.Flush()
+	return data.ConvertError(writer.Error())
+}
+
+func writeTrafficTable(out io.Writer, report *cdn.TrafficReport) *data.CodeError {
+	unit, summary, readable := "bytes", "Sum", utils.FormatFileSize
+	if report.Type == cdn.TrafficTypeBandwidth {
+		unit, summary, readable = "bps", "Peak", formatBandwidth
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintf(writer, "Type: %s (%s)  From: %s  To: %s  Granularity: %s\n",
+		report.Type, unit, report.StartDate, report.EndDate, report.Granularity)
+	_, _ = fmt.Fprintln(writer, "\n[Points]")
+	_, _ = fmt.Fprintln(writer, "TIME\tDOMAIN\tCHINA\tOVERSEA\tTOTAL\tREADABLE")
+	for _, p := range report.Points {
+		_, _ = fmt.Fprintf(writer, "%s\t%s\t%d\t%d\t%d\t%s\n", p.Time, p.Domain, p.China, p.Oversea, p.Total, readable(p.Total))
+	}
+	_, _ = fmt.Fprintf(writer, "\n[%s]\n", summary)
+	_, _ = fmt.Fprintln(writer, "DOMAIN\tCHINA\tOVERSEA\tTOTAL\tREADABLE\tTIME")
+	for _, s := range report.Summaries {
+		_, _ = fmt.Fprintf(writer, "%s\t%d\t%d\t%d\t%s\t%s\n", s.Domain, s.China, s.Oversea, s.Total, readable(s.Total), s.Time)
+	}
+	return data.ConvertError(writer.Flush())
+}
+
+// formatBandwidth 带宽转换为可读的形式，例：1.50 Mbps
+func formatBandwidth(bps int64) string {
+	units := []string{"bps", "Kbps", "Mbps", "Gbps", "Tbps"}
+	value := float64(bps)
+	index := 0
+	for value >= 1000 && index < len(units)-1 {
+		value /= 1000
+		index++
+	}
+	if index == 0 {
+		return fmt.Sprintf("%d %s", bps, units[index])
+	}
+	return fmt.Sprintf("%.2f %s", value, units[index])
+}
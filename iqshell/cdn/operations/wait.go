@@ -0,0 +1,173 @@
+package operations
+
+import (
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/cdn"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+)
+
+const (
+	defaultWaitInterval = 10
+
+	// 没有处理中的 url 时，查询不到的 url 的状态连续多少次查询没有变化后结束等待
+	maxUnchangedPendingQueryCount = 6
+)
+
+// WaitInfo 等待刷新、预取任务处理结束的配置
+type WaitInfo struct {
+	Wait         bool // 等待任务中所有 url 处理结束
+	WaitInterval int  // 查询任务状态的间隔，单位：秒
+	WaitTimeout  int  // 等待的最长时间，0 为不限制，单位：秒
+}
+
+func (info *WaitInfo) Check() *data.CodeError {
+	if info.WaitInterval <= 0 {
+		info.WaitInterval = defaultWaitInterval
+	}
+	if info.WaitTimeout < 0 {
+		return alert.Error("wait timeout can't be negative", "")
+	}
+	return nil
+}
+
+// taskStateCount 各状态的 url 数
+type taskStateCount struct {
+	total      int
+	success    int
+	failure    int
+	processing int
+	pending    int // 查询不到的 url 数
+}
+
+func (c *taskStateCount) add(r *cdn.UrlResult) {
+	c.total++
+	switch {
+	case r.IsSuccess():
+		c.success++
+	case r.IsFinished():
+		c.failure++
+	case r.State == cdn.TaskStatePending:
+		c.pending++
+	default:
+		c.processing++
+	}
+}
+
+// queryTaskResults 查询所有任务的 url 处理结果；查询失败的任务中的 url 按处理中计
+func queryTaskResults(tasks []*cdn.Task) ([]*cdn.UrlResult, *data.CodeError) {
+	var err *data.CodeError
+	results := make([]*cdn.UrlResult, 0)
+	for _, task := range tasks {
+		items, qErr := cdn.QueryTask(task)
+		if qErr != nil {
+			err = qErr
+		}
+		results = append(results, cdn.TaskResults(task, items)...)
+	}
+	return results, err
+}
+
+// waitTasks 周期性查询任务状态，直到所有 url 处理结束、超时或被中断，返回最后一次查询到的 url 处理结果
+func waitTasks(info WaitInfo, tasks []*cdn.Task) []*cdn.UrlResult {
+	if len(tasks) == 0 {
+		log.Alert("CDN wait: no task to wait")
+		return nil
+	}
+
+	interval := time.Duration(info.WaitInterval) * time.Second
+	timeout := time.Duration(info.WaitTimeout) * time.Second
+	startTime := time.Now()
+	var results []*cdn.UrlResult
+	// 查询不到的 url 可能一直不会出现在查询结果中，没有处理中的 url 且状态多次查询没有变化时不再等待
+	lastCount := taskStateCount{}
+	unchangedCount := 0
+	for {
+		var err *data.CodeError
+		results, err = queryTaskResults(tasks)
+		if err != nil {
+			log.WarningF("CDN wait: %v, will retry later", err)
+		}
+
+		count := &taskStateCount{}
+		for _, r := range results {
+			count.add(r)
+		}
+		elapsed := time.Since(startTime).Truncate(time.Second)
+		log.AlertF("CDN wait: %d/%d success, %d processing, %d pending, %d failure, elapsed: %s",
+			count.success, count.total, count.processing, count.pending, count.failure, elapsed)
+		if err == nil && count.processing == 0 {
+			if count.pending == 0 {
+				break
+			}
+			if *count == lastCount {
+				unchangedCount++
+			} else {
+				unchangedCount = 0
+			}
+			if unchangedCount >= maxUnchangedPendingQueryCount {
+				data.SetCmdStatusError()
+				log.ErrorF("CDN wait: %d urls are not found in query results after %s, stop waiting", count.pending, elapsed)
+				break
+			}
+		} else {
+			unchangedCount = 0
+		}
+		lastCount = *count
+
+		notFinished := count.processing + count.pending
+		if timeout > 0 && time.Since(startTime)+interval > timeout {
+			data.SetCmdStatusError()
+			log.ErrorF("CDN wait timeout after %s, %d urls are not finished", elapsed, notFinished)
+			break
+		}
+		if !sleepUntilInterrupt(interval) {
+			data.SetCmdStatusError()
+			log.ErrorF("CDN wait interrupted, %d urls are not finished", notFinished)
+			break
+		}
+	}
+	return results
+}
+
+// logFailedResults 输出处理失败的 url，存在失败的 url 时命令以失败状态结束
+func logFailedResults(results []*cdn.UrlResult) {
+	for _, r := range results {
+		if r.IsFinished() && !r.IsSuccess() {
+			data.SetCmdStatusError()
+			log.ErrorF("CDN %s failed, Url: %s, State: %s, Desc: %s, RequestId: %s", r.Type, r.Url, r.State, r.StateDesc, r.RequestId)
+		}
+	}
+}
+
+// printTaskResults 输出每个 url 的处理结果，存在失败的 url 时命令以失败状态结束
+func printTaskResults(results []*cdn.UrlResult) {
+	log.AlertF("%-10s\t%-10s\t%8s\t%-19s\t%-32s\t%s", "Type", "State", "Progress", "EndAt", "RequestId", "Url")
+	for _, r := range results {
+		output.Result(r)
+		log.AlertF("%-10s\t%-10s\t%7d%%\t%-19s\t%-32s\t%s", r.Type, r.State, r.Progress, r.EndAt, r.RequestId, r.Url)
+		if r.IsFinished() && !r.IsSuccess() {
+			data.SetCmdStatusError()
+		}
+	}
+}
+
+// sleepUntilInterrupt 等待 duration，被中断时返回 false
+func sleepUntilInterrupt(duration time.Duration) bool {
+	deadline := time.Now().Add(duration)
+	for time.Now().Before(deadline) {
+		if workspace.IsCmdInterrupt() {
+			return false
+		}
+		step := time.Until(deadline)
+		if step > time.Second {
+			step = time.Second
+		}
+		time.Sleep(step)
+	}
+	return !workspace.IsCmdInterrupt()
+}
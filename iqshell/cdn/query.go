@@ -0,0 +1,174 @@
+package cdn
+
+import (
+	"github.com/qiniu/go-sdk/v7/auth"
+	"github.com/qiniu/go-sdk/v7/cdn"
+	"github.com/qiniu/go-sdk/v7/client"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+)
+
+const (
+	TaskStateProcessing = "processing" // 服务端处理中
+	TaskStateSuccess    = "success"
+	TaskStateFailure    = "failure"
+	TaskStatePending    = "pending" // 服务端查询不到，任务可能还未开始处理
+	TaskStateInvalid    = "invalid" // 提交时服务端返回的无效的 url
+)
+
+const queryTaskPageSize = 100
+
+// TaskItem 刷新、预取查询接口返回的单个 url 的处理状态
+type TaskItem struct {
+	Url       string `json:"url"`
+	State     string `json:"state"`
+	StateDesc string `json:"stateDesc"`
+	Progress  int    `json:"progress"`
+	RequestId string `json:"requestId"`
+	CreateAt  string `json:"createAt"`
+	BeginAt   string `json:"beginAt"`
+	EndAt     string `json:"endAt"`
+}
+
+type queryTaskRequest struct {
+	RequestId string `json:"requestId"`
+	PageNo    int    `json:"pageNo"`
+	PageSize  int    `json:"pageSize"`
+}
+
+type queryTaskResponse struct {
+	Code       int        `json:"code"`
+	Error      string     `json:"error"`
+	Items      []TaskItem `json:"items"`
+	TotalCount int        `json:"totalCount"`
+}
+
+// QueryRefresh 查询刷新任务中所有 url 的处理状态
+func QueryRefresh(requestId string) ([]TaskItem, *data.CodeError) {
+	return queryTask("/v2/tune/refresh/list", requestId)
+}
+
+// QueryPrefetch 查询预取任务中所有 url 的处理状态
+func QueryPrefetch(requestId string) ([]TaskItem, *data.CodeError) {
+	return queryTask("/v2/tune/prefetch/list", requestId)
+}
+
+// QueryTask 根据任务类型查询任务中所有 url 的处理状态
+func QueryTask(task *Task) ([]TaskItem, *data.CodeError) {
+	if task.Type == TaskTypePrefetch {
+		return QueryPrefetch(task.RequestId)
+	}
+	return QueryRefresh(task.RequestId)
+}
+
+func queryTask(path string, requestId string) ([]TaskItem, *data.CodeError) {
+	mac, err := workspace.GetMac()
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]TaskItem, 0)
+	for pageNo := 0; ; pageNo++ {
+		resp := &queryTaskResponse{}
+		if e := client.DefaultClient.CredentialedCallWithJson(workspace.GetContext(), mac, auth.TokenQBox, resp,
+			"POST", cdn.FusionHost+path, nil, queryTaskRequest{
+				RequestId: requestId,
+				PageNo:    pageNo,
+				PageSize:  queryTaskPageSize,
+			}); e != nil {
+			return nil, data.NewEmptyError().AppendDescF("query cdn task:%s error:%v", requestId, e)
+		}
+		if resp.Code != 200 {
+			return nil, data.NewEmptyError().AppendDescF("query cdn task:%s Code: %d, Error: %s", requestId, resp.Code, resp.Error)
+		}
+
+		items = append(items, resp.Items...)
+		if len(resp.Items) < queryTaskPageSize || len(items) >= resp.TotalCount {
+			break
+		}
+	}
+	return items, nil
+}
+
+// UrlResult 任务中单个 url 的处理结果
+type UrlResult struct {
+	Url       string `json:"url"`
+	Type      string `json:"type"`
+	IsDir     bool   `json:"is_dir,omitempty"`
+	RequestId string `json:"request_id"`
+	State     string `json:"state"`
+	StateDesc string `json:"state_desc,omitempty"`
+	Progress  int    `json:"progress"`
+	CreateAt  string `json:"create_at,omitempty"`
+	EndAt     string `json:"end_at,omitempty"`
+}
+
+// IsFinished url 已处理结束，成功、失败或无效
+func (r *UrlResult) IsFinished() bool {
+	return r.State == TaskStateSuccess || r.State == TaskStateFailure || r.State == TaskStateInvalid
+}
+
+// IsSuccess url 处理成功
+func (r *UrlResult) IsSuccess() bool {
+	return r.State == TaskStateSuccess
+}
+
+// TaskResults 将查询到的状态对应到任务中的每个 url，查询不到的 url 为 pending 状态；
+// 查询结果中存在但不在任务记录中的 url（如服务端对 url 做了规范化）也会返回
+func TaskResults(task *Task, items []TaskItem) []*UrlResult {
+	newResult := func(url string) *UrlResult {
+		return &UrlResult{
+			Url:       url,
+			Type:      task.Type,
+			IsDir:     task.IsDir,
+			RequestId: task.RequestId,
+			State:     TaskStatePending,
+		}
+	}
+
+	invalid := make(map[string]bool, len(task.InvalidUrls))
+	for _, url := range task.InvalidUrls {
+		invalid[url] = true
+	}
+
+	results := make([]*UrlResult, 0, len(task.Urls))
+	resultMap := make(map[string]*UrlResult, len(task.Urls))
+	for _, url := range task.Urls {
+		if _, ok := resultMap[url]; ok {
+			continue
+		}
+		r := newResult(url)
+		if invalid[url] {
+			r.State = TaskStateInvalid
+		}
+		results = append(results, r)
+		resultMap[url] = r
+	}
+
+	for _, item := range items {
+		r, ok := resultMap[item.Url]
+		if !ok {
+			r = newResult(item.Url)
+			results = append(results, r)
+			resultMap[item.Url] = r
+		}
+		r.State = item.State
+		r.StateDesc = item.StateDesc
+		r.Progress = item.Progress
+		r.CreateAt = item.CreateAt
+		r.EndAt = item.EndAt
+	}
+
+	// 服务端可能对 url 做了规范化，查询到的 url 数不少于提交的有效 url 数时，未对应上的 url 已包含在查询结果中
+	if validCount := len(task.Urls) - len(invalid); len(items) > 0 && len(items) >= validCount {
+		matched := make([]*UrlResult, 0, len(results))
+		for _, r := range results {
+			if r.State != TaskStatePending {
+				matched = append(matched, r)
+			}
+		}
+		results = matched
+	}
+	return results
+}
@@ -0,0 +1,81 @@
+package cdn
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+const (
+	TaskTypeRefresh  = "refresh"
+	TaskTypePrefetch = "prefetch"
+)
+
+// TasksFileName 刷新、预取任务的记录文件名，保存在 job 目录下，每行一个任务
+const TasksFileName = "cdn_tasks.jsonl"
+
+// Task 一次刷新或预取请求，RequestId 用于查询任务进度
+type Task struct {
+	RequestId   string   `json:"request_id"`
+	Type        string   `json:"type"`
+	IsDir       bool     `json:"is_dir,omitempty"`
+	Urls        []string `json:"urls"`
+	InvalidUrls []string `json:"invalid_urls,omitempty"`
+	SubmitTime  int64    `json:"submit_time"`
+}
+
+var tasksFileLock sync.Mutex
+
+// SaveTask 将任务追加到 job 目录下的任务记录文件中
+func SaveTask(jobDir string, task *Task) *data.CodeError {
+	d, err := json.Marshal(task)
+	if err != nil {
+		return data.NewEmptyError().AppendDesc("marshal cdn task error").AppendError(err)
+	}
+
+	tasksFileLock.Lock()
+	defer tasksFileLock.Unlock()
+
+	f, err := os.OpenFile(filepath.Join(jobDir, TasksFileName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return data.NewEmptyError().AppendDesc("open cdn task file error").AppendError(err)
+	}
+	defer f.Close()
+
+	if _, err = f.Write(append(d, '\n')); err != nil {
+		return data.NewEmptyError().AppendDesc("save cdn task error").AppendError(err)
+	}
+	return nil
+}
+
+// LoadTasks 加载 job 目录下记录的所有任务
+func LoadTasks(jobDir string) ([]*Task, *data.CodeError) {
+	f, err := os.Open(filepath.Join(jobDir, TasksFileName))
+	if err != nil {
+		return nil, data.NewEmptyError().AppendDesc("open cdn task file error").AppendError(err)
+	}
+	defer f.Close()
+
+	tasks := make([]*Task, 0)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		task := &Task{}
+		if err = json.Unmarshal(line, task); err != nil {
+			return nil, data.NewEmptyError().AppendDesc("parse cdn task error").AppendError(err)
+		}
+		tasks = append(tasks, task)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, data.NewEmptyError().AppendDesc("read cdn task file error").AppendError(err)
+	}
+	return tasks, nil
+}
@@ -0,0 +1,67 @@
+package cdn
+
+import (
+	"testing"
+)
+
+func TestSaveAndLoadTasks(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := LoadTasks(dir); err == nil {
+		t.Fatal("load tasks without task file should error")
+	}
+
+	for _, task := range []*Task{
+		{RequestId: "r1", Type: TaskTypeRefresh, Urls: []string{"http://a.com/1"}},
+		{RequestId: "r2", Type: TaskTypePrefetch, Urls: []string{"http://a.com/2", "http://a.com/3"}},
+	} {
+		if err := SaveTask(dir, task); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tasks, err := LoadTasks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 2 || tasks[0].RequestId != "r1" || tasks[1].Type != TaskTypePrefetch || len(tasks[1].Urls) != 2 {
+		t.Fatalf("tasks invalid:%+v", tasks)
+	}
+}
+
+func TestTaskResults(t *testing.T) {
+	task := &Task{
+		RequestId:   "r1",
+		Type:        TaskTypeRefresh,
+		Urls:        []string{"http://a.com/1", "http://a.com/2", "http://a.com/3"},
+		InvalidUrls: []string{"http://a.com/3"},
+	}
+
+	results := TaskResults(task, nil)
+	if len(results) != 3 || results[0].State != TaskStatePending || results[2].State != TaskStateInvalid {
+		t.Fatalf("results without items invalid:%+v", results)
+	}
+	if results[0].IsFinished() || !results[2].IsFinished() || results[2].IsSuccess() {
+		t.Fatal("result state check error")
+	}
+
+	results = TaskResults(task, []TaskItem{
+		{Url: "http://a.com/1", State: TaskStateSuccess, Progress: 100},
+	})
+	if len(results) != 3 || !results[0].IsSuccess() || results[1].State != TaskStatePending {
+		t.Fatalf("results with part items invalid:%+v", results)
+	}
+
+	// 服务端规范化后的 url 无法对应，但查询到的数量与有效 url 数一致
+	results = TaskResults(task, []TaskItem{
+		{Url: "http://a.com/1", State: TaskStateSuccess, Progress: 100},
+		{Url: "http://a.com:80/2", State: TaskStateProcessing, Progress: 50},
+	})
+	if len(results) != 3 {
+		t.Fatalf("results with normalized url invalid:%+v", results)
+	}
+	for _, r := range results {
+		if r.State == TaskStatePending {
+			t.Fatalf("normalized url should not be pending:%+v", r)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package cdn
+
+import (
+	"sort"
+
+	"github.com/qiniu/go-sdk/v7/cdn"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+)
+
+const (
+	TrafficTypeFlux      = "flux"      // 流量，单位：Byte
+	TrafficTypeBandwidth = "bandwidth" // 带宽，单位：bps
+)
+
+const (
+	TrafficGranularity5Min = "5min"
+	TrafficGranularityHour = "hour"
+	TrafficGranularityDay  = "day"
+)
+
+const (
+	TrafficDateLayout = "2006-01-02"
+
+	// 统计接口单次查询的最大域名数
+	trafficQueryDomainsMax = 100
+
+	// 所有域名汇总的名称
+	TrafficAllDomains = "(all)"
+)
+
+// TrafficPoint 一个域名在一个时间点的流量或带宽
+type TrafficPoint struct {
+	Time    string `json:"time"`
+	Domain  string `json:"domain"`
+	China   int64  `json:"china"`
+	Oversea int64  `json:"oversea"`
+	Total   int64  `json:"total"`
+}
+
+func (p *TrafficPoint) add(china, oversea int64) {
+	p.China += china
+	p.Oversea += oversea
+	p.Total += china + oversea
+}
+
+// TrafficReport 流量或带宽的统计结果，Points 按时间、域名排序；
+// Summaries 为每个域名及所有域名（TrafficAllDomains）的汇总：流量为总和，带宽为峰值
+type TrafficReport struct {
+	Type        string          `json:"type"`
+	Granularity string          `json:"granularity"`
+	StartDate   string          `json:"start_date"`
+	EndDate     string          `json:"end_date"`
+	Points      []*TrafficPoint `json:"points"`
+	Summaries   []*TrafficPoint `json:"summaries"`
+}
+
+// QueryTraffic 查询域名的流量（TrafficTypeFlux）或带宽（TrafficTypeBandwidth），域名较多时分批查询
+func QueryTraffic(trafficType, startDate, endDate, granularity string, domains []string) (*TrafficReport, *data.CodeError) {
+	cdnManager, err := getCdnManager()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &TrafficReport{
+		Type:        trafficType,
+		Granularity: granularity,
+		StartDate:   startDate,
+		EndDate:     endDate,
+		Points:      make([]*TrafficPoint, 0),
+	}
+	for start := 0; start < len(domains); start += trafficQueryDomainsMax {
+		end := start + trafficQueryDomainsMax
+		if end > len(domains) {
+			end = len(domains)
+		}
+
+		var resp cdn.TrafficResp
+		var e error
+		if trafficType == TrafficTypeBandwidth {
+			resp, e = cdnManager.GetBandwidthData(startDate, endDate, granularity, domains[start:end])
+		} else {
+			resp, e = cdnManager.GetFluxData(startDate, endDate, granularity, domains[start:end])
+		}
+		if e != nil {
+			return nil, data.NewEmptyError().AppendDescF("CDN %s query error:%v", trafficType, e)
+		} else if resp.Code != 200 {
+			return nil, data.NewError(resp.Code, "").AppendDescF("CDN %s query Code: %d, Error: %s", trafficType, resp.Code, resp.Error)
+		}
+		log.DebugF("CDN %s query, domains:%v, time count:%d", trafficType, domains[start:end], len(resp.Time))
+		report.Points = append(report.Points, trafficPoints(resp)...)
+	}
+	report.sort()
+	report.summarize()
+	return report, nil
+}
+
+// trafficPoints 将接口返回的按域名分组的数据转换为时间点，缺失的数据按 0 处理
+func trafficPoints(resp cdn.TrafficResp) []*TrafficPoint {
+	valueOf := func(values []int, index int) int64 {
+		if index < len(values) {
+			return int64(values[index])
+		}
+		return 0
+	}
+
+	points := make([]*TrafficPoint, 0, len(resp.Time)*len(resp.Data))
+	for domain, d := range resp.Data {
+		for i, t := range resp.Time {
+			p := &TrafficPoint{
+				Time:   t,
+				Domain: domain,
+			}
+			p.add(valueOf(d.DomainChina, i), valueOf(d.DomainOversea, i))
+			points = append(points, p)
+		}
+	}
+	return points
+}
+
+func (r *TrafficReport) sort() {
+	sort.SliceStable(r.Points, func(i, j int) bool {
+		if r.Points[i].Time != r.Points[j].Time {
+			return r.Points[i].Time < r.Points[j].Time
+		}
+		return r.Points[i].Domain < r.Points[j].Domain
+	})
+}
+
+// summarize 汇总每个域名及所有域名的数据，所有域名的带宽峰值为同一时间点带宽之和的峰值
+func (r *TrafficReport) summarize() {
+	domains := make(map[string]*TrafficPoint)
+	domainList := make([]string, 0)
+	times := make(map[string]*TrafficPoint)
+	timeList := make([]string, 0)
+	for _, p := range r.Points {
+		if _, ok := domains[p.Domain]; !ok {
+			domains[p.Domain] = &TrafficPoint{Domain: p.Domain}
+			domainList = append(domainList, p.Domain)
+		}
+		r.merge(domains[p.Domain], p)
+
+		if _, ok := times[p.Time]; !ok {
+			times[p.Time] = &TrafficPoint{Time: p.Time, Domain: TrafficAllDomains}
+			timeList = append(timeList, p.Time)
+		}
+		times[p.Time].add(p.China, p.Oversea)
+	}
+
+	all := &TrafficPoint{Domain: TrafficAllDomains}
+	for _, t := range timeList {
+		r.merge(all, times[t])
+	}
+
+	sort.Strings(domainList)
+	r.Summaries = make([]*TrafficPoint, 0, len(domainList)+1)
+	for _, domain := range domainList {
+		r.Summaries = append(r.Summaries, domains[domain])
+	}
+	r.Summaries = append(r.Summaries, all)
+}
+
+// merge 流量累加；带宽取峰值，峰值的时间记录在 Time 中
+func (r *TrafficReport) merge(summary *TrafficPoint, p *TrafficPoint) {
+	if r.Type != TrafficTypeBandwidth {
+		summary.add(p.China, p.Oversea)
+		return
+	}
+	if p.China > summary.China {
+		summary.China = p.China
+	}
+	if p.Oversea > summary.Oversea {
+		summary.Oversea = p.Oversea
+	}
+	if p.Total > summary.Total || len(summary.Time) == 0 {
+		summary.Total = p.Total
+		summary.Time = p.Time
+	}
+}
@@ -0,0 +1,57 @@
+package cdn
+
+import (
+	"testing"
+
+	"github.com/qiniu/go-sdk/v7/cdn"
+)
+
+var testTrafficResp = cdn.TrafficResp{
+	Code: 200,
+	Time: []string{"2024-01-02 00:00:00", "2024-01-03 00:00:00"},
+	Data: map[string]cdn.TrafficData{
+		"b.example.com": {DomainChina: []int{10, 30}, DomainOversea: []int{1}},
+		"a.example.com": {DomainChina: []int{20, 5}, DomainOversea: []int{2, 2}},
+	},
+}
+
+func TestTrafficPoints(t *testing.T) {
+	report := &TrafficReport{Type: TrafficTypeFlux, Points: trafficPoints(testTrafficResp)}
+	report.sort()
+	if len(report.Points) != 4 {
+		t.Fatalf("points count invalid:%d", len(report.Points))
+	}
+	first, last := report.Points[0], report.Points[3]
+	if first.Time != "2024-01-02 00:00:00" || first.Domain != "a.example.com" || first.Total != 22 {
+		t.Fatalf("first point invalid:%+v", first)
+	}
+	if last.Domain != "b.example.com" || last.China != 30 || last.Oversea != 0 || last.Total != 30 {
+		t.Fatalf("missing oversea value should be 0:%+v", last)
+	}
+}
+
+func TestTrafficSummaries(t *testing.T) {
+	report := &TrafficReport{Type: TrafficTypeFlux, Points: trafficPoints(testTrafficResp)}
+	report.sort()
+	report.summarize()
+	if len(report.Summaries) != 3 {
+		t.Fatalf("summaries count invalid:%d", len(report.Summaries))
+	}
+	if s := report.Summaries[0]; s.Domain != "a.example.com" || s.Total != 29 {
+		t.Fatalf("flux summary should be sum:%+v", s)
+	}
+	if s := report.Summaries[2]; s.Domain != TrafficAllDomains || s.Total != 70 {
+		t.Fatalf("flux summary of all domains invalid:%+v", s)
+	}
+
+	report = &TrafficReport{Type: TrafficTypeBandwidth, Points: trafficPoints(testTrafficResp)}
+	report.sort()
+	report.summarize()
+	if s := report.Summaries[1]; s.Domain != "b.example.com" || s.Total != 30 || s.Time != "2024-01-03 00:00:00" {
+		t.Fatalf("bandwidth summary should be peak:%+v", s)
+	}
+	// 所有域名：2024-01-02 为 33，2024-01-03 为 37
+	if s := report.Summaries[2]; s.Total != 37 || s.Time != "2024-01-03 00:00:00" || s.China != 35 || s.Oversea != 3 {
+		t.Fatalf("bandwidth summary of all domains invalid:%+v", s)
+	}
+}
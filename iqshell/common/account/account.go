@@ -13,6 +13,7 @@ type Account struct {
 	Name      string
 	AccessKey string
 	SecretKey string
+	Source    string `json:"-"` // 凭证来源，不保存
 }
 
 // 获取qbox.Mac
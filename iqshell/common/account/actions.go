@@ -190,21 +190,9 @@ func GetMac() (mac *qbox.Mac, err *data.CodeError) {
 // 切换账户
 func ChUser(userName string) (name string, err *data.CodeError) {
 	if userName != "" {
-		db, oErr := leveldb.OpenFile(info.AccountDBPath, nil)
-		if oErr != nil {
-			err = data.NewEmptyError().AppendDescF("open db: %v", oErr)
-			return
-		}
-		defer db.Close()
-
-		value, gErr := db.Get([]byte(userName), nil)
+		user, gErr := GetUser(userName)
 		if gErr != nil {
-			err = data.NewEmptyError().AppendDescF("can't find user by name:%s , error:%v", userName, gErr)
-			return
-		}
-		user, dErr := decrypt(string(value))
-		if dErr != nil {
-			err = data.NewEmptyError().AppendDescF("Decrypt account bytes: %v", dErr)
+			err = gErr
 			return
 		}
 
@@ -238,6 +226,34 @@ func ChUser(userName string) (name string, err *data.CodeError) {
 	return
 }
 
+// GetUser 根据用户名获取本地数据库中的账户，用户名需完全匹配
+func GetUser(userName string) (user Account, err *data.CodeError) {
+	if len(info.AccountDBPath) == 0 {
+		err = data.NewEmptyError().AppendDesc("empty account db path")
+		return
+	}
+
+	// 只读打开，多个进程（如：--accounts）可以同时读取账户
+	db, oErr := leveldb.OpenFile(info.AccountDBPath, &opt.Options{ReadOnly: true})
+	if oErr != nil {
+		err = data.NewEmptyError().AppendDescF("open db: %v", oErr)
+		return
+	}
+	defer db.Close()
+
+	value, gErr := db.Get([]byte(userName), nil)
+	if gErr != nil {
+		err = data.NewEmptyError().AppendDescF("can't find user by name:%s , error:%v", userName, gErr)
+		return
+	}
+	user, dErr := decrypt(string(value))
+	if dErr != nil {
+		err = data.NewEmptyError().AppendDescF("Decrypt account bytes: %v", dErr)
+		return
+	}
+	return
+}
+
 // 获取用户列表
 func GetUsers() (ret []*Account, err *data.CodeError) {
 
@@ -322,7 +338,7 @@ func LookUp(userName string) ([]Account, *data.CodeError) {
 			value = string(iter.Value())
 			acc, DErr := decrypt(value)
 			if DErr != nil {
-				log.ErrorF("Decrypt account bytes: %v", err)
+				log.ErrorF("Decrypt account bytes: %v", DErr)
 				continue
 			}
 			accounts = append(accounts, acc)
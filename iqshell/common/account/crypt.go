@@ -46,8 +46,32 @@ func encrypt(accessKey, encryptedKey, name string) string {
 	return strings.Join([]string{name, accessKey, encryptedKey}, ":")
 }
 
-// 对SecretKey加密, 返回加密后的字符串
+// 对SecretKey加密, 返回加密后的字符串；开启口令保护模式时使用主密钥加密
 func encryptSecretKey(accessKey, secretKey string) (string, *data.CodeError) {
+	if IsPassphraseEnabled() {
+		key, err := getMasterKey()
+		if err != nil {
+			return "", err
+		}
+		return encryptSecretKeyWithPassphrase(key, accessKey, secretKey)
+	}
+	return encryptSecretKeyWithAccessKey(accessKey, secretKey)
+}
+
+// 对加密的SecretKey进行解密， 返回SecretKey
+func decryptSecretKey(accessKey, encryptedKey string) (string, *data.CodeError) {
+	if isPassphraseSecretKey(encryptedKey) {
+		key, err := getMasterKey()
+		if err != nil {
+			return "", err
+		}
+		return decryptSecretKeyWithPassphrase(key, accessKey, encryptedKey)
+	}
+	return decryptSecretKeyWithAccessKey(accessKey, encryptedKey)
+}
+
+// 使用由 AccessKey 派生的密钥加密 SecretKey，未开启口令保护模式时使用
+func encryptSecretKeyWithAccessKey(accessKey, secretKey string) (string, *data.CodeError) {
 	aesKey := utils.Md5Hex(accessKey)
 	encryptedSecretKeyBytes, encryptedErr := utils.AesEncrypt([]byte(secretKey), []byte(aesKey[7:23]))
 	if encryptedErr != nil {
@@ -57,8 +81,7 @@ func encryptSecretKey(accessKey, secretKey string) (string, *data.CodeError) {
 	return encryptedSecretKey, nil
 }
 
-// 对加密的SecretKey进行解密， 返回SecretKey
-func decryptSecretKey(accessKey, encryptedKey string) (string, *data.CodeError) {
+func decryptSecretKeyWithAccessKey(accessKey, encryptedKey string) (string, *data.CodeError) {
 	aesKey := utils.Md5Hex(accessKey)
 	encryptedSecretKeyBytes, decodeErr := base64.URLEncoding.DecodeString(encryptedKey)
 	if decodeErr != nil {
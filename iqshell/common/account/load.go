@@ -10,6 +10,8 @@ type LoadInfo struct {
 	AccountPath    string
 	OldAccountPath string
 	AccountDBPath  string
+	MasterKeyPath  string // 口令保护模式的主密钥派生参数文件，文件存在时开启口令保护模式
+	SessionPath    string // 口令缓存文件
 }
 
 var info LoadInfo
@@ -33,5 +35,6 @@ func Load(i LoadInfo) *data.CodeError {
 	log.Debug("account db path:" + info.AccountDBPath)
 	log.Debug("account path:" + info.AccountPath)
 	log.Debug("account old path:" + info.OldAccountPath)
+	log.Debug("account master key path:" + info.MasterKeyPath)
 	return nil
 }
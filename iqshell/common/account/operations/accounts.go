@@ -0,0 +1,300 @@
+package operations
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/account"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
+)
+
+// MultiAccountInfo 使用多个本地账户分别执行同一个命令，各账户的结果汇总输出并增加账户列
+type MultiAccountInfo struct {
+	AllAccounts bool   // 使用本地账户数据库中的所有账户
+	Accounts    string // 使用的账户名，以逗号分隔
+	Worker      int    // 同时执行命令的账户数
+	OutputFile  string // 命令结果的输出文件，多个账户不能输出至同一文件，仅支持输出至标准输出
+}
+
+// Enable 是否使用多个账户执行命令
+func (info *MultiAccountInfo) Enable() bool {
+	return info.AllAccounts || len(info.Accounts) > 0
+}
+
+func (info *MultiAccountInfo) Check() *data.CodeError {
+	if info.AllAccounts && len(info.Accounts) > 0 {
+		return alert.Error("--all-accounts and --accounts can't be specified at the same time", "")
+	}
+	if len(info.Accounts) > 0 && len(splitAccountNames(info.Accounts)) == 0 {
+		return alert.CannotEmptyError("account name of --accounts", "")
+	}
+	if len(info.OutputFile) > 0 {
+		return alert.Error("output file is not supported when execute with --all-accounts or --accounts, results are written to stdout with an account column", "")
+	}
+	if info.Worker <= 0 {
+		info.Worker = 1
+	}
+	return nil
+}
+
+// RunWithAccounts 使用每个账户重新执行当前命令（命令参数中增加 --account <Name>），各账户在独立的进程中执行，
+// 可以并发执行；文本格式下子进程输出的每行前增加账户名及 Tab，结构化输出时 Record 中增加 account 字段。
+func RunWithAccounts(cfg *iqshell.Config, info MultiAccountInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	if len(cfg.Account) > 0 || len(cfg.Profile) > 0 {
+		data.SetCmdStatusError()
+		log.Error("--account and --profile can't be used with --all-accounts or --accounts")
+		return
+	}
+
+	names, err := info.accountNames()
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("get accounts error:%v", err)
+		return
+	}
+	if len(names) == 0 {
+		data.SetCmdStatusError()
+		log.Error("no account found, please add account by: qshell user add")
+		return
+	}
+
+	executable, eErr := os.Executable()
+	if eErr != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("get executable error:%v", eErr)
+		return
+	}
+
+	structured := output.IsStructured()
+	runner := &accountCmdRunner{
+		executable: executable,
+		structured: structured,
+	}
+	nameChan := make(chan string)
+	wait := &sync.WaitGroup{}
+	for i := 0; i < info.Worker && i < len(names); i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for name := range nameChan {
+				runner.run(name, accountCmdArgs(os.Args[1:], name, structured))
+			}
+		}()
+	}
+	for _, name := range names {
+		nameChan <- name
+	}
+	close(nameChan)
+	wait.Wait()
+}
+
+func (info *MultiAccountInfo) accountNames() ([]string, *data.CodeError) {
+	if info.AllAccounts {
+		accounts, err := account.GetUsers()
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, 0, len(accounts))
+		for _, acc := range accounts {
+			names = append(names, acc.Name)
+		}
+		return names, nil
+	}
+
+	names := splitAccountNames(info.Accounts)
+	notFound := make([]string, 0)
+	for _, name := range names {
+		if _, err := account.GetUser(name); err != nil {
+			notFound = append(notFound, name)
+		}
+	}
+	if len(notFound) > 0 {
+		return nil, data.NewEmptyError().AppendDescF("can't find account:%s in local db, you can list accounts by: qshell user ls --name",
+			strings.Join(notFound, ","))
+	}
+	return names, nil
+}
+
+// splitAccountNames 解析以逗号分隔的账户名，忽略空白及重复的账户名
+func splitAccountNames(accounts string) []string {
+	names := make([]string, 0)
+	exist := make(map[string]bool)
+	for _, name := range strings.Split(accounts, ",") {
+		name = strings.TrimSpace(name)
+		if len(name) == 0 || exist[name] {
+			continue
+		}
+		exist[name] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+// 子进程中不再使用多账户执行，结构化输出时子进程统一使用 jsonl 输出，由当前进程汇总
+var multiAccountValueFlags = []string{"--account", "--profile", "--accounts", "--accounts-worker", "--output"}
+var multiAccountBoolFlags = []string{"--all-accounts"}
+
+// accountCmdArgs 生成使用账户 name 执行当前命令的参数
+func accountCmdArgs(args []string, name string, structured bool) []string {
+	ret := make([]string, 0, len(args)+4)
+	tail := make([]string, 0)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			// -- 之后均为命令参数
+			tail = args[i:]
+			break
+		}
+		if isFlag(arg, multiAccountBoolFlags) {
+			continue
+		}
+		if isFlag(arg, multiAccountValueFlags) {
+			if !strings.Contains(arg, "=") {
+				// 跳过选项的值
+				i++
+			}
+			continue
+		}
+		ret = append(ret, arg)
+	}
+
+	ret = append(ret, "--account", name)
+	if structured {
+		ret = append(ret, "--output", output.FormatJsonl)
+	}
+	return append(ret, tail...)
+}
+
+func isFlag(arg string, flags []string) bool {
+	for _, flag := range flags {
+		if arg == flag || strings.HasPrefix(arg, flag+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+type accountCmdRunner struct {
+	executable string
+	structured bool
+	writeLock  sync.Mutex
+}
+
+func (r *accountCmdRunner) run(name string, args []string) {
+	log.DebugF("run with account:%s, qshell %s", name, strings.Join(args, " "))
+	c := exec.Command(r.executable, args...)
+	stdout, oErr := c.StdoutPipe()
+	if oErr != nil {
+		r.fail(name, oErr)
+		return
+	}
+	stderr, oErr := c.StderrPipe()
+	if oErr != nil {
+		r.fail(name, oErr)
+		return
+	}
+	if sErr := c.Start(); sErr != nil {
+		r.fail(name, sErr)
+		return
+	}
+
+	wait := &sync.WaitGroup{}
+	wait.Add(2)
+	go func() {
+		defer wait.Done()
+		readLines(stdout, func(line string) {
+			r.stdout(name, line)
+		})
+	}()
+	go func() {
+		defer wait.Done()
+		readLines(stderr, func(line string) {
+			r.write(data.Stderr(), name, line)
+		})
+	}()
+	// 需读取完输出后再等待子进程结束
+	wait.Wait()
+
+	if wErr := c.Wait(); wErr != nil {
+		var exitErr *exec.ExitError
+		if errors.As(wErr, &exitErr) {
+			data.SetCmdStatus(exitErr.ExitCode())
+			// 错误信息已由子进程输出
+			log.WarningF("account:%s execute error, exit status:%d", name, exitErr.ExitCode())
+		} else {
+			r.fail(name, wErr)
+		}
+	}
+}
+
+func (r *accountCmdRunner) stdout(name string, line string) {
+	if !r.structured {
+		r.write(data.Stdout(), name, line)
+		return
+	}
+
+	record := &struct {
+		Type  string          `json:"type"`
+		Cmd   string          `json:"cmd"`
+		Data  json.RawMessage `json:"data"`
+		Error string          `json:"error"`
+	}{}
+	if err := json.Unmarshal([]byte(line), record); err != nil || len(record.Type) == 0 {
+		// 非 Record 的输出作为日志输出至标准错误
+		r.write(data.Stderr(), name, line)
+		return
+	}
+
+	ret := &output.Record{
+		Type:    record.Type,
+		Cmd:     record.Cmd,
+		Account: name,
+		Error:   record.Error,
+	}
+	if len(record.Data) > 0 {
+		ret.Data = record.Data
+	}
+	output.Forward(ret)
+}
+
+func (r *accountCmdRunner) write(w io.Writer, name string, line string) {
+	r.writeLock.Lock()
+	defer r.writeLock.Unlock()
+	_, _ = fmt.Fprintf(w, "%s\t%s\n", name, line)
+}
+
+func (r *accountCmdRunner) fail(name string, err error) {
+	data.SetCmdStatusError()
+	log.ErrorF("account:%s execute error:%v", name, err)
+}
+
+func readLines(reader io.Reader, handler func(line string)) {
+	bufReader := bufio.NewReader(reader)
+	for {
+		line, err := bufReader.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		if len(line) > 0 || err == nil {
+			handler(line)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
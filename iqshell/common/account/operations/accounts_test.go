@@ -0,0 +1,53 @@
+package operations
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitAccountNames(t *testing.T) {
+	names := splitAccountNames(" a,b,, a ,c ")
+	if !reflect.DeepEqual(names, []string{"a", "b", "c"}) {
+		t.Fatalf("split account names error:%v", names)
+	}
+	if names = splitAccountNames(" , "); len(names) != 0 {
+		t.Fatalf("split empty account names error:%v", names)
+	}
+}
+
+func TestMultiAccountInfoCheck(t *testing.T) {
+	info := &MultiAccountInfo{AllAccounts: true, Accounts: "a"}
+	if err := info.Check(); err == nil {
+		t.Fatal("--all-accounts and --accounts should not be specified at the same time")
+	}
+
+	info = &MultiAccountInfo{Accounts: ","}
+	if err := info.Check(); err == nil {
+		t.Fatal("empty account name should error")
+	}
+
+	info = &MultiAccountInfo{Accounts: "a", OutputFile: "list.txt"}
+	if err := info.Check(); err == nil {
+		t.Fatal("output file should not be supported")
+	}
+
+	info = &MultiAccountInfo{AllAccounts: true}
+	if err := info.Check(); err != nil || info.Worker != 1 {
+		t.Fatalf("check error:%v worker:%d", err, info.Worker)
+	}
+}
+
+func TestAccountCmdArgs(t *testing.T) {
+	args := []string{"listbucket2", "bucket", "--accounts", "a,b", "--accounts-worker=2", "--all-accounts",
+		"--output", "json", "--account=c", "-p", "logs/"}
+	want := []string{"listbucket2", "bucket", "-p", "logs/", "--account", "a", "--output", "jsonl"}
+	if got := accountCmdArgs(args, "a", true); !reflect.DeepEqual(got, want) {
+		t.Fatalf("structured args, want:%v got:%v", want, got)
+	}
+
+	args = []string{"stat", "--accounts", "a,b", "--", "bucket", "--accounts"}
+	want = []string{"stat", "--account", "b", "--", "bucket", "--accounts"}
+	if got := accountCmdArgs(args, "b", false); !reflect.DeepEqual(got, want) {
+		t.Fatalf("text args, want:%v got:%v", want, got)
+	}
+}
@@ -0,0 +1,88 @@
+package operations
+
+import (
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/account"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+)
+
+type EncryptInfo struct {
+	SessionTimeout time.Duration // 口令缓存的有效期，0 表示不缓存
+}
+
+func (info *EncryptInfo) Check() *data.CodeError {
+	if info.SessionTimeout < 0 {
+		return alert.Error("--session-timeout can't be negative", "")
+	}
+	return nil
+}
+
+// Encrypt 开启口令保护模式，并迁移本地已保存的账户
+func Encrypt(cfg *iqshell.Config, info EncryptInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	enabled := account.IsPassphraseEnabled()
+	count, err := account.EnablePassphrase(account.ReadNewPassphrase, info.SessionTimeout)
+	if err != nil {
+		log.ErrorF("user encrypt error:%v", err)
+		data.SetCmdStatusError()
+		return
+	}
+	if !enabled {
+		log.Alert("Passphrase enabled")
+	}
+	log.AlertF("%d account(s) migrated to passphrase encryption", count)
+}
+
+type DecryptInfo struct {
+}
+
+func (info *DecryptInfo) Check() *data.CodeError {
+	return nil
+}
+
+// Decrypt 关闭口令保护模式
+func Decrypt(cfg *iqshell.Config, info DecryptInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	count, err := account.DisablePassphrase()
+	if err != nil {
+		log.ErrorF("user decrypt error:%v", err)
+		data.SetCmdStatusError()
+		return
+	}
+	log.AlertF("Passphrase disabled, %d account(s) restored", count)
+}
+
+type LockInfo struct {
+}
+
+func (info *LockInfo) Check() *data.CodeError {
+	return nil
+}
+
+// Lock 清除口令缓存
+func Lock(cfg *iqshell.Config, info LockInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	if err := account.Lock(); err != nil {
+		log.ErrorF("user lock error:%v", err)
+		data.SetCmdStatusError()
+	}
+}
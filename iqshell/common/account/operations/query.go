@@ -6,6 +6,7 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/common/alert"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
 )
 
 type UserInfo struct {
@@ -63,13 +64,14 @@ func Current(cfg *iqshell.Config) {
 		return
 	}
 
-	acc, err := account.GetAccount()
+	acc, err := workspace.GetAccount()
 	if err != nil {
 		log.ErrorF("user current error: %v", err)
 		data.SetCmdStatusError()
 		return
 	}
 	log.AlertF(acc.String())
+	log.AlertF("Source: %s", acc.Source)
 }
 
 // LookUpInfo 查找某个用户
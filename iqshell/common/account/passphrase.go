@@ -0,0 +1,395 @@
+package account
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+)
+
+// 口令保护模式：开启后账户的 SecretKey 使用由主口令通过 scrypt 派生的密钥以 AES-GCM 加密，
+// 未开启时使用由 AccessKey 派生的密钥加密（仅做混淆，拿到账户文件即可解密）。
+const (
+	// EnvPassphrase 主口令的环境变量，用于无法交互输入口令的场景
+	EnvPassphrase = "QSHELL_PASSPHRASE"
+
+	// envRuntimeDir 用户的运行时目录，基于内存且仅当前用户可访问，注销或重启后清空
+	envRuntimeDir = "XDG_RUNTIME_DIR"
+
+	// 口令加密的 SecretKey 前缀，base64 URL 编码中不包含 . 及 :
+	passphraseSecretKeyPrefix = "pp1."
+
+	DefaultSessionTimeout = 15 * time.Minute
+
+	masterKeyVersion = 1
+	masterKeyLength  = 32
+	masterKeyCheck   = "qshell-master-key-check"
+)
+
+// masterKeyInfo 主密钥的派生参数，保存在账户目录的 account.key 中，不包含口令及密钥
+type masterKeyInfo struct {
+	Version        int    `json:"version"`
+	Kdf            string `json:"kdf"`
+	Salt           string `json:"salt"`
+	N              int    `json:"n"`
+	R              int    `json:"r"`
+	P              int    `json:"p"`
+	Check          string `json:"check"`           // 使用主密钥加密的校验数据，用于校验口令
+	SessionTimeout int64  `json:"session_timeout"` // 口令缓存的有效期，单位：秒，0 表示不缓存
+}
+
+// passphraseSession 口令缓存，保存派生的主密钥及过期时间
+type passphraseSession struct {
+	Salt     string `json:"salt"`
+	Key      string `json:"key"`
+	ExpireAt int64  `json:"expire_at"`
+}
+
+var (
+	masterKeyLock sync.Mutex
+	masterKey     []byte
+)
+
+// IsPassphraseEnabled 是否开启了口令保护模式
+func IsPassphraseEnabled() bool {
+	if len(info.MasterKeyPath) == 0 {
+		return false
+	}
+	_, err := os.Stat(info.MasterKeyPath)
+	return err == nil
+}
+
+func loadMasterKeyInfo() (*masterKeyInfo, *data.CodeError) {
+	content, rErr := os.ReadFile(info.MasterKeyPath)
+	if rErr != nil {
+		return nil, data.NewEmptyError().AppendDescF("read master key info error:%v", rErr)
+	}
+	keyInfo := &masterKeyInfo{}
+	if uErr := json.Unmarshal(content, keyInfo); uErr != nil {
+		return nil, data.NewEmptyError().AppendDescF("parse master key info error:%v", uErr)
+	}
+	if keyInfo.Version != masterKeyVersion || keyInfo.Kdf != "scrypt" {
+		return nil, data.NewEmptyError().AppendDescF("master key version:%d kdf:%s not support", keyInfo.Version, keyInfo.Kdf)
+	}
+	return keyInfo, nil
+}
+
+func newMasterKeyInfo(passphrase string, sessionTimeout time.Duration) (*masterKeyInfo, []byte, *data.CodeError) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, nil, data.NewEmptyError().AppendDescF("generate salt error:%v", err)
+	}
+	keyInfo := &masterKeyInfo{
+		Version:        masterKeyVersion,
+		Kdf:            "scrypt",
+		Salt:           base64.StdEncoding.EncodeToString(salt),
+		N:              1 << 15,
+		R:              8,
+		P:              1,
+		SessionTimeout: int64(sessionTimeout / time.Second),
+	}
+	key, err := keyInfo.deriveKey(passphrase)
+	if err != nil {
+		return nil, nil, err
+	}
+	check, err := sealWithKey(key, []byte(masterKeyCheck), []byte(masterKeyCheck))
+	if err != nil {
+		return nil, nil, err
+	}
+	keyInfo.Check = check
+	return keyInfo, key, nil
+}
+
+func (i *masterKeyInfo) deriveKey(passphrase string) ([]byte, *data.CodeError) {
+	salt, dErr := base64.StdEncoding.DecodeString(i.Salt)
+	if dErr != nil {
+		return nil, data.NewEmptyError().AppendDescF("decode salt error:%v", dErr)
+	}
+	key, sErr := scrypt.Key([]byte(passphrase), salt, i.N, i.R, i.P, masterKeyLength)
+	if sErr != nil {
+		return nil, data.NewEmptyError().AppendDescF("derive master key error:%v", sErr)
+	}
+	return key, nil
+}
+
+// verifyKey 校验主密钥是否由正确的口令派生
+func (i *masterKeyInfo) verifyKey(key []byte) bool {
+	check, err := openWithKey(key, i.Check, []byte(masterKeyCheck))
+	return err == nil && string(check) == masterKeyCheck
+}
+
+func (i *masterKeyInfo) save() *data.CodeError {
+	content, mErr := json.MarshalIndent(i, "", "\t")
+	if mErr != nil {
+		return data.ConvertError(mErr)
+	}
+	if wErr := os.WriteFile(info.MasterKeyPath, content, 0600); wErr != nil {
+		return data.NewEmptyError().AppendDescF("save master key info error:%v", wErr)
+	}
+	return nil
+}
+
+// getMasterKey 获取主密钥，依次从进程缓存、口令缓存、环境变量 QSHELL_PASSPHRASE 及终端输入中获取
+func getMasterKey() ([]byte, *data.CodeError) {
+	masterKeyLock.Lock()
+	defer masterKeyLock.Unlock()
+
+	if len(masterKey) > 0 {
+		return masterKey, nil
+	}
+
+	keyInfo, err := loadMasterKeyInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	if key := loadSessionKey(keyInfo); len(key) > 0 {
+		masterKey = key
+		return masterKey, nil
+	}
+
+	fromTerminal := false
+	passphrase := os.Getenv(EnvPassphrase)
+	if len(passphrase) == 0 {
+		if passphrase, err = readPassphrase("Enter qshell passphrase: "); err != nil {
+			return nil, err
+		}
+		fromTerminal = true
+	}
+
+	key, err := keyInfo.deriveKey(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if !keyInfo.verifyKey(key) {
+		return nil, data.NewEmptyError().AppendDesc("passphrase is incorrect")
+	}
+
+	// 仅缓存终端输入的口令
+	if fromTerminal {
+		saveSessionKey(keyInfo, key)
+	}
+	masterKey = key
+	return masterKey, nil
+}
+
+func setMasterKey(key []byte) {
+	masterKeyLock.Lock()
+	defer masterKeyLock.Unlock()
+	masterKey = key
+}
+
+// PassphraseSessionPath 工作目录对应的口令缓存文件：优先保存在用户的运行时目录（XDG_RUNTIME_DIR）中，基于内存且重启后失效；
+// 没有运行时目录时（如：macOS、Windows）保存在临时目录下当前用户独有的目录中，缓存过期后读取时删除；均不可用时返回空，不缓存口令
+func PassphraseSessionPath(workspaceDir string) string {
+	sessionDir := ""
+	if runtimeDir := os.Getenv(envRuntimeDir); len(runtimeDir) > 0 {
+		sessionDir = filepath.Join(runtimeDir, "qshell")
+	} else if u, uErr := user.Current(); uErr == nil {
+		sessionDir = filepath.Join(os.TempDir(), "qshell-"+utils.Md5Hex(u.Uid))
+	} else {
+		log.WarningF("get current user error:%v, passphrase session is disabled", uErr)
+		return ""
+	}
+
+	if absDir, aErr := filepath.Abs(workspaceDir); aErr == nil {
+		workspaceDir = absDir
+	}
+	return filepath.Join(sessionDir, "account-"+utils.Md5Hex(workspaceDir)+".session")
+}
+
+// checkSessionDir 口令缓存目录可能位于多用户共享的临时目录中，仅允许当前用户访问
+func checkSessionDir(dir string) *data.CodeError {
+	if mErr := os.MkdirAll(dir, 0700); mErr != nil {
+		return data.NewEmptyError().AppendError(mErr)
+	}
+	fileInfo, sErr := os.Lstat(dir)
+	if sErr != nil {
+		return data.NewEmptyError().AppendError(sErr)
+	}
+	if !fileInfo.IsDir() {
+		return data.NewEmptyError().AppendDescF("%s is not a dir", dir)
+	}
+	// Windows 的临时目录本身仅当前用户可访问，不检查权限位
+	if runtime.GOOS != "windows" && fileInfo.Mode().Perm()&0077 != 0 {
+		return data.NewEmptyError().AppendDescF("%s should only be accessible to the current user, mode:%s", dir, fileInfo.Mode().Perm())
+	}
+	return nil
+}
+
+func loadSessionKey(keyInfo *masterKeyInfo) []byte {
+	if len(info.SessionPath) == 0 || keyInfo.SessionTimeout <= 0 {
+		return nil
+	}
+	content, rErr := os.ReadFile(info.SessionPath)
+	if rErr != nil {
+		return nil
+	}
+	session := &passphraseSession{}
+	if uErr := json.Unmarshal(content, session); uErr != nil {
+		return nil
+	}
+	if session.Salt != keyInfo.Salt || time.Now().Unix() > session.ExpireAt {
+		_ = os.Remove(info.SessionPath)
+		return nil
+	}
+	key, dErr := base64.StdEncoding.DecodeString(session.Key)
+	if dErr != nil || !keyInfo.verifyKey(key) {
+		_ = os.Remove(info.SessionPath)
+		return nil
+	}
+	return key
+}
+
+func saveSessionKey(keyInfo *masterKeyInfo, key []byte) {
+	if keyInfo.SessionTimeout <= 0 {
+		return
+	}
+	if len(info.SessionPath) == 0 {
+		log.Warning("passphrase session is disabled, you need to enter the passphrase for every command")
+		return
+	}
+	if cErr := checkSessionDir(filepath.Dir(info.SessionPath)); cErr != nil {
+		log.WarningF("save passphrase session error:%v", cErr)
+		return
+	}
+	content, _ := json.Marshal(&passphraseSession{
+		Salt:     keyInfo.Salt,
+		Key:      base64.StdEncoding.EncodeToString(key),
+		ExpireAt: time.Now().Unix() + keyInfo.SessionTimeout,
+	})
+	if wErr := os.WriteFile(info.SessionPath, content, 0600); wErr != nil {
+		log.WarningF("save passphrase session error:%v", wErr)
+	}
+}
+
+// Lock 清除口令缓存，之后的命令需要重新输入口令
+func Lock() *data.CodeError {
+	setMasterKey(nil)
+	if len(info.SessionPath) == 0 {
+		return nil
+	}
+	if err := os.Remove(info.SessionPath); err != nil && !os.IsNotExist(err) {
+		return data.NewEmptyError().AppendDescF("remove passphrase session error:%v", err)
+	}
+	return nil
+}
+
+func readPassphrase(prompt string) (string, *data.CodeError) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", data.NewEmptyError().AppendDescF("account db is protected by passphrase, please run in terminal or set passphrase by environment variable %s", EnvPassphrase)
+	}
+	_, _ = fmt.Fprint(os.Stderr, prompt)
+	passphrase, rErr := term.ReadPassword(fd)
+	_, _ = fmt.Fprintln(os.Stderr)
+	if rErr != nil {
+		return "", data.NewEmptyError().AppendDescF("read passphrase error:%v", rErr)
+	}
+	return string(passphrase), nil
+}
+
+// ReadNewPassphrase 从终端读取新的主口令，需输入两次；设置了环境变量 QSHELL_PASSPHRASE 时使用环境变量
+func ReadNewPassphrase() (string, *data.CodeError) {
+	if passphrase := os.Getenv(EnvPassphrase); len(passphrase) > 0 {
+		return passphrase, nil
+	}
+	passphrase, err := readPassphrase("Enter new qshell passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if len(passphrase) == 0 {
+		return "", data.NewEmptyError().AppendDesc("passphrase can't be empty")
+	}
+	confirm, err := readPassphrase("Confirm new qshell passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase != confirm {
+		return "", data.NewEmptyError().AppendDesc("passphrases don't match")
+	}
+	return passphrase, nil
+}
+
+func isPassphraseSecretKey(encryptedKey string) bool {
+	return strings.HasPrefix(encryptedKey, passphraseSecretKeyPrefix)
+}
+
+// encryptSecretKeyWithPassphrase 使用主密钥加密 SecretKey，AccessKey 作为附加数据，防止密文被挪用至其他账户
+func encryptSecretKeyWithPassphrase(key []byte, accessKey, secretKey string) (string, *data.CodeError) {
+	sealed, err := sealWithKey(key, []byte(secretKey), []byte(accessKey))
+	if err != nil {
+		return "", err
+	}
+	return passphraseSecretKeyPrefix + sealed, nil
+}
+
+func decryptSecretKeyWithPassphrase(key []byte, accessKey, encryptedKey string) (string, *data.CodeError) {
+	secretKey, err := openWithKey(key, strings.TrimPrefix(encryptedKey, passphraseSecretKeyPrefix), []byte(accessKey))
+	if err != nil {
+		return "", err
+	}
+	return string(secretKey), nil
+}
+
+// sealWithKey AES-GCM 加密，返回 base64 URL 编码的 nonce + 密文
+func sealWithKey(key, plaintext, additionalData []byte) (string, *data.CodeError) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, rErr := rand.Read(nonce); rErr != nil {
+		return "", data.NewEmptyError().AppendDescF("generate nonce error:%v", rErr)
+	}
+	sealed := gcm.Seal(nonce, nonce, plaintext, additionalData)
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+func openWithKey(key []byte, sealed string, additionalData []byte) ([]byte, *data.CodeError) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	sealedBytes, dErr := base64.RawURLEncoding.DecodeString(sealed)
+	if dErr != nil {
+		return nil, data.NewEmptyError().AppendDescF("decode encrypted data error:%v", dErr)
+	}
+	if len(sealedBytes) < gcm.NonceSize() {
+		return nil, data.NewEmptyError().AppendDesc("encrypted data is too short")
+	}
+	nonce, ciphertext := sealedBytes[:gcm.NonceSize()], sealedBytes[gcm.NonceSize():]
+	plaintext, oErr := gcm.Open(nil, nonce, ciphertext, additionalData)
+	if oErr != nil {
+		return nil, data.NewEmptyError().AppendDescF("decrypt error:%v", oErr)
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, *data.CodeError) {
+	block, bErr := aes.NewCipher(key)
+	if bErr != nil {
+		return nil, data.NewEmptyError().AppendDescF("create cipher error:%v", bErr)
+	}
+	gcm, gErr := cipher.NewGCM(block)
+	if gErr != nil {
+		return nil, data.NewEmptyError().AppendDescF("create gcm error:%v", gErr)
+	}
+	return gcm, nil
+}
@@ -0,0 +1,169 @@
+package account
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+)
+
+// secretKeyTransformer 转换加密的 SecretKey，changed 为 false 时不需要修改
+type secretKeyTransformer func(accessKey, encryptedKey string) (newEncryptedKey string, changed bool, err *data.CodeError)
+
+// EnablePassphrase 开启口令保护模式，并将本地数据库、当前账户及上一次使用的账户中未使用主密钥加密的 SecretKey 使用主密钥重新加密；
+// 已开启口令保护模式时使用已有的口令（不调用 newPassphrase），仅迁移未迁移的账户并更新口令缓存的有效期。返回迁移的账户数
+func EnablePassphrase(newPassphrase func() (string, *data.CodeError), sessionTimeout time.Duration) (count int, err *data.CodeError) {
+	var keyInfo *masterKeyInfo
+	var key []byte
+	if IsPassphraseEnabled() {
+		if keyInfo, err = loadMasterKeyInfo(); err != nil {
+			return
+		}
+		if key, err = getMasterKey(); err != nil {
+			return
+		}
+	} else {
+		passphrase, pErr := newPassphrase()
+		if pErr != nil {
+			return 0, pErr
+		}
+		if keyInfo, key, err = newMasterKeyInfo(passphrase, sessionTimeout); err != nil {
+			return
+		}
+	}
+
+	keyInfo.SessionTimeout = int64(sessionTimeout / time.Second)
+	if err = keyInfo.save(); err != nil {
+		return
+	}
+	setMasterKey(key)
+	// 与 getMasterKey 一致，仅缓存终端输入的口令
+	if len(os.Getenv(EnvPassphrase)) == 0 {
+		saveSessionKey(keyInfo, key)
+	}
+
+	// 主密钥已保存，迁移中断后再次执行可以继续迁移
+	return rewriteSecretKeys(func(accessKey, encryptedKey string) (string, bool, *data.CodeError) {
+		if isPassphraseSecretKey(encryptedKey) {
+			return encryptedKey, false, nil
+		}
+		secretKey, dErr := decryptSecretKeyWithAccessKey(accessKey, encryptedKey)
+		if dErr != nil {
+			return "", false, dErr
+		}
+		newEncryptedKey, eErr := encryptSecretKeyWithPassphrase(key, accessKey, secretKey)
+		return newEncryptedKey, eErr == nil, eErr
+	})
+}
+
+// DisablePassphrase 关闭口令保护模式，将使用主密钥加密的 SecretKey 恢复为由 AccessKey 派生的密钥加密，并删除主密钥参数及口令缓存。
+// 返回恢复的账户数
+func DisablePassphrase() (count int, err *data.CodeError) {
+	if !IsPassphraseEnabled() {
+		return 0, data.NewEmptyError().AppendDesc("passphrase is not enabled")
+	}
+	key, err := getMasterKey()
+	if err != nil {
+		return
+	}
+
+	count, err = rewriteSecretKeys(func(accessKey, encryptedKey string) (string, bool, *data.CodeError) {
+		if !isPassphraseSecretKey(encryptedKey) {
+			return encryptedKey, false, nil
+		}
+		secretKey, dErr := decryptSecretKeyWithPassphrase(key, accessKey, encryptedKey)
+		if dErr != nil {
+			return "", false, dErr
+		}
+		newEncryptedKey, eErr := encryptSecretKeyWithAccessKey(accessKey, secretKey)
+		return newEncryptedKey, eErr == nil, eErr
+	})
+	if err != nil {
+		return
+	}
+
+	if rErr := os.Remove(info.MasterKeyPath); rErr != nil {
+		return count, data.NewEmptyError().AppendDescF("remove master key info error:%v", rErr)
+	}
+	return count, Lock()
+}
+
+// rewriteSecretKeys 转换本地数据库、当前账户文件及上一次使用的账户文件中加密的 SecretKey；
+// 部分账户转换失败时其他账户仍会转换，但返回错误，再次执行时会重新转换失败的账户
+func rewriteSecretKeys(transformer secretKeyTransformer) (count int, err *data.CodeError) {
+	db, oErr := leveldb.OpenFile(info.AccountDBPath, nil)
+	if oErr != nil {
+		return 0, data.NewEmptyError().AppendDescF("open db: %v", oErr)
+	}
+	defer db.Close()
+
+	failed := make([]string, 0)
+	batch := new(leveldb.Batch)
+	iter := db.NewIterator(nil, nil)
+	for iter.Next() {
+		name := string(iter.Key())
+		value, changed, tErr := transformAccountValue(string(iter.Value()), transformer)
+		if tErr != nil {
+			log.ErrorF("account:%s convert error:%v", name, tErr)
+			failed = append(failed, name)
+			continue
+		}
+		if changed {
+			batch.Put([]byte(name), []byte(value))
+			count++
+		}
+	}
+	iter.Release()
+	if iErr := iter.Error(); iErr != nil {
+		return 0, data.NewEmptyError().AppendDescF("iterate db: %v", iErr)
+	}
+	if wErr := db.Write(batch, &opt.WriteOptions{Sync: true}); wErr != nil {
+		return 0, data.NewEmptyError().AppendDescF("write db: %v", wErr)
+	}
+	// 压缩数据库，清除旧的 SecretKey 在 leveldb 日志及旧版本数据中的残留
+	if cErr := db.CompactRange(util.Range{}); cErr != nil {
+		return count, data.NewEmptyError().AppendDescF("compact db: %v", cErr)
+	}
+
+	for _, path := range []string{info.AccountPath, info.OldAccountPath} {
+		content, rErr := os.ReadFile(path)
+		if rErr != nil || len(content) == 0 {
+			continue
+		}
+		value, changed, tErr := transformAccountValue(string(content), transformer)
+		if tErr != nil {
+			log.ErrorF("account file:%s convert error:%v", path, tErr)
+			failed = append(failed, path)
+			continue
+		}
+		if !changed {
+			continue
+		}
+		if wErr := os.WriteFile(path, []byte(value), 0600); wErr != nil {
+			return count, data.NewEmptyError().AppendDescF("write account file:%s error:%v", path, wErr)
+		}
+	}
+	if len(failed) > 0 {
+		return count, data.NewEmptyError().AppendDescF("%d account(s) convert failed: %s", len(failed), strings.Join(failed, ", "))
+	}
+	return count, nil
+}
+
+func transformAccountValue(value string, transformer secretKeyTransformer) (string, bool, *data.CodeError) {
+	ss := splits(value)
+	if len(ss) != 3 {
+		return "", false, data.NewEmptyError().AppendDesc("account format error")
+	}
+	name, accessKey, encryptedKey := ss[0], ss[1], ss[2]
+	newEncryptedKey, changed, err := transformer(accessKey, encryptedKey)
+	if err != nil || !changed {
+		return value, false, err
+	}
+	return encrypt(accessKey, newEncryptedKey, name), true, nil
+}
@@ -0,0 +1,207 @@
+package account
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+func loadTestAccountInfo(t *testing.T) {
+	dir := t.TempDir()
+	oldInfo := info
+	if err := Load(LoadInfo{
+		AccountPath:    filepath.Join(dir, "account.json"),
+		OldAccountPath: filepath.Join(dir, "old_account.json"),
+		AccountDBPath:  filepath.Join(dir, "account.db"),
+		MasterKeyPath:  filepath.Join(dir, "account.key"),
+		SessionPath:    filepath.Join(dir, "session", "account.session"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		info = oldInfo
+		setMasterKey(nil)
+	})
+}
+
+func TestSealWithKey(t *testing.T) {
+	keyInfo, key, err := newMasterKeyInfo("passphrase", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !keyInfo.verifyKey(key) {
+		t.Fatal("master key should be verified")
+	}
+	wrongKey, _ := keyInfo.deriveKey("wrong")
+	if keyInfo.verifyKey(wrongKey) {
+		t.Fatal("wrong passphrase should not be verified")
+	}
+
+	encrypted, err := encryptSecretKeyWithPassphrase(key, "ak", "sk")
+	if err != nil || !isPassphraseSecretKey(encrypted) || strings.Contains(encrypted, ":") {
+		t.Fatalf("encrypt error:%v encrypted:%s", err, encrypted)
+	}
+	if secretKey, dErr := decryptSecretKeyWithPassphrase(key, "ak", encrypted); dErr != nil || secretKey != "sk" {
+		t.Fatalf("decrypt error:%v secret key:%s", dErr, secretKey)
+	}
+	// AccessKey 作为附加数据，密文不能用于其他账户
+	if _, dErr := decryptSecretKeyWithPassphrase(key, "other", encrypted); dErr == nil {
+		t.Fatal("decrypt with other access key should error")
+	}
+}
+
+func TestEnableAndDisablePassphrase(t *testing.T) {
+	loadTestAccountInfo(t)
+	for _, name := range []string{"a", "b"} {
+		if err := SaveToDB(Account{Name: name, AccessKey: "ak_" + name, SecretKey: "sk_" + name}, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := SetAccountToLocalFile(Account{Name: "a", AccessKey: "ak_a", SecretKey: "sk_a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(EnvPassphrase, "passphrase")
+	count, err := EnablePassphrase(func() (string, *data.CodeError) {
+		return "passphrase", nil
+	}, time.Minute)
+	if err != nil || count != 2 || !IsPassphraseEnabled() {
+		t.Fatalf("enable passphrase error:%v count:%d", err, count)
+	}
+	content, _ := os.ReadFile(info.AccountPath)
+	if !strings.Contains(string(content), passphraseSecretKeyPrefix) {
+		t.Fatalf("current account should be migrated:%s", content)
+	}
+
+	// 新的进程使用环境变量中的口令
+	setMasterKey(nil)
+	acc, gErr := GetUser("b")
+	if gErr != nil || acc.SecretKey != "sk_b" {
+		t.Fatalf("get user error:%v account:%+v", gErr, acc)
+	}
+	if err = SaveToDB(Account{Name: "c", AccessKey: "ak_c", SecretKey: "sk_c"}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	setMasterKey(nil)
+	t.Setenv(EnvPassphrase, "wrong")
+	if _, gErr = GetUser("b"); gErr == nil {
+		t.Fatal("get user with wrong passphrase should error")
+	}
+
+	t.Setenv(EnvPassphrase, "passphrase")
+	count, err = DisablePassphrase()
+	if err != nil || count != 3 || IsPassphraseEnabled() {
+		t.Fatalf("disable passphrase error:%v count:%d", err, count)
+	}
+	setMasterKey(nil)
+	t.Setenv(EnvPassphrase, "")
+	acc, gErr = GetUser("c")
+	if gErr != nil || acc.SecretKey != "sk_c" {
+		t.Fatalf("get user after disable error:%v account:%+v", gErr, acc)
+	}
+}
+
+func TestEnablePassphraseConvertFailed(t *testing.T) {
+	loadTestAccountInfo(t)
+	if err := SaveToDB(Account{Name: "a", AccessKey: "ak_a", SecretKey: "sk_a"}, false); err != nil {
+		t.Fatal(err)
+	}
+	db, oErr := leveldb.OpenFile(info.AccountDBPath, nil)
+	if oErr != nil {
+		t.Fatal(oErr)
+	}
+	if pErr := db.Put([]byte("broken"), []byte("broken"), nil); pErr != nil {
+		t.Fatal(pErr)
+	}
+	_ = db.Close()
+
+	t.Setenv(EnvPassphrase, "passphrase")
+	count, err := EnablePassphrase(func() (string, *data.CodeError) {
+		return "passphrase", nil
+	}, 0)
+	if err == nil || !strings.Contains(err.Error(), "broken") || count != 1 {
+		t.Fatalf("enable passphrase should fail with broken account, error:%v count:%d", err, count)
+	}
+
+	// 存在转换失败的账户时关闭口令保护模式也会失败，且保留主密钥参数
+	if _, err = DisablePassphrase(); err == nil || !IsPassphraseEnabled() {
+		t.Fatalf("disable passphrase should fail with broken account, error:%v", err)
+	}
+}
+
+func TestPassphraseSession(t *testing.T) {
+	loadTestAccountInfo(t)
+	keyInfo, key, err := newMasterKeyInfo("passphrase", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = keyInfo.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	saveSessionKey(keyInfo, key)
+	if cached := loadSessionKey(keyInfo); string(cached) != string(key) {
+		t.Fatal("session key should be loaded")
+	}
+
+	if err = Lock(); err != nil {
+		t.Fatal(err)
+	}
+	if cached := loadSessionKey(keyInfo); cached != nil {
+		t.Fatal("session key should be removed after lock")
+	}
+
+	keyInfo.SessionTimeout = -1
+	saveSessionKey(keyInfo, key)
+	if _, sErr := os.Stat(info.SessionPath); sErr == nil {
+		t.Fatal("session should not be saved when timeout is not positive")
+	}
+}
+
+func TestPassphraseSessionPath(t *testing.T) {
+	runtimeDir := t.TempDir()
+	t.Setenv(envRuntimeDir, runtimeDir)
+	path := PassphraseSessionPath("/workspace/a")
+	if filepath.Dir(filepath.Dir(path)) != runtimeDir {
+		t.Fatalf("session should be saved in runtime dir, path:%s", path)
+	}
+	if path == PassphraseSessionPath("/workspace/b") {
+		t.Fatal("session of different workspaces should be different")
+	}
+
+	// 没有运行时目录时保存在临时目录下当前用户的目录中
+	tempDir := t.TempDir()
+	t.Setenv(envRuntimeDir, "")
+	t.Setenv("TMPDIR", tempDir)
+	t.Setenv("TMP", tempDir)
+	t.Setenv("TEMP", tempDir)
+	if path = PassphraseSessionPath("/workspace/a"); filepath.Dir(filepath.Dir(path)) != tempDir {
+		t.Fatalf("session should be saved in temp dir without runtime dir, path:%s", path)
+	}
+}
+
+func TestCheckSessionDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "session")
+	if err := checkSessionDir(dir); err != nil {
+		t.Fatal("check session dir error:", err)
+	}
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	// 其他用户可访问的目录不能保存口令缓存
+	if err := os.Chmod(dir, 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkSessionDir(dir); err == nil {
+		t.Fatal("session dir accessible to other users should be rejected")
+	}
+}
@@ -0,0 +1,266 @@
+package account
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/config"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+)
+
+// 凭证来源
+const (
+	SourceAccountDB         = "account-db"         // --account 指定的本地数据库账户
+	SourceEnv               = "env"                // 环境变量 QSHELL_ACCESS_KEY、QSHELL_SECRET_KEY
+	SourceProfile           = "profile"            // 凭证文件中 profile 的 access_key、secret_key
+	SourceCredentialProcess = "credential-process" // 凭证文件中 profile 的 credential_process 命令
+	SourceConfig            = "config"             // 配置文件中的 access_key、secret_key
+	SourceCurrentAccount    = "current-account"    // 当前账户，通过 qshell account 或 qshell user cu 设置
+)
+
+// 凭证相关的环境变量
+const (
+	EnvAccessKey       = "QSHELL_ACCESS_KEY"
+	EnvSecretKey       = "QSHELL_SECRET_KEY"
+	EnvProfile         = "QSHELL_PROFILE"
+	EnvCredentialsFile = "QSHELL_CREDENTIALS_FILE"
+
+	DefaultProfile = "default"
+
+	credentialProcessTimeout = time.Minute
+)
+
+// ProviderInfo 凭证链的配置
+type ProviderInfo struct {
+	AccountName     string // 本地数据库中的账户名，对应 --account
+	Profile         string // 凭证文件中的 profile，对应 --profile，为空时使用环境变量 QSHELL_PROFILE
+	CredentialsPath string // 凭证文件路径，环境变量 QSHELL_CREDENTIALS_FILE 优先
+}
+
+// GetAccountFromProviders 按顺序从凭证链中获取账户，使用第一个可用的凭证，Account.Source 为凭证来源：
+// 1. --account 指定的本地数据库账户
+// 2. --profile 指定的凭证文件 profile
+// 3. 环境变量 QSHELL_ACCESS_KEY、QSHELL_SECRET_KEY
+// 4. 环境变量 QSHELL_PROFILE 指定的凭证文件 profile
+// 5. 配置文件中的 access_key、secret_key
+// 6. 当前账户
+// 7. 凭证文件中的 default profile
+// 明确指定的凭证（1、2、3、4）不可用时返回错误，不再使用后续的凭证；没有可用的凭证时 found 为 false
+func GetAccountFromProviders(pInfo ProviderInfo) (acc Account, found bool, err *data.CodeError) {
+	if len(pInfo.AccountName) > 0 && len(pInfo.Profile) > 0 {
+		err = data.NewEmptyError().AppendDesc("--account and --profile can't be specified at the same time")
+		return
+	}
+
+	if len(pInfo.AccountName) > 0 {
+		acc, err = GetUser(pInfo.AccountName)
+		if err != nil {
+			err = data.NewEmptyError().AppendDescF("get account:%s error:%v", pInfo.AccountName, err)
+			return
+		}
+		acc.Source = SourceAccountDB
+		return acc, true, nil
+	}
+
+	credentialsPath := pInfo.CredentialsPath
+	if p := os.Getenv(EnvCredentialsFile); len(p) > 0 {
+		credentialsPath = p
+	}
+	if len(pInfo.Profile) > 0 {
+		acc, err = getProfileAccount(credentialsPath, pInfo.Profile)
+		return acc, err == nil, err
+	}
+
+	if acc, found, err = getEnvAccount(); found || err != nil {
+		return
+	}
+
+	if profile := os.Getenv(EnvProfile); len(profile) > 0 {
+		acc, err = getProfileAccount(credentialsPath, profile)
+		return acc, err == nil, err
+	}
+
+	credentials := config.GetCredentials(config.ConfigTypeDefault)
+	if credentials.AccessKey != "" && credentials.SecretKey != nil {
+		return Account{
+			AccessKey: credentials.AccessKey,
+			SecretKey: string(credentials.SecretKey),
+			Source:    SourceConfig,
+		}, true, nil
+	}
+
+	if acc, err = getAccount(info.AccountPath); err == nil {
+		acc.Source = SourceCurrentAccount
+		return acc, true, nil
+	}
+	if IsPassphraseEnabled() {
+		// 口令保护模式下通常是口令错误或无法输入口令
+		log.WarningF("get current account error:%v", err)
+	} else {
+		log.DebugF("get current account error:%v", err)
+	}
+
+	if profiles, pErr := loadProfiles(credentialsPath); pErr == nil && profiles[DefaultProfile] != nil {
+		acc, err = getProfileAccount(credentialsPath, DefaultProfile)
+		return acc, err == nil, err
+	}
+	return Account{}, false, nil
+}
+
+func getEnvAccount() (acc Account, found bool, err *data.CodeError) {
+	accessKey := os.Getenv(EnvAccessKey)
+	secretKey := os.Getenv(EnvSecretKey)
+	if len(accessKey) == 0 && len(secretKey) == 0 {
+		return
+	}
+	if len(accessKey) == 0 || len(secretKey) == 0 {
+		err = data.NewEmptyError().AppendDescF("both %s and %s should be set", EnvAccessKey, EnvSecretKey)
+		return
+	}
+	return Account{
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+		Source:    SourceEnv,
+	}, true, nil
+}
+
+func getProfileAccount(credentialsPath, profile string) (acc Account, err *data.CodeError) {
+	profiles, err := loadProfiles(credentialsPath)
+	if err != nil {
+		return
+	}
+
+	values := profiles[profile]
+	if values == nil {
+		err = data.NewEmptyError().AppendDescF("can't find profile:%s in credentials file:%s", profile, credentialsPath)
+		return
+	}
+
+	if process := values["credential_process"]; len(process) > 0 {
+		acc, err = runCredentialProcess(process)
+		if err != nil {
+			err = data.NewEmptyError().AppendDescF("profile:%s credential_process error:%v", profile, err)
+			return
+		}
+		acc.Name = profile
+		acc.Source = SourceCredentialProcess
+		return
+	}
+
+	acc = Account{
+		Name:      profile,
+		AccessKey: values["access_key"],
+		SecretKey: values["secret_key"],
+		Source:    SourceProfile,
+	}
+	if len(acc.AccessKey) == 0 || len(acc.SecretKey) == 0 {
+		err = data.NewEmptyError().AppendDescF("profile:%s should have access_key and secret_key, or credential_process", profile)
+	}
+	return
+}
+
+// loadProfiles 读取 INI 格式的凭证文件，返回 profile 名到配置的映射，例：
+//
+//	[default]
+//	access_key = <AccessKey>
+//	secret_key = <SecretKey>
+//
+//	[ci]
+//	credential_process = /usr/local/bin/qiniu-credentials --role ci
+func loadProfiles(credentialsPath string) (map[string]map[string]string, *data.CodeError) {
+	if len(credentialsPath) == 0 {
+		return nil, data.NewEmptyError().AppendDesc("credentials file path is empty")
+	}
+
+	content, rErr := os.ReadFile(credentialsPath)
+	if rErr != nil {
+		return nil, data.NewEmptyError().AppendDescF("read credentials file error:%v", rErr)
+	}
+	return parseProfiles(content)
+}
+
+func parseProfiles(content []byte) (map[string]map[string]string, *data.CodeError) {
+	profiles := make(map[string]map[string]string)
+	var values map[string]string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			// 兼容 [profile name] 的写法
+			name = strings.TrimSpace(strings.TrimPrefix(name, "profile "))
+			if len(name) == 0 {
+				return nil, data.NewEmptyError().AppendDescF("credentials file line %d: empty profile name", lineNumber)
+			}
+			if profiles[name] == nil {
+				profiles[name] = make(map[string]string)
+			}
+			values = profiles[name]
+			continue
+		}
+
+		index := strings.Index(line, "=")
+		if index < 0 {
+			return nil, data.NewEmptyError().AppendDescF("credentials file line %d: should be key = value", lineNumber)
+		}
+		if values == nil {
+			return nil, data.NewEmptyError().AppendDescF("credentials file line %d: key should be in a profile section", lineNumber)
+		}
+		key := strings.ToLower(strings.TrimSpace(line[:index]))
+		values[key] = strings.TrimSpace(line[index+1:])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, data.NewEmptyError().AppendDescF("read credentials file error:%v", err)
+	}
+	return profiles, nil
+}
+
+// runCredentialProcess 执行外部命令获取凭证，命令的标准输出需为 json：
+// {"access_key": "<AccessKey>", "secret_key": "<SecretKey>"}
+func runCredentialProcess(process string) (acc Account, err *data.CodeError) {
+	ctx, cancel := context.WithTimeout(context.Background(), credentialProcessTimeout)
+	defer cancel()
+
+	var c *exec.Cmd
+	if runtime.GOOS == "windows" {
+		c = exec.CommandContext(ctx, "cmd", "/C", process)
+	} else {
+		c = exec.CommandContext(ctx, "sh", "-c", process)
+	}
+	c.Stderr = os.Stderr
+	out, rErr := c.Output()
+	if rErr != nil {
+		err = data.NewEmptyError().AppendDescF("run %s error:%v", process, rErr)
+		return
+	}
+
+	ret := &struct {
+		AccessKey string `json:"access_key"`
+		SecretKey string `json:"secret_key"`
+	}{}
+	if uErr := json.Unmarshal(out, ret); uErr != nil {
+		err = data.NewEmptyError().AppendDescF("output should be json like {\"access_key\":\"\", \"secret_key\":\"\"}, parse error:%v", uErr)
+		return
+	}
+	if len(ret.AccessKey) == 0 || len(ret.SecretKey) == 0 {
+		err = data.NewEmptyError().AppendDesc("output should contain access_key and secret_key")
+		return
+	}
+	log.DebugF("get credentials from credential_process, access key:%s", ret.AccessKey)
+	return Account{
+		AccessKey: ret.AccessKey,
+		SecretKey: ret.SecretKey,
+	}, nil
+}
@@ -0,0 +1,129 @@
+package account
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+const testCredentials = `
+# qshell credentials
+[default]
+access_key = default_ak
+secret_key = default_sk
+
+[profile ci]
+credential_process = echo '{"access_key":"ci_ak","secret_key":"ci_sk"}'
+
+[broken]
+access_key = broken_ak
+`
+
+func writeTestCredentials(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "credentials")
+	if err := os.WriteFile(path, []byte(testCredentials), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseProfiles(t *testing.T) {
+	profiles, err := parseProfiles([]byte(testCredentials))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(profiles) != 3 {
+		t.Fatalf("profiles count should be 3, but:%d", len(profiles))
+	}
+	if profiles["default"]["access_key"] != "default_ak" || profiles["default"]["secret_key"] != "default_sk" {
+		t.Fatalf("default profile invalid:%v", profiles["default"])
+	}
+	if len(profiles["ci"]["credential_process"]) == 0 {
+		t.Fatalf("ci profile invalid:%v", profiles["ci"])
+	}
+
+	if _, err = parseProfiles([]byte("access_key = ak")); err == nil {
+		t.Fatal("key without profile should error")
+	}
+	if _, err = parseProfiles([]byte("[default]\naccess_key")); err == nil {
+		t.Fatal("line without = should error")
+	}
+}
+
+func TestGetAccountFromEnv(t *testing.T) {
+	t.Setenv(EnvAccessKey, "env_ak")
+	t.Setenv(EnvSecretKey, "env_sk")
+	acc, found, err := GetAccountFromProviders(ProviderInfo{})
+	if err != nil || !found {
+		t.Fatalf("get account from env error:%v", err)
+	}
+	if acc.AccessKey != "env_ak" || acc.SecretKey != "env_sk" || acc.Source != SourceEnv {
+		t.Fatalf("account from env invalid:%+v", acc)
+	}
+
+	t.Setenv(EnvSecretKey, "")
+	if _, _, err = GetAccountFromProviders(ProviderInfo{}); err == nil {
+		t.Fatal("only access key in env should error")
+	}
+}
+
+func TestGetAccountFromProfile(t *testing.T) {
+	path := writeTestCredentials(t)
+
+	acc, found, err := GetAccountFromProviders(ProviderInfo{Profile: "default", CredentialsPath: path})
+	if err != nil || !found {
+		t.Fatalf("get account from profile error:%v", err)
+	}
+	if acc.Name != "default" || acc.AccessKey != "default_ak" || acc.Source != SourceProfile {
+		t.Fatalf("account from profile invalid:%+v", acc)
+	}
+
+	if _, _, err = GetAccountFromProviders(ProviderInfo{Profile: "broken", CredentialsPath: path}); err == nil {
+		t.Fatal("profile without secret key should error")
+	}
+	if _, _, err = GetAccountFromProviders(ProviderInfo{Profile: "none", CredentialsPath: path}); err == nil {
+		t.Fatal("profile not exist should error")
+	}
+	if _, _, err = GetAccountFromProviders(ProviderInfo{AccountName: "a", Profile: "default"}); err == nil {
+		t.Fatal("account and profile should not be specified at the same time")
+	}
+
+	// 环境变量指定的 profile 优先级低于环境变量中的 AccessKey
+	t.Setenv(EnvAccessKey, "")
+	t.Setenv(EnvSecretKey, "")
+	t.Setenv(EnvProfile, "default")
+	t.Setenv(EnvCredentialsFile, path)
+	acc, _, err = GetAccountFromProviders(ProviderInfo{})
+	if err != nil || acc.Source != SourceProfile {
+		t.Fatalf("get account from env profile error:%v account:%+v", err, acc)
+	}
+	t.Setenv(EnvAccessKey, "env_ak")
+	t.Setenv(EnvSecretKey, "env_sk")
+	acc, _, err = GetAccountFromProviders(ProviderInfo{})
+	if err != nil || acc.Source != SourceEnv {
+		t.Fatalf("env access key should be used first, error:%v account:%+v", err, acc)
+	}
+}
+
+func TestGetAccountFromCredentialProcess(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("credential process test uses sh")
+	}
+	path := writeTestCredentials(t)
+
+	acc, found, err := GetAccountFromProviders(ProviderInfo{Profile: "ci", CredentialsPath: path})
+	if err != nil || !found {
+		t.Fatalf("get account from credential process error:%v", err)
+	}
+	if acc.Name != "ci" || acc.AccessKey != "ci_ak" || acc.SecretKey != "ci_sk" || acc.Source != SourceCredentialProcess {
+		t.Fatalf("account from credential process invalid:%+v", acc)
+	}
+
+	if _, err = runCredentialProcess("echo not-json"); err == nil {
+		t.Fatal("credential process output not json should error")
+	}
+	if _, err = runCredentialProcess("exit 1"); err == nil {
+		t.Fatal("credential process exit with error should error")
+	}
+}
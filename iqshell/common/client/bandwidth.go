@@ -0,0 +1,81 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/limit"
+)
+
+// 每次读取的最大字节数，避免单次读取过大导致限速不平滑
+const bandwidthLimitReadSize = 32 * 1024
+
+var (
+	bandwidthLimitLock sync.RWMutex
+	bandwidthLimit     limit.BandwidthLimit
+)
+
+// SetBandwidthLimit 设置全局的带宽限制，通过 TransferStorageClient 进行的上传（表单、分片 v1/v2）和下载（包含分片下载）共享此限制
+func SetBandwidthLimit(l limit.BandwidthLimit) {
+	bandwidthLimitLock.Lock()
+	bandwidthLimit = l
+	bandwidthLimitLock.Unlock()
+}
+
+func getBandwidthLimit() limit.BandwidthLimit {
+	bandwidthLimitLock.RLock()
+	defer bandwidthLimitLock.RUnlock()
+	return bandwidthLimit
+}
+
+// bandwidthLimitTransport 对请求体及响应体进行限速
+type bandwidthLimitTransport struct {
+	transport http.RoundTripper
+}
+
+func (t *bandwidthLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	l := getBandwidthLimit()
+	if l == nil {
+		return t.transport.RoundTrip(req)
+	}
+
+	if req.Body != nil && req.Body != http.NoBody {
+		limitReq := new(http.Request)
+		*limitReq = *req
+		limitReq.Body = &bandwidthLimitReader{
+			reader: req.Body,
+			limit:  l,
+		}
+		req = limitReq
+	}
+
+	resp, err := t.transport.RoundTrip(req)
+	if resp != nil && resp.Body != nil && resp.Body != http.NoBody {
+		resp.Body = &bandwidthLimitReader{
+			reader: resp.Body,
+			limit:  l,
+		}
+	}
+	return resp, err
+}
+
+type bandwidthLimitReader struct {
+	reader io.ReadCloser
+	limit  limit.BandwidthLimit
+}
+
+func (r *bandwidthLimitReader) Read(p []byte) (int, error) {
+	if len(p) > bandwidthLimitReadSize {
+		p = p[:bandwidthLimitReadSize]
+	}
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		_ = r.limit.Acquire(n)
+	}
+	return n, err
+}
+
+func (r *bandwidthLimitReader) Close() error {
+	return r.reader.Close()
+}
@@ -8,25 +8,40 @@ import (
 	"github.com/qiniu/go-sdk/v7/storage"
 )
 
+var defaultTransport = &http.Transport{
+	Proxy: http.ProxyFromEnvironment,
+	DialContext: (&net.Dialer{
+		Timeout:   20 * time.Second,
+		KeepAlive: 20 * time.Second,
+	}).DialContext,
+	ForceAttemptHTTP2:     true,
+	MaxIdleConns:          2000,
+	MaxIdleConnsPerHost:   1000,
+	ResponseHeaderTimeout: 60 * time.Second,
+	IdleConnTimeout:       15 * time.Second,
+	TLSHandshakeTimeout:   15 * time.Second,
+	ExpectContinueTimeout: 1 * time.Second,
+}
+
 var defaultClient = storage.Client{
 	Client: &http.Client{
-		Transport: &http.Transport{
-			Proxy: http.ProxyFromEnvironment,
-			DialContext: (&net.Dialer{
-				Timeout:   20 * time.Second,
-				KeepAlive: 20 * time.Second,
-			}).DialContext,
-			ForceAttemptHTTP2:     true,
-			MaxIdleConns:          2000,
-			MaxIdleConnsPerHost:   1000,
-			ResponseHeaderTimeout: 60 * time.Second,
-			IdleConnTimeout:       15 * time.Second,
-			TLSHandshakeTimeout:   15 * time.Second,
-			ExpectContinueTimeout: 1 * time.Second,
-		},
+		Transport: defaultTransport,
+	},
+}
+
+// transferClient 与 defaultClient 共用连接，请求体及响应体受带宽限制
+var transferClient = storage.Client{
+	Client: &http.Client{
+		Transport: &bandwidthLimitTransport{transport: defaultTransport},
 	},
 }
 
+// DefaultStorageClient 用于 rs、rsf、uc 等 API 请求，不受带宽限制
 func DefaultStorageClient() storage.Client {
 	return defaultClient
 }
+
+// TransferStorageClient 用于上传、下载文件内容，受 SetBandwidthLimit 设置的带宽限制
+func TransferStorageClient() storage.Client {
+	return transferClient
+}
@@ -0,0 +1,13 @@
+package client
+
+import "testing"
+
+func TestStorageClientTransport(t *testing.T) {
+	// API 请求不受带宽限制
+	if _, ok := DefaultStorageClient().Client.Transport.(*bandwidthLimitTransport); ok {
+		t.Fatal("default storage client should not be bandwidth limited")
+	}
+	if _, ok := TransferStorageClient().Client.Transport.(*bandwidthLimitTransport); !ok {
+		t.Fatal("transfer storage client should be bandwidth limited")
+	}
+}
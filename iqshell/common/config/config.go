@@ -15,6 +15,7 @@ type Config struct {
 	UseHttps    *data.Bool        `json:"use_https,omitempty"`
 	Hosts       *Hosts            `json:"hosts,omitempty"`
 	Log         *LogSetting       `json:"log"`
+	Record      *RecordSetting    `json:"record,omitempty"`
 }
 
 func (c *Config) IsUseHttps() bool {
@@ -79,6 +80,13 @@ func (c *Config) Merge(from *Config) {
 		}
 		c.Log.merge(from.Log)
 	}
+
+	if from.Record != nil {
+		if c.Record == nil {
+			c.Record = &RecordSetting{}
+		}
+		c.Record.merge(from.Record)
+	}
 }
 
 func (c *Config) String() string {
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+// 任务执行记录的存储后端
+const (
+	RecordBackendLevelDB   = "leveldb"    // 本地 LevelDB，记录保存在 job 目录下
+	RecordBackendSharedDir = "shared-dir" // 共享目录追加日志，多台机器可通过共享目录（如 NFS）共用同一任务的记录
+)
+
+type RecordSetting struct {
+	Backend *data.String `json:"backend,omitempty"`
+	Dir     *data.String `json:"dir,omitempty"`
+}
+
+func (r *RecordSetting) GetBackend() string {
+	if r == nil || data.Empty(r.Backend) || len(r.Backend.Value()) == 0 {
+		return RecordBackendLevelDB
+	}
+	return r.Backend.Value()
+}
+
+func (r *RecordSetting) GetDir() string {
+	if r == nil || data.Empty(r.Dir) {
+		return ""
+	}
+	return r.Dir.Value()
+}
+
+func (r *RecordSetting) Check() *data.CodeError {
+	switch r.GetBackend() {
+	case RecordBackendLevelDB:
+		return nil
+	case RecordBackendSharedDir:
+		if len(r.GetDir()) == 0 {
+			return data.NewEmptyError().AppendDescF("record: dir can't be empty when backend is %s", RecordBackendSharedDir)
+		}
+		return nil
+	default:
+		return data.NewEmptyError().AppendDescF("record: backend:%s not support, should be one of %s and %s",
+			r.GetBackend(), RecordBackendLevelDB, RecordBackendSharedDir)
+	}
+}
+
+func (r *RecordSetting) merge(from *RecordSetting) {
+	if from == nil {
+		return
+	}
+
+	r.Backend = data.GetNotEmptyStringIfExist(r.Backend, from.Backend)
+	r.Dir = data.GetNotEmptyStringIfExist(r.Dir, from.Dir)
+}
@@ -36,6 +36,8 @@ func ConvertError(err error) *CodeError {
 type CodeError struct {
 	Code int
 	Desc string
+
+	cause error // 产生此错误的原始错误，可通过 errors.Is 及 errors.As 判断
 }
 
 func NewAlreadyDoneError(desc string) *CodeError {
@@ -96,6 +98,7 @@ func (c *CodeError) AppendError(err error) *CodeError {
 			c.Desc += " => "
 		}
 		c.Desc += err.Error()
+		c.cause = errors.Join(c.cause, err)
 	}
 	return c
 }
@@ -106,6 +109,7 @@ func NewErrorWithError(code int, desc string, err error) *CodeError {
 	e.Desc = desc
 	if err != nil {
 		e.Desc += " => " + err.Error()
+		e.cause = err
 	}
 	return e
 }
@@ -126,6 +130,14 @@ func (c *CodeError) Error() string {
 	return fmt.Sprintf("【%d】%s", c.Code, c.Desc)
 }
 
+// Unwrap 返回产生此错误的原始错误
+func (c *CodeError) Unwrap() error {
+	if c == nil {
+		return nil
+	}
+	return c.cause
+}
+
 func (c *CodeError) IsCancel() bool {
 	if c == nil {
 		return false
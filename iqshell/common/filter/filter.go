@@ -0,0 +1,557 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+// Kind 字段的类型
+type Kind int
+
+const (
+	KindString Kind = iota // 字符串，值类型为 string
+	KindNumber             // 数字，值类型为 int64，字面量支持 B、KB、MB、GB、TB 单位（1024 进制）
+	KindTime               // 时间，值类型为 time.Time，字面量为日期字符串或 Unix 时间戳（秒）
+)
+
+// Schema 过滤表达式中可以使用的字段及其类型，字段名不区分大小写
+type Schema map[string]Kind
+
+func (s Schema) field(name string) (string, Kind, bool) {
+	for field, kind := range s {
+		if strings.EqualFold(field, name) {
+			return field, kind, true
+		}
+	}
+	return "", 0, false
+}
+
+// Object 被过滤的对象
+type Object interface {
+	// FilterValue 获取字段的值，字段为 Schema 中定义的字段名；
+	// KindString 返回 string，KindNumber 返回 int64，KindTime 返回 time.Time
+	FilterValue(field string) interface{}
+}
+
+// Expression 编译后的过滤表达式
+type Expression interface {
+	Match(obj Object) bool
+	String() string
+}
+
+// Parse 编译过滤表达式
+// 语法：
+//
+//	表达式：<比较> | !<表达式> | (<表达式>) | <表达式> && <表达式> | <表达式> || <表达式>
+//	比较：<字段> <操作符> <字面量>
+//	操作符：==、!=、>、>=、<、<=、=~（正则匹配）、!~（正则不匹配）、glob（通配符匹配，* 不匹配 /，** 匹配任意字符，? 匹配单个字符）
+//	字面量：字符串使用双引号或单引号，支持 \ 转义；数字支持 B、KB、MB、GB、TB 单位
+//
+// 例：key =~ "^logs/2024-.*\.gz$" && size > 1MB && !(mime == "text/plain")
+func Parse(expression string, schema Schema) (Expression, *data.CodeError) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 1 {
+		return nil, data.NewEmptyError().AppendDesc("filter: expression is empty")
+	}
+
+	p := &parser{
+		tokens: tokens,
+		schema: schema,
+	}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.error(t, "unexpected %s", t)
+	}
+	return node, nil
+}
+
+const (
+	tokenEOF = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenLeftParen
+	tokenRightParen
+)
+
+type token struct {
+	kind  int
+	value string
+	pos   int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of expression"
+	case tokenString:
+		return strconv.Quote(t.value)
+	default:
+		return fmt.Sprintf("'%s'", t.value)
+	}
+}
+
+func tokenize(expression string) ([]token, *data.CodeError) {
+	tokens := make([]token, 0)
+	runes := []rune(expression)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenLeftParen, value: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenRightParen, value: ")", pos: i})
+			i++
+		case c == '"' || c == '\'':
+			value, end, err := readString(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, value: value, pos: i})
+			i = end
+		case c >= '0' && c <= '9' || c == '.' || c == '-':
+			start := i
+			i++
+			for i < len(runes) && (isIdentRune(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, value: string(runes[start:i]), pos: start})
+		case isIdentRune(c):
+			start := i
+			for i < len(runes) && isIdentRune(runes[i]) {
+				i++
+			}
+			word := string(runes[start:i])
+			if strings.EqualFold(word, "glob") {
+				tokens = append(tokens, token{kind: tokenOperator, value: "glob", pos: start})
+			} else {
+				tokens = append(tokens, token{kind: tokenIdent, value: word, pos: start})
+			}
+		default:
+			op := ""
+			for _, o := range []string{"&&", "||", "==", "!=", ">=", "<=", "=~", "!~", ">", "<", "!"} {
+				if strings.HasPrefix(string(runes[i:]), o) {
+					op = o
+					break
+				}
+			}
+			switch op {
+			case "":
+				return nil, data.NewEmptyError().AppendDescF("filter: unexpected character '%c' at position %d", c, i)
+			case "&&":
+				tokens = append(tokens, token{kind: tokenAnd, value: op, pos: i})
+			case "||":
+				tokens = append(tokens, token{kind: tokenOr, value: op, pos: i})
+			case "!":
+				tokens = append(tokens, token{kind: tokenNot, value: op, pos: i})
+			default:
+				tokens = append(tokens, token{kind: tokenOperator, value: op, pos: i})
+			}
+			i += len([]rune(op))
+		}
+	}
+	tokens = append(tokens, token{kind: tokenEOF, pos: len(runes)})
+	return tokens, nil
+}
+
+func isIdentRune(c rune) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// readString 读取字符串字面量，返回字符串的值及字面量结束的位置
+func readString(runes []rune, start int) (string, int, *data.CodeError) {
+	quote := runes[start]
+	builder := strings.Builder{}
+	for i := start + 1; i < len(runes); i++ {
+		c := runes[i]
+		if c == quote {
+			return builder.String(), i + 1, nil
+		}
+		if c == '\\' && i+1 < len(runes) {
+			i++
+			switch runes[i] {
+			case 'n':
+				builder.WriteRune('\n')
+			case 't':
+				builder.WriteRune('\t')
+			case 'r':
+				builder.WriteRune('\r')
+			case '\\', '"', '\'':
+				builder.WriteRune(runes[i])
+			default:
+				// 其他转义保留 \，方便书写正则，如：\.、\d
+				builder.WriteRune('\\')
+				builder.WriteRune(runes[i])
+			}
+			continue
+		}
+		builder.WriteRune(c)
+	}
+	return "", 0, data.NewEmptyError().AppendDescF("filter: string starting at position %d is not terminated", start)
+}
+
+type parser struct {
+	tokens []token
+	index  int
+	schema Schema
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.index]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.index]
+	if t.kind != tokenEOF {
+		p.index++
+	}
+	return t
+}
+
+func (p *parser) error(t token, format string, a ...interface{}) *data.CodeError {
+	return data.NewEmptyError().AppendDescF("filter: %s at position %d", fmt.Sprintf(format, a...), t.pos)
+}
+
+func (p *parser) parseOr() (Expression, *data.CodeError) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOr {
+		p.next()
+		right, rErr := p.parseAnd()
+		if rErr != nil {
+			return nil, rErr
+		}
+		left = &orNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expression, *data.CodeError) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenAnd {
+		p.next()
+		right, rErr := p.parseUnary()
+		if rErr != nil {
+			return nil, rErr
+		}
+		left = &andNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (Expression, *data.CodeError) {
+	t := p.peek()
+	switch t.kind {
+	case tokenNot:
+		p.next()
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{node: node}, nil
+	case tokenLeftParen:
+		p.next()
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if r := p.next(); r.kind != tokenRightParen {
+			return nil, p.error(r, "expect ')' but got %s", r)
+		}
+		return node, nil
+	case tokenIdent:
+		return p.parseComparison()
+	default:
+		return nil, p.error(t, "expect field, '!' or '(' but got %s", t)
+	}
+}
+
+func (p *parser) parseComparison() (Expression, *data.CodeError) {
+	fieldToken := p.next()
+	field, kind, ok := p.schema.field(fieldToken.value)
+	if !ok {
+		return nil, p.error(fieldToken, "unknown field '%s', supported fields:%s", fieldToken.value, p.schemaFields())
+	}
+
+	opToken := p.next()
+	if opToken.kind != tokenOperator {
+		return nil, p.error(opToken, "expect operator after '%s' but got %s", fieldToken.value, opToken)
+	}
+	valueToken := p.next()
+	if valueToken.kind != tokenString && valueToken.kind != tokenNumber {
+		return nil, p.error(valueToken, "expect value after '%s' but got %s", opToken.value, valueToken)
+	}
+
+	node := &compareNode{
+		field:    field,
+		kind:     kind,
+		operator: opToken.value,
+		literal:  valueToken.value,
+	}
+	switch opToken.value {
+	case "=~", "!~", "glob":
+		if kind != KindString || valueToken.kind != tokenString {
+			return nil, p.error(opToken, "operator '%s' can only be used between string field and string", opToken.value)
+		}
+		pattern := valueToken.value
+		if opToken.value == "glob" {
+			pattern = globToRegexp(pattern)
+		}
+		reg, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, p.error(valueToken, "invalid pattern %s, %v", valueToken, err)
+		}
+		node.regexp = reg
+		return node, nil
+	}
+
+	switch kind {
+	case KindString:
+		if valueToken.kind != tokenString {
+			return nil, p.error(valueToken, "field '%s' should be compared with string, but got %s", field, valueToken)
+		}
+		node.stringValue = valueToken.value
+	case KindNumber:
+		if valueToken.kind != tokenNumber {
+			return nil, p.error(valueToken, "field '%s' should be compared with number, but got %s", field, valueToken)
+		}
+		number, err := ParseNumber(valueToken.value)
+		if err != nil {
+			return nil, p.error(valueToken, "%v", err)
+		}
+		node.numberValue = number
+	case KindTime:
+		t, err := parseTime(valueToken)
+		if err != nil {
+			return nil, p.error(valueToken, "%v", err)
+		}
+		node.timeValue = t
+	}
+	return node, nil
+}
+
+func (p *parser) schemaFields() string {
+	fields := make([]string, 0, len(p.schema))
+	for field := range p.schema {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return strings.Join(fields, ",")
+}
+
+// ParseNumber 解析数字，支持 B、KB、MB、GB、TB 单位（1024 进制），如：1MB、1.5GB、100
+func ParseNumber(value string) (int64, *data.CodeError) {
+	v := strings.ToUpper(value)
+	unit := float64(1)
+	for _, u := range []struct {
+		suffix string
+		size   float64
+	}{
+		{"TB", 1 << 40},
+		{"GB", 1 << 30},
+		{"MB", 1 << 20},
+		{"KB", 1 << 10},
+		{"T", 1 << 40},
+		{"G", 1 << 30},
+		{"M", 1 << 20},
+		{"K", 1 << 10},
+		{"B", 1},
+	} {
+		if strings.HasSuffix(v, u.suffix) {
+			v = strings.TrimSuffix(v, u.suffix)
+			unit = u.size
+			break
+		}
+	}
+	number, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, data.NewEmptyError().AppendDescF("invalid number '%s'", value)
+	}
+	return int64(number * unit), nil
+}
+
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+func parseTime(t token) (time.Time, *data.CodeError) {
+	if t.kind == tokenNumber {
+		seconds, err := strconv.ParseInt(t.value, 10, 64)
+		if err != nil {
+			return time.Time{}, data.NewEmptyError().AppendDescF("invalid unix timestamp '%s'", t.value)
+		}
+		return time.Unix(seconds, 0), nil
+	}
+	for _, layout := range timeLayouts {
+		if v, err := time.ParseInLocation(layout, t.value, time.Local); err == nil {
+			return v, nil
+		}
+	}
+	return time.Time{}, data.NewEmptyError().AppendDescF("invalid time '%s', should be like 2006-01-02 or 2006-01-02 15:04:05", t.value)
+}
+
+// globToRegexp 将通配符转为正则：* 匹配除 / 外的任意字符，** 匹配任意字符，? 匹配除 / 外的单个字符，[...] 原样保留
+func globToRegexp(glob string) string {
+	builder := strings.Builder{}
+	builder.WriteString("^")
+	runes := []rune(glob)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch c {
+		case '*':
+			if i+1 < len(runes) && runes[i+1] == '*' {
+				builder.WriteString(".*")
+				i++
+			} else {
+				builder.WriteString("[^/]*")
+			}
+		case '?':
+			builder.WriteString("[^/]")
+		case '[':
+			end := strings.IndexRune(string(runes[i:]), ']')
+			if end < 0 {
+				builder.WriteString(regexp.QuoteMeta(string(c)))
+			} else {
+				class := []rune(string(runes[i:])[:end+1])
+				builder.WriteString(string(class))
+				i += len(class) - 1
+			}
+		default:
+			builder.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	builder.WriteString("$")
+	return builder.String()
+}
+
+type andNode struct {
+	left, right Expression
+}
+
+func (n *andNode) Match(obj Object) bool {
+	return n.left.Match(obj) && n.right.Match(obj)
+}
+
+func (n *andNode) String() string {
+	return fmt.Sprintf("(%s && %s)", n.left, n.right)
+}
+
+type orNode struct {
+	left, right Expression
+}
+
+func (n *orNode) Match(obj Object) bool {
+	return n.left.Match(obj) || n.right.Match(obj)
+}
+
+func (n *orNode) String() string {
+	return fmt.Sprintf("(%s || %s)", n.left, n.right)
+}
+
+type notNode struct {
+	node Expression
+}
+
+func (n *notNode) Match(obj Object) bool {
+	return !n.node.Match(obj)
+}
+
+func (n *notNode) String() string {
+	return fmt.Sprintf("!%s", n.node)
+}
+
+type compareNode struct {
+	field       string
+	kind        Kind
+	operator    string
+	literal     string
+	regexp      *regexp.Regexp
+	stringValue string
+	numberValue int64
+	timeValue   time.Time
+}
+
+func (n *compareNode) String() string {
+	return fmt.Sprintf("%s %s %s", n.field, n.operator, strconv.Quote(n.literal))
+}
+
+func (n *compareNode) Match(obj Object) bool {
+	value := obj.FilterValue(n.field)
+	if n.regexp != nil {
+		s, _ := value.(string)
+		matched := n.regexp.MatchString(s)
+		if n.operator == "!~" {
+			return !matched
+		}
+		return matched
+	}
+
+	var result int
+	switch n.kind {
+	case KindString:
+		s, _ := value.(string)
+		result = strings.Compare(s, n.stringValue)
+	case KindNumber:
+		number, _ := value.(int64)
+		result = compareInt64(number, n.numberValue)
+	case KindTime:
+		t, _ := value.(time.Time)
+		result = compareInt64(t.UnixNano(), n.timeValue.UnixNano())
+	}
+
+	switch n.operator {
+	case "==":
+		return result == 0
+	case "!=":
+		return result != 0
+	case ">":
+		return result > 0
+	case ">=":
+		return result >= 0
+	case "<":
+		return result < 0
+	case "<=":
+		return result <= 0
+	default:
+		return false
+	}
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a > b:
+		return 1
+	case a < b:
+		return -1
+	default:
+		return 0
+	}
+}
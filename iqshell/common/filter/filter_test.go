@@ -0,0 +1,76 @@
+package filter
+
+import (
+	"testing"
+	"time"
+)
+
+var testSchema = Schema{
+	"key":     KindString,
+	"size":    KindNumber,
+	"mime":    KindString,
+	"putTime": KindTime,
+}
+
+type testObject map[string]interface{}
+
+func (o testObject) FilterValue(field string) interface{} {
+	return o[field]
+}
+
+func TestParseAndMatch(t *testing.T) {
+	obj := testObject{
+		"key":     "logs/2024-01-02/a\tb.gz",
+		"size":    int64(2 * 1024 * 1024),
+		"mime":    "application/gzip",
+		"putTime": time.Date(2024, 1, 2, 10, 0, 0, 0, time.Local),
+	}
+
+	for expression, expect := range map[string]bool{
+		`key =~ "^logs/2024-.*\.gz$" && size > 1MB && !(mime == "text/plain")`: true,
+		`key =~ "^logs/2023-"`:                                      false,
+		`key !~ "^logs/2023-"`:                                      true,
+		`key glob "logs/*.gz"`:                                      false,
+		`key glob "logs/**.gz"`:                                     true,
+		`key glob "logs/2024-01-0?/*"`:                              true,
+		`size >= 2MB && size <= 2097152`:                            true,
+		`size < 1.5m || mime == 'application/gzip'`:                 true,
+		`size < 1.5m || mime == "text/plain"`:                       false,
+		`!(size > 1KB)`:                                             false,
+		`putTime >= "2024-01-02" && putTime < "2024-01-03"`:         true,
+		`putTime > "2024-01-02 10:00:00"`:                           false,
+		`KEY == "logs/2024-01-02/a\tb.gz"`:                          true,
+		`size > 1MB && (mime == "a" || mime == "application/gzip")`: true,
+	} {
+		e, err := Parse(expression, testSchema)
+		if err != nil {
+			t.Fatalf("parse expression:%s error:%v", expression, err)
+		}
+		if match := e.Match(obj); match != expect {
+			t.Fatalf("expression:%s should match:%v, but:%v", expression, expect, match)
+		}
+	}
+}
+
+func TestParseError(t *testing.T) {
+	for _, expression := range []string{
+		``,
+		`name == "a"`,
+		`key == 1`,
+		`size == "a"`,
+		`size =~ "a"`,
+		`key =~ "("`,
+		`key == "a" &&`,
+		`(key == "a"`,
+		`key == "a")`,
+		`key "a"`,
+		`key == "a`,
+		`putTime > "yesterday"`,
+		`size > 1XB`,
+		`key == "a" # b`,
+	} {
+		if _, err := Parse(expression, testSchema); err == nil {
+			t.Fatalf("parse expression:%s should error", expression)
+		}
+	}
+}
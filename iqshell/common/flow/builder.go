@@ -1,9 +1,11 @@
 package flow
 
 import (
+	"github.com/qiniu/qshell/v2/iqshell/common/config"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/limit"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
 )
 
 func New(info Info) *WorkProvideBuilder {
@@ -59,8 +61,16 @@ func (b *WorkProvideBuilder) WorkProviderWithArray(workList []Work) *WorkerProvi
 	}
 }
 
+// WorkProviderWithFile 从文件或 stdin 读取 work；配置了 Info.RetryFailedFrom 时仅读取之前执行失败的 work
 func (b *WorkProvideBuilder) WorkProviderWithFile(filePath string, enableStdin bool, creator WorkCreator) *WorkerProvideBuilder {
-	if provider, err := NewWorkProviderOfFile(filePath, enableStdin, creator); err != nil {
+	var provider WorkProvider
+	var err *data.CodeError
+	if len(b.flow.Info.RetryFailedFrom) > 0 {
+		provider, err = NewRetryFailedWorkProvider(b.flow.Info.RetryFailedFrom, creator)
+	} else {
+		provider, err = NewWorkProviderOfFile(filePath, enableStdin, creator)
+	}
+	if err != nil {
 		return &WorkerProvideBuilder{
 			flow: b.flow,
 			err:  err,
@@ -107,8 +117,19 @@ func (b *FlowBuilder) SetOverseerEnable(enable bool) *FlowBuilder {
 	return b
 }
 
+// SetDBOverseer 设置记录 work 执行状态的 Overseer，存储后端由配置 record.backend 决定：
+// leveldb 时记录保存在 dbPath 中；shared-dir 时记录保存在共享目录 record.dir 下，路径为 dbPath 相对于工作区的路径
 func (b *FlowBuilder) SetDBOverseer(dbPath string, blankWorkRecordBuilder func() *WorkRecord) *FlowBuilder {
-	if overseer, err := NewDBRecordOverseer(dbPath, blankWorkRecordBuilder); err != nil {
+	var overseer Overseer
+	var err *data.CodeError
+	if setting := workspace.GetRecordConfig(); setting.GetBackend() == config.RecordBackendSharedDir {
+		recordDir := workspace.GetSharedRecordPath(setting.GetDir(), dbPath)
+		log.DebugF("shared dir recorder:%s", recordDir)
+		overseer, err = NewSharedDirRecordOverseer(recordDir, blankWorkRecordBuilder)
+	} else {
+		overseer, err = NewDBRecordOverseer(dbPath, blankWorkRecordBuilder)
+	}
+	if err != nil {
 		b.err = err
 		return b
 	} else {
@@ -162,6 +183,11 @@ func (b *FlowBuilder) OnWorkFail(f func(workInfo *WorkInfo, err *data.CodeError)
 	return b
 }
 
+func (b *FlowBuilder) OnConcurrencyChange(f func(change *ConcurrencyChange)) *FlowBuilder {
+	b.flow.EventListener.OnConcurrencyChangeFunc = f
+	return b
+}
+
 type FlowBuilder struct {
 	enableOverseer bool
 	flow           *Flow
@@ -0,0 +1,297 @@
+package flow
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+const (
+	defaultConcurrencyAdjustInterval = 3 * time.Second
+	defaultMaxConcurrencyMultiple    = 4 // 未指定最大并发数时，最大并发数为初始并发数的倍数
+
+	concurrencyThrottleDecreaseFactor = 0.5  // 遇到限流错误时并发数、批大小的缩减比例
+	concurrencyOverloadDecreaseFactor = 0.7  // 服务端过载或延迟升高时并发数、批大小的缩减比例
+	concurrencyOverloadErrorRate      = 0.05 // 过载类错误比例超过此值时认为服务端过载
+	concurrencyLatencyGradientLimit   = 0.5  // 基准延迟与当前延迟的比值小于此值时认为延迟升高
+	concurrencyBaselineDrift          = 0.1  // 每个窗口基准延迟向当前延迟靠拢的比例，用于适应延迟的长期变化
+)
+
+// 错误分类，仅限流及过载类错误会触发并发缩减
+const (
+	workErrorClassNone     = iota
+	workErrorClassThrottle // 限流：573、429
+	workErrorClassOverload // 服务端过载：5xx、超时、连接异常
+	workErrorClassOther    // 其他与并发无关的错误，如文件不存在
+)
+
+func classifyWorkError(err *data.CodeError) int {
+	if err == nil {
+		return workErrorClassNone
+	}
+	switch err.Code {
+	case 573, 429:
+		return workErrorClassThrottle
+	case 500, 502, 503, 504, 599:
+		return workErrorClassOverload
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return workErrorClassOverload
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return workErrorClassOverload
+	}
+	desc := strings.ToLower(err.Error())
+	for _, keyword := range []string{"timeout", "connection reset", "connection refused", "broken pipe"} {
+		if strings.Contains(desc, keyword) {
+			return workErrorClassOverload
+		}
+	}
+	return workErrorClassOther
+}
+
+// ConcurrencyChange 自适应并发控制器的调整决策
+type ConcurrencyChange struct {
+	PreConcurrency int           `json:"pre_concurrency"` // 调整前的并发数
+	Concurrency    int           `json:"concurrency"`     // 调整后的并发数
+	PreBatchSize   int           `json:"pre_batch_size"`  // 调整前每批 work 的数量
+	BatchSize      int           `json:"batch_size"`      // 调整后每批 work 的数量
+	Reason         string        `json:"reason"`          // 调整原因
+	Latency        time.Duration `json:"latency"`         // 窗口内每个 work 的平均耗时
+	Throughput     float64       `json:"throughput"`      // 窗口内每秒完成的 work 数
+	ThrottleCount  int           `json:"throttle_count"`  // 窗口内限流错误数
+	OverloadCount  int           `json:"overload_count"`  // 窗口内服务端过载错误数
+	ErrorCount     int           `json:"error_count"`     // 窗口内其他错误数
+}
+
+func (c *ConcurrencyChange) String() string {
+	return fmt.Sprintf("concurrency:%d => %d, batch size:%d => %d, reason:%s, latency:%s, throughput:%.2f/s, throttle:%d, overload:%d, error:%d",
+		c.PreConcurrency, c.Concurrency, c.PreBatchSize, c.BatchSize, c.Reason,
+		c.Latency, c.Throughput, c.ThrottleCount, c.OverloadCount, c.ErrorCount)
+}
+
+type ConcurrencyControllerConfig struct {
+	InitConcurrency int           // 初始并发数
+	MinConcurrency  int           // 最小并发数
+	MaxConcurrency  int           // 最大并发数
+	InitBatchSize   int           // 初始每批 work 数量
+	MinBatchSize    int           // 最小每批 work 数量
+	MaxBatchSize    int           // 最大每批 work 数量
+	AdjustInterval  time.Duration // 调整周期，默认 3s
+}
+
+func (c *ConcurrencyControllerConfig) check() {
+	if c.MinConcurrency < 1 {
+		c.MinConcurrency = 1
+	}
+	if c.MaxConcurrency < c.MinConcurrency {
+		c.MaxConcurrency = c.MinConcurrency
+	}
+	if c.InitConcurrency < c.MinConcurrency {
+		c.InitConcurrency = c.MinConcurrency
+	}
+	if c.InitConcurrency > c.MaxConcurrency {
+		c.InitConcurrency = c.MaxConcurrency
+	}
+
+	if c.MinBatchSize < 1 {
+		c.MinBatchSize = 1
+	}
+	if c.MaxBatchSize < c.MinBatchSize {
+		c.MaxBatchSize = c.MinBatchSize
+	}
+	if c.InitBatchSize < c.MinBatchSize || c.InitBatchSize > c.MaxBatchSize {
+		c.InitBatchSize = c.MaxBatchSize
+	}
+
+	if c.AdjustInterval <= 0 {
+		c.AdjustInterval = defaultConcurrencyAdjustInterval
+	}
+}
+
+// NewConcurrencyController 创建自适应并发控制器
+// 控制器统计每批 work 的耗时、吞吐及错误类型，每个调整周期按 AIMD 调整并发数及每批 work 的数量：
+// 遇到限流或服务端过载错误、延迟明显升高时按比例减小，否则逐步增加
+func NewConcurrencyController(cfg ConcurrencyControllerConfig) *ConcurrencyController {
+	cfg.check()
+	c := &ConcurrencyController{
+		cfg:         cfg,
+		concurrency: cfg.InitConcurrency,
+		batchSize:   cfg.InitBatchSize,
+		now:         time.Now,
+	}
+	c.cond = sync.NewCond(&c.mu)
+	c.windowStart = c.now()
+	return c
+}
+
+type ConcurrencyController struct {
+	cfg  ConcurrencyControllerConfig
+	mu   sync.Mutex
+	cond *sync.Cond
+	now  func() time.Time
+
+	concurrency int // 当前并发数
+	running     int // 正在执行的 worker 数
+	batchSize   int // 当前每批 work 数量
+
+	baselineLatency time.Duration // 基准延迟，每个 work 的最小平均耗时
+
+	// 当前窗口的统计信息
+	windowStart   time.Time
+	workCount     int
+	workDuration  time.Duration
+	throttleCount int
+	overloadCount int
+	errorCount    int
+}
+
+// Concurrency 当前并发数
+func (c *ConcurrencyController) Concurrency() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.concurrency
+}
+
+// BatchSize 当前每批 work 数量
+func (c *ConcurrencyController) BatchSize() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.batchSize
+}
+
+// Acquire 获取执行权限，正在执行的 worker 数达到并发数时阻塞
+func (c *ConcurrencyController) Acquire() {
+	c.mu.Lock()
+	for c.running >= c.concurrency {
+		c.cond.Wait()
+	}
+	c.running++
+	c.mu.Unlock()
+}
+
+// Release 释放执行权限
+func (c *ConcurrencyController) Release() {
+	c.mu.Lock()
+	c.running--
+	c.mu.Unlock()
+	c.cond.Broadcast()
+}
+
+// Feedback 反馈一批 work 的执行情况，达到调整周期时返回调整决策，未调整时返回 nil
+func (c *ConcurrencyController) Feedback(workCount int, duration time.Duration, errs []*data.CodeError) *ConcurrencyChange {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.workCount += workCount
+	c.workDuration += duration
+	for _, err := range errs {
+		switch classifyWorkError(err) {
+		case workErrorClassThrottle:
+			c.throttleCount++
+		case workErrorClassOverload:
+			c.overloadCount++
+		case workErrorClassOther:
+			c.errorCount++
+		}
+	}
+
+	now := c.now()
+	elapsed := now.Sub(c.windowStart)
+	if elapsed < c.cfg.AdjustInterval || c.workCount == 0 {
+		return nil
+	}
+
+	change := c.adjust(elapsed)
+	c.windowStart = now
+	c.workCount = 0
+	c.workDuration = 0
+	c.throttleCount = 0
+	c.overloadCount = 0
+	c.errorCount = 0
+
+	if change.PreConcurrency == change.Concurrency && change.PreBatchSize == change.BatchSize {
+		return nil
+	}
+	if change.Concurrency > change.PreConcurrency {
+		c.cond.Broadcast()
+	}
+	return change
+}
+
+func (c *ConcurrencyController) adjust(elapsed time.Duration) *ConcurrencyChange {
+	latency := c.workDuration / time.Duration(c.workCount)
+	change := &ConcurrencyChange{
+		PreConcurrency: c.concurrency,
+		PreBatchSize:   c.batchSize,
+		Latency:        latency,
+		Throughput:     float64(c.workCount) / elapsed.Seconds(),
+		ThrottleCount:  c.throttleCount,
+		OverloadCount:  c.overloadCount,
+		ErrorCount:     c.errorCount,
+	}
+
+	latencyIncreased := false
+	if c.baselineLatency <= 0 || latency < c.baselineLatency {
+		c.baselineLatency = latency
+	} else {
+		latencyIncreased = float64(c.baselineLatency)/float64(latency) < concurrencyLatencyGradientLimit
+		c.baselineLatency += time.Duration(float64(latency-c.baselineLatency) * concurrencyBaselineDrift)
+	}
+
+	switch {
+	case c.throttleCount > 0:
+		change.Reason = "throttled"
+		c.decrease(concurrencyThrottleDecreaseFactor, true)
+	case float64(c.overloadCount)/float64(c.workCount) > concurrencyOverloadErrorRate:
+		change.Reason = "server overloaded"
+		c.decrease(concurrencyOverloadDecreaseFactor, true)
+	case latencyIncreased:
+		// 延迟升高仅减小并发，批大小变化本身也会影响延迟
+		change.Reason = "latency increased"
+		c.decrease(concurrencyOverloadDecreaseFactor, false)
+	default:
+		change.Reason = "healthy"
+		c.increase()
+	}
+
+	change.Concurrency = c.concurrency
+	change.BatchSize = c.batchSize
+	return change
+}
+
+func (c *ConcurrencyController) decrease(factor float64, decreaseBatchSize bool) {
+	c.concurrency = int(float64(c.concurrency) * factor)
+	if c.concurrency < c.cfg.MinConcurrency {
+		c.concurrency = c.cfg.MinConcurrency
+	}
+
+	if decreaseBatchSize {
+		c.batchSize = int(float64(c.batchSize) * factor)
+		if c.batchSize < c.cfg.MinBatchSize {
+			c.batchSize = c.cfg.MinBatchSize
+		}
+	}
+}
+
+func (c *ConcurrencyController) increase() {
+	if c.concurrency < c.cfg.MaxConcurrency {
+		c.concurrency++
+	}
+
+	step := c.cfg.MaxBatchSize / 10
+	if step < 1 {
+		step = 1
+	}
+	c.batchSize += step
+	if c.batchSize > c.cfg.MaxBatchSize {
+		c.batchSize = c.cfg.MaxBatchSize
+	}
+}
@@ -0,0 +1,192 @@
+package flow
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+func newTestConcurrencyController(cfg ConcurrencyControllerConfig) (*ConcurrencyController, *time.Time) {
+	now := time.Now()
+	c := NewConcurrencyController(cfg)
+	c.now = func() time.Time {
+		return now
+	}
+	c.windowStart = now
+	return c, &now
+}
+
+func TestConcurrencyControllerAIMD(t *testing.T) {
+	c, now := newTestConcurrencyController(ConcurrencyControllerConfig{
+		InitConcurrency: 8,
+		MinConcurrency:  2,
+		MaxConcurrency:  10,
+		MinBatchSize:    10,
+		MaxBatchSize:    100,
+		AdjustInterval:  time.Second,
+	})
+	if c.Concurrency() != 8 || c.BatchSize() != 100 {
+		t.Fatalf("init concurrency:%d batch size:%d error", c.Concurrency(), c.BatchSize())
+	}
+
+	// 未达到调整周期不调整
+	if change := c.Feedback(100, time.Second, nil); change != nil {
+		t.Fatal("should not adjust before adjust interval:", change)
+	}
+
+	// 限流：并发数及批大小减半
+	*now = now.Add(time.Second)
+	change := c.Feedback(100, time.Second, []*data.CodeError{data.NewError(573, "too many requests")})
+	if change == nil || change.Reason != "throttled" || change.Concurrency != 4 || change.BatchSize != 50 {
+		t.Fatal("throttled change error:", change)
+	}
+
+	// 服务端过载
+	*now = now.Add(time.Second)
+	errs := []*data.CodeError{data.NewError(503, "service unavailable"), data.NewError(612, "no such file or directory")}
+	change = c.Feedback(10, 100*time.Millisecond, errs)
+	if change == nil || change.Reason != "server overloaded" || change.Concurrency != 2 || change.BatchSize != 35 {
+		t.Fatal("overloaded change error:", change)
+	}
+	if change.OverloadCount != 1 || change.ErrorCount != 1 {
+		t.Fatal("error class count error:", change)
+	}
+
+	// 不低于最小值
+	*now = now.Add(time.Second)
+	change = c.Feedback(10, 100*time.Millisecond, []*data.CodeError{data.NewError(573, "")})
+	if change == nil || change.Concurrency != 2 || change.BatchSize != 17 {
+		t.Fatal("min concurrency change error:", change)
+	}
+
+	// 正常：并发数加一，批大小增加最大值的 1/10
+	*now = now.Add(time.Second)
+	change = c.Feedback(10, 100*time.Millisecond, []*data.CodeError{data.NewError(612, "no such file or directory")})
+	if change == nil || change.Reason != "healthy" || change.Concurrency != 3 || change.BatchSize != 27 {
+		t.Fatal("healthy change error:", change)
+	}
+
+	// 延迟升高：仅减小并发数
+	*now = now.Add(time.Second)
+	change = c.Feedback(10, 500*time.Millisecond, nil)
+	if change == nil || change.Reason != "latency increased" || change.Concurrency != 2 || change.BatchSize != 27 {
+		t.Fatal("latency increased change error:", change)
+	}
+}
+
+func TestConcurrencyControllerAcquire(t *testing.T) {
+	c, now := newTestConcurrencyController(ConcurrencyControllerConfig{
+		InitConcurrency: 1,
+		MinConcurrency:  1,
+		MaxConcurrency:  2,
+		AdjustInterval:  time.Second,
+	})
+
+	c.Acquire()
+	acquired := make(chan bool)
+	go func() {
+		c.Acquire()
+		acquired <- true
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("acquire should block when running count reaches concurrency")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// 并发数增加后唤醒等待的 worker
+	*now = now.Add(time.Second)
+	if change := c.Feedback(1, time.Millisecond, nil); change == nil || change.Concurrency != 2 {
+		t.Fatal("concurrency should increase:", change)
+	}
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("acquire should not block after concurrency increase")
+	}
+	c.Release()
+	c.Release()
+}
+
+func TestClassifyWorkError(t *testing.T) {
+	for _, c := range []struct {
+		err   *data.CodeError
+		class int
+	}{
+		{err: nil, class: workErrorClassNone},
+		{err: data.NewError(429, "too many requests"), class: workErrorClassThrottle},
+		{err: data.NewError(503, "service unavailable"), class: workErrorClassOverload},
+		{err: data.NewEmptyError().AppendDesc("read body").AppendError(io.ErrUnexpectedEOF), class: workErrorClassOverload},
+		{err: data.ConvertError(fmt.Errorf("get object: %w", io.EOF)), class: workErrorClassOverload},
+		// 描述中包含 eof 但不是 EOF 错误
+		{err: data.NewEmptyError().AppendDesc("file geofence.txt not exist"), class: workErrorClassOther},
+	} {
+		if class := classifyWorkError(c.err); class != c.class {
+			t.Fatalf("error:%v class should be %d, but:%d", c.err, c.class, class)
+		}
+	}
+}
+
+func TestInfoMaxWorkerCount(t *testing.T) {
+	info := Info{WorkerCount: 5, AdaptiveConcurrency: true}
+	_ = info.Check()
+	if info.MaxWorkerCount != 5*defaultMaxConcurrencyMultiple {
+		t.Fatal("max worker count should default to a multiple of worker count, but:", info.MaxWorkerCount)
+	}
+
+	info = Info{WorkerCount: 5}
+	_ = info.Check()
+	if info.MaxWorkerCount != 5 {
+		t.Fatal("max worker count should be worker count without adaptive concurrency, but:", info.MaxWorkerCount)
+	}
+}
+
+func TestFlowAdaptiveConcurrencyWorkers(t *testing.T) {
+	works := make([]Work, 0, 20)
+	for i := 0; i < 20; i++ {
+		works = append(works, keyWork(fmt.Sprintf("key%d", i)))
+	}
+
+	mu := sync.Mutex{}
+	workerCount, running, maxRunning, doneCount := 0, 0, 0, 0
+	New(Info{
+		Force:               true,
+		WorkerCount:         2,
+		AdaptiveConcurrency: true,
+		MaxWorkerCount:      8,
+	}).WorkProviderWithArray(works).
+		WorkerProvider(NewWorkerProvider(func() (Worker, *data.CodeError) {
+			mu.Lock()
+			workerCount++
+			mu.Unlock()
+			return NewSimpleWorker(func(workInfo *WorkInfo) (Result, *data.CodeError) {
+				mu.Lock()
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				mu.Unlock()
+				time.Sleep(10 * time.Millisecond)
+				mu.Lock()
+				running--
+				doneCount++
+				mu.Unlock()
+				return nil, nil
+			}), nil
+		})).
+		DoWorkListMaxCount(1).
+		Build().Start()
+
+	if doneCount != len(works) {
+		t.Fatalf("all works should be done, done:%d", doneCount)
+	}
+	// 并发数未调整时，只创建当前并发数的 worker
+	if workerCount > 2 || maxRunning > 2 {
+		t.Fatalf("workers should be limited by current concurrency, workers:%d max running:%d", workerCount, maxRunning)
+	}
+}
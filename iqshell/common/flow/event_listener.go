@@ -9,6 +9,8 @@ type EventListener struct {
 	OnWorkSkipFunc    func(work *WorkInfo, result Result, err *data.CodeError)
 	OnWorkSuccessFunc func(work *WorkInfo, result Result)
 	OnWorkFailFunc    func(work *WorkInfo, err *data.CodeError)
+
+	OnConcurrencyChangeFunc func(change *ConcurrencyChange) // 自适应并发控制器调整并发数或每批 work 数量时回调
 }
 
 func (e *EventListener) FlowWillStart(flow *Flow) (err *data.CodeError) {
@@ -52,3 +54,10 @@ func (e *EventListener) OnWorkFail(work *WorkInfo, err *data.CodeError) {
 	}
 	e.OnWorkFailFunc(work, err)
 }
+
+func (e *EventListener) OnConcurrencyChange(change *ConcurrencyChange) {
+	if e.OnConcurrencyChangeFunc == nil {
+		return
+	}
+	e.OnConcurrencyChangeFunc(change)
+}
@@ -13,11 +13,14 @@ import (
 )
 
 type Info struct {
-	Force                     bool // 是否强制直接进行 Flow, 不强制需要用户输入验证码验证
-	WorkerCount               int  // worker 数量
-	MinWorkerCount            int  // 最小 work 数量，当遇到限制错误会减小 work 数，最小 1
-	WorkerCountIncreasePeriod int  // WorkerCount 递增的周期，当在 WorkerCountIncreasePeriod 时间内没有遇到限制错误时，会尝试增加 WorkerCount，最小 10s
-	StopWhenWorkError         bool // 当某个 work 遇到执行错误是否结束 batch 任务
+	Force                     bool   // 是否强制直接进行 Flow, 不强制需要用户输入验证码验证
+	WorkerCount               int    // worker 数量
+	MinWorkerCount            int    // 最小 work 数量，当遇到限制错误会减小 work 数，最小 1
+	WorkerCountIncreasePeriod int    // WorkerCount 递增的周期，当在 WorkerCountIncreasePeriod 时间内没有遇到限制错误时，会尝试增加 WorkerCount，最小 10s
+	StopWhenWorkError         bool   // 当某个 work 遇到执行错误是否结束 batch 任务
+	AdaptiveConcurrency       bool   // 是否开启自适应并发控制，开启后根据 work 耗时、吞吐及错误类型动态调整并发数及每批 work 数量
+	MaxWorkerCount            int    // 开启自适应并发控制时最大的 worker 数量，默认为 WorkerCount 的 4 倍
+	RetryFailedFrom           string // 仅重新执行之前失败的 work，值为失败列表导出文件或 job id
 }
 
 func (i *Info) Check() *data.CodeError {
@@ -33,6 +36,13 @@ func (i *Info) Check() *data.CodeError {
 		i.WorkerCountIncreasePeriod = 10
 	}
 
+	if i.AdaptiveConcurrency && i.MaxWorkerCount <= 0 {
+		i.MaxWorkerCount = i.WorkerCount * defaultMaxConcurrencyMultiple
+	}
+	if i.MaxWorkerCount < i.WorkerCount {
+		i.MaxWorkerCount = i.WorkerCount
+	}
+
 	return nil
 }
 
@@ -51,8 +61,9 @@ type Flow struct {
 	Skipper       Skipper          // work 是否跳过相关逻辑 【可选】
 	Redo          Redo             // work 是否需要重新做相关逻辑，有些工作虽然已经做过，但下次处理时可能条件发生变化，需要重新处理 【可选】
 
-	mu                sync.Mutex //
-	workErrorHappened bool       // 执行中是否出现错误 【内部变量】
+	mu                sync.Mutex             //
+	workErrorHappened bool                   // 执行中是否出现错误 【内部变量】
+	concurrency       *ConcurrencyController // 自适应并发控制器，Info.AdaptiveConcurrency 开启时创建 【内部变量】
 }
 
 func (f *Flow) Check() *data.CodeError {
@@ -77,6 +88,17 @@ func (f *Flow) Check() *data.CodeError {
 
 	f.doWorkInfoListCount = f.DoWorkInfoListMaxCount
 
+	if f.Info.AdaptiveConcurrency {
+		f.concurrency = NewConcurrencyController(ConcurrencyControllerConfig{
+			InitConcurrency: f.Info.WorkerCount,
+			MinConcurrency:  f.Info.MinWorkerCount,
+			MaxConcurrency:  f.Info.MaxWorkerCount,
+			InitBatchSize:   f.DoWorkInfoListMaxCount,
+			MinBatchSize:    f.DoWorkInfoListMinCount,
+			MaxBatchSize:    f.DoWorkInfoListMaxCount,
+		})
+	}
+
 	return nil
 }
 
@@ -96,12 +118,13 @@ func (f *Flow) Start() {
 	}
 
 	log.Debug("work flow did start")
-	workChan := make(chan []*WorkInfo, f.Info.WorkerCount)
+	workerCount := f.workerCount()
+	workChan := make(chan []*WorkInfo, workerCount)
 	// 生产者
 	go func() {
 		log.DebugF("work producer start")
 
-		workList := make([]*WorkInfo, 0, f.doWorkInfoListCount)
+		workList := make([]*WorkInfo, 0, f.getDoWorkInfoListCount())
 		for {
 			hasMore, workInfo, err := f.WorkProvider.Provide()
 			if err != nil {
@@ -153,7 +176,7 @@ func (f *Flow) Start() {
 			}
 
 			workList = append(workList, workInfo)
-			if len(workList) >= f.doWorkInfoListCount {
+			if len(workList) >= f.getDoWorkInfoListCount() {
 				workChan <- workList
 				workList = make([]*WorkInfo, 0, f.DoWorkInfoListMaxCount)
 			}
@@ -169,8 +192,8 @@ func (f *Flow) Start() {
 
 	// 消费者
 	wait := &sync.WaitGroup{}
-	wait.Add(f.Info.WorkerCount)
-	for i := 0; i < f.Info.WorkerCount; i++ {
+	wait.Add(workerCount)
+	for i := 0; i < workerCount; i++ {
 		time.Sleep(time.Millisecond * time.Duration(50))
 		go func(index int) {
 			log.DebugF("work consumer %d start", index)
@@ -179,23 +202,47 @@ func (f *Flow) Start() {
 				log.DebugF("work consumer %d   end", index)
 			}()
 
-			worker, err := f.WorkerProvider.Provide()
-			if err != nil {
-				log.ErrorF("Create Worker Error:%v", err)
-				return
-			}
-
-			for workList := range workChan {
+			// 自适应并发时消费者数量为最大并发数，先获取执行权限再取 work，取到 work 后才创建 worker，
+			// 避免超出当前并发数的消费者创建多余的 worker 并持有已取出却未执行的 work
+			var worker Worker
+			for {
+				f.concurrencyAcquire()
 				if workspace.IsCmdInterrupt() {
+					f.concurrencyRelease()
+					break
+				}
+
+				workList, ok := <-workChan
+				if !ok {
+					f.concurrencyRelease()
 					break
 				}
 
+				if worker == nil {
+					var err *data.CodeError
+					if worker, err = f.WorkerProvider.Provide(); err != nil {
+						f.concurrencyRelease()
+						log.ErrorF("Create Worker Error:%v", err)
+						for _, workInfo := range workList {
+							f.handleWorkResult(&WorkRecord{
+								WorkInfo: workInfo,
+								Result:   nil,
+								Err:      err,
+							})
+						}
+						return
+					}
+				}
+
 				workCount := len(workList)
 
 				_ = f.limitAcquire(workCount)
+				startTime := time.Now()
 				// workRecordList 有数据则长度和 workList 长度相同
 				workRecordList, workErr := worker.DoWork(workList)
+				duration := time.Since(startTime)
 				f.limitRelease(workCount)
+				f.concurrencyRelease()
 
 				if len(workRecordList) == 0 && workErr != nil {
 					log.ErrorF("Do Worker Error:%+v", workErr)
@@ -206,6 +253,7 @@ func (f *Flow) Start() {
 							Err:      workErr,
 						})
 					}
+					f.concurrencyFeedback(workCount, duration, []*data.CodeError{workErr})
 					break
 				}
 
@@ -213,12 +261,16 @@ func (f *Flow) Start() {
 
 				hitLimitCount := 0
 				hasTooManyFileError := false
+				workErrors := make([]*data.CodeError, 0)
 				for _, record := range workRecordList {
 					if (record.Result == nil || !record.Result.IsValid()) && record.Err == nil {
 						record.Err = workErr
 					}
 
 					f.handleWorkResult(record)
+					if record.Err != nil {
+						workErrors = append(workErrors, record.Err)
+					}
 					if f.isWorkResultHitLimit(record) {
 						hitLimitCount += 1
 					}
@@ -229,7 +281,11 @@ func (f *Flow) Start() {
 						hasTooManyFileError = true
 					}
 				}
-				f.limitCountDecrease(hitLimitCount)
+				if f.concurrency != nil {
+					f.concurrencyFeedback(workCount, duration, workErrors)
+				} else {
+					f.limitCountDecrease(hitLimitCount)
+				}
 
 				if hasTooManyFileError {
 					time.Sleep(5 * time.Second)
@@ -243,6 +299,13 @@ func (f *Flow) Start() {
 	}
 	wait.Wait()
 
+	if f.Overseer != nil {
+		if err := f.Overseer.Close(); err != nil {
+			data.SetCmdStatusError()
+			log.ErrorF("Flow close overseer error:%v", err)
+		}
+	}
+
 	if err := f.notifyFlowWillEnd(); err != nil {
 		log.ErrorF("Flow end error:%v", err)
 		return
@@ -266,6 +329,7 @@ func (f *Flow) shouldWorkSkip(work *WorkInfo) (skip bool, cause *data.CodeError)
 }
 
 func (f *Flow) notifyWorkSkip(work *WorkInfo, result Result, err *data.CodeError) {
+	workspace.AddJobSkipCount(1)
 	f.EventListener.OnWorkSkip(work, result, err)
 }
 
@@ -273,7 +337,12 @@ func (f *Flow) getWorkRecordIfHasDone(work *WorkInfo) (hasDone bool, record *Wor
 	if f.Overseer == nil {
 		return false, nil
 	}
-	return f.Overseer.GetWorkRecordIfHasDone(work)
+	hasDone, record = f.Overseer.GetWorkRecordIfHasDone(work)
+	// 重试失败的 work 时，之前失败的 work 需要重新执行
+	if hasDone && len(f.Info.RetryFailedFrom) > 0 && record != nil && record.Err != nil {
+		return false, nil
+	}
+	return hasDone, record
 }
 
 func (f *Flow) shouldWorkRedo(work *WorkInfo, workRecord *WorkRecord) (shouldRedo bool, cause *data.CodeError) {
@@ -318,7 +387,8 @@ func (f *Flow) limitCountDecrease(count int) {
 }
 
 func (f *Flow) tryChangeWorkGroupCount(err *data.CodeError) {
-	if err == nil {
+	// 开启自适应并发控制时由控制器调整每批 work 数量
+	if err == nil || f.concurrency != nil {
 		return
 	}
 
@@ -334,6 +404,47 @@ func (f *Flow) tryChangeWorkGroupCount(err *data.CodeError) {
 	f.mu.Unlock()
 }
 
+func (f *Flow) workerCount() int {
+	if f.concurrency != nil {
+		return f.Info.MaxWorkerCount
+	}
+	return f.Info.WorkerCount
+}
+
+func (f *Flow) getDoWorkInfoListCount() int {
+	if f.concurrency != nil {
+		return f.concurrency.BatchSize()
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.doWorkInfoListCount
+}
+
+func (f *Flow) concurrencyAcquire() {
+	if f.concurrency == nil {
+		return
+	}
+	f.concurrency.Acquire()
+}
+
+func (f *Flow) concurrencyRelease() {
+	if f.concurrency == nil {
+		return
+	}
+	f.concurrency.Release()
+}
+
+func (f *Flow) concurrencyFeedback(workCount int, duration time.Duration, errs []*data.CodeError) {
+	if f.concurrency == nil {
+		return
+	}
+	if change := f.concurrency.Feedback(workCount, duration, errs); change != nil {
+		log.InfoF("adaptive concurrency change, %s", change)
+		f.EventListener.OnConcurrencyChange(change)
+	}
+}
+
 func (f *Flow) handleWorkResult(workRecord *WorkRecord) {
 	if f.Overseer != nil {
 		f.Overseer.WorkDone(&WorkRecord{
@@ -351,10 +462,12 @@ func (f *Flow) handleWorkResult(workRecord *WorkRecord) {
 }
 
 func (f *Flow) notifyWorkSuccess(work *WorkInfo, result Result) {
+	workspace.AddJobSuccessCount(1)
 	f.EventListener.OnWorkSuccess(work, result)
 }
 
 func (f *Flow) notifyWorkFail(work *WorkInfo, err *data.CodeError) {
+	workspace.AddJobFailureCount(1)
 	f.EventListener.OnWorkFail(work, err)
 }
 
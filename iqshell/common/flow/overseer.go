@@ -6,6 +6,8 @@ type Overseer interface {
 	WillWork(work *WorkInfo)
 	WorkDone(record *WorkRecord)
 	GetWorkRecordIfHasDone(work *WorkInfo) (hasDone bool, record *WorkRecord)
+	// Close flow 结束或命令被中断时调用，保证记录已落盘
+	Close() *data.CodeError
 }
 
 type WorkRecord struct {
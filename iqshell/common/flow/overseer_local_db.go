@@ -10,19 +10,24 @@ func NewDBRecordOverseer(dbPath string, blankWorkRecordBuilder func() *WorkRecor
 	if r, err := recorder.CreateDBRecorder(dbPath); err != nil {
 		return nil, err
 	} else {
-		return &localDBRecordOverseer{
-			Recorder:               r,
-			BlankWorkRecordBuilder: blankWorkRecordBuilder,
-		}, nil
+		return NewRecorderOverseer(r, blankWorkRecordBuilder), nil
 	}
 }
 
-type localDBRecordOverseer struct {
+// NewRecorderOverseer 使用 r 保存 work 执行记录，work 记录的 key 为 WorkId
+func NewRecorderOverseer(r recorder.Recorder, blankWorkRecordBuilder func() *WorkRecord) Overseer {
+	return &recordOverseer{
+		Recorder:               r,
+		BlankWorkRecordBuilder: blankWorkRecordBuilder,
+	}
+}
+
+type recordOverseer struct {
 	Recorder               recorder.Recorder
 	BlankWorkRecordBuilder func() *WorkRecord
 }
 
-func (l *localDBRecordOverseer) WillWork(work *WorkInfo) {
+func (l *recordOverseer) WillWork(work *WorkInfo) {
 	if l == nil || l.Recorder == nil {
 		return
 	}
@@ -35,7 +40,7 @@ func (l *localDBRecordOverseer) WillWork(work *WorkInfo) {
 	l.setWorkStatus(work, status)
 }
 
-func (l *localDBRecordOverseer) WorkDone(record *WorkRecord) {
+func (l *recordOverseer) WorkDone(record *WorkRecord) {
 	if l == nil || l.Recorder == nil {
 		return
 	}
@@ -50,7 +55,7 @@ func (l *localDBRecordOverseer) WorkDone(record *WorkRecord) {
 	l.setWorkStatus(record.WorkInfo, status)
 }
 
-func (l *localDBRecordOverseer) GetWorkRecordIfHasDone(work *WorkInfo) (hasDone bool, record *WorkRecord) {
+func (l *recordOverseer) GetWorkRecordIfHasDone(work *WorkInfo) (hasDone bool, record *WorkRecord) {
 	if l == nil || l.Recorder == nil {
 		return false, nil
 	}
@@ -62,7 +67,14 @@ func (l *localDBRecordOverseer) GetWorkRecordIfHasDone(work *WorkInfo) (hasDone
 	}
 }
 
-func (l *localDBRecordOverseer) getWorkStatus(work *WorkInfo) *workStatus {
+func (l *recordOverseer) Close() *data.CodeError {
+	if l == nil || l.Recorder == nil {
+		return nil
+	}
+	return recorder.Close(l.Recorder)
+}
+
+func (l *recordOverseer) getWorkStatus(work *WorkInfo) *workStatus {
 	if l == nil || l.Recorder == nil || work == nil || work.Work == nil || l.BlankWorkRecordBuilder == nil {
 		return nil
 	}
@@ -94,7 +106,7 @@ func (l *localDBRecordOverseer) getWorkStatus(work *WorkInfo) *workStatus {
 	}
 }
 
-func (l *localDBRecordOverseer) setWorkStatus(work *WorkInfo, status *workStatus) {
+func (l *recordOverseer) setWorkStatus(work *WorkInfo, status *workStatus) {
 	if l == nil || l.Recorder == nil || work == nil || work.Work == nil || status == nil {
 		return
 	}
@@ -134,3 +146,46 @@ func (s *workStatus) toData() (string, *data.CodeError) {
 	d, err := json.Marshal(s)
 	return string(d), data.ConvertError(err)
 }
+
+// RecordedWork Overseer 记录中 work 的执行信息
+type RecordedWork struct {
+	WorkId string          `json:"work_id"`
+	Data   string          `json:"data"`
+	Status string          `json:"status"`
+	Err    *data.CodeError `json:"err,omitempty"`
+}
+
+const (
+	RecordedWorkStatusDoing   = "doing"
+	RecordedWorkStatusSuccess = "success"
+	RecordedWorkStatusError   = "error"
+)
+
+// RangeRecordedWorks 遍历 Overseer 记录中的所有 work，f 返回 false 时停止遍历
+func RangeRecordedWorks(r recorder.Recorder, f func(work *RecordedWork) bool) *data.CodeError {
+	return r.Range(func(key, value string) bool {
+		status := &struct {
+			Data   string          `json:"data"`
+			Err    *data.CodeError `json:"err"`
+			Status int             `json:"status"`
+		}{}
+		if e := json.Unmarshal([]byte(value), status); e != nil {
+			return true
+		}
+
+		work := &RecordedWork{
+			WorkId: key,
+			Data:   status.Data,
+		}
+		switch status.Status {
+		case workStatusSuccess:
+			work.Status = RecordedWorkStatusSuccess
+		case workStatusError:
+			work.Status = RecordedWorkStatusError
+			work.Err = status.Err
+		default:
+			work.Status = RecordedWorkStatusDoing
+		}
+		return f(work)
+	})
+}
@@ -0,0 +1,26 @@
+package flow
+
+import (
+	"os"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/recorder"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+)
+
+// NewSharedDirRecordOverseer 记录保存在 dir 目录下的追加日志中，dir 可位于多台机器共享的文件系统上，
+// 任务在其他机器上恢复执行时会跳过已完成的 work；命令被中断时日志文件会落盘并关闭
+func NewSharedDirRecordOverseer(dir string, blankWorkRecordBuilder func() *WorkRecord) (Overseer, *data.CodeError) {
+	if r, err := recorder.CreateAppendLogRecorder(dir); err != nil {
+		return nil, err
+	} else {
+		overseer := NewRecorderOverseer(r, blankWorkRecordBuilder)
+		workspace.AddCancelObserver(func(s os.Signal) {
+			if cErr := overseer.Close(); cErr != nil {
+				log.ErrorF("close shared dir recorder:%s error:%v", dir, cErr)
+			}
+		})
+		return overseer, nil
+	}
+}
@@ -0,0 +1,98 @@
+package flow
+
+import (
+	"bufio"
+	"os"
+	"strings"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/job"
+	"github.com/qiniu/qshell/v2/iqshell/common/recorder"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+)
+
+// NewRetryFailedWorkProvider 仅提供之前执行失败的 work，from 为失败列表导出文件（--failure-list）或 job id
+func NewRetryFailedWorkProvider(from string, creator WorkCreator) (WorkProvider, *data.CodeError) {
+	lines, err := LoadRetryFailedLines(from)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := NewReaderWorkProvider(strings.NewReader(strings.Join(lines, "\n")), creator)
+	if err != nil {
+		return nil, err
+	}
+	return &fileWorkProvider{
+		workCount:    int64(len(lines)),
+		workProvider: provider,
+	}, nil
+}
+
+// LoadRetryFailedLines 加载之前执行失败的 work 数据，即 work 的输入行
+// from 为已存在的文件时按失败列表导出文件解析，否则按 job id 从当前用户 job 的执行记录中查找状态为失败的 work
+func LoadRetryFailedLines(from string) ([]string, *data.CodeError) {
+	if len(from) == 0 {
+		return nil, data.NewEmptyError().AppendDesc("retry failed from can't be empty")
+	}
+
+	if stat, err := os.Stat(from); err == nil && !stat.IsDir() {
+		return loadRetryFailedLinesFromFile(from)
+	}
+	return loadRetryFailedLinesFromJob(from)
+}
+
+func loadRetryFailedLinesFromFile(filePath string) ([]string, *data.CodeError) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, data.NewEmptyError().AppendDescF("open failure list file:%s error:%v", filePath, err)
+	}
+	defer f.Close()
+
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if index := strings.Index(line, ErrorSeparate); index >= 0 {
+			line = line[:index]
+		}
+		if len(line) > 0 {
+			lines = append(lines, line)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, data.NewEmptyError().AppendDescF("read failure list file:%s error:%v", filePath, err)
+	}
+	return lines, nil
+}
+
+// createJobRecorder 打开 job 的执行记录，测试时可替换
+var createJobRecorder = recorder.CreateRecorder
+
+func loadRetryFailedLinesFromJob(jobId string) ([]string, *data.CodeError) {
+	j, err := job.FindJob(job.ListJobs([]string{workspace.GetUserDir()}), jobId)
+	if err != nil {
+		return nil, data.NewEmptyError().AppendDescF("%s is neither a failure list file nor a job id:%v", jobId, err)
+	}
+
+	lines := make([]string, 0)
+	for path, backend := range j.RecordPaths() {
+		r, cErr := createJobRecorder(backend, path)
+		if cErr != nil {
+			return nil, data.NewEmptyError().AppendDescF("open job record:%s error:%v", path, cErr)
+		}
+		rErr := RangeRecordedWorks(r, func(work *RecordedWork) bool {
+			if work.Status == RecordedWorkStatusError && len(work.Data) > 0 {
+				lines = append(lines, work.Data)
+			}
+			return true
+		})
+		// LevelDB 的记录会持有文件锁，重试时可能会再次打开同一记录
+		if cErr = recorder.Close(r); cErr != nil && rErr == nil {
+			rErr = cErr
+		}
+		if rErr != nil {
+			return nil, data.NewEmptyError().AppendDescF("read job record:%s error:%v", path, rErr)
+		}
+	}
+	return lines, nil
+}
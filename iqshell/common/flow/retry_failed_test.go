@@ -0,0 +1,128 @@
+package flow
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/account"
+	"github.com/qiniu/qshell/v2/iqshell/common/config"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/recorder"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+)
+
+type keyWork string
+
+func (w keyWork) WorkId() string {
+	return string(w)
+}
+
+func TestRetryFailedWorkProviderFromFile(t *testing.T) {
+	failureFile := filepath.Join(t.TempDir(), "failure.txt")
+	content := "bucket\tkey1" + ErrorSeparate + "612 no such file or directory\n" +
+		"\n" +
+		"bucket\tkey2" + ErrorSeparate + "573 too many requests\n"
+	if err := os.WriteFile(failureFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lines, err := LoadRetryFailedLines(failureFile)
+	if err != nil {
+		t.Fatal("load failed lines error:", err)
+	}
+	if len(lines) != 2 || lines[0] != "bucket\tkey1" || lines[1] != "bucket\tkey2" {
+		t.Fatalf("failed lines error:%q", lines)
+	}
+
+	provider, err := NewRetryFailedWorkProvider(failureFile, NewItemsWorkCreator("\t", 2, func(items []string) (Work, *data.CodeError) {
+		return keyWork(items[1]), nil
+	}))
+	if err != nil {
+		t.Fatal("create provider error:", err)
+	}
+	if provider.WorkTotalCount() != 2 {
+		t.Fatal("work total count should be 2, but:", provider.WorkTotalCount())
+	}
+
+	keys := make([]string, 0)
+	for {
+		hasMore, work, pErr := provider.Provide()
+		if pErr != nil {
+			t.Fatal("provide error:", pErr)
+		}
+		if work != nil && work.Work != nil {
+			keys = append(keys, string(work.Work.(keyWork)))
+		}
+		if !hasMore {
+			break
+		}
+	}
+	if len(keys) != 2 || keys[0] != "key1" || keys[1] != "key2" {
+		t.Fatalf("retry works error:%q", keys)
+	}
+}
+
+func TestLoadRetryFailedLinesFromJob(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(account.EnvAccessKey, "")
+	t.Setenv(account.EnvSecretKey, "")
+	if err := workspace.Load(workspace.LoadInfo{
+		WorkspacePath: filepath.Join(home, ".qshell"),
+		CmdConfig:     &config.Config{CmdId: "test"},
+	}); err != nil {
+		t.Fatal("load workspace error:", err)
+	}
+
+	jobId := utils.Md5Hex("job")
+	recordPath := filepath.Join(workspace.GetUserDir(), "batchdelete", jobId, ".recorder")
+	r, err := recorder.CreateDBRecorder(recordPath)
+	if err != nil {
+		t.Fatal("create recorder error:", err)
+	}
+	failed, _ := (&workStatus{WorkRecord: &WorkRecord{WorkInfo: &WorkInfo{Data: "bucket\tkey1"}}, Status: workStatusError}).toData()
+	success, _ := (&workStatus{WorkRecord: &WorkRecord{WorkInfo: &WorkInfo{Data: "bucket\tkey2"}}, Status: workStatusSuccess}).toData()
+	_ = r.Put("key1", failed)
+	_ = r.Put("key2", success)
+	if err = recorder.Close(r); err != nil {
+		t.Fatal("close recorder error:", err)
+	}
+
+	// 读取后需关闭记录，LevelDB 会持有文件锁，重试时会再次打开
+	opened := make([]*closeCheckRecorder, 0)
+	createJobRecorder = func(backend, path string) (recorder.Recorder, *data.CodeError) {
+		r, err := recorder.CreateRecorder(backend, path)
+		if err != nil {
+			return nil, err
+		}
+		c := &closeCheckRecorder{Recorder: r}
+		opened = append(opened, c)
+		return c, nil
+	}
+	defer func() {
+		createJobRecorder = recorder.CreateRecorder
+	}()
+
+	lines, err := LoadRetryFailedLines(jobId)
+	if err != nil {
+		t.Fatal("load failed lines error:", err)
+	}
+	if len(lines) != 1 || lines[0] != "bucket\tkey1" {
+		t.Fatalf("failed lines error:%q", lines)
+	}
+	if len(opened) != 1 || !opened[0].closed {
+		t.Fatal("job record should be closed after load")
+	}
+}
+
+type closeCheckRecorder struct {
+	recorder.Recorder
+	closed bool
+}
+
+func (r *closeCheckRecorder) Close() *data.CodeError {
+	r.closed = true
+	return recorder.Close(r.Recorder)
+}
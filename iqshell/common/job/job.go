@@ -0,0 +1,159 @@
+package job
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/config"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+)
+
+// job 目录名为命令参数的 md5
+var jobIdRegexp = regexp.MustCompile("^[0-9a-f]{32}$")
+
+// IsJobId 是否为合法的 JobId，仅 JobId 合法的 job 可以被列举
+func IsJobId(id string) bool {
+	return jobIdRegexp.MatchString(id)
+}
+
+type Job struct {
+	Id       string             `json:"id"`
+	UserName string             `json:"user_name"`
+	Dir      string             `json:"dir"`
+	ModTime  time.Time          `json:"mod_time"`
+	Info     *workspace.JobInfo `json:"info,omitempty"` // 旧版本 qshell 创建的 job 没有 job 信息
+}
+
+func (j *Job) CmdId() string {
+	if j.Info != nil {
+		return j.Info.CmdId
+	}
+	return filepath.Base(filepath.Dir(j.Dir))
+}
+
+func (j *Job) Status() string {
+	if j.Info != nil {
+		return j.Info.Status
+	}
+	return "unknown"
+}
+
+func (j *Job) StartTime() time.Time {
+	if j.Info != nil && j.Info.StartTime > 0 {
+		return time.Unix(j.Info.StartTime, 0)
+	}
+	return j.ModTime
+}
+
+// LastActiveTime job 最后活跃的时间
+func (j *Job) LastActiveTime() time.Time {
+	t := j.ModTime
+	if j.Info != nil {
+		if start := time.Unix(j.Info.StartTime, 0); start.After(t) {
+			t = start
+		}
+		if end := time.Unix(j.Info.EndTime, 0); end.After(t) {
+			t = end
+		}
+	}
+	return t
+}
+
+// RecordPaths job 的 work 执行记录路径及其存储后端；存储后端为 shared-dir 时仅包含共享目录中属于此 job 的记录
+func (j *Job) RecordPaths() map[string]string {
+	paths := make(map[string]string)
+	if j.Info != nil && j.Info.RecordBackend == config.RecordBackendSharedDir && len(j.Info.RecordDir) > 0 {
+		for _, dir := range subDirs(workspace.GetSharedRecordPath(j.Info.RecordDir, j.Dir)) {
+			paths[dir] = config.RecordBackendSharedDir
+		}
+		return paths
+	}
+
+	// LevelDB 目录中包含 CURRENT 文件
+	for _, dir := range subDirs(j.Dir) {
+		if _, err := os.Stat(filepath.Join(dir, "CURRENT")); err == nil {
+			paths[dir] = config.RecordBackendLevelDB
+		}
+	}
+	return paths
+}
+
+func subDirs(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	dirs := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			dirs = append(dirs, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return dirs
+}
+
+// ListJobs 列举用户目录下的所有 job，job 目录为 <用户目录>/<命令>/<JobId>，按开始时间排序
+func ListJobs(userDirs []string) []*Job {
+	jobs := make([]*Job, 0)
+	for _, userDir := range userDirs {
+		for _, cmdDir := range subDirs(userDir) {
+			for _, jobDir := range subDirs(cmdDir) {
+				if j := loadJob(userDir, jobDir); j != nil {
+					jobs = append(jobs, j)
+				}
+			}
+		}
+	}
+	sort.Slice(jobs, func(i, k int) bool {
+		return jobs[i].StartTime().Before(jobs[k].StartTime())
+	})
+	return jobs
+}
+
+func loadJob(userDir, jobDir string) *Job {
+	id := filepath.Base(jobDir)
+	if !jobIdRegexp.MatchString(id) {
+		return nil
+	}
+	stat, err := os.Stat(jobDir)
+	if err != nil {
+		return nil
+	}
+
+	j := &Job{
+		Id:       id,
+		UserName: filepath.Base(userDir),
+		Dir:      jobDir,
+		ModTime:  stat.ModTime(),
+	}
+	if info, lErr := workspace.LoadJobInfo(jobDir); lErr == nil {
+		j.Info = info
+	}
+	return j
+}
+
+// FindJob 根据 JobId 查找 job，JobId 可以为前缀，但必须能唯一确定一个 job
+func FindJob(jobs []*Job, id string) (*Job, *data.CodeError) {
+	var found *Job
+	for _, j := range jobs {
+		if j.Id == id {
+			return j, nil
+		}
+		if !strings.HasPrefix(j.Id, id) {
+			continue
+		}
+		if found != nil {
+			return nil, data.NewEmptyError().AppendDescF("job id:%s is ambiguous, it matches %s and %s", id, found.Id, j.Id)
+		}
+		found = j
+	}
+	if found == nil {
+		return nil, data.NewEmptyError().AppendDescF("job:%s not found", id)
+	}
+	return found, nil
+}
@@ -0,0 +1,81 @@
+package job
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/config"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+)
+
+func TestListAndFindJobs(t *testing.T) {
+	userDir := filepath.Join(t.TempDir(), "user")
+	for _, dir := range []string{
+		filepath.Join(userDir, "batchdelete", "0123456789abcdef0123456789abcdef"),
+		filepath.Join(userDir, "batchdelete", "0123aaaaaaaaaaaaaaaaaaaaaaaaaaaa"),
+		filepath.Join(userDir, "batchcopy", "fedcba9876543210fedcba9876543210"),
+		filepath.Join(userDir, "batchcopy", "not-a-job"),
+	} {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	info := `{"cmd_id":"batchcopy","args":["batchcopy","a","b"],"status":"finished","start_time":1}`
+	if err := os.WriteFile(filepath.Join(userDir, "batchcopy", "fedcba9876543210fedcba9876543210", ".job.json"), []byte(info), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	jobs := ListJobs([]string{userDir})
+	if len(jobs) != 3 {
+		t.Fatal("should list 3 jobs, but:", len(jobs))
+	}
+
+	j, err := FindJob(jobs, "fedcba")
+	if err != nil {
+		t.Fatal("find job error:", err)
+	}
+	if j.CmdId() != "batchcopy" || j.Status() != "finished" || len(j.Info.Args) != 3 {
+		t.Fatalf("job info error:%+v", j.Info)
+	}
+
+	j, err = FindJob(jobs, "0123456789abcdef0123456789abcdef")
+	if err != nil || j.CmdId() != "batchdelete" || j.Info != nil {
+		t.Fatal("find legacy job error:", err)
+	}
+
+	if _, err = FindJob(jobs, "0123"); err == nil {
+		t.Fatal("ambiguous job id should error")
+	}
+	if _, err = FindJob(jobs, "ffff"); err == nil {
+		t.Fatal("not exist job id should error")
+	}
+}
+
+func TestRecordPaths(t *testing.T) {
+	userDir := filepath.Join(t.TempDir(), "user")
+	recordDir := t.TempDir()
+	jobDirs := []string{
+		filepath.Join(userDir, "batchdelete", "0123456789abcdef0123456789abcdef"),
+		filepath.Join(userDir, "batchdelete", "fedcba9876543210fedcba9876543210"),
+	}
+	for _, jobDir := range jobDirs {
+		if err := os.MkdirAll(workspace.GetSharedRecordPath(recordDir, filepath.Join(jobDir, ".recorder")), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	j := &Job{
+		Id:  "0123456789abcdef0123456789abcdef",
+		Dir: jobDirs[0],
+		Info: &workspace.JobInfo{
+			RecordBackend: config.RecordBackendSharedDir,
+			RecordDir:     recordDir,
+		},
+	}
+	paths := j.RecordPaths()
+	expected := workspace.GetSharedRecordPath(recordDir, filepath.Join(jobDirs[0], ".recorder"))
+	if len(paths) != 1 || paths[expected] != config.RecordBackendSharedDir {
+		t.Fatalf("record paths should only contain the record of the job:%s, but:%v", expected, paths)
+	}
+}
@@ -0,0 +1,274 @@
+package operations
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/common/job"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
+	"github.com/qiniu/qshell/v2/iqshell/common/recorder"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+)
+
+const timeFormat = "2006-01-02 15:04:05"
+
+// Job 【job】无子命令时仅加载，--doc 时展示文档
+func Job(cfg *iqshell.Config) {
+	iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{})
+}
+
+type ListInfo struct {
+	AllUsers bool // 列举所有用户的 job
+}
+
+func (info *ListInfo) Check() *data.CodeError {
+	return nil
+}
+
+// List 列举 job
+func List(cfg *iqshell.Config, info ListInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	jobs := job.ListJobs(userDirs(info.AllUsers))
+	if len(jobs) == 0 {
+		log.Warning("No jobs found")
+		return
+	}
+
+	log.AlertF("%-32s\t%-15s\t%-15s\t%-11s\t%-19s\t%10s\t%10s\t%10s\t%s",
+		"Id", "User", "Cmd", "Status", "StartTime", "Success", "Failure", "Skip", "Args")
+	for _, j := range jobs {
+		output.Result(j)
+		var success, failure, skip int64
+		args := ""
+		if j.Info != nil {
+			success, failure, skip = j.Info.SuccessCount, j.Info.FailureCount, j.Info.SkipCount
+			args = strings.Join(j.Info.Args, " ")
+		}
+		log.AlertF("%-32s\t%-15s\t%-15s\t%-11s\t%-19s\t%10d\t%10d\t%10d\t%s",
+			j.Id, j.UserName, j.CmdId(), j.Status(), j.StartTime().Format(timeFormat), success, failure, skip, args)
+	}
+}
+
+type ShowInfo struct {
+	JobId    string
+	AllUsers bool
+}
+
+func (info *ShowInfo) Check() *data.CodeError {
+	if len(info.JobId) == 0 {
+		return alert.CannotEmptyError("JobId", "")
+	}
+	return nil
+}
+
+// Show 查看 job 信息及执行失败的 work
+func Show(cfg *iqshell.Config, info ShowInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	j, err := job.FindJob(job.ListJobs(userDirs(info.AllUsers)), info.JobId)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("Job show error:%v", err)
+		return
+	}
+
+	log.AlertF("%-15s:%s", "Id", j.Id)
+	log.AlertF("%-15s:%s", "User", j.UserName)
+	log.AlertF("%-15s:%s", "Cmd", j.CmdId())
+	log.AlertF("%-15s:%s", "Dir", j.Dir)
+	log.AlertF("%-15s:%s", "Status", j.Status())
+	log.AlertF("%-15s:%s", "StartTime", j.StartTime().Format(timeFormat))
+	if j.Info != nil {
+		if j.Info.EndTime > 0 {
+			log.AlertF("%-15s:%s", "EndTime", time.Unix(j.Info.EndTime, 0).Format(timeFormat))
+		}
+		log.AlertF("%-15s:%s", "WorkDir", j.Info.WorkDir)
+		log.AlertF("%-15s:%s", "Args", strings.Join(j.Info.Args, " "))
+		log.AlertF("%-15s:%d", "Success", j.Info.SuccessCount)
+		log.AlertF("%-15s:%d", "Failure", j.Info.FailureCount)
+		log.AlertF("%-15s:%d", "Skip", j.Info.SkipCount)
+	}
+	output.Result(j)
+
+	failedCount := 0
+	for path, backend := range j.RecordPaths() {
+		r, cErr := recorder.CreateRecorder(backend, path)
+		if cErr != nil {
+			data.SetCmdStatusError()
+			log.ErrorF("Job show, open record:%s error:%v", path, cErr)
+			continue
+		}
+		if rErr := flow.RangeRecordedWorks(r, func(work *flow.RecordedWork) bool {
+			if work.Status != flow.RecordedWorkStatusError {
+				return true
+			}
+			if failedCount == 0 {
+				log.Alert("Failed works:")
+			}
+			failedCount++
+			output.Result(work)
+			log.AlertF("%s%s%v", work.Data, flow.ErrorSeparate, work.Err)
+			return true
+		}); rErr != nil {
+			data.SetCmdStatusError()
+			log.ErrorF("Job show, read record:%s error:%v", path, rErr)
+		}
+	}
+	if failedCount == 0 {
+		log.Alert("No failed works found in job records")
+	}
+}
+
+type ResumeInfo struct {
+	JobId    string
+	AllUsers bool
+}
+
+func (info *ResumeInfo) Check() *data.CodeError {
+	if len(info.JobId) == 0 {
+		return alert.CannotEmptyError("JobId", "")
+	}
+	return nil
+}
+
+// Resume 使用 job 原始的参数在原始的工作目录下重新执行 job，已执行的 work 是否跳过由命令本身的记录配置决定
+func Resume(cfg *iqshell.Config, info ResumeInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	j, err := job.FindJob(job.ListJobs(userDirs(info.AllUsers)), info.JobId)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("Job resume error:%v", err)
+		return
+	}
+	if j.Info == nil || len(j.Info.Args) == 0 {
+		data.SetCmdStatusError()
+		log.ErrorF("Job resume error:job:%s has no job info, it may be created by old version qshell", j.Id)
+		return
+	}
+	if j.UserName != workspace.GetUserName() {
+		log.WarningF("job:%s belongs to user:%s, but it will be resumed with current user:%s",
+			j.Id, j.UserName, workspace.GetUserName())
+	}
+
+	executable, eErr := os.Executable()
+	if eErr != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("Job resume, get executable error:%v", eErr)
+		return
+	}
+
+	log.InfoF("Resume job:%s in %s: qshell %s", j.Id, j.Info.WorkDir, strings.Join(j.Info.Args, " "))
+	c := exec.Command(executable, j.Info.Args...)
+	c.Dir = j.Info.WorkDir
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if rErr := c.Run(); rErr != nil {
+		var exitErr *exec.ExitError
+		if errors.As(rErr, &exitErr) {
+			data.SetCmdStatus(exitErr.ExitCode())
+		} else {
+			data.SetCmdStatusError()
+			log.ErrorF("Job resume error:%v", rErr)
+		}
+	}
+}
+
+type RemoveInfo struct {
+	JobIds     []string
+	BeforeDays int  // 删除最后活跃时间在 BeforeDays 天之前的 job
+	AllUsers   bool // 是否包含所有用户的 job
+	Force      bool
+}
+
+func (info *RemoveInfo) Check() *data.CodeError {
+	if len(info.JobIds) == 0 && info.BeforeDays <= 0 {
+		return alert.Error("JobId or --before should be specified", "")
+	}
+	if len(info.JobIds) > 0 && info.BeforeDays > 0 {
+		return alert.Error("JobId and --before can't be specified at the same time", "")
+	}
+	return nil
+}
+
+// Remove 删除 job 目录及其中的执行记录、缓存文件等
+func Remove(cfg *iqshell.Config, info RemoveInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	jobs := job.ListJobs(userDirs(info.AllUsers))
+	removeList := make([]*job.Job, 0)
+	if len(info.JobIds) > 0 {
+		for _, id := range info.JobIds {
+			j, err := job.FindJob(jobs, id)
+			if err != nil {
+				data.SetCmdStatusError()
+				log.ErrorF("Job remove error:%v", err)
+				return
+			}
+			removeList = append(removeList, j)
+		}
+	} else {
+		deadline := time.Now().Add(-time.Duration(info.BeforeDays) * 24 * time.Hour)
+		for _, j := range jobs {
+			if j.LastActiveTime().Before(deadline) {
+				removeList = append(removeList, j)
+			}
+		}
+	}
+
+	if len(removeList) == 0 {
+		log.Alert("No jobs need to remove")
+		return
+	}
+
+	for _, j := range removeList {
+		log.AlertF("%-32s\t%-15s\t%-15s\t%s", j.Id, j.UserName, j.CmdId(), j.Dir)
+	}
+	log.AlertF("%d jobs will be removed", len(removeList))
+	if !info.Force && !flow.UserCodeVerification() {
+		return
+	}
+
+	for _, j := range removeList {
+		if err := os.RemoveAll(j.Dir); err != nil {
+			data.SetCmdStatusError()
+			log.ErrorF("Remove job:%s error:%v", j.Id, err)
+			continue
+		}
+		output.Result(j)
+		log.InfoF("Remove job:%s success", j.Id)
+	}
+}
+
+func userDirs(allUsers bool) []string {
+	if allUsers {
+		return workspace.GetAllUserDirs()
+	}
+	return []string{workspace.GetUserDir()}
+}
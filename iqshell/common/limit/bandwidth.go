@@ -0,0 +1,210 @@
+package limit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+// BandwidthLimit 带宽限制，单位：字节/秒，所有上传、下载共享
+type BandwidthLimit interface {
+	// Acquire 获取 count 字节的传输额度，额度不足时阻塞
+	Acquire(count int) *data.CodeError
+}
+
+const (
+	bandwidthBurstDuration = 200 * time.Millisecond // 令牌桶容量为限速值在此时间内可传输的字节数
+	minuteOfDay            = 24 * 60
+)
+
+// bandwidthRule 某个时间段的限速规则
+type bandwidthRule struct {
+	rate        int64 // 字节/秒，<= 0 表示不限速
+	startMinute int   // 时间段开始，当天的分钟数
+	endMinute   int   // 时间段结束，当天的分钟数，小于 startMinute 表示跨越零点
+	allDay      bool  // 是否全天生效，未指定时间段或开始结束时间相同时全天生效
+}
+
+func (r *bandwidthRule) match(t time.Time) bool {
+	if r.allDay {
+		return true
+	}
+	minute := t.Hour()*60 + t.Minute()
+	if r.startMinute <= r.endMinute {
+		return minute >= r.startMinute && minute < r.endMinute
+	}
+	return minute >= r.startMinute || minute < r.endMinute
+}
+
+// NewBandwidthLimit 根据限速配置创建带宽限制
+// 配置格式：<Rate>[@<HH:MM>-<HH:MM>][,<Rate>[@<HH:MM>-<HH:MM>]...]，例：10MB/s@09:00-18:00,50MB/s
+// 按顺序使用第一个匹配当前时间的规则，未指定时间段的规则全天生效，没有匹配的规则时不限速；
+// Rate 单位支持 B、KB、MB、GB（1024 进制），/s 可省略，0 表示不限速
+func NewBandwidthLimit(value string) (BandwidthLimit, *data.CodeError) {
+	rules, err := parseBandwidthRules(value)
+	if err != nil {
+		return nil, err
+	}
+	return &bandwidthLimit{
+		rules: rules,
+		now:   time.Now,
+	}, nil
+}
+
+func parseBandwidthRules(value string) ([]*bandwidthRule, *data.CodeError) {
+	rules := make([]*bandwidthRule, 0)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			continue
+		}
+
+		rateValue, timeRange := item, ""
+		if index := strings.Index(item, "@"); index >= 0 {
+			rateValue, timeRange = item[:index], item[index+1:]
+		}
+
+		rate, err := ParseBandwidthRate(rateValue)
+		if err != nil {
+			return nil, err
+		}
+		rule := &bandwidthRule{rate: rate, allDay: true}
+		if len(timeRange) > 0 {
+			times := strings.Split(timeRange, "-")
+			if len(times) != 2 {
+				return nil, data.NewEmptyError().AppendDescF("bandwidth limit: invalid time range:%s, should be HH:MM-HH:MM", timeRange)
+			}
+			if rule.startMinute, err = parseMinuteOfDay(times[0]); err != nil {
+				return nil, err
+			}
+			if rule.endMinute, err = parseMinuteOfDay(times[1]); err != nil {
+				return nil, err
+			}
+			rule.allDay = rule.startMinute == rule.endMinute
+		}
+		rules = append(rules, rule)
+	}
+
+	if len(rules) == 0 {
+		return nil, data.NewEmptyError().AppendDescF("bandwidth limit: no rule found in:%s", value)
+	}
+	return rules, nil
+}
+
+// ParseBandwidthRate 解析速率，例：512KB/s、50MB/s、1048576，返回字节/秒
+func ParseBandwidthRate(value string) (int64, *data.CodeError) {
+	v := strings.ToUpper(strings.TrimSpace(value))
+	v = strings.TrimSuffix(v, "/S")
+
+	unit := int64(1)
+	for _, u := range []struct {
+		suffix string
+		size   int64
+	}{
+		{"GB", 1024 * 1024 * 1024},
+		{"MB", 1024 * 1024},
+		{"KB", 1024},
+		{"G", 1024 * 1024 * 1024},
+		{"M", 1024 * 1024},
+		{"K", 1024},
+		{"B", 1},
+	} {
+		if strings.HasSuffix(v, u.suffix) {
+			v = strings.TrimSuffix(v, u.suffix)
+			unit = u.size
+			break
+		}
+	}
+
+	number, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil || number < 0 {
+		return 0, data.NewEmptyError().AppendDescF("bandwidth limit: invalid rate:%s", value)
+	}
+	return int64(number * float64(unit)), nil
+}
+
+func parseMinuteOfDay(value string) (int, *data.CodeError) {
+	var hour, minute int
+	if _, err := fmt.Sscanf(strings.TrimSpace(value), "%d:%d", &hour, &minute); err != nil ||
+		hour < 0 || hour > 24 || minute < 0 || minute > 59 || hour*60+minute > minuteOfDay {
+		return 0, data.NewEmptyError().AppendDescF("bandwidth limit: invalid time:%s, should be HH:MM", value)
+	}
+	return (hour*60 + minute) % minuteOfDay, nil
+}
+
+type bandwidthLimit struct {
+	mu     sync.Mutex
+	rules  []*bandwidthRule
+	now    func() time.Time
+	rate   int64     // 当前限速值
+	tokens float64   // 令牌桶中剩余的字节数
+	last   time.Time // 上次填充令牌的时间
+}
+
+func (l *bandwidthLimit) Acquire(count int) *data.CodeError {
+	for count > 0 {
+		acquired, wait := l.tryAcquire(count)
+		count -= acquired
+		if wait > 0 {
+			time.Sleep(wait)
+		}
+	}
+	return nil
+}
+
+// tryAcquire 获取部分或全部额度，额度不足时返回需要等待的时间
+func (l *bandwidthLimit) tryAcquire(count int) (acquired int, wait time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	rate := l.currentRate(now)
+	if rate <= 0 {
+		l.rate = rate
+		return count, 0
+	}
+
+	capacity := float64(rate) * bandwidthBurstDuration.Seconds()
+	if capacity < 1 {
+		capacity = 1
+	}
+	if rate != l.rate || l.last.IsZero() {
+		// 限速值变化时重新填满令牌桶
+		l.rate = rate
+		l.tokens = capacity
+	} else if elapsed := now.Sub(l.last).Seconds(); elapsed > 0 {
+		l.tokens += elapsed * float64(rate)
+		if l.tokens > capacity {
+			l.tokens = capacity
+		}
+	}
+	l.last = now
+
+	if l.tokens >= 1 {
+		acquired = count
+		if float64(acquired) > l.tokens {
+			acquired = int(l.tokens)
+		}
+		l.tokens -= float64(acquired)
+		return acquired, 0
+	}
+
+	need := float64(count)
+	if need > capacity {
+		need = capacity
+	}
+	return 0, time.Duration((need - l.tokens) / float64(rate) * float64(time.Second))
+}
+
+func (l *bandwidthLimit) currentRate(now time.Time) int64 {
+	for _, rule := range l.rules {
+		if rule.match(now) {
+			return rule.rate
+		}
+	}
+	return 0
+}
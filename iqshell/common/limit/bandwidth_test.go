@@ -0,0 +1,84 @@
+package limit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseBandwidthRate(t *testing.T) {
+	for value, expect := range map[string]int64{
+		"1024":     1024,
+		"512KB/s":  512 * 1024,
+		"50MB/s":   50 * 1024 * 1024,
+		"1.5m":     1536 * 1024,
+		"1GB":      1024 * 1024 * 1024,
+		"0":        0,
+		" 100B/s ": 100,
+	} {
+		rate, err := ParseBandwidthRate(value)
+		if err != nil {
+			t.Fatalf("parse rate:%s error:%v", value, err)
+		}
+		if rate != expect {
+			t.Fatalf("parse rate:%s should be %d, but:%d", value, expect, rate)
+		}
+	}
+
+	for _, value := range []string{"", "abc", "-1MB", "10TB"} {
+		if _, err := ParseBandwidthRate(value); err == nil {
+			t.Fatalf("parse rate:%s should error", value)
+		}
+	}
+}
+
+func TestBandwidthLimitSchedule(t *testing.T) {
+	l, err := NewBandwidthLimit("10MB/s@09:00-18:00, 0@22:00-06:00, 50MB/s")
+	if err != nil {
+		t.Fatal("new bandwidth limit error:", err)
+	}
+	bl := l.(*bandwidthLimit)
+	for clock, expect := range map[string]int64{
+		"08:59": 50 * 1024 * 1024,
+		"09:00": 10 * 1024 * 1024,
+		"17:59": 10 * 1024 * 1024,
+		"18:00": 50 * 1024 * 1024,
+		"23:30": 0,
+		"05:59": 0,
+		"06:00": 50 * 1024 * 1024,
+	} {
+		now, _ := time.Parse("15:04", clock)
+		if rate := bl.currentRate(now); rate != expect {
+			t.Fatalf("rate at %s should be %d, but:%d", clock, expect, rate)
+		}
+	}
+
+	for _, value := range []string{"", "10MB@09:00", "10MB@25:00-01:00", "10MB@09:00-18:70"} {
+		if _, err = NewBandwidthLimit(value); err == nil {
+			t.Fatalf("bandwidth limit:%s should error", value)
+		}
+	}
+}
+
+func TestBandwidthLimitAcquire(t *testing.T) {
+	l, err := NewBandwidthLimit("1MB/s")
+	if err != nil {
+		t.Fatal("new bandwidth limit error:", err)
+	}
+
+	// 令牌桶初始容量为 200ms 的流量，剩余 300KB 需要约 300ms
+	start := time.Now()
+	for i := 0; i < 16; i++ {
+		_ = l.Acquire(32 * 1024)
+	}
+	elapsed := time.Since(start)
+	if elapsed < 200*time.Millisecond || elapsed > 2*time.Second {
+		t.Fatal("acquire 512KB at 1MB/s should take about 300ms, but:", elapsed)
+	}
+
+	unlimited, _ := NewBandwidthLimit("0")
+	start = time.Now()
+	_ = unlimited.Acquire(1024 * 1024 * 1024)
+	if time.Since(start) > 100*time.Millisecond {
+		t.Fatal("unlimited acquire should not block")
+	}
+}
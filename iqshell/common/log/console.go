@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
 	"strings"
 	"time"
 
@@ -69,7 +70,8 @@ func (c *consoleWriter) WriteMsg(when time.Time, msg string, level int) (err err
 	if c.Colorful {
 		msg = colors[level](msg)
 	}
-	if level == logs.LevelError {
+	// 结构化输出时，标准输出仅输出 Record
+	if level == logs.LevelError || output.IsStructured() {
 		_, err = fmt.Fprintln(data.Stderr(), msg)
 	} else {
 		_, err = fmt.Fprintln(data.Stdout(), msg)
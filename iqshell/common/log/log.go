@@ -3,6 +3,8 @@ package log
 import (
 	"fmt"
 	"github.com/astaxie/beego/logs"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
 )
 
 var progressLog *logs.BeeLogger
@@ -51,6 +53,8 @@ func Error(a ...interface{}) {
 }
 
 func ErrorF(format string, v ...interface{}) {
+	// 结构化输出时，错误信息同时以 Record 形式输出
+	output.ErrorF(format, v...)
 	if progressLog != nil {
 		progressLog.Error(format, v...)
 	} else {
@@ -0,0 +1,146 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+// 命令结果的输出格式
+const (
+	FormatText  = "text"  // 默认格式，输出便于阅读的文本
+	FormatJson  = "json"  // 命令结束时输出一个 json 数组，数组元素为 Record
+	FormatJsonl = "jsonl" // 每行输出一个 json 格式的 Record
+)
+
+const (
+	RecordTypeResult = "result"
+	RecordTypeError  = "error"
+)
+
+// Record 结构化输出的一条记录，Type 为 result 时 Data 为命令的结果，Type 为 error 时 Error 为错误信息
+type Record struct {
+	Type    string      `json:"type"`
+	Cmd     string      `json:"cmd"`
+	Account string      `json:"account,omitempty"` // 使用多个账户执行命令（--accounts）时记录所属的账户
+	Data    interface{} `json:"data,omitempty"`
+	Error   string      `json:"error,omitempty"`
+}
+
+var (
+	mu      sync.Mutex
+	format  = FormatText
+	cmdId   = ""
+	records = make([]*Record, 0)
+)
+
+// SetFormat 设置输出格式，format 为空时使用 FormatText
+func SetFormat(f string) *data.CodeError {
+	if len(f) == 0 {
+		f = FormatText
+	}
+	if f != FormatText && f != FormatJson && f != FormatJsonl {
+		return data.NewEmptyError().AppendDescF("output format:%s not support, should be one of %s, %s and %s",
+			f, FormatText, FormatJson, FormatJsonl)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	format = f
+	records = make([]*Record, 0)
+	return nil
+}
+
+func GetFormat() string {
+	mu.Lock()
+	defer mu.Unlock()
+	return format
+}
+
+// IsStructured 是否为结构化输出，结构化输出时标准输出仅输出 Record，日志等文本信息输出至标准错误
+func IsStructured() bool {
+	f := GetFormat()
+	return f == FormatJson || f == FormatJsonl
+}
+
+// SetCmd 设置 Record 中的命令名
+func SetCmd(cmd string) {
+	mu.Lock()
+	defer mu.Unlock()
+	cmdId = cmd
+}
+
+// Result 输出命令结果，result 需可 json 序列化；非结构化输出时不做任何处理
+func Result(result interface{}) {
+	output(&Record{
+		Type: RecordTypeResult,
+		Data: result,
+	})
+}
+
+// Error 输出错误信息；非结构化输出时不做任何处理
+func Error(message string) {
+	output(&Record{
+		Type:  RecordTypeError,
+		Error: message,
+	})
+}
+
+func ErrorF(format string, a ...interface{}) {
+	Error(fmt.Sprintf(format, a...))
+}
+
+// Forward 输出其他 qshell 进程产生的 Record，Record 中的命令名为空时使用当前命令名；非结构化输出时不做任何处理
+func Forward(record *Record) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(record.Cmd) == 0 {
+		record.Cmd = cmdId
+	}
+	outputWithoutLock(record)
+}
+
+func output(record *Record) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	record.Cmd = cmdId
+	outputWithoutLock(record)
+}
+
+func outputWithoutLock(record *Record) {
+	switch format {
+	case FormatJsonl:
+		writeWithoutLock(record)
+	case FormatJson:
+		records = append(records, record)
+	}
+}
+
+// Flush 输出缓存的 Record 并恢复为默认格式，命令结束时调用
+func Flush() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if format == FormatJson {
+		writeWithoutLock(records)
+	}
+	format = FormatText
+	cmdId = ""
+	records = make([]*Record, 0)
+}
+
+func writeWithoutLock(v interface{}) {
+	d, err := json.Marshal(v)
+	if err != nil {
+		d, _ = json.Marshal(&Record{
+			Type:  RecordTypeError,
+			Cmd:   cmdId,
+			Error: fmt.Sprintf("marshal output record error:%v", err),
+		})
+	}
+	_, _ = data.Stdout().Write(append(d, '\n'))
+}
@@ -0,0 +1,114 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+type bufferWriteCloser struct {
+	bytes.Buffer
+}
+
+func (b *bufferWriteCloser) Close() error {
+	return nil
+}
+
+func setTestStdout(t *testing.T) *bufferWriteCloser {
+	stdout := data.Stdout()
+	buffer := &bufferWriteCloser{}
+	data.SetStdout(buffer)
+	t.Cleanup(func() {
+		data.SetStdout(stdout)
+		Flush()
+	})
+	return buffer
+}
+
+func TestSetFormat(t *testing.T) {
+	if err := SetFormat("xml"); err == nil {
+		t.Fatal("set format xml should error")
+	}
+	if err := SetFormat(""); err != nil || GetFormat() != FormatText || IsStructured() {
+		t.Fatal("empty format should be text")
+	}
+}
+
+func TestJsonlOutput(t *testing.T) {
+	buffer := setTestStdout(t)
+	if err := SetFormat(FormatJsonl); err != nil {
+		t.Fatal(err)
+	}
+	SetCmd("stat")
+	Result(map[string]string{"key": "a"})
+	ErrorF("stat %s error", "b")
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("jsonl should output 2 lines, but:%s", buffer.String())
+	}
+	record := &Record{}
+	if err := json.Unmarshal([]byte(lines[1]), record); err != nil {
+		t.Fatal(err)
+	}
+	if record.Type != RecordTypeError || record.Cmd != "stat" || record.Error != "stat b error" {
+		t.Fatalf("error record invalid:%+v", record)
+	}
+}
+
+func TestJsonOutput(t *testing.T) {
+	buffer := setTestStdout(t)
+	if err := SetFormat(FormatJson); err != nil {
+		t.Fatal(err)
+	}
+	Result(1)
+	Result(2)
+	if buffer.Len() > 0 {
+		t.Fatal("json should output after flush, but:", buffer.String())
+	}
+
+	Flush()
+	records := make([]*Record, 0)
+	if err := json.Unmarshal(buffer.Bytes(), &records); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].Type != RecordTypeResult {
+		t.Fatalf("json records invalid:%s", buffer.String())
+	}
+	if GetFormat() != FormatText {
+		t.Fatal("format should reset to text after flush")
+	}
+}
+
+func TestTextOutput(t *testing.T) {
+	buffer := setTestStdout(t)
+	if err := SetFormat(FormatText); err != nil {
+		t.Fatal(err)
+	}
+	Result(1)
+	Error("error")
+	Flush()
+	if buffer.Len() > 0 {
+		t.Fatal("text format should not output record, but:", buffer.String())
+	}
+}
+
+func TestForward(t *testing.T) {
+	buffer := setTestStdout(t)
+	if err := SetFormat(FormatJsonl); err != nil {
+		t.Fatal(err)
+	}
+	SetCmd("buckets")
+	Forward(&Record{Type: RecordTypeResult, Account: "alice", Data: json.RawMessage(`{"name":"a"}`)})
+
+	record := &Record{}
+	if err := json.Unmarshal(buffer.Bytes(), record); err != nil {
+		t.Fatal(err)
+	}
+	if record.Cmd != "buckets" || record.Account != "alice" {
+		t.Fatalf("forward record invalid:%s", buffer.String())
+	}
+}
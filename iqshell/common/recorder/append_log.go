@@ -0,0 +1,216 @@
+package recorder
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+const appendLogFileSuffix = ".log"
+
+// appendLogRecorder 基于目录的追加日志记录，目录可位于多台机器共享的文件系统（如 NFS）上。
+// 每个进程只追加写自己的日志文件，避免多台机器同时写同一个文件；创建时加载目录下所有日志文件。
+// 同一 key 以版本号最大的记录为准，版本号为写入时已知的该 key 的版本号加 1，不依赖各机器的时钟；
+// 同一文件中后写入的记录优先，不同文件的记录版本号相同时以文件名排序靠后的为准。
+type appendLogRecorder struct {
+	dir      string
+	filePath string
+	lock     sync.RWMutex
+	file     *os.File
+	records  map[string]*appendLogEntry
+}
+
+type appendLogEntry struct {
+	Key     string `json:"key"`
+	Value   string `json:"value,omitempty"`
+	Deleted bool   `json:"deleted,omitempty"`
+	Version int64  `json:"version"`
+}
+
+var appendLogMap map[string]*appendLogRecorder
+var appendLogMapLock sync.Mutex
+
+func CreateAppendLogRecorder(dir string) (Recorder, *data.CodeError) {
+	appendLogMapLock.Lock()
+	defer appendLogMapLock.Unlock()
+
+	if appendLogMap == nil {
+		appendLogMap = make(map[string]*appendLogRecorder)
+	}
+
+	if appendLogMap[dir] != nil {
+		return appendLogMap[dir], nil
+	}
+
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, data.NewEmptyError().AppendDesc("open append log: make dir").AppendError(err)
+	}
+
+	r := &appendLogRecorder{
+		dir:     dir,
+		records: make(map[string]*appendLogEntry),
+	}
+	if err := r.load(); err != nil {
+		return nil, data.NewEmptyError().AppendDesc("open append log: load").AppendError(err)
+	}
+
+	hostname, _ := os.Hostname()
+	if len(hostname) == 0 {
+		hostname = "unknown"
+	}
+	// 日志文件在第一次写入时创建
+	r.filePath = filepath.Join(dir, fmt.Sprintf("%s-%d%s", hostname, os.Getpid(), appendLogFileSuffix))
+	appendLogMap[dir] = r
+	return r, nil
+}
+
+func (r *appendLogRecorder) load() *data.CodeError {
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		return data.NewEmptyError().AppendError(err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), appendLogFileSuffix) {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if lErr := r.loadFile(filepath.Join(r.dir, name)); lErr != nil {
+			return lErr
+		}
+	}
+	return nil
+}
+
+func (r *appendLogRecorder) loadFile(path string) *data.CodeError {
+	file, err := os.Open(path)
+	if err != nil {
+		return data.NewEmptyError().AppendError(err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		entry := &appendLogEntry{}
+		// 进程异常退出时最后一行可能不完整，忽略无法解析的行
+		if e := json.Unmarshal(scanner.Bytes(), entry); e != nil || len(entry.Key) == 0 {
+			continue
+		}
+		if old, ok := r.records[entry.Key]; ok && old.Version > entry.Version {
+			continue
+		}
+		r.records[entry.Key] = entry
+	}
+	if err = scanner.Err(); err != nil {
+		return data.NewEmptyError().AppendDescF("read %s error", path).AppendError(err)
+	}
+	return nil
+}
+
+func (r *appendLogRecorder) append(entry *appendLogEntry) *data.CodeError {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.file == nil {
+		file, err := os.OpenFile(r.filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return data.NewEmptyError().AppendDescF("append log key:%s, open file error", entry.Key).AppendError(err)
+		}
+		r.file = file
+	}
+
+	if old, ok := r.records[entry.Key]; ok {
+		entry.Version = old.Version + 1
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return data.NewEmptyError().AppendError(err)
+	}
+	// 一次写入整行，保证行的完整
+	if _, err = r.file.Write(append(line, '\n')); err != nil {
+		return data.NewEmptyError().AppendError(err)
+	}
+	r.records[entry.Key] = entry
+	return nil
+}
+
+// Close 将日志文件落盘并关闭，之后再写入时会重新打开日志文件
+func (r *appendLogRecorder) Close() *data.CodeError {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	file := r.file
+	r.file = nil
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return data.NewEmptyError().AppendDescF("append log sync %s error", r.filePath).AppendError(err)
+	}
+	if err := file.Close(); err != nil {
+		return data.NewEmptyError().AppendDescF("append log close %s error", r.filePath).AppendError(err)
+	}
+	return nil
+}
+
+func (r *appendLogRecorder) Get(key string) (string, *data.CodeError) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	entry, ok := r.records[key]
+	if !ok || entry.Deleted {
+		return "", data.NewEmptyError().AppendDescF("append log get key:%s error:not found", key)
+	}
+	return entry.Value, nil
+}
+
+func (r *appendLogRecorder) Put(key, value string) *data.CodeError {
+	return r.append(&appendLogEntry{
+		Key:   key,
+		Value: value,
+	})
+}
+
+func (r *appendLogRecorder) Delete(key string) *data.CodeError {
+	return r.append(&appendLogEntry{
+		Key:     key,
+		Deleted: true,
+	})
+}
+
+func (r *appendLogRecorder) Range(f func(key, value string) bool) *data.CodeError {
+	r.lock.RLock()
+	keys := make([]string, 0, len(r.records))
+	for key, entry := range r.records {
+		if !entry.Deleted {
+			keys = append(keys, key)
+		}
+	}
+	r.lock.RUnlock()
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		value, err := r.Get(key)
+		if err != nil {
+			continue
+		}
+		if !f(key, value) {
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package recorder
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAppendLogRecorder(t *testing.T) {
+	dir := t.TempDir()
+	r, err := CreateAppendLogRecorder(dir)
+	if err != nil {
+		t.Fatal("create append log recorder error:", err)
+	}
+	_ = r.Put("a", "1")
+	_ = r.Put("b", "2")
+	_ = r.Delete("b")
+
+	// 其他机器写入的日志，包含一条不完整的行
+	other := `{"key":"a","value":"3","version":5}
+{"key":"c","value":"4","version":0}
+{"key":"d","val`
+	if e := os.WriteFile(filepath.Join(dir, "other-1.log"), []byte(other), 0644); e != nil {
+		t.Fatal(e)
+	}
+
+	// 重新加载
+	appendLogMap = nil
+	r, err = CreateAppendLogRecorder(dir)
+	if err != nil {
+		t.Fatal("reopen append log recorder error:", err)
+	}
+	if value, _ := r.Get("a"); value != "3" {
+		t.Fatal("a should be the latest value 3, but:", value)
+	}
+	if _, err = r.Get("b"); err == nil {
+		t.Fatal("b has been deleted")
+	}
+	if value, _ := r.Get("c"); value != "4" {
+		t.Fatal("c should be 4, but:", value)
+	}
+	if _, err = r.Get("d"); err == nil {
+		t.Fatal("incomplete line should be ignored")
+	}
+}
+
+func TestAppendLogRecorderVersion(t *testing.T) {
+	dir := t.TempDir()
+	r, err := CreateAppendLogRecorder(dir)
+	if err != nil {
+		t.Fatal("create append log recorder error:", err)
+	}
+	_ = r.Put("a", "1")
+	_ = r.Put("a", "2")
+	if cErr := Close(r); cErr != nil {
+		t.Fatal("close append log recorder error:", cErr)
+	}
+
+	// 其他机器的时钟不影响记录的先后：版本号小的记录即使写入时间更晚也不生效，版本号相同时以文件名排序靠后的为准
+	other := `{"key":"a","value":"3","version":0,"time":9223372036854775807}
+{"key":"b","value":"4","version":0}`
+	if e := os.WriteFile(filepath.Join(dir, "a-1.log"), []byte(other), 0644); e != nil {
+		t.Fatal(e)
+	}
+	if e := os.WriteFile(filepath.Join(dir, "z-1.log"), []byte(`{"key":"b","value":"5","version":0}`), 0644); e != nil {
+		t.Fatal(e)
+	}
+
+	appendLogMap = nil
+	r, err = CreateAppendLogRecorder(dir)
+	if err != nil {
+		t.Fatal("reopen append log recorder error:", err)
+	}
+	if value, _ := r.Get("a"); value != "2" {
+		t.Fatal("a should be the value of the highest version 2, but:", value)
+	}
+	if value, _ := r.Get("b"); value != "5" {
+		t.Fatal("b should be the value of the last file 5, but:", value)
+	}
+
+	// 关闭后可继续写入，新记录的版本号大于已加载的记录
+	_ = r.Put("b", "6")
+	_ = Close(r)
+	appendLogMap = nil
+	if r, err = CreateAppendLogRecorder(dir); err != nil {
+		t.Fatal("reopen append log recorder error:", err)
+	}
+	if value, _ := r.Get("b"); value != "6" {
+		t.Fatal("b should be 6, but:", value)
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	from, err := CreateAppendLogRecorder(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = from.Put("a", "1")
+	_ = from.Put("b", "2")
+
+	buffer := &bytes.Buffer{}
+	if count, eErr := Export(from, buffer); eErr != nil || count != 2 {
+		t.Fatalf("export error:%v count:%d", eErr, count)
+	}
+
+	to, err := CreateDBRecorder(filepath.Join(t.TempDir(), ".recorder"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count, iErr := Import(to, buffer); iErr != nil || count != 2 {
+		t.Fatalf("import error:%v count:%d", iErr, count)
+	}
+	if value, _ := to.Get("b"); value != "2" {
+		t.Fatal("b should be 2, but:", value)
+	}
+}
@@ -91,3 +91,20 @@ func (db *dbRecorder) Delete(key string) *data.CodeError {
 	}
 	return nil
 }
+
+func (db *dbRecorder) Range(f func(key, value string) bool) *data.CodeError {
+	if db.db == nil {
+		return data.NewEmptyError().AppendDesc("db range error:no db exist")
+	}
+	iter := db.db.NewIterator(nil, nil)
+	defer iter.Release()
+	for iter.Next() {
+		if !f(string(iter.Key()), string(iter.Value())) {
+			break
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return data.NewEmptyError().AppendError(err)
+	}
+	return nil
+}
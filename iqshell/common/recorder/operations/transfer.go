@@ -0,0 +1,166 @@
+package operations
+
+import (
+	"io"
+	"os"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/config"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
+	"github.com/qiniu/qshell/v2/iqshell/common/recorder"
+)
+
+// Record 【record】无子命令时仅加载，--doc 时展示文档
+func Record(cfg *iqshell.Config) {
+	iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{})
+}
+
+type ExportInfo struct {
+	Backend    string // 记录的存储后端
+	RecordPath string // 记录路径，leveldb 为数据库路径，shared-dir 为追加日志所在目录
+	SaveToFile string // 导出文件路径，为空时输出至标准输出
+}
+
+func (info *ExportInfo) Check() *data.CodeError {
+	if len(info.RecordPath) == 0 {
+		return alert.CannotEmptyError("RecordPath", "")
+	}
+	return checkBackend(info.Backend)
+}
+
+// Export 导出 job 的 work 执行记录，导出的记录可通过 Import 导入至其他存储后端
+func Export(cfg *iqshell.Config, info ExportInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	if _, err := os.Stat(info.RecordPath); err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("record export: record path:%s error:%v", info.RecordPath, err)
+		return
+	}
+
+	r, err := recorder.CreateRecorder(info.Backend, info.RecordPath)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("record export: open record error:%v", err)
+		return
+	}
+
+	// 结构化输出时，标准输出仅输出 Record，每条记录为一个 Record
+	if len(info.SaveToFile) == 0 && output.IsStructured() {
+		var count int64
+		if rErr := r.Range(func(key, value string) bool {
+			output.Result(&recorder.TransferRecord{
+				Key:   key,
+				Value: value,
+			})
+			count++
+			return true
+		}); rErr != nil {
+			data.SetCmdStatusError()
+			log.ErrorF("record export error:%v", rErr)
+			return
+		}
+		log.DebugF("Export %d records", count)
+		return
+	}
+
+	var writer io.Writer = data.Stdout()
+	if len(info.SaveToFile) > 0 {
+		file, oErr := os.Create(info.SaveToFile)
+		if oErr != nil {
+			data.SetCmdStatusError()
+			log.ErrorF("record export: create file:%s error:%v", info.SaveToFile, oErr)
+			return
+		}
+		defer file.Close()
+		writer = file
+	}
+
+	count, err := recorder.Export(r, writer)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("record export error:%v", err)
+		return
+	}
+	output.Result(map[string]interface{}{
+		"record_path": info.RecordPath,
+		"count":       count,
+	})
+	if len(info.SaveToFile) > 0 {
+		log.AlertF("Export %d records to %s", count, info.SaveToFile)
+	} else {
+		log.DebugF("Export %d records", count)
+	}
+}
+
+type ImportInfo struct {
+	Backend    string // 记录的存储后端
+	RecordPath string // 记录路径，leveldb 为数据库路径，shared-dir 为追加日志所在目录
+	ImportFile string // Export 导出的文件
+}
+
+func (info *ImportInfo) Check() *data.CodeError {
+	if len(info.ImportFile) == 0 {
+		return alert.CannotEmptyError("ImportFile", "")
+	}
+	if len(info.RecordPath) == 0 {
+		return alert.CannotEmptyError("RecordPath", "")
+	}
+	return checkBackend(info.Backend)
+}
+
+// Import 导入 Export 导出的 work 执行记录，已存在的记录会被覆盖
+func Import(cfg *iqshell.Config, info ImportInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	file, oErr := os.Open(info.ImportFile)
+	if oErr != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("record import: open file:%s error:%v", info.ImportFile, oErr)
+		return
+	}
+	defer file.Close()
+
+	r, err := recorder.CreateRecorder(info.Backend, info.RecordPath)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("record import: open record error:%v", err)
+		return
+	}
+
+	count, err := recorder.Import(r, file)
+	if cErr := recorder.Close(r); cErr != nil && err == nil {
+		err = cErr
+	}
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("record import error, %d records have been imported:%v", count, err)
+		return
+	}
+	output.Result(map[string]interface{}{
+		"record_path": info.RecordPath,
+		"count":       count,
+	})
+	log.AlertF("Import %d records to %s", count, info.RecordPath)
+}
+
+func checkBackend(backend string) *data.CodeError {
+	switch backend {
+	case "", config.RecordBackendLevelDB, config.RecordBackendSharedDir:
+		return nil
+	default:
+		return data.NewEmptyError().AppendDescF("backend:%s not support, should be one of %s and %s",
+			backend, config.RecordBackendLevelDB, config.RecordBackendSharedDir)
+	}
+}
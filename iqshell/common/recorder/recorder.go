@@ -1,6 +1,9 @@
 package recorder
 
-import "github.com/qiniu/qshell/v2/iqshell/common/data"
+import (
+	"github.com/qiniu/qshell/v2/iqshell/common/config"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
 
 type Recorder interface {
 
@@ -12,4 +15,33 @@ type Recorder interface {
 
 	// Delete 删除记录
 	Delete(key string) *data.CodeError
+
+	// Range 遍历所有记录，f 返回 false 时停止遍历
+	Range(f func(key, value string) bool) *data.CodeError
+}
+
+// Closer 需要在使用结束时关闭的记录，如 shared-dir 的追加日志需要落盘并关闭日志文件
+type Closer interface {
+	Close() *data.CodeError
+}
+
+// Close 关闭 r，r 不需要关闭时不做任何处理
+func Close(r Recorder) *data.CodeError {
+	if c, ok := r.(Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// CreateRecorder 根据存储后端创建记录，backend 为 config.RecordBackendLevelDB 时 path 为 LevelDB 路径，
+// 为 config.RecordBackendSharedDir 时 path 为追加日志所在目录
+func CreateRecorder(backend, path string) (Recorder, *data.CodeError) {
+	switch backend {
+	case "", config.RecordBackendLevelDB:
+		return CreateDBRecorder(path)
+	case config.RecordBackendSharedDir:
+		return CreateAppendLogRecorder(path)
+	default:
+		return nil, data.NewEmptyError().AppendDescF("record backend:%s not support", backend)
+	}
 }
@@ -0,0 +1,74 @@
+package recorder
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+// TransferRecord 导出的记录，每行一个 json
+type TransferRecord struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Export 将 r 中的所有记录导出至 writer，返回导出的记录数
+func Export(r Recorder, writer io.Writer) (count int64, err *data.CodeError) {
+	bWriter := bufio.NewWriter(writer)
+	rErr := r.Range(func(key, value string) bool {
+		line, e := json.Marshal(&TransferRecord{
+			Key:   key,
+			Value: value,
+		})
+		if e == nil {
+			_, e = bWriter.Write(append(line, '\n'))
+		}
+		if e != nil {
+			err = data.NewEmptyError().AppendDescF("export record key:%s error", key).AppendError(e)
+			return false
+		}
+		count++
+		return true
+	})
+	if err != nil {
+		return count, err
+	}
+	if rErr != nil {
+		return count, rErr
+	}
+	if e := bWriter.Flush(); e != nil {
+		return count, data.NewEmptyError().AppendDesc("export record flush error").AppendError(e)
+	}
+	return count, nil
+}
+
+// Import 将 reader 中 Export 导出的记录导入至 r，已存在的记录会被覆盖，返回导入的记录数
+func Import(r Recorder, reader io.Reader) (count int64, err *data.CodeError) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		record := &TransferRecord{}
+		if e := json.Unmarshal(scanner.Bytes(), record); e != nil {
+			return count, data.NewEmptyError().AppendDescF("import record, line:%d invalid", line).AppendError(e)
+		}
+		if len(record.Key) == 0 {
+			return count, data.NewEmptyError().AppendDescF("import record, line:%d key is empty", line)
+		}
+		if pErr := r.Put(record.Key, record.Value); pErr != nil {
+			return count, data.NewEmptyError().AppendDescF("import record key:%s error", record.Key).AppendError(pErr)
+		}
+		count++
+	}
+	if e := scanner.Err(); e != nil {
+		return count, data.NewEmptyError().AppendDesc("import record, read error").AppendError(e)
+	}
+	return count, nil
+}
@@ -5,6 +5,11 @@ import "github.com/qiniu/qshell/v2/iqshell/common/data"
 type ParserResult interface {
 }
 
+// LocationResult 可以获取 ip 所在地的解析结果
+type LocationResult interface {
+	Location() string
+}
+
 type Parser interface {
 	Parse(ip string) (ParserResult, *data.CodeError)
 }
@@ -78,6 +78,17 @@ func (i *aliIpInfo) String() string {
 	return fmt.Sprintf("%v", i.Data.String())
 }
 
+// Location ip 所在地，包含国家、地区、城市及运营商
+func (i *aliIpInfo) Location() string {
+	items := make([]string, 0, 4)
+	for _, item := range []string{i.Data.Country, i.Data.Region, i.Data.City, i.Data.Isp} {
+		if len(item) > 0 && item != "XX" {
+			items = append(items, item)
+		}
+	}
+	return strings.Join(items, " ")
+}
+
 // IpData ip 具体的信息
 type aliIpData struct {
 	Country   string `json:"country"`
@@ -7,10 +7,10 @@ import (
 	"github.com/qiniu/go-sdk/v7/auth/qbox"
 	"github.com/qiniu/go-sdk/v7/storage"
 	"github.com/qiniu/qshell/v2/iqshell"
-	"github.com/qiniu/qshell/v2/iqshell/common/account"
 	"github.com/qiniu/qshell/v2/iqshell/common/alert"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -153,7 +153,7 @@ func CreateUploadToken(cfg *iqshell.Config, info UploadTokenInfo) {
 	if info.AccessKey != "" && info.SecretKey != "" {
 		mac = qbox.NewMac(info.AccessKey, info.SecretKey)
 	} else {
-		mac, mErr = account.GetMac()
+		mac, mErr = workspace.GetMac()
 		if mErr != nil {
 			data.SetCmdStatusError()
 			log.ErrorF("get mac: %v\n", mErr)
@@ -169,7 +169,7 @@ func getMacAndRequest(info TokenInfo) (mac *qbox.Mac, req *http.Request, err *da
 		mac = qbox.NewMac(info.AccessKey, info.SecretKey)
 	} else {
 		var mErr *data.CodeError
-		mac, mErr = account.GetMac()
+		mac, mErr = workspace.GetMac()
 		if mErr != nil {
 			err = data.NewEmptyError().AppendDescF("get mac: %v\n", mErr)
 			return
@@ -22,6 +22,9 @@ func defaultConfig() *config.Config {
 			LogRotate: data.NewInt(7),
 			LogStdout: data.NewBool(true),
 		},
+		Record: &config.RecordSetting{
+			Backend: data.NewString(config.RecordBackendLevelDB),
+		},
 	}
 }
 
@@ -45,6 +48,12 @@ func checkConfig(cfg *config.Config) (err *data.CodeError) {
 	}
 	if configHostCount != 0 && configHostCount != 5 {
 		err = data.NewEmptyError().AppendDesc("hosts: api/rs/rsf/io/up should config all")
+		return
+	}
+
+	// record
+	if cfg.Record != nil {
+		err = cfg.Record.Check()
 	}
 	return
 }
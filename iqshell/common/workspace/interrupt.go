@@ -49,6 +49,7 @@ func observerCmdInterrupt() {
 		data.SetCmdStatusUserCancel()
 		Cancel()
 		notifyCancelSignalToObservers(si)
+		endJob(JobStatusInterrupted)
 		os.Exit(data.StatusUserCancel)
 	}()
 }
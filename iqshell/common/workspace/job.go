@@ -0,0 +1,141 @@
+package workspace
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+// JobInfoFileName job 信息文件名，保存在 job 目录下
+const JobInfoFileName = ".job.json"
+
+const (
+	JobStatusRunning     = "running"     // 执行中，或进程异常退出
+	JobStatusFinished    = "finished"    // 执行结束
+	JobStatusInterrupted = "interrupted" // 用户中断
+)
+
+// JobInfo 批量任务的 job 信息，用于查看、恢复及清理 job
+type JobInfo struct {
+	CmdId         string   `json:"cmd_id"`
+	Args          []string `json:"args"`
+	WorkDir       string   `json:"work_dir"`
+	RecordBackend string   `json:"record_backend,omitempty"`
+	RecordDir     string   `json:"record_dir,omitempty"`
+	Status        string   `json:"status"`
+	CmdStatus     int      `json:"cmd_status"`
+	StartTime     int64    `json:"start_time"`
+	EndTime       int64    `json:"end_time,omitempty"`
+	SuccessCount  int64    `json:"success_count"`
+	FailureCount  int64    `json:"failure_count"`
+	SkipCount     int64    `json:"skip_count"`
+}
+
+var (
+	jobInfo     *JobInfo
+	jobInfoLock sync.Mutex
+
+	jobSuccessCount int64
+	jobFailureCount int64
+	jobSkipCount    int64
+)
+
+// 仅配置了 JobPathBuilder 的命令才会记录 job 信息
+func startJob(cmdId string) *data.CodeError {
+	workDir, _ := os.Getwd()
+	info := &JobInfo{
+		CmdId:     cmdId,
+		Args:      os.Args[1:],
+		WorkDir:   workDir,
+		Status:    JobStatusRunning,
+		StartTime: time.Now().Unix(),
+	}
+	if setting := GetRecordConfig(); setting != nil {
+		info.RecordBackend = setting.GetBackend()
+		info.RecordDir = setting.GetDir()
+	}
+
+	jobInfoLock.Lock()
+	jobInfo = info
+	jobInfoLock.Unlock()
+	return saveJobInfo()
+}
+
+// AddJobSuccessCount 记录 job 中执行成功的 work 数
+func AddJobSuccessCount(count int64) {
+	atomic.AddInt64(&jobSuccessCount, count)
+}
+
+// AddJobFailureCount 记录 job 中执行失败的 work 数
+func AddJobFailureCount(count int64) {
+	atomic.AddInt64(&jobFailureCount, count)
+}
+
+// AddJobSkipCount 记录 job 中跳过的 work 数，包含之前已执行过的 work
+func AddJobSkipCount(count int64) {
+	atomic.AddInt64(&jobSkipCount, count)
+}
+
+// EndJob 命令结束时保存 job 信息
+func EndJob() {
+	endJob(JobStatusFinished)
+}
+
+func endJob(status string) {
+	info := getJobInfo()
+	if info == nil {
+		return
+	}
+
+	jobInfoLock.Lock()
+	info.Status = status
+	info.CmdStatus = data.GetCmdStatus()
+	info.EndTime = time.Now().Unix()
+	jobInfoLock.Unlock()
+	_ = saveJobInfo()
+}
+
+func getJobInfo() *JobInfo {
+	jobInfoLock.Lock()
+	defer jobInfoLock.Unlock()
+	return jobInfo
+}
+
+func saveJobInfo() *data.CodeError {
+	jobInfoLock.Lock()
+	defer jobInfoLock.Unlock()
+
+	if jobInfo == nil || len(jobDir) == 0 {
+		return nil
+	}
+
+	jobInfo.SuccessCount = atomic.LoadInt64(&jobSuccessCount)
+	jobInfo.FailureCount = atomic.LoadInt64(&jobFailureCount)
+	jobInfo.SkipCount = atomic.LoadInt64(&jobSkipCount)
+	d, err := json.MarshalIndent(jobInfo, "", "\t")
+	if err != nil {
+		return data.NewEmptyError().AppendDesc("marshal job info error").AppendError(err)
+	}
+	if err = os.WriteFile(filepath.Join(jobDir, JobInfoFileName), d, 0644); err != nil {
+		return data.NewEmptyError().AppendDesc("save job info error").AppendError(err)
+	}
+	return nil
+}
+
+// LoadJobInfo 加载 job 目录下的 job 信息
+func LoadJobInfo(dir string) (*JobInfo, *data.CodeError) {
+	d, err := os.ReadFile(filepath.Join(dir, JobInfoFileName))
+	if err != nil {
+		return nil, data.NewEmptyError().AppendDesc("read job info error").AppendError(err)
+	}
+	info := &JobInfo{}
+	if err = json.Unmarshal(d, info); err != nil {
+		return nil, data.NewEmptyError().AppendDesc("parse job info error").AppendError(err)
+	}
+	return info, nil
+}
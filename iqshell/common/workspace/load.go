@@ -16,6 +16,8 @@ import (
 
 type LoadInfo struct {
 	UserConfigPath   string
+	AccountName      string // 本次命令使用的账户名，为空时使用当前账户
+	Profile          string // 本次命令使用的凭证文件 profile
 	CmdConfig        *config.Config
 	WorkspacePath    string
 	JobPathBuilder   func(cmdPath string) string
@@ -57,6 +59,8 @@ func Load(info LoadInfo) (err *data.CodeError) {
 		AccountPath:    accountPath,
 		OldAccountPath: oldAccountPath,
 		AccountDBPath:  accountDBPath,
+		MasterKeyPath:  filepath.Join(workspaceDir, masterKeyFileName),
+		SessionPath:    account.PassphraseSessionPath(workspaceDir),
 	})
 	if err != nil {
 		log.ErrorF("load account error:%v", err)
@@ -71,9 +75,15 @@ func Load(info LoadInfo) (err *data.CodeError) {
 			return
 		}
 
-		loadUserInfo()
+		err = loadUserInfo(info)
+		if err != nil {
+			return
+		}
 	} else {
-		loadUserInfo()
+		err = loadUserInfo(info)
+		if err != nil {
+			return
+		}
 		info.UserConfigPath = filepath.Join(userDir, configFileName)
 
 		err = config.LoadUserConfig(info.UserConfigPath)
@@ -112,6 +122,13 @@ func Load(info LoadInfo) (err *data.CodeError) {
 	}
 	log.DebugF("job dir:%s", jobDir)
 
+	// 记录 job 信息
+	if info.JobPathBuilder != nil {
+		if sErr := startJob(info.CmdConfig.CmdId); sErr != nil {
+			log.WarningF("save job info error:%v", sErr)
+		}
+	}
+
 	// uc 缓存路径, 实际路径在用户目录下，不存在 uc_cache
 	storage.SetRegionCachePath(filepath.Join(userDir, "uc_cache"))
 
@@ -134,15 +151,24 @@ func (w *LoadInfo) initInfo() *data.CodeError {
 	return nil
 }
 
-func loadUserInfo() {
-	acc, err := account.GetAccount()
-	if err == nil {
+// loadUserInfo 从凭证链中加载账户信息，--account、--profile 等明确指定的凭证不可用时返回错误
+func loadUserInfo(info LoadInfo) *data.CodeError {
+	acc, found, err := account.GetAccountFromProviders(account.ProviderInfo{
+		AccountName:     info.AccountName,
+		Profile:         info.Profile,
+		CredentialsPath: filepath.Join(workspaceDir, credentialsFileName),
+	})
+	if err != nil {
+		return err
+	}
+
+	if found {
 		currentAccount = &acc
 		accountName := acc.Name
 		if len(accountName) == 0 {
 			accountName = currentAccount.AccessKey
 		}
-		log.DebugF("current user name:%s", accountName)
+		log.DebugF("current user name:%s, credentials source:%s", accountName, acc.Source)
 
 		userDir = filepath.Join(workspaceDir, usersDirName, accountName)
 
@@ -156,4 +182,5 @@ func loadUserInfo() {
 	}
 
 	log.DebugF("user dir:%s", userDir)
+	return nil
 }
@@ -1,5 +1,10 @@
 package workspace
 
+import (
+	"os"
+	"path/filepath"
+)
+
 var (
 	// 工作路径
 	workspaceDir = ""
@@ -22,3 +27,31 @@ func GetUserDir() string {
 func GetJobDir() string {
 	return jobDir
 }
+
+// GetSharedRecordPath 记录存储后端为 shared-dir 时，path 对应的记录在共享目录 recordDir 中的路径；
+// 使用 path 相对于工作区的路径，不同机器上同一 job 的记录路径相同，不同 job 的记录互不影响；
+// path 不在工作区中时使用 path 的完整路径
+func GetSharedRecordPath(recordDir string, path string) string {
+	relativePath, err := filepath.Rel(workspaceDir, path)
+	if len(workspaceDir) == 0 || err != nil || !filepath.IsLocal(relativePath) {
+		relativePath = path[len(filepath.VolumeName(path)):]
+	}
+	return filepath.Join(recordDir, relativePath)
+}
+
+// GetAllUserDirs 获取工作区下所有用户的目录
+func GetAllUserDirs() []string {
+	usersDir := filepath.Join(workspaceDir, usersDirName)
+	entries, err := os.ReadDir(usersDir)
+	if err != nil {
+		return nil
+	}
+
+	dirs := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			dirs = append(dirs, filepath.Join(usersDir, entry.Name()))
+		}
+	}
+	return dirs
+}
@@ -25,6 +25,8 @@ const (
 	taskDirName           = "task"
 	taskDBName            = "task.db"
 	configFileName        = ".qshell.json"
+	credentialsFileName   = "credentials"
+	masterKeyFileName     = "account.key"
 )
 
 var (
@@ -51,6 +53,13 @@ func GetLogConfig() *config.LogSetting {
 	return cfg.Log
 }
 
+func GetRecordConfig() *config.RecordSetting {
+	if cfg == nil {
+		return nil
+	}
+	return cfg.Record
+}
+
 func GetStorageConfig() *storage.Config {
 	r := cfg.GetRegion()
 	ucHost := cfg.Hosts.GetOneUc()
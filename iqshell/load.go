@@ -9,9 +9,12 @@ import (
 	"github.com/qiniu/go-sdk/v7/client"
 
 	"github.com/qiniu/qshell/v2/docs"
+	qclient "github.com/qiniu/qshell/v2/iqshell/common/client"
 	"github.com/qiniu/qshell/v2/iqshell/common/config"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/limit"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
 	"github.com/qiniu/qshell/v2/iqshell/common/utils"
 	"github.com/qiniu/qshell/v2/iqshell/common/version"
 	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
@@ -24,7 +27,13 @@ type Config struct {
 	DDebugEnable   bool                        // go SDK client 和命令行开启调试模式
 	ConfigFilePath string                      // 配置文件路径，用户可以指定配置文件
 	Local          bool                        // 是否使用当前文件夹作为工作区
+	Account        string                      // 本次命令使用的账户名，为空时使用当前账户；不修改当前账户
+	Profile        string                      // 本次命令使用的凭证文件（$HOME/.qshell/credentials）中的 profile
 	StdoutColorful bool                        // 控制台输出是否多彩
+	Output         string                      // 命令结果的输出格式：text / json / jsonl
+	RecordBackend  string                      // job 执行记录的存储后端：leveldb / shared-dir
+	RecordDir      string                      // 存储后端为 shared-dir 时，job 执行记录的保存目录
+	BandwidthLimit string                      // 上传、下载的带宽限制，可按时间段配置
 	JobPathBuilder func(cmdPath string) string // job 路径生成器
 	CmdCfg         config.Config
 }
@@ -110,12 +119,29 @@ func loadBase(cfg *Config) (shouldContinue bool) {
 		logLevel = log.LevelWarning
 	}
 
+	// 结构化输出
+	if err := output.SetFormat(cfg.Output); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%v\n", err)
+		return false
+	}
+	output.SetCmd(cfg.CmdCfg.CmdId)
+
 	// 加载本地输出
 	_ = log.Prepare()
 	_ = log.LoadConsole(log.Config{
 		Level:          logLevel,
 		StdOutColorful: cfg.StdoutColorful,
 	})
+
+	// 带宽限制
+	if len(cfg.BandwidthLimit) > 0 {
+		bandwidthLimit, err := limit.NewBandwidthLimit(cfg.BandwidthLimit)
+		if err != nil {
+			log.ErrorF("load bandwidth limit error:%v", err)
+			return false
+		}
+		qclient.SetBandwidthLimit(bandwidthLimit)
+	}
 	return true
 }
 
@@ -131,11 +157,30 @@ func loadWorkspace(cfg *Config) (shouldContinue bool) {
 		workspacePath = dir
 	}
 
+	// job 执行记录的存储配置
+	if len(cfg.RecordBackend) > 0 || len(cfg.RecordDir) > 0 {
+		if cfg.CmdCfg.Record == nil {
+			cfg.CmdCfg.Record = &config.RecordSetting{}
+		}
+		if len(cfg.RecordBackend) > 0 {
+			cfg.CmdCfg.Record.Backend = data.NewString(cfg.RecordBackend)
+		}
+		if len(cfg.RecordDir) > 0 {
+			cfg.CmdCfg.Record.Dir = data.NewString(cfg.RecordDir)
+			// 仅指定了记录目录时使用共享目录存储
+			if len(cfg.RecordBackend) == 0 {
+				cfg.CmdCfg.Record.Backend = data.NewString(config.RecordBackendSharedDir)
+			}
+		}
+	}
+
 	// 加载工作区
 	if err := workspace.Load(workspace.LoadInfo{
 		CmdConfig:      &cfg.CmdCfg,
 		WorkspacePath:  workspacePath,
 		UserConfigPath: cfg.ConfigFilePath,
+		AccountName:    cfg.Account,
+		Profile:        cfg.Profile,
 		JobPathBuilder: cfg.JobPathBuilder,
 	}); err != nil {
 		_, _ = fmt.Fprintf(os.Stderr, "load workspace error: %v\n", err)
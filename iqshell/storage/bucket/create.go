@@ -10,9 +10,9 @@ import (
 )
 
 type CreateApiInfo struct {
-	RegionId string
-	Bucket   string
-	Private  bool
+	RegionId string `json:"region_id"`
+	Bucket   string `json:"bucket"`
+	Private  bool   `json:"private"`
 }
 
 func Create(info CreateApiInfo) *data.CodeError {
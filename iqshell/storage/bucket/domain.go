@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/qiniu/go-sdk/v7/auth"
 	"github.com/qiniu/go-sdk/v7/storage"
+	"github.com/qiniu/qshell/v2/iqshell/common/account"
 	"github.com/qiniu/qshell/v2/iqshell/common/config"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/utils"
@@ -75,7 +76,20 @@ func (i *DomainInfo) DetailDescriptionString() string {
 
 // AllDomainsOfBucket 获取一个存储空间绑定的CDN域名
 func AllDomainsOfBucket(bucket string) (domains []DomainInfo, err *data.CodeError) {
-	domains, err = allDomainsOfBucket(workspace.GetConfig(), bucket)
+	bucketManager, gErr := GetBucketManager()
+	if gErr != nil {
+		return nil, gErr
+	}
+	return allDomainsOfBucketWithManager(bucketManager, bucket)
+}
+
+// AllDomainsOfBucketWithAccount 使用指定账户获取一个存储空间绑定的CDN域名
+func AllDomainsOfBucketWithAccount(acc account.Account, bucket string) (domains []DomainInfo, err *data.CodeError) {
+	return allDomainsOfBucketWithManager(GetBucketManagerWithAccount(acc), bucket)
+}
+
+func allDomainsOfBucketWithManager(bucketManager *storage.BucketManager, bucket string) (domains []DomainInfo, err *data.CodeError) {
+	domains, err = allDomainsOfBucket(workspace.GetConfig(), bucketManager, bucket)
 	if len(domains) == 0 {
 		return domains, data.NewEmptyError().AppendDesc("domain list is empty").AppendError(err)
 	}
@@ -95,12 +109,7 @@ func AllDomainsOfBucket(bucket string) (domains []DomainInfo, err *data.CodeErro
 	return domains, err
 }
 
-func allDomainsOfBucket(cfg *config.Config, bucket string) ([]DomainInfo, *data.CodeError) {
-	bucketManager, gErr := GetBucketManager()
-	if gErr != nil {
-		return nil, gErr
-	}
-
+func allDomainsOfBucket(cfg *config.Config, bucketManager *storage.BucketManager, bucket string) ([]DomainInfo, *data.CodeError) {
 	var domains []DomainInfo
 	reqHost := workspace.GetConfig().Hosts.GetOneUc()
 	reqURL := fmt.Sprintf("%s/v3/domains?tbl=%s", utils.Endpoint(cfg.IsUseHttps(), reqHost), bucket)
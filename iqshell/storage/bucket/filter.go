@@ -0,0 +1,86 @@
+package bucket
+
+import (
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/filter"
+)
+
+// ListObjectFilterSchema 列举文件过滤表达式支持的字段
+// key：文件名；size：文件大小，单位 B；hash：文件 qetag；md5：文件 md5；mime：文件 MimeType；
+// type：存储类型；status：文件状态，0 启用 1 禁用；putTime：上传时间；endUser：文件 EndUser
+var ListObjectFilterSchema = filter.Schema{
+	"key":      filter.KindString,
+	"size":     filter.KindNumber,
+	"fsize":    filter.KindNumber,
+	"hash":     filter.KindString,
+	"md5":      filter.KindString,
+	"mime":     filter.KindString,
+	"mimeType": filter.KindString,
+	"type":     filter.KindNumber,
+	"fileType": filter.KindNumber,
+	"status":   filter.KindNumber,
+	"putTime":  filter.KindTime,
+	"endUser":  filter.KindString,
+}
+
+// ListObjectFilter 列举文件的过滤器，为 nil 时不过滤
+type ListObjectFilter struct {
+	expression filter.Expression
+}
+
+// NewListObjectFilter 根据过滤表达式创建过滤器，表达式为空时返回 nil，语法参考 filter.Parse
+func NewListObjectFilter(expression string) (*ListObjectFilter, *data.CodeError) {
+	if len(expression) == 0 {
+		return nil, nil
+	}
+	e, err := filter.Parse(expression, ListObjectFilterSchema)
+	if err != nil {
+		return nil, err
+	}
+	return &ListObjectFilter{expression: e}, nil
+}
+
+// Match 文件是否满足过滤条件
+func (f *ListObjectFilter) Match(object ListObject) bool {
+	if f == nil {
+		return true
+	}
+	return f.expression.Match(listObjectFilterValue(object))
+}
+
+func (f *ListObjectFilter) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.expression.String()
+}
+
+type listObjectFilterValue ListObject
+
+func (o listObjectFilterValue) FilterValue(field string) interface{} {
+	switch field {
+	case "key":
+		return o.Key
+	case "size", "fsize":
+		return o.Fsize
+	case "hash":
+		return o.Hash
+	case "md5":
+		return o.Md5
+	case "mime", "mimeType":
+		return o.MimeType
+	case "type", "fileType":
+		return int64(o.Type)
+	case "status":
+		return int64(o.Status)
+	case "putTime":
+		// PutTime 单位为 100ns
+		return time.Unix(0, o.PutTime*100)
+	case "endUser":
+		return o.EndUser
+	default:
+		return nil
+	}
+}
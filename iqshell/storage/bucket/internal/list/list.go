@@ -24,6 +24,9 @@ type ApiInfo struct {
 	Delimiter  string
 	Marker     string
 	V1Limit    int
+
+	// 每次请求结束后回调下次请求的 marker，当次请求没有文件时（比如仅返回了公共前缀）也会回调，仅 list v1 支持【可选】
+	OnMarker func(marker string)
 }
 
 type Item storage.ListItem
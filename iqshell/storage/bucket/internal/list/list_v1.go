@@ -3,6 +3,7 @@ package list
 import (
 	"context"
 	"github.com/qiniu/go-sdk/v7/storage"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"strings"
 )
@@ -27,5 +28,34 @@ func listBucketByV1(ctx context.Context, info ApiInfo, handler Handler) (hasMore
 			break
 		}
 	}
+	if info.OnMarker != nil {
+		info.OnMarker(rets.Marker)
+	}
 	return hasMore, nil
 }
+
+// ListCommonPrefixes 使用 Delimiter 列举 Prefix 下一级的公共前缀（模拟列举目录），返回本次请求的公共前缀及下次请求的 marker
+func ListCommonPrefixes(ctx context.Context, info ApiInfo) (commonPrefixes []string, marker string, hasMore bool, err *data.CodeError) {
+	if info.Manager == nil {
+		return nil, "", true, alert.CannotEmptyError("bucket manager", "")
+	}
+	if len(info.Delimiter) == 0 {
+		return nil, "", false, alert.CannotEmptyError("delimiter", "")
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	rets, hasMore, e := info.Manager.ListFilesWithContext(ctx, info.Bucket,
+		storage.ListInputOptionsMarker(info.Marker),
+		storage.ListInputOptionsPrefix(info.Prefix),
+		storage.ListInputOptionsLimit(info.V1Limit),
+		storage.ListInputOptionsDelimiter(info.Delimiter))
+	if e != nil {
+		return nil, info.Marker, hasMore, data.ConvertError(e)
+	}
+	if rets == nil {
+		return nil, info.Marker, hasMore, data.NewError(0, "v1 meet empty body when list not completed")
+	}
+	return rets.CommonPrefixes, rets.Marker, hasMore, nil
+}
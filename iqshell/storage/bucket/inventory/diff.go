@@ -0,0 +1,98 @@
+package inventory
+
+import (
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+const (
+	DiffActionAdded    = "added"
+	DiffActionRemoved  = "removed"
+	DiffActionModified = "modified"
+)
+
+// DiffEntry 两个快照间一个文件的差异
+type DiffEntry struct {
+	Action string   `json:"action"`           // added / removed / modified
+	Key    string   `json:"key"`              // 文件名
+	Fields []string `json:"fields,omitempty"` // modified 时发生变化的字段
+	Old    *Entry   `json:"old,omitempty"`    // 文件在旧快照中的信息，added 时为空
+	New    *Entry   `json:"new,omitempty"`    // 文件在新快照中的信息，removed 时为空
+}
+
+// DiffResult 差异统计
+type DiffResult struct {
+	Added     int64 `json:"added"`
+	Removed   int64 `json:"removed"`
+	Modified  int64 `json:"modified"`
+	Unchanged int64 `json:"unchanged"`
+}
+
+// Diff 比较旧快照 oldReader 与新快照 newReader，两个快照均按 Key 排序，因此只需顺序遍历一次；
+// 每个差异回调一次 handler，handler 返回错误时停止比较
+func Diff(oldReader, newReader *Reader, handler func(entry *DiffEntry) *data.CodeError) (result DiffResult, err *data.CodeError) {
+	oldEntry, err := oldReader.Next()
+	if err != nil {
+		return
+	}
+	newEntry, err := newReader.Next()
+	if err != nil {
+		return
+	}
+
+	for oldEntry != nil || newEntry != nil {
+		var diff *DiffEntry
+		advanceOld, advanceNew := false, false
+		switch {
+		case newEntry == nil || (oldEntry != nil && oldEntry.Key < newEntry.Key):
+			result.Removed++
+			diff = &DiffEntry{Action: DiffActionRemoved, Key: oldEntry.Key, Old: oldEntry}
+			advanceOld = true
+		case oldEntry == nil || newEntry.Key < oldEntry.Key:
+			result.Added++
+			diff = &DiffEntry{Action: DiffActionAdded, Key: newEntry.Key, New: newEntry}
+			advanceNew = true
+		default:
+			if fields := changedFields(oldEntry, newEntry); len(fields) > 0 {
+				result.Modified++
+				diff = &DiffEntry{Action: DiffActionModified, Key: newEntry.Key, Fields: fields, Old: oldEntry, New: newEntry}
+			} else {
+				result.Unchanged++
+			}
+			advanceOld, advanceNew = true, true
+		}
+
+		if diff != nil {
+			if err = handler(diff); err != nil {
+				return
+			}
+		}
+		if advanceOld {
+			if oldEntry, err = oldReader.Next(); err != nil {
+				return
+			}
+		}
+		if advanceNew {
+			if newEntry, err = newReader.Next(); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+func changedFields(oldEntry, newEntry *Entry) []string {
+	var fields []string
+	if oldEntry.Hash != newEntry.Hash {
+		fields = append(fields, "hash")
+	}
+	if oldEntry.Fsize != newEntry.Fsize {
+		fields = append(fields, "fsize")
+	}
+	if oldEntry.PutTime != newEntry.PutTime {
+		fields = append(fields, "put_time")
+	}
+	if oldEntry.Type != newEntry.Type {
+		fields = append(fields, "type")
+	}
+	return fields
+}
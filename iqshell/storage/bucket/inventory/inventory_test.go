@@ -0,0 +1,139 @@
+package inventory
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+func createSnapshot(t *testing.T, filePath string, entries []Entry) {
+	w, err := NewWriter(filePath, Header{Bucket: "bucket"})
+	if err != nil {
+		t.Fatal("create writer error:", err)
+	}
+	for _, entry := range entries {
+		if err = w.Add(entry); err != nil {
+			t.Fatal("add entry error:", err)
+		}
+	}
+	count, err := w.Close()
+	if err != nil {
+		t.Fatal("close writer error:", err)
+	}
+	if _, e := os.Stat(filePath + ".tmp.db"); !os.IsNotExist(e) {
+		t.Fatal("temp db should be removed")
+	}
+	if _, e := os.Stat(filePath + ".tmp"); !os.IsNotExist(e) {
+		t.Fatal("temp snapshot should be renamed")
+	}
+	if count != int64(len(entries)) {
+		t.Fatalf("snapshot count:%d should be:%d", count, len(entries))
+	}
+}
+
+func openSnapshot(t *testing.T, filePath string) *Reader {
+	file, e := os.Open(filePath)
+	if e != nil {
+		t.Fatal("open snapshot error:", e)
+	}
+	t.Cleanup(func() {
+		_ = file.Close()
+	})
+	reader, err := NewReader(file)
+	if err != nil {
+		t.Fatal("create reader error:", err)
+	}
+	return reader
+}
+
+func TestSnapshotAndDiff(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "old.inventory")
+	newPath := filepath.Join(dir, "new.inventory")
+
+	// 乱序添加，快照中按 key 排序
+	createSnapshot(t, oldPath, []Entry{
+		{Key: "c", Hash: "h-c", Fsize: 3, PutTime: 30},
+		{Key: "a", Hash: "h-a", Fsize: 1, PutTime: 10},
+		{Key: "b", Hash: "h-b", Fsize: 2, PutTime: 20},
+		{Key: "d", Hash: "h-d", Fsize: 4, PutTime: 40},
+	})
+	createSnapshot(t, newPath, []Entry{
+		{Key: "e", Hash: "h-e", Fsize: 5, PutTime: 50},
+		{Key: "b", Hash: "h-b2", Fsize: 22, PutTime: 21},
+		{Key: "a", Hash: "h-a", Fsize: 1, PutTime: 10},
+		{Key: "d", Hash: "h-d", Fsize: 4, PutTime: 40, Type: 1},
+	})
+
+	oldReader := openSnapshot(t, oldPath)
+	if oldReader.Header.Bucket != "bucket" || oldReader.Header.Count != 4 {
+		t.Fatalf("snapshot header error:%+v", oldReader.Header)
+	}
+
+	var diffs []string
+	result, err := Diff(oldReader, openSnapshot(t, newPath), func(entry *DiffEntry) *data.CodeError {
+		diffs = append(diffs, entry.Action+":"+entry.Key+":"+strings.Join(entry.Fields, ","))
+		return nil
+	})
+	if err != nil {
+		t.Fatal("diff error:", err)
+	}
+	expect := []string{
+		"modified:b:hash,fsize,put_time",
+		"removed:c:",
+		"modified:d:type",
+		"added:e:",
+	}
+	if strings.Join(diffs, "|") != strings.Join(expect, "|") {
+		t.Fatalf("diff:%v should be:%v", diffs, expect)
+	}
+	if result.Added != 1 || result.Removed != 1 || result.Modified != 2 || result.Unchanged != 1 {
+		t.Fatalf("diff result error:%+v", result)
+	}
+}
+
+func TestSnapshotKeepPreviousOnDiscard(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "snapshot.jsonl")
+	createSnapshot(t, filePath, []Entry{{Key: "a", Hash: "h1"}})
+
+	// 未写完的快照不会覆盖之前的快照
+	w, err := NewWriter(filePath, Header{Bucket: "bucket"})
+	if err != nil {
+		t.Fatal("create writer error:", err)
+	}
+	_ = w.Add(Entry{Key: "b", Hash: "h2"})
+	w.Discard()
+
+	reader := openSnapshot(t, filePath)
+	if reader.Header.Count != 1 {
+		t.Fatalf("previous snapshot should be kept, count:%d", reader.Header.Count)
+	}
+
+	createSnapshot(t, filePath, []Entry{{Key: "a", Hash: "h1"}, {Key: "b", Hash: "h2"}})
+	if reader = openSnapshot(t, filePath); reader.Header.Count != 2 {
+		t.Fatalf("snapshot should be replaced, count:%d", reader.Header.Count)
+	}
+}
+
+func TestReaderError(t *testing.T) {
+	if _, err := NewReader(strings.NewReader("a\t1\n")); err == nil {
+		t.Fatal("reader should error when header is invalid")
+	}
+
+	reader, err := NewReader(strings.NewReader(`{"inventory":"qshell-inventory","version":1}
+{"key":"b"}
+{"key":"a"}
+`))
+	if err != nil {
+		t.Fatal("create reader error:", err)
+	}
+	if _, err = reader.Next(); err != nil {
+		t.Fatal("read entry error:", err)
+	}
+	if _, err = reader.Next(); err == nil {
+		t.Fatal("reader should error when snapshot is not sorted")
+	}
+}
@@ -0,0 +1,200 @@
+package inventory
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+const (
+	headerInventory = "qshell-inventory"
+	headerVersion   = 1
+
+	maxLineSize = 64 * 1024 * 1024
+)
+
+// Header 快照文件的第一行，描述快照的来源
+type Header struct {
+	Inventory  string `json:"inventory"`        // 固定为 qshell-inventory
+	Version    int    `json:"version"`          // 快照格式版本
+	Bucket     string `json:"bucket"`           // 空间名
+	Prefix     string `json:"prefix,omitempty"` // 列举的前缀
+	Source     string `json:"source,omitempty"` // 快照的来源，为空时表示列举空间，否则为导入的列举结果文件
+	CreateTime string `json:"create_time"`      // 快照创建时间
+	Count      int64  `json:"count"`            // 快照中文件的数量
+}
+
+// Entry 快照中的一个文件，快照中的文件按 Key 的字节序升序排列
+type Entry struct {
+	Key     string `json:"key"`
+	Hash    string `json:"hash"`
+	Fsize   int64  `json:"fsize"`
+	PutTime int64  `json:"put_time"` // 单位：100ns
+	Type    int    `json:"type"`
+}
+
+// Writer 创建快照；文件可以以任意顺序添加，添加时暂存于临时的 LevelDB 中，Close 时按 Key 排序后写入快照文件
+type Writer struct {
+	header   Header
+	filePath string
+	dbPath   string
+	db       *leveldb.DB
+}
+
+// NewWriter 创建快照 Writer，快照保存在 filePath，filePath 已存在时会被覆盖
+func NewWriter(filePath string, header Header) (*Writer, *data.CodeError) {
+	dbPath := filePath + ".tmp.db"
+	if e := os.RemoveAll(dbPath); e != nil {
+		return nil, data.NewEmptyError().AppendDescF("inventory: remove temp db:%s", dbPath).AppendError(e)
+	}
+	db, e := leveldb.OpenFile(dbPath, nil)
+	if e != nil {
+		return nil, data.NewEmptyError().AppendDescF("inventory: open temp db:%s", dbPath).AppendError(e)
+	}
+
+	header.Inventory = headerInventory
+	header.Version = headerVersion
+	return &Writer{
+		header:   header,
+		filePath: filePath,
+		dbPath:   dbPath,
+		db:       db,
+	}, nil
+}
+
+// Add 添加文件，Key 相同时后添加的文件会覆盖之前的文件
+func (w *Writer) Add(entry Entry) *data.CodeError {
+	value, e := json.Marshal(entry)
+	if e != nil {
+		return data.NewEmptyError().AppendDescF("inventory: marshal entry:%s", entry.Key).AppendError(e)
+	}
+	if e = w.db.Put([]byte(entry.Key), value, nil); e != nil {
+		return data.NewEmptyError().AppendDescF("inventory: put entry:%s", entry.Key).AppendError(e)
+	}
+	return nil
+}
+
+// Close 将暂存的文件排序后写入快照文件，并删除临时的 LevelDB；
+// 先写入临时文件，完成后再替换快照文件，写入中断时不会破坏之前的快照
+func (w *Writer) Close() (count int64, err *data.CodeError) {
+	defer w.Discard()
+
+	iter := w.db.NewIterator(nil, nil)
+	for iter.Next() {
+		count++
+	}
+	iter.Release()
+	if e := iter.Error(); e != nil {
+		return 0, data.NewEmptyError().AppendDesc("inventory: count entries").AppendError(e)
+	}
+
+	tempPath := w.filePath + ".tmp"
+	file, e := os.Create(tempPath)
+	if e != nil {
+		return 0, data.NewEmptyError().AppendDescF("inventory: create snapshot:%s", tempPath).AppendError(e)
+	}
+	err = w.write(file, count)
+	if e = file.Close(); e != nil && err == nil {
+		err = data.NewEmptyError().AppendDescF("inventory: close snapshot:%s", tempPath).AppendError(e)
+	}
+	if err == nil {
+		if e = os.Rename(tempPath, w.filePath); e != nil {
+			err = data.NewEmptyError().AppendDescF("inventory: rename snapshot:%s to %s", tempPath, w.filePath).AppendError(e)
+		}
+	}
+	if err != nil {
+		_ = os.Remove(tempPath)
+		return 0, err
+	}
+	return count, nil
+}
+
+func (w *Writer) write(file *os.File, count int64) *data.CodeError {
+	writer := bufio.NewWriter(file)
+	w.header.Count = count
+	header, _ := json.Marshal(w.header)
+	_, _ = writer.Write(header)
+	_ = writer.WriteByte('\n')
+
+	iter := w.db.NewIterator(nil, nil)
+	defer iter.Release()
+	for iter.Next() {
+		_, _ = writer.Write(iter.Value())
+		if e := writer.WriteByte('\n'); e != nil {
+			return data.NewEmptyError().AppendDescF("inventory: write snapshot:%s", file.Name()).AppendError(e)
+		}
+	}
+	if e := iter.Error(); e != nil {
+		return data.NewEmptyError().AppendDesc("inventory: read entries").AppendError(e)
+	}
+	if e := writer.Flush(); e != nil {
+		return data.NewEmptyError().AppendDescF("inventory: write snapshot:%s", file.Name()).AppendError(e)
+	}
+	return nil
+}
+
+// Discard 放弃创建快照，删除临时的 LevelDB
+func (w *Writer) Discard() {
+	if w.db == nil {
+		return
+	}
+	_ = w.db.Close()
+	w.db = nil
+	_ = os.RemoveAll(w.dbPath)
+}
+
+// Reader 按顺序读取快照中的文件
+type Reader struct {
+	Header  Header
+	scanner *bufio.Scanner
+	line    int
+	lastKey string
+}
+
+// NewReader 读取快照的 Header 并创建 Reader
+func NewReader(r io.Reader) (*Reader, *data.CodeError) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	reader := &Reader{
+		scanner: scanner,
+	}
+	if !scanner.Scan() {
+		if e := scanner.Err(); e != nil {
+			return nil, data.NewEmptyError().AppendDesc("inventory: read header").AppendError(e)
+		}
+		return nil, data.NewEmptyError().AppendDesc("inventory: empty snapshot")
+	}
+	reader.line++
+	if e := json.Unmarshal(scanner.Bytes(), &reader.Header); e != nil || reader.Header.Inventory != headerInventory {
+		return nil, data.NewEmptyError().AppendDesc("inventory: not a snapshot file, header invalid")
+	}
+	if reader.Header.Version > headerVersion {
+		return nil, data.NewEmptyError().AppendDescF("inventory: snapshot version:%d not support", reader.Header.Version)
+	}
+	return reader, nil
+}
+
+// Next 读取下一个文件，读取结束时返回 nil, nil
+func (r *Reader) Next() (*Entry, *data.CodeError) {
+	if !r.scanner.Scan() {
+		if e := r.scanner.Err(); e != nil {
+			return nil, data.NewEmptyError().AppendDescF("inventory: read line:%d", r.line+1).AppendError(e)
+		}
+		return nil, nil
+	}
+	r.line++
+
+	entry := &Entry{}
+	if e := json.Unmarshal(r.scanner.Bytes(), entry); e != nil {
+		return nil, data.NewEmptyError().AppendDescF("inventory: parse line:%d", r.line).AppendError(e)
+	}
+	if r.line > 2 && entry.Key <= r.lastKey {
+		return nil, data.NewEmptyError().AppendDescF("inventory: snapshot is not sorted at line:%d, key:%s", r.line, entry.Key)
+	}
+	r.lastKey = entry.Key
+	return entry, nil
+}
@@ -10,36 +10,44 @@ import (
 	"sync"
 	"time"
 
+	"github.com/qiniu/go-sdk/v7/storage"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/account"
 	"github.com/qiniu/qshell/v2/iqshell/common/alert"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/file"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
 	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
 	"github.com/qiniu/qshell/v2/iqshell/storage/bucket/internal/list"
 )
 
 type ListApiInfo struct {
-	Bucket             string    // 空间名	【必选】
-	Prefix             string    // 前缀
-	Marker             string    // 标记
-	Delimiter          string    //
-	StartTime          time.Time // list item 的 put time 区间的开始时间 【闭区间】
-	EndTime            time.Time // list item 的 put time 区间的终止时间 【闭区间】
-	Suffixes           []string  // list item 必须包含后缀
-	FileTypes          []int     // list item 存储类型，多个使用逗号隔开， 0:普通存储 1:低频存储 2:归档存储 3:深度归档存储 4:归档直读存储
-	MimeTypes          []string  // list item Mimetype类型，多个使用逗号隔开
-	MinFileSize        int64     // 文件最小值，单位: B
-	MaxFileSize        int64     // 文件最大值，单位: B
-	MaxRetry           int       // -1: 无限重试
-	ShowFields         []string  // 需要展示的字段  【必选】
-	ApiVersion         string    // list api 版本，v1 / v2【可选】
-	V1Limit            int       // 每次请求 size ，list v1 特有
-	OutputLimit        int       // 最大输出条数，默认：-1, 无限输出
-	OutputFieldsSep    string    // 输出信息，每行的分隔符 【必选】
-	OutputFileMaxLines int64     // 输出文件的最大行数，超过则自动创建新的文件，0：不限制输出文件的行数 【可选】
-	OutputFileMaxSize  int64     // 输出文件的最大 Size，超过则自动创建新的文件，0：不限制输出文件的大小 【可选】
-	EnableRecord       bool      // 是否开启 record 记录，开启后会记录 list 信息，下次 list 会自动指定 Marker 继续 list 【可选】
-	CacheDir           string    // 历史数据存储路径 【内部使用】
+	Bucket             string            // 空间名	【必选】
+	Prefix             string            // 前缀
+	Marker             string            // 标记
+	Delimiter          string            //
+	StartTime          time.Time         // list item 的 put time 区间的开始时间 【闭区间】
+	EndTime            time.Time         // list item 的 put time 区间的终止时间 【闭区间】
+	Suffixes           []string          // list item 必须包含后缀
+	FileTypes          []int             // list item 存储类型，多个使用逗号隔开， 0:普通存储 1:低频存储 2:归档存储 3:深度归档存储 4:归档直读存储
+	MimeTypes          []string          // list item Mimetype类型，多个使用逗号隔开
+	MinFileSize        int64             // 文件最小值，单位: B
+	MaxFileSize        int64             // 文件最大值，单位: B
+	Filter             *ListObjectFilter // 过滤表达式，参考 NewListObjectFilter 【可选】
+	MaxRetry           int               // -1: 无限重试
+	ShowFields         []string          // 需要展示的字段  【必选】
+	ApiVersion         string            // list api 版本，v1 / v2【可选】
+	V1Limit            int               // 每次请求 size ，list v1 特有
+	OutputLimit        int               // 最大输出条数，默认：-1, 无限输出
+	OutputFieldsSep    string            // 输出信息，每行的分隔符 【必选】
+	OutputFileMaxLines int64             // 输出文件的最大行数，超过则自动创建新的文件，0：不限制输出文件的行数 【可选】
+	OutputFileMaxSize  int64             // 输出文件的最大 Size，超过则自动创建新的文件，0：不限制输出文件的大小 【可选】
+	EnableRecord       bool              // 是否开启 record 记录，开启后会记录 list 信息，下次 list 会自动指定 Marker 继续 list 【可选】
+	ShardConcurrency   int               // 分片列举的并发数，> 1 时开启分片列举：通过 ShardDelimiter 发现前缀分片后并发列举各分片，输出不再按 key 排序 【可选】
+	ShardDelimiter     string            // 分片列举时发现前缀分片使用的分隔符，默认：/ 【可选】
+	Account            *account.Account  // 列举使用的账户，为空时使用当前账户 【可选】
+	CacheDir           string            // 历史数据存储路径 【内部使用】
 }
 
 func (l *ListApiInfo) init() {
@@ -78,10 +86,14 @@ func List(info ListApiInfo,
 		log.Warning("list bucket: not set error handler")
 	}
 
-	bucketManager, err := GetBucketManager()
-	if err != nil {
+	var bucketManager *storage.BucketManager
+	if info.Account != nil {
+		bucketManager = GetBucketManagerWithAccount(*info.Account)
+	} else if m, err := GetBucketManager(); err != nil {
 		errorHandler("", err)
 		return
+	} else {
+		bucketManager = m
 	}
 
 	info.init()
@@ -120,6 +132,11 @@ func List(info ListApiInfo,
 			return false
 		}
 
+		if info.Filter != nil && !info.Filter.Match(ListObject(listItem)) {
+			log.DebugF("filter %s: key not match, filter:%s", listItem.Key, info.Filter)
+			return false
+		}
+
 		return true
 	}
 
@@ -137,29 +154,148 @@ func List(info ListApiInfo,
 	if err != nil {
 		log.Debug(err)
 	}
+	if cacheInfoP == nil {
+		cacheInfoP = &cacheInfo{}
+	}
+
+	l := &lister{
+		bucketManager:  bucketManager,
+		info:           &info,
+		isItemExcepted: isItemExcepted,
+		objectHandler:  objectHandler,
+		errorHandler:   errorHandler,
+	}
+	l.list(cache, cacheInfoP)
+
+	log.Debug("list bucket end")
+
+	listWaiter.Done()
+}
+
+// lister 执行列举，列举结果的处理（objectHandler、errorHandler）是串行的
+type lister struct {
+	bucketManager  *storage.BucketManager
+	info           *ListApiInfo
+	isItemExcepted func(listItem list.Item) bool
+	objectHandler  func(marker string, object ListObject) (shouldContinue bool, err *data.CodeError)
+	errorHandler   func(marker string, err *data.CodeError)
+
+	mu          sync.Mutex
+	outputCount int
+	complete    bool
+}
+
+// list 根据参数选择分片列举或顺序列举；列举记录的列举方式与本次不同时不继续列举，
+// 否则按本次的方式从记录的位置继续列举会导致重复输出
+func (l *lister) list(cache *listCache, cacheInfoP *cacheInfo) {
+	info := l.info
+	shard := info.ShardConcurrency > 1 && len(info.Delimiter) == 0 && len(info.Marker) == 0
+	if !shard && info.ShardConcurrency > 1 {
+		log.Warning("list bucket: shard listing is disabled because delimiter or marker is set")
+	}
+
+	if shard && len(cacheInfoP.Shards) == 0 && len(cacheInfoP.Marker) > 0 {
+		l.handleError(cacheInfoP.Marker, data.NewEmptyError().AppendDescF("list bucket: the record %s is created by sequential listing, "+
+			"please resume without shard concurrency, or remove the record to list again with sharding", cache.cachePath))
+		return
+	}
+	if !shard && len(cacheInfoP.Shards) > 0 {
+		l.handleError("", data.NewEmptyError().AppendDescF("list bucket: the record %s is created by shard listing, "+
+			"please resume with shard concurrency and without marker or delimiter, or remove the record to list again", cache.cachePath))
+		return
+	}
+
+	if shard {
+		l.listShards(cache, cacheInfoP)
+	} else {
+		l.listSequential(cache, cacheInfoP)
+	}
+}
+
+func (l *lister) isComplete() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.complete
+}
+
+func (l *lister) handleError(marker string, err *data.CodeError) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.errorHandler(marker, err)
+}
+
+// handleItem 处理列举的文件，返回是否停止列举
+func (l *lister) handleItem(marker string, listItem list.Item) (stop bool) {
+	if listItem.IsNull() || !l.isItemExcepted(listItem) {
+		return false
+	}
 
-	if cacheInfoP != nil && len(cacheInfoP.Marker) > 0 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.complete {
+		return true
+	}
+
+	shouldContinue, hErr := l.objectHandler(marker, listItem)
+	if hErr != nil {
+		l.errorHandler(marker, hErr)
+	}
+	if !shouldContinue {
+		l.complete = true
+		return true
+	}
+
+	l.outputCount++
+	if l.info.OutputLimit > 0 && l.outputCount >= l.info.OutputLimit {
+		l.complete = true
+		return true
+	}
+	return false
+}
+
+func (l *lister) listSequential(cache *listCache, cacheInfoP *cacheInfo) {
+	info := l.info
+	if len(cacheInfoP.Marker) > 0 {
 		if len(info.Marker) == 0 {
 			info.Marker = cacheInfoP.Marker
 		}
+		log.InfoF("use marker:%s", cacheInfoP.Marker)
 	}
 
-	if cacheInfoP == nil {
-		cacheInfoP = &cacheInfo{}
-	} else if len(cacheInfoP.Marker) > 0 {
-		log.InfoF("use marker:%s", cacheInfoP.Marker)
+	marker, lErr := l.listPrefix(info.Prefix, info.Delimiter, info.Marker, func(marker string) {
+		// 保存信息
+		cacheInfoP.Bucket = info.Bucket
+		cacheInfoP.Prefix = info.Prefix
+		cacheInfoP.Marker = marker
+		_ = cache.saveCache(cacheInfoP)
+	})
+	info.Marker = marker
+
+	if lErr == nil && len(info.Marker) == 0 && info.EnableRecord {
+		if rErr := cache.removeCache(); rErr != nil {
+			log.ErrorF("list remove cache status error: %v", rErr)
+		} else {
+			log.InfoF("list complete, remove cache status: %s", cache.cachePath)
+		}
+	} else {
+		log.InfoF("Marker: %s", info.Marker)
 	}
+}
 
+// listPrefix 从 marker 开始列举 prefix 下的文件直到列举结束，出错时按 MaxRetry 重试；每次请求后通过 onMarker 回调最新的 marker，
+// 返回最后的 marker，列举结束时 marker 为空
+func (l *lister) listPrefix(prefix, delimiter, marker string, onMarker func(marker string)) (string, *data.CodeError) {
+	info := l.info
 	retryCount := 0
-	outputCount := 0
-	complete := false
 	var lErr *data.CodeError = nil
-	for !complete && (info.MaxRetry < 0 || retryCount <= info.MaxRetry) {
+	for !l.isComplete() && (info.MaxRetry < 0 || retryCount <= info.MaxRetry) {
 		lErr = nil
 		var hasMore = false
 		limit := info.V1Limit
 		if info.OutputLimit > 0 {
-			limit = info.OutputLimit - outputCount
+			l.mu.Lock()
+			limit = info.OutputLimit - l.outputCount
+			l.mu.Unlock()
 			if limit > info.V1Limit {
 				limit = info.V1Limit
 			}
@@ -167,51 +303,28 @@ func List(info ListApiInfo,
 
 		if !workspace.IsCmdInterrupt() {
 			hasMore, lErr = list.ListBucket(workspace.GetContext(), list.ApiInfo{
-				Manager:    bucketManager,
+				Manager:    l.bucketManager,
 				ApiVersion: list.ApiVersion(info.ApiVersion),
 				Bucket:     info.Bucket,
-				Prefix:     info.Prefix,
-				Delimiter:  info.Delimiter,
-				Marker:     info.Marker,
+				Prefix:     prefix,
+				Delimiter:  delimiter,
+				Marker:     marker,
 				V1Limit:    limit,
-			}, func(marker string, dir string, listItem list.Item) (stop bool) {
-				if marker != info.Marker {
-					info.Marker = marker
-				}
-
-				if listItem.IsNull() {
-					return false
-				}
-
-				if !isItemExcepted(listItem) {
-					return false
-				}
-
-				shouldContinue, hErr := objectHandler(marker, listItem)
-				if hErr != nil {
-					errorHandler(marker, hErr)
-				}
-				if !shouldContinue {
-					complete = true
-					return true
-				}
-
-				outputCount++
-				if info.OutputLimit > 0 && outputCount >= info.OutputLimit {
-					complete = true
-					return true
+				OnMarker: func(m string) {
+					if len(m) > 0 {
+						marker = m
+					}
+				},
+			}, func(m string, dir string, listItem list.Item) (stop bool) {
+				if m != marker {
+					marker = m
 				}
-
-				return false
+				return l.handleItem(m, listItem)
 			})
 		}
 
-		// 保存信息
-		if len(info.Marker) > 0 {
-			cacheInfoP.Bucket = info.Bucket
-			cacheInfoP.Prefix = info.Prefix
-			cacheInfoP.Marker = info.Marker
-			_ = cache.saveCache(cacheInfoP)
+		if len(marker) > 0 && onMarker != nil {
+			onMarker(marker)
 		}
 
 		if workspace.IsCmdInterrupt() && lErr == nil {
@@ -219,7 +332,7 @@ func List(info ListApiInfo,
 		}
 
 		if lErr != nil || workspace.IsCmdInterrupt() {
-			errorHandler(info.Marker, lErr)
+			l.handleError(marker, lErr)
 
 			if workspace.IsCmdInterrupt() || // 取消
 				lErr.Code >= 300 && lErr.Code < 500 || // Bad Request
@@ -237,26 +350,19 @@ func List(info ListApiInfo,
 			continue
 		}
 
-		if !hasMore || complete || workspace.IsCmdInterrupt() {
+		if !hasMore {
+			// 列举结束
+			marker = ""
 			break
 		}
 
-		retryCount = 0
-	}
-
-	if lErr == nil && len(info.Marker) == 0 && info.EnableRecord {
-		if rErr := cache.removeCache(); rErr != nil {
-			log.ErrorF("list remove cache status error: %v", rErr)
-		} else {
-			log.InfoF("list complete, remove cache status: %s", cache.cachePath)
+		if l.isComplete() || workspace.IsCmdInterrupt() {
+			break
 		}
-	} else {
-		log.InfoF("Marker: %s", info.Marker)
-	}
 
-	log.Debug("list bucket end")
-
-	listWaiter.Done()
+		retryCount = 0
+	}
+	return marker, lErr
 }
 
 type ListToFileApiInfo struct {
@@ -287,14 +393,24 @@ func ListToFile(info ListToFileApiInfo, errorHandler func(marker string, err *da
 	// 文件头
 	title := strings.Join(info.ShowFields, info.OutputFieldsSep)
 
-	var output io.WriteCloser
+	// 结构化输出时，列举结果以 Record 形式输出至标准输出
+	if info.FilePath == "" && output.IsStructured() {
+		log.Debug("prepare list bucket to structured output")
+		List(info.ListApiInfo, func(marker string, object ListObject) (bool, *data.CodeError) {
+			output.Result(object)
+			return true, nil
+		}, errorHandler)
+		return
+	}
+
+	var writer io.WriteCloser
 	if info.FilePath == "" {
-		output = data.Stdout()
-		_, _ = output.Write([]byte(title + "\n"))
+		writer = data.Stdout()
+		_, _ = writer.Write([]byte(title + "\n"))
 		log.Debug("prepare list bucket to stdout")
 	} else {
 		var nErr *data.CodeError
-		output, nErr = file.NewRotateFile(info.FilePath,
+		writer, nErr = file.NewRotateFile(info.FilePath,
 			file.RotateOptionMaxSize(info.OutputFileMaxSize),
 			file.RotateOptionMaxLine(info.OutputFileMaxLines),
 			file.RotateOptionAppendMode(info.AppendMode),
@@ -307,11 +423,11 @@ func ListToFile(info ListToFileApiInfo, errorHandler func(marker string, err *da
 			errorHandler("", data.NewEmptyError().AppendDescF("failed to create rotate file:`%s`, error:%v", info.FilePath, nErr))
 			return
 		}
-		defer output.Close()
+		defer writer.Close()
 		log.Debug("prepare list bucket to file")
 	}
 
-	bWriter := bufio.NewWriter(output)
+	bWriter := bufio.NewWriter(writer)
 	lineCreator := &ListLineCreator{
 		Fields:   info.ShowFields,
 		Sep:      info.OutputFieldsSep,
@@ -7,9 +7,18 @@ import (
 )
 
 type cacheInfo struct {
-	Bucket string `json:"bucket"`
-	Prefix string `json:"prefix"`
-	Marker string `json:"marker"`
+	Bucket string       `json:"bucket"`
+	Prefix string       `json:"prefix"`
+	Marker string       `json:"marker"`
+	Shards []*listShard `json:"shards,omitempty"` // 分片列举时各分片的列举状态
+}
+
+// listShard 分片列举的一个分片
+type listShard struct {
+	Prefix    string `json:"prefix"`              // 分片的前缀
+	Delimiter string `json:"delimiter,omitempty"` // 不为空时仅列举 Prefix 下一级的文件，不包含公共前缀下的文件
+	Marker    string `json:"marker,omitempty"`    // 分片列举的位置
+	Done      bool   `json:"done,omitempty"`      // 分片是否列举完成
 }
 
 type listCache struct {
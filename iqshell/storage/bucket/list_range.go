@@ -0,0 +1,78 @@
+package bucket
+
+import (
+	"bufio"
+	"os"
+	"strings"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+)
+
+// RangeListFile 遍历 listbucket2 输出文件中的文件，跳过表头、空行及 Key 为空的行；handler 返回错误时停止遍历
+func RangeListFile(filePath, itemSeparate string, handler func(object ListObject) *data.CodeError) *data.CodeError {
+	file, e := os.Open(filePath)
+	if e != nil {
+		return data.NewEmptyError().AppendDescF("open list file:%s", filePath).AppendError(e)
+	}
+	defer file.Close()
+
+	lineParser := NewListLineParser()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		if workspace.IsCmdInterrupt() {
+			return nil
+		}
+
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(line) == 0 {
+			continue
+		}
+		object, err := lineParser.Parse(strings.Split(line, itemSeparate))
+		if err != nil {
+			if err.Code == data.ErrorCodeLineHeader {
+				continue
+			}
+			return data.NewEmptyError().AppendDescF("parse list file line:%d", lineNumber).AppendError(err)
+		}
+		if len(object.Key) == 0 {
+			continue
+		}
+		if err = handler(*object); err != nil {
+			return err
+		}
+	}
+	if e = scanner.Err(); e != nil {
+		return data.NewEmptyError().AppendDescF("read list file:%s", filePath).AppendError(e)
+	}
+	return nil
+}
+
+// RangeObjects 遍历空间中的文件：fromListFile 不为空时读取 listbucket2 的输出文件，否则列举空间；
+// 列举最终失败或被中断时返回错误，handler 返回错误时停止遍历
+func RangeObjects(info ListApiInfo, fromListFile, itemSeparate string, handler func(object ListObject) *data.CodeError) (err *data.CodeError) {
+	if len(fromListFile) > 0 {
+		err = RangeListFile(fromListFile, itemSeparate, handler)
+	} else {
+		List(info, func(marker string, object ListObject) (bool, *data.CodeError) {
+			// 重试成功后会继续列举，只保留最后一次的错误
+			err = nil
+			if e := handler(object); e != nil {
+				err = e
+				return false, e
+			}
+			return true, nil
+		}, func(marker string, e *data.CodeError) {
+			log.WarningF("list bucket:%s marker:%s error:%v", info.Bucket, marker, e)
+			err = e
+		})
+	}
+	if err == nil && workspace.IsCmdInterrupt() {
+		err = data.NewEmptyError().AppendDesc("interrupted")
+	}
+	return err
+}
@@ -0,0 +1,173 @@
+package bucket
+
+import (
+	"sync"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+	"github.com/qiniu/qshell/v2/iqshell/storage/bucket/internal/list"
+)
+
+const (
+	defaultShardDelimiter = "/"
+	maxShardDiscoverDepth = 3 // 发现分片时最多展开的前缀层级
+	shardCountPerWorker   = 4 // 每个并发期望的分片数，分片数多于并发数可以使各并发的负载更均衡
+)
+
+// listShards 分片列举：先通过 ShardDelimiter 发现前缀分片，然后并发列举各分片；
+// 每个分片在 listCache 中记录自己的 marker，中断后再次列举时各分片从记录的位置继续
+func (l *lister) listShards(cache *listCache, cacheInfoP *cacheInfo) {
+	info := l.info
+	if len(info.ShardDelimiter) == 0 {
+		info.ShardDelimiter = defaultShardDelimiter
+	}
+
+	// 分片信息的修改及保存需要加锁
+	var cacheLock sync.Mutex
+	saveCache := func() {
+		cacheInfoP.Bucket = info.Bucket
+		cacheInfoP.Prefix = info.Prefix
+		_ = cache.saveCache(cacheInfoP)
+	}
+
+	shards := cacheInfoP.Shards
+	if len(shards) > 0 {
+		log.InfoF("list bucket: resume %d shards from record", len(shards))
+	} else {
+		var err *data.CodeError
+		shards, err = l.discoverShards(info.Prefix, info.ShardDelimiter, info.ShardConcurrency*shardCountPerWorker)
+		if err != nil {
+			l.handleError("", data.NewEmptyError().AppendDesc("discover list shards").AppendError(err))
+			return
+		}
+		cacheInfoP.Shards = shards
+		saveCache()
+	}
+
+	shardChan := make(chan *listShard, len(shards))
+	for _, shard := range shards {
+		if !shard.Done {
+			shardChan <- shard
+		}
+	}
+	close(shardChan)
+	log.InfoF("list bucket: %d shards, %d to list, concurrency:%d", len(shards), len(shardChan), info.ShardConcurrency)
+
+	wait := sync.WaitGroup{}
+	for i := 0; i < info.ShardConcurrency; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for shard := range shardChan {
+				if l.isComplete() || workspace.IsCmdInterrupt() {
+					continue
+				}
+
+				cacheLock.Lock()
+				marker := shard.Marker
+				cacheLock.Unlock()
+
+				log.DebugF("list bucket: start shard prefix:%s delimiter:%s marker:%s", shard.Prefix, shard.Delimiter, marker)
+				marker, err := l.listPrefix(shard.Prefix, shard.Delimiter, marker, func(marker string) {
+					cacheLock.Lock()
+					shard.Marker = marker
+					saveCache()
+					cacheLock.Unlock()
+				})
+
+				cacheLock.Lock()
+				shard.Marker = marker
+				shard.Done = err == nil && len(marker) == 0
+				saveCache()
+				cacheLock.Unlock()
+				log.DebugF("list bucket: end shard prefix:%s delimiter:%s done:%v", shard.Prefix, shard.Delimiter, shard.Done)
+			}
+		}()
+	}
+	wait.Wait()
+
+	undoneCount := 0
+	for _, shard := range shards {
+		if !shard.Done {
+			undoneCount++
+		}
+	}
+	if undoneCount == 0 && info.EnableRecord {
+		if rErr := cache.removeCache(); rErr != nil {
+			log.ErrorF("list remove cache status error: %v", rErr)
+		} else {
+			log.InfoF("list complete, remove cache status: %s", cache.cachePath)
+		}
+	} else if undoneCount > 0 {
+		log.InfoF("list bucket: %d shards not completed", undoneCount)
+	}
+}
+
+// discoverShards 发现前缀分片：逐层展开公共前缀，直到分片数不少于 target 或达到最大层级；
+// 展开过的前缀只列举其下一级的文件（Delimiter 不为空），未展开的前缀列举其下所有的文件
+func (l *lister) discoverShards(prefix, delimiter string, target int) ([]*listShard, *data.CodeError) {
+	shards := make([]*listShard, 0)
+	pending := []string{prefix}
+	for depth := 0; depth < maxShardDiscoverDepth && len(pending) > 0 && len(pending) < target; depth++ {
+		next := make([]string, 0)
+		for _, p := range pending {
+			commonPrefixes, err := l.listCommonPrefixes(p, delimiter)
+			if err != nil {
+				return nil, err
+			}
+			shards = append(shards, &listShard{
+				Prefix:    p,
+				Delimiter: delimiter,
+			})
+			next = append(next, commonPrefixes...)
+		}
+		pending = next
+		log.DebugF("list bucket: discover shards depth:%d, prefix count:%d", depth, len(pending))
+	}
+
+	for _, p := range pending {
+		shards = append(shards, &listShard{
+			Prefix: p,
+		})
+	}
+	return shards, nil
+}
+
+func (l *lister) listCommonPrefixes(prefix, delimiter string) ([]string, *data.CodeError) {
+	info := l.info
+	prefixes := make([]string, 0)
+	marker := ""
+	retryCount := 0
+	for {
+		if workspace.IsCmdInterrupt() {
+			return nil, data.NewError(0, "list is interrupted")
+		}
+
+		commonPrefixes, nextMarker, hasMore, err := list.ListCommonPrefixes(workspace.GetContext(), list.ApiInfo{
+			Manager:   l.bucketManager,
+			Bucket:    info.Bucket,
+			Prefix:    prefix,
+			Delimiter: delimiter,
+			Marker:    marker,
+			V1Limit:   info.V1Limit,
+		})
+		if err != nil {
+			retryCount++
+			if (info.MaxRetry >= 0 && retryCount > info.MaxRetry) || (err.Code >= 300 && err.Code < 500) {
+				return nil, err
+			}
+			log.DebugF("list bucket: list common prefixes of prefix:%s error:%v, retry:%d", prefix, err, retryCount)
+			time.Sleep(time.Millisecond * 500)
+			continue
+		}
+
+		retryCount = 0
+		prefixes = append(prefixes, commonPrefixes...)
+		marker = nextMarker
+		if !hasMore || len(marker) == 0 {
+			return prefixes, nil
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package bucket
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/qiniu/go-sdk/v7/auth"
+	"github.com/qiniu/go-sdk/v7/storage"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+// newListTestServer 模拟 rsf list v1 接口，按 key 顺序列举 keys
+func newListTestServer(t *testing.T, keys []string) *httptest.Server {
+	keys = append([]string{}, keys...)
+	sort.Strings(keys)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parse list request error:%v", err)
+		}
+		prefix := r.Form.Get("prefix")
+		delimiter := r.Form.Get("delimiter")
+		marker := r.Form.Get("marker")
+		limit, _ := strconv.Atoi(r.Form.Get("limit"))
+		if limit <= 0 {
+			limit = 1000
+		}
+
+		ret := storage.ListFilesRet{
+			Items:          make([]storage.ListItem, 0),
+			CommonPrefixes: make([]string, 0),
+		}
+		count := 0
+		for _, key := range keys {
+			if !strings.HasPrefix(key, prefix) || key <= marker {
+				continue
+			}
+			if len(delimiter) > 0 && strings.HasSuffix(marker, delimiter) && strings.HasPrefix(key, marker) {
+				continue
+			}
+
+			entry := key
+			if len(delimiter) > 0 {
+				if index := strings.Index(key[len(prefix):], delimiter); index >= 0 {
+					entry = key[:len(prefix)+index+len(delimiter)]
+				}
+			}
+			if count > 0 && entry == ret.Marker {
+				continue
+			}
+			if count == limit {
+				// 还有未列举的数据
+				json.NewEncoder(w).Encode(ret)
+				return
+			}
+
+			if entry == key {
+				ret.Items = append(ret.Items, storage.ListItem{Key: key, Fsize: 1})
+			} else {
+				ret.CommonPrefixes = append(ret.CommonPrefixes, entry)
+			}
+			ret.Marker = entry
+			count++
+		}
+		ret.Marker = ""
+		json.NewEncoder(w).Encode(ret)
+	}))
+}
+
+func newTestLister(t *testing.T, keys []string, info *ListApiInfo) (*lister, *[]string, *[]*data.CodeError) {
+	server := newListTestServer(t, keys)
+	t.Cleanup(server.Close)
+
+	info.init()
+	listed := make([]string, 0)
+	errs := make([]*data.CodeError, 0)
+	return &lister{
+		bucketManager: storage.NewBucketManager(auth.New("ak", "sk"), &storage.Config{
+			RsfHost: server.URL,
+		}),
+		info: info,
+		isItemExcepted: func(item ListObject) bool {
+			return true
+		},
+		objectHandler: func(marker string, object ListObject) (bool, *data.CodeError) {
+			listed = append(listed, object.Key)
+			return true, nil
+		},
+		errorHandler: func(marker string, err *data.CodeError) {
+			errs = append(errs, err)
+		},
+	}, &listed, &errs
+}
+
+var listShardTestKeys = []string{
+	"a.txt",
+	"a/1.txt", "a/2.txt", "a/x/1.txt", "a/x/2.txt", "a/y/1.txt",
+	"b/1.txt", "b/2.txt", "b/3.txt",
+	"c/1.txt", "c/z/1.txt",
+	"d.txt",
+}
+
+func TestDiscoverShards(t *testing.T) {
+	l, _, _ := newTestLister(t, listShardTestKeys, &ListApiInfo{Bucket: "bucket", V1Limit: 2})
+
+	shards, err := l.discoverShards("", "/", 2)
+	if err != nil {
+		t.Fatal("discover shards error:", err)
+	}
+	got := make([]string, 0, len(shards))
+	for _, shard := range shards {
+		got = append(got, shard.Prefix+"|"+shard.Delimiter)
+	}
+	want := []string{"|/", "a/|", "b/|", "c/|"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("shards:%v, want:%v", got, want)
+	}
+
+	shards, err = l.discoverShards("", "/", 5)
+	if err != nil {
+		t.Fatal("discover shards error:", err)
+	}
+	got = got[:0]
+	for _, shard := range shards {
+		got = append(got, shard.Prefix+"|"+shard.Delimiter)
+	}
+	want = []string{"|/", "a/|/", "b/|/", "c/|/", "a/x/|/", "a/y/|/", "c/z/|/"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("shards:%v, want:%v", got, want)
+	}
+}
+
+func TestListShards(t *testing.T) {
+	for _, concurrency := range []int{2, 4} {
+		cache := &listCache{
+			enableRecord: true,
+			cachePath:    filepath.Join(t.TempDir(), "info.json"),
+		}
+		l, listed, errs := newTestLister(t, listShardTestKeys, &ListApiInfo{
+			Bucket:           "bucket",
+			V1Limit:          2,
+			EnableRecord:     true,
+			ShardConcurrency: concurrency,
+		})
+		l.list(cache, &cacheInfo{})
+
+		if len(*errs) > 0 {
+			t.Fatalf("concurrency:%d list error:%v", concurrency, (*errs)[0])
+		}
+		sort.Strings(*listed)
+		if strings.Join(*listed, ",") != strings.Join(listShardTestKeys, ",") {
+			t.Fatalf("concurrency:%d listed:%v, want:%v", concurrency, *listed, listShardTestKeys)
+		}
+		if info, _ := cache.loadCache(); len(info.Shards) > 0 {
+			t.Fatalf("concurrency:%d record should be removed after list complete", concurrency)
+		}
+	}
+}
+
+func TestListShardsResume(t *testing.T) {
+	cache := &listCache{
+		enableRecord: true,
+		cachePath:    filepath.Join(t.TempDir(), "info.json"),
+	}
+	l, listed, errs := newTestLister(t, listShardTestKeys, &ListApiInfo{
+		Bucket:           "bucket",
+		V1Limit:          2,
+		EnableRecord:     true,
+		ShardConcurrency: 2,
+	})
+	l.list(cache, &cacheInfo{
+		Bucket: "bucket",
+		Shards: []*listShard{
+			{Prefix: "", Delimiter: "/", Done: true},
+			{Prefix: "a/", Done: true},
+			{Prefix: "b/", Marker: "b/1.txt"},
+			{Prefix: "c/"},
+		},
+	})
+
+	if len(*errs) > 0 {
+		t.Fatal("list error:", (*errs)[0])
+	}
+	sort.Strings(*listed)
+	want := []string{"b/2.txt", "b/3.txt", "c/1.txt", "c/z/1.txt"}
+	if strings.Join(*listed, ",") != strings.Join(want, ",") {
+		t.Fatalf("listed:%v, want:%v", *listed, want)
+	}
+}
+
+func TestListRejectModeSwitch(t *testing.T) {
+	cache := &listCache{
+		enableRecord: true,
+		cachePath:    filepath.Join(t.TempDir(), "info.json"),
+	}
+
+	// 顺序列举的记录不能以分片方式继续
+	l, listed, errs := newTestLister(t, listShardTestKeys, &ListApiInfo{
+		Bucket:           "bucket",
+		EnableRecord:     true,
+		ShardConcurrency: 2,
+	})
+	l.list(cache, &cacheInfo{Bucket: "bucket", Marker: "b/1.txt"})
+	if len(*errs) != 1 || len(*listed) > 0 {
+		t.Fatalf("sequential record should be rejected in shard listing, errors:%v listed:%v", *errs, *listed)
+	}
+
+	// 分片列举的记录不能以顺序方式继续
+	l, listed, errs = newTestLister(t, listShardTestKeys, &ListApiInfo{
+		Bucket:       "bucket",
+		EnableRecord: true,
+	})
+	l.list(cache, &cacheInfo{Bucket: "bucket", Shards: []*listShard{{Prefix: "a/"}}})
+	if len(*errs) != 1 || len(*listed) > 0 {
+		t.Fatalf("shard record should be rejected in sequential listing, errors:%v listed:%v", *errs, *listed)
+	}
+
+	// 顺序列举的记录以顺序方式继续
+	l, listed, errs = newTestLister(t, listShardTestKeys, &ListApiInfo{
+		Bucket:       "bucket",
+		EnableRecord: true,
+	})
+	l.list(cache, &cacheInfo{Bucket: "bucket", Marker: "c/z/1.txt"})
+	if len(*errs) > 0 || strings.Join(*listed, ",") != "d.txt" {
+		t.Fatalf("sequential record should be resumed, errors:%v listed:%v", *errs, *listed)
+	}
+}
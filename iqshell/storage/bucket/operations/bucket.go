@@ -5,9 +5,16 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/common/alert"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
 	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
 )
 
+// bucketInfoRecord 结构化输出时的空间信息
+type bucketInfoRecord struct {
+	Bucket string `json:"bucket"`
+	*bucket.BucketInfo
+}
+
 type GetBucketInfo struct {
 	Bucket string
 }
@@ -31,6 +38,11 @@ func GetBucket(cfg *iqshell.Config, info GetBucketInfo) {
 	}); err != nil {
 		log.ErrorF("get bucket(%s) info error:%v", info.Bucket, err)
 	} else {
+		output.Result(&bucketInfoRecord{
+			Bucket:     info.Bucket,
+			BucketInfo: bucketInfo,
+		})
+
 		desc := ""
 		log.AlertF("%-20s:%s", "Bucket", info.Bucket)
 		log.AlertF("%-20s:%s", "RegionID", bucketInfo.Region)
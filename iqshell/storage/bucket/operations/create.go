@@ -5,6 +5,7 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/common/alert"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
 	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
 )
 
@@ -31,13 +32,15 @@ func Create(cfg *iqshell.Config, info CreateInfo) {
 		return
 	}
 
-	if err := bucket.Create(bucket.CreateApiInfo{
+	apiInfo := bucket.CreateApiInfo{
 		RegionId: info.RegionId,
 		Bucket:   info.Bucket,
 		Private:  info.Private,
-	}); err != nil {
+	}
+	if err := bucket.Create(apiInfo); err != nil {
 		log.ErrorF("bucket:%s create at region:%s error:%v", info.Bucket, info.RegionId, err)
 	} else {
+		output.Result(apiInfo)
 		log.AlertF("bucket:%s create at region:%s success", info.Bucket, info.RegionId)
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/common/alert"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
 	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
 	"os"
 )
@@ -36,6 +37,9 @@ func ListDomains(cfg *iqshell.Config, info ListDomainInfo) {
 		if len(domains) == 0 {
 			log.ErrorF("No domains found for bucket `%s`\n", info.Bucket)
 		} else {
+			for _, domain := range domains {
+				output.Result(domain)
+			}
 			if info.Detail {
 				for _, domain := range domains {
 					log.Alert(domain.DetailDescriptionString())
@@ -0,0 +1,212 @@
+package operations
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
+	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
+	"github.com/qiniu/qshell/v2/iqshell/storage/bucket/inventory"
+)
+
+// Inventory 【inventory】无子命令时仅加载，--doc 时展示文档
+func Inventory(cfg *iqshell.Config) {
+	iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{})
+}
+
+type InventorySnapshotInfo struct {
+	Bucket       string // 空间名 【必选】
+	Prefix       string // 只记录前缀匹配的文件 【可选】
+	FromListFile string // 从 listbucket2 的输出文件导入，不指定时列举空间 【可选】
+	ItemSeparate string // FromListFile 每行中各字段的分隔符，默认：\t 【可选】
+	SaveToFile   string // 快照保存路径 【必选】
+	MaxRetry     int    // 列举出错时的最大重试次数，-1: 无限重试 【可选】
+}
+
+func (info *InventorySnapshotInfo) Check() *data.CodeError {
+	if len(info.Bucket) == 0 {
+		return alert.CannotEmptyError("Bucket", "")
+	}
+	if len(info.SaveToFile) == 0 {
+		return alert.CannotEmptyError("SnapshotFile (-o)", "")
+	}
+	if len(info.ItemSeparate) == 0 {
+		info.ItemSeparate = "\t"
+	}
+	return nil
+}
+
+// InventorySnapshot 创建空间文件快照：列举空间或导入 listbucket2 的输出，按 Key 排序后保存
+func InventorySnapshot(cfg *iqshell.Config, info InventorySnapshotInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	writer, err := inventory.NewWriter(info.SaveToFile, inventory.Header{
+		Bucket:     info.Bucket,
+		Prefix:     info.Prefix,
+		Source:     info.FromListFile,
+		CreateTime: time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("inventory snapshot error:%v", err)
+		return
+	}
+
+	err = bucket.RangeObjects(bucket.ListApiInfo{
+		Bucket:   info.Bucket,
+		Prefix:   info.Prefix,
+		MaxRetry: info.MaxRetry,
+		V1Limit:  1000,
+	}, info.FromListFile, info.ItemSeparate, func(object bucket.ListObject) *data.CodeError {
+		if !strings.HasPrefix(object.Key, info.Prefix) {
+			return nil
+		}
+		return writer.Add(listObjectToInventoryEntry(object))
+	})
+	if err != nil {
+		writer.Discard()
+		data.SetCmdStatusError()
+		log.ErrorF("inventory snapshot error:%v", err)
+		return
+	}
+
+	count, err := writer.Close()
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("inventory snapshot error:%v", err)
+		return
+	}
+	output.Result(map[string]interface{}{
+		"bucket":   info.Bucket,
+		"snapshot": info.SaveToFile,
+		"count":    count,
+	})
+	log.AlertF("Snapshot %d files of bucket:%s to %s", count, info.Bucket, info.SaveToFile)
+}
+
+func listObjectToInventoryEntry(object bucket.ListObject) inventory.Entry {
+	return inventory.Entry{
+		Key:     object.Key,
+		Hash:    object.Hash,
+		Fsize:   object.Fsize,
+		PutTime: object.PutTime,
+		Type:    object.Type,
+	}
+}
+
+type InventoryDiffInfo struct {
+	OldSnapshot string // 旧快照 【必选】
+	NewSnapshot string // 新快照 【必选】
+	SaveToFile  string // 差异保存路径，为空时输出至标准输出 【可选】
+}
+
+func (info *InventoryDiffInfo) Check() *data.CodeError {
+	if len(info.OldSnapshot) == 0 {
+		return alert.CannotEmptyError("SnapshotA", "")
+	}
+	if len(info.NewSnapshot) == 0 {
+		return alert.CannotEmptyError("SnapshotB", "")
+	}
+	return nil
+}
+
+// InventoryDiff 比较两个快照，以 jsonl 格式输出新增、删除及修改的文件
+func InventoryDiff(cfg *iqshell.Config, info InventoryDiffInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	oldReader, oldCloser, err := openInventorySnapshot(info.OldSnapshot)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("inventory diff error:%v", err)
+		return
+	}
+	defer oldCloser.Close()
+
+	newReader, newCloser, err := openInventorySnapshot(info.NewSnapshot)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("inventory diff error:%v", err)
+		return
+	}
+	defer newCloser.Close()
+
+	if oldReader.Header.Bucket != newReader.Header.Bucket || oldReader.Header.Prefix != newReader.Header.Prefix {
+		log.WarningF("inventory diff: snapshots are of different buckets or prefixes, [%s:%s] vs [%s:%s]",
+			oldReader.Header.Bucket, oldReader.Header.Prefix, newReader.Header.Bucket, newReader.Header.Prefix)
+	}
+
+	var out io.Writer = data.Stdout()
+	if len(info.SaveToFile) > 0 {
+		file, e := os.Create(info.SaveToFile)
+		if e != nil {
+			data.SetCmdStatusError()
+			log.ErrorF("inventory diff: create file:%s error:%v", info.SaveToFile, e)
+			return
+		}
+		defer file.Close()
+		out = file
+	}
+	// 结构化输出时，标准输出仅输出 Record，每个差异为一个 Record
+	structured := len(info.SaveToFile) == 0 && output.IsStructured()
+	bufWriter := bufio.NewWriter(out)
+	result, err := inventory.Diff(oldReader, newReader, func(entry *inventory.DiffEntry) *data.CodeError {
+		if structured {
+			output.Result(entry)
+			return nil
+		}
+
+		line, e := json.Marshal(entry)
+		if e != nil {
+			return data.ConvertError(e)
+		}
+		_, _ = bufWriter.Write(line)
+		if e = bufWriter.WriteByte('\n'); e != nil {
+			return data.ConvertError(e)
+		}
+		return nil
+	})
+	if fErr := bufWriter.Flush(); fErr != nil && err == nil {
+		err = data.ConvertError(fErr)
+	}
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("inventory diff error:%v", err)
+		return
+	}
+
+	output.Result(result)
+	if len(info.SaveToFile) > 0 {
+		log.AlertF("Added: %d, Removed: %d, Modified: %d, Unchanged: %d", result.Added, result.Removed, result.Modified, result.Unchanged)
+	} else {
+		log.DebugF("Added: %d, Removed: %d, Modified: %d, Unchanged: %d", result.Added, result.Removed, result.Modified, result.Unchanged)
+	}
+}
+
+func openInventorySnapshot(filePath string) (*inventory.Reader, io.Closer, *data.CodeError) {
+	file, e := os.Open(filePath)
+	if e != nil {
+		return nil, nil, data.NewEmptyError().AppendDescF("open snapshot:%s", filePath).AppendError(e)
+	}
+	reader, err := inventory.NewReader(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, nil, data.NewEmptyError().AppendDescF("snapshot:%s", filePath).AppendError(err)
+	}
+	return reader, file, nil
+}
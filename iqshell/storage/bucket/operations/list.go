@@ -29,6 +29,7 @@ type ListInfo struct {
 	MimeTypes          string // list item Mimetype类型，多个使用逗号隔开 【可选】
 	MinFileSize        string // 文件最小值，单位: B 【可选】
 	MaxFileSize        string // 文件最大值，单位: B 【可选】
+	Filter             string // 过滤表达式，例：key =~ "^logs/.*\.gz$" && size > 1MB 【可选】
 	MaxRetry           int    // -1: 无限重试 【可选】
 	SaveToFile         string // 【可选】
 	AppendMode         bool   // 【可选】
@@ -41,6 +42,10 @@ type ListInfo struct {
 	OutputFileMaxLines int64  // 输出文件的最大行数，超过则自动创建新的文件，0：不限制输出文件的行数 【可选】
 	OutputFileMaxSize  int64  // 输出文件的最大 Size，超过则自动创建新的文件，0：不限制输出文件的大小 【可选】
 	EnableRecord       bool   // 是否开启 record 记录，开启后会记录 list 信息，下次 list 会自动指定 Marker 继续 list 【可选】
+	ShardConcurrency   int    // 分片列举的并发数，> 1 时开启分片列举，输出不再按 key 排序 【可选】
+	ShardDelimiter     string // 分片列举时发现前缀分片使用的分隔符，默认：/ 【可选】
+
+	filter *bucket.ListObjectFilter
 }
 
 func (info *ListInfo) Check() *data.CodeError {
@@ -72,6 +77,12 @@ func (info *ListInfo) Check() *data.CodeError {
 		info.ShowFields = strings.Join(fieldsNew, ",")
 	}
 
+	if f, err := bucket.NewListObjectFilter(info.Filter); err != nil {
+		return err
+	} else {
+		info.filter = f
+	}
+
 	if info.EnableRecord {
 		// 记录模式开启 append
 		info.AppendMode = true
@@ -147,6 +158,7 @@ func List(cfg *iqshell.Config, info ListInfo) {
 			MimeTypes:          info.getMimeTypes(),
 			MinFileSize:        info.getMinFileSize(),
 			MaxFileSize:        info.getMaxFileSize(),
+			Filter:             info.filter,
 			MaxRetry:           info.MaxRetry,
 			ShowFields:         info.getShowFields(),
 			ApiVersion:         info.ApiVersion,
@@ -157,6 +169,8 @@ func List(cfg *iqshell.Config, info ListInfo) {
 			OutputFileMaxSize:  info.OutputFileMaxSize,
 			CacheDir:           cacheDir,
 			EnableRecord:       info.EnableRecord,
+			ShardConcurrency:   info.ShardConcurrency,
+			ShardDelimiter:     info.ShardDelimiter,
 		},
 		FilePath:   info.SaveToFile,
 		AppendMode: info.AppendMode,
@@ -0,0 +1,204 @@
+package operations
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/filter"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
+)
+
+const (
+	StatsFormatTable = "table"
+	StatsFormatJson  = "json"
+	StatsFormatCsv   = "csv"
+)
+
+type StatsInfo struct {
+	Bucket          string // 空间名 【必选】
+	Prefix          string // 只统计前缀匹配的文件 【可选】
+	Filter          string // 过滤表达式，参考 listbucket2 --filter 【可选】
+	FromListFile    string // 从 listbucket2 的输出文件统计，不指定时列举空间 【可选】
+	ItemSeparate    string // FromListFile 每行中各字段的分隔符，默认：\t 【可选】
+	PrefixDepth     int    // 按前缀统计时前缀的层级，0：不按前缀统计 【可选】
+	PrefixDelimiter string // 前缀的分隔符，默认：/ 【可选】
+	Top             int    // 按前缀及 MimeType 统计时展示的最大条数 【可选】
+	SizeBuckets     string // 文件大小直方图的边界，逗号分隔，例：1KB,1MB,1GB 【可选】
+	AgeBuckets      string // 上传时间距今天数直方图的边界，逗号分隔，单位：天，例：30,90,180 【可选】
+	Format          string // 输出格式：table / json / csv 【可选】
+	SaveToFile      string // 报告保存路径，为空时输出至标准输出 【可选】
+	MaxRetry        int    // 列举出错时的最大重试次数，-1: 无限重试 【可选】
+
+	filter      *bucket.ListObjectFilter
+	sizeBuckets []int64
+	ageBuckets  []int
+}
+
+func (info *StatsInfo) Check() *data.CodeError {
+	if len(info.Bucket) == 0 {
+		return alert.CannotEmptyError("Bucket", "")
+	}
+	if len(info.ItemSeparate) == 0 {
+		info.ItemSeparate = "\t"
+	}
+
+	switch info.Format {
+	case "":
+		info.Format = StatsFormatTable
+	case StatsFormatTable, StatsFormatJson, StatsFormatCsv:
+	default:
+		return data.NewEmptyError().AppendDescF("format:%s not support, should be one of %s, %s and %s",
+			info.Format, StatsFormatTable, StatsFormatJson, StatsFormatCsv)
+	}
+
+	if f, err := bucket.NewListObjectFilter(info.Filter); err != nil {
+		return err
+	} else {
+		info.filter = f
+	}
+
+	for _, item := range splitStatsBuckets(info.SizeBuckets) {
+		size, err := filter.ParseNumber(item)
+		if err != nil || size <= 0 {
+			return data.NewEmptyError().AppendDescF("size-buckets value error:%s is not a valid size", item)
+		}
+		info.sizeBuckets = append(info.sizeBuckets, size)
+	}
+	for _, item := range splitStatsBuckets(info.AgeBuckets) {
+		days, e := strconv.Atoi(strings.TrimSuffix(item, "d"))
+		if e != nil || days <= 0 {
+			return data.NewEmptyError().AppendDescF("age-buckets value error:%s is not a valid number of days", item)
+		}
+		info.ageBuckets = append(info.ageBuckets, days)
+	}
+	return nil
+}
+
+func splitStatsBuckets(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Stats 统计空间中文件的数量及大小：按存储类型、MimeType、前缀、文件大小及上传时间分组
+func Stats(cfg *iqshell.Config, info StatsInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	collector := bucket.NewStatsCollector(bucket.StatsConfig{
+		PrefixDepth:     info.PrefixDepth,
+		PrefixDelimiter: info.PrefixDelimiter,
+		Top:             info.Top,
+		SizeBuckets:     info.sizeBuckets,
+		AgeBuckets:      info.ageBuckets,
+	})
+	err := bucket.RangeObjects(bucket.ListApiInfo{
+		Bucket:   info.Bucket,
+		Prefix:   info.Prefix,
+		MaxRetry: info.MaxRetry,
+		V1Limit:  1000,
+	}, info.FromListFile, info.ItemSeparate, func(object bucket.ListObject) *data.CodeError {
+		if strings.HasPrefix(object.Key, info.Prefix) && info.filter.Match(object) {
+			collector.Add(object)
+		}
+		return nil
+	})
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("bucket stats error:%v", err)
+		return
+	}
+
+	report := collector.Report(info.Bucket, info.Prefix)
+	output.Result(report)
+
+	// 结构化输出时，标准输出仅输出 Record
+	if len(info.SaveToFile) == 0 && output.IsStructured() {
+		return
+	}
+
+	var out io.Writer = data.Stdout()
+	if len(info.SaveToFile) > 0 {
+		file, e := os.Create(info.SaveToFile)
+		if e != nil {
+			data.SetCmdStatusError()
+			log.ErrorF("bucket stats: create file:%s error:%v", info.SaveToFile, e)
+			return
+		}
+		defer file.Close()
+		out = file
+	}
+
+	switch info.Format {
+	case StatsFormatJson:
+		err = writeStatsJson(out, report)
+	case StatsFormatCsv:
+		err = writeStatsCsv(out, report)
+	default:
+		err = writeStatsTable(out, report)
+	}
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("bucket stats: write report error:%v", err)
+		return
+	}
+	if len(info.SaveToFile) > 0 {
+		log.AlertF("Stats %d files of bucket:%s to %s", report.Total.Count, info.Bucket, info.SaveToFile)
+	}
+}
+
+func writeStatsJson(out io.Writer, report *bucket.StatsReport) *data.CodeError {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return data.ConvertError(encoder.Encode(report))
+}
+
+func writeStatsCsv(out io.Writer, report *bucket.StatsReport) *data.CodeError {
+	writer := csv.NewWriter(out)
+	_ = writer.Write([]string{"section", "name", "count", "bytes"})
+	_ = writer.Write([]string{"total", report.Total.Name, strconv.FormatInt(report.Total.Count, 10), strconv.FormatInt(report.Total.Bytes, 10)})
+	for _, section := range report.Sections {
+		for _, g := range section.Groups {
+			_ = writer.Write([]string{section.Name, g.Name, strconv.FormatInt(g.Count, 10), strconv.FormatInt(g.Bytes, 10)})
+		}
+	}
+	writer.Flush()
+	return data.ConvertError(writer.Error())
+}
+
+func writeStatsTable(out io.Writer, report *bucket.StatsReport) *data.CodeError {
+	writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintf(writer, "Bucket: %s  Prefix: %s\n", report.Bucket, report.Prefix)
+	_, _ = fmt.Fprintf(writer, "Total: %d files  %d bytes (%s)\n", report.Total.Count, report.Total.Bytes, utils.FormatFileSize(report.Total.Bytes))
+	for _, section := range report.Sections {
+		_, _ = fmt.Fprintf(writer, "\n[%s]\n", section.Name)
+		_, _ = fmt.Fprintln(writer, "NAME\tCOUNT\tBYTES\tSIZE\tBYTES%")
+		for _, g := range section.Groups {
+			percent := float64(0)
+			if report.Total.Bytes > 0 {
+				percent = float64(g.Bytes) * 100 / float64(report.Total.Bytes)
+			}
+			_, _ = fmt.Fprintf(writer, "%s\t%d\t%d\t%s\t%.2f%%\n", g.Name, g.Count, g.Bytes, utils.FormatFileSize(g.Bytes), percent)
+		}
+	}
+	return data.ConvertError(writer.Flush())
+}
@@ -0,0 +1,284 @@
+package bucket
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+)
+
+const (
+	StatsSectionFileType    = "file_type"
+	StatsSectionMimeType    = "mime_type"
+	StatsSectionPrefix      = "prefix"
+	StatsSectionSize        = "size"
+	StatsSectionAge         = "age"
+	StatsSectionFileTypeAge = "file_type_age"
+
+	statsGroupOthers   = "(others)"
+	statsGroupNoPrefix = "(none)"
+	statsGroupNoMime   = "(empty)"
+)
+
+var statsFileTypeNames = []string{"标准存储", "低频存储", "归档存储", "深度归档存储", "归档直读存储"}
+
+// StatsConfig 空间文件统计配置
+type StatsConfig struct {
+	PrefixDepth     int       // 按前缀统计时前缀的层级，<= 0 时不按前缀统计
+	PrefixDelimiter string    // 前缀的分隔符，默认：/
+	Top             int       // 按前缀及 MimeType 统计时只展示 Bytes 最大的 Top 个，其余合并为 (others)，<= 0 时展示所有
+	SizeBuckets     []int64   // 文件大小直方图的边界，单位：B，升序
+	AgeBuckets      []int     // 上传时间距今的天数直方图的边界，单位：天，升序
+	Now             time.Time // 计算上传时间距今天数的时间，默认为当前时间
+}
+
+// StatsGroup 一组文件的统计
+type StatsGroup struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+	Bytes int64  `json:"bytes"`
+}
+
+func (g *StatsGroup) add(fsize int64) {
+	g.Count++
+	g.Bytes += fsize
+}
+
+// StatsSection 一种维度的统计
+type StatsSection struct {
+	Name   string        `json:"name"`
+	Groups []*StatsGroup `json:"groups"`
+}
+
+// StatsReport 空间文件统计报告
+type StatsReport struct {
+	Bucket   string          `json:"bucket"`
+	Prefix   string          `json:"prefix,omitempty"`
+	Total    *StatsGroup     `json:"total"`
+	Sections []*StatsSection `json:"sections"`
+}
+
+// StatsCollector 统计列举的文件，非并发安全
+type StatsCollector struct {
+	cfg         StatsConfig
+	total       *StatsGroup
+	fileTypes   map[int]*StatsGroup
+	mimeTypes   map[string]*StatsGroup
+	prefixes    map[string]*StatsGroup
+	sizes       []*StatsGroup
+	ages        []*StatsGroup
+	fileTypeAge map[int][]*StatsGroup
+}
+
+func NewStatsCollector(cfg StatsConfig) *StatsCollector {
+	if len(cfg.PrefixDelimiter) == 0 {
+		cfg.PrefixDelimiter = "/"
+	}
+	if cfg.Now.IsZero() {
+		cfg.Now = time.Now()
+	}
+	sort.Slice(cfg.SizeBuckets, func(i, j int) bool { return cfg.SizeBuckets[i] < cfg.SizeBuckets[j] })
+	sort.Ints(cfg.AgeBuckets)
+
+	return &StatsCollector{
+		cfg:         cfg,
+		total:       &StatsGroup{Name: "total"},
+		fileTypes:   make(map[int]*StatsGroup),
+		mimeTypes:   make(map[string]*StatsGroup),
+		prefixes:    make(map[string]*StatsGroup),
+		sizes:       histogramGroups(len(cfg.SizeBuckets)+1, func(i int) string { return sizeBucketName(cfg.SizeBuckets, i) }),
+		ages:        histogramGroups(len(cfg.AgeBuckets)+1, func(i int) string { return ageBucketName(cfg.AgeBuckets, i) }),
+		fileTypeAge: make(map[int][]*StatsGroup),
+	}
+}
+
+// Add 统计一个文件
+func (c *StatsCollector) Add(object ListObject) {
+	c.total.add(object.Fsize)
+
+	fileType, ok := c.fileTypes[object.Type]
+	if !ok {
+		fileType = &StatsGroup{Name: fileTypeName(object.Type)}
+		c.fileTypes[object.Type] = fileType
+	}
+	fileType.add(object.Fsize)
+
+	mime := object.MimeType
+	if len(mime) == 0 {
+		mime = statsGroupNoMime
+	}
+	mimeType, ok := c.mimeTypes[mime]
+	if !ok {
+		mimeType = &StatsGroup{Name: mime}
+		c.mimeTypes[mime] = mimeType
+	}
+	mimeType.add(object.Fsize)
+
+	if c.cfg.PrefixDepth > 0 {
+		p := keyPrefix(object.Key, c.cfg.PrefixDelimiter, c.cfg.PrefixDepth)
+		prefix, ok := c.prefixes[p]
+		if !ok {
+			prefix = &StatsGroup{Name: p}
+			c.prefixes[p] = prefix
+		}
+		prefix.add(object.Fsize)
+	}
+
+	c.sizes[sizeBucketIndex(c.cfg.SizeBuckets, object.Fsize)].add(object.Fsize)
+
+	// PutTime 单位为 100ns
+	age := c.cfg.Now.Sub(time.Unix(0, object.PutTime*100))
+	ageIndex := ageBucketIndex(c.cfg.AgeBuckets, age)
+	c.ages[ageIndex].add(object.Fsize)
+
+	typeAges, ok := c.fileTypeAge[object.Type]
+	if !ok {
+		name := fileTypeName(object.Type)
+		typeAges = histogramGroups(len(c.cfg.AgeBuckets)+1, func(i int) string {
+			return name + " " + ageBucketName(c.cfg.AgeBuckets, i)
+		})
+		c.fileTypeAge[object.Type] = typeAges
+	}
+	typeAges[ageIndex].add(object.Fsize)
+}
+
+// Report 生成统计报告
+func (c *StatsCollector) Report(bucket, prefix string) *StatsReport {
+	report := &StatsReport{
+		Bucket: bucket,
+		Prefix: prefix,
+		Total:  c.total,
+	}
+
+	fileTypes := make([]int, 0, len(c.fileTypes))
+	for t := range c.fileTypes {
+		fileTypes = append(fileTypes, t)
+	}
+	sort.Ints(fileTypes)
+
+	fileTypeSection := &StatsSection{Name: StatsSectionFileType}
+	fileTypeAgeSection := &StatsSection{Name: StatsSectionFileTypeAge}
+	for _, t := range fileTypes {
+		fileTypeSection.Groups = append(fileTypeSection.Groups, c.fileTypes[t])
+		for _, g := range c.fileTypeAge[t] {
+			if g.Count > 0 {
+				fileTypeAgeSection.Groups = append(fileTypeAgeSection.Groups, g)
+			}
+		}
+	}
+
+	report.Sections = append(report.Sections,
+		fileTypeSection,
+		&StatsSection{Name: StatsSectionMimeType, Groups: topGroups(c.mimeTypes, c.cfg.Top)})
+	if c.cfg.PrefixDepth > 0 {
+		report.Sections = append(report.Sections,
+			&StatsSection{Name: StatsSectionPrefix, Groups: topGroups(c.prefixes, c.cfg.Top)})
+	}
+	report.Sections = append(report.Sections,
+		&StatsSection{Name: StatsSectionSize, Groups: c.sizes},
+		&StatsSection{Name: StatsSectionAge, Groups: c.ages},
+		fileTypeAgeSection)
+	return report
+}
+
+func histogramGroups(count int, name func(index int) string) []*StatsGroup {
+	groups := make([]*StatsGroup, count)
+	for i := range groups {
+		groups[i] = &StatsGroup{Name: name(i)}
+	}
+	return groups
+}
+
+// topGroups 按 Bytes 降序排列，只保留前 top 个，其余合并为 (others)
+func topGroups(groupMap map[string]*StatsGroup, top int) []*StatsGroup {
+	groups := make([]*StatsGroup, 0, len(groupMap))
+	for _, g := range groupMap {
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Bytes != groups[j].Bytes {
+			return groups[i].Bytes > groups[j].Bytes
+		}
+		return groups[i].Name < groups[j].Name
+	})
+	if top <= 0 || len(groups) <= top {
+		return groups
+	}
+
+	others := &StatsGroup{Name: statsGroupOthers}
+	for _, g := range groups[top:] {
+		others.Count += g.Count
+		others.Bytes += g.Bytes
+	}
+	return append(groups[:top:top], others)
+}
+
+// keyPrefix 获取 key 前 depth 层的前缀，层级不足时为 key 所在的目录，key 不在任何目录下时为 (none)
+func keyPrefix(key, delimiter string, depth int) string {
+	index := 0
+	for i := 0; i < depth; i++ {
+		n := strings.Index(key[index:], delimiter)
+		if n < 0 {
+			break
+		}
+		index += n + len(delimiter)
+	}
+	if index == 0 {
+		return statsGroupNoPrefix
+	}
+	return key[:index]
+}
+
+func fileTypeName(fileType int) string {
+	if fileType >= 0 && fileType < len(statsFileTypeNames) {
+		return fmt.Sprintf("%d:%s", fileType, statsFileTypeNames[fileType])
+	}
+	return fmt.Sprintf("%d", fileType)
+}
+
+func sizeBucketIndex(buckets []int64, size int64) int {
+	return sort.Search(len(buckets), func(i int) bool { return size < buckets[i] })
+}
+
+func sizeBucketName(buckets []int64, index int) string {
+	return bucketName(index, len(buckets), func(i int) string { return sizeName(buckets[i]) })
+}
+
+func ageBucketIndex(buckets []int, age time.Duration) int {
+	return sort.Search(len(buckets), func(i int) bool { return age < time.Duration(buckets[i])*24*time.Hour })
+}
+
+func ageBucketName(buckets []int, index int) string {
+	return bucketName(index, len(buckets), func(i int) string { return fmt.Sprintf("%dd", buckets[i]) })
+}
+
+// bucketName 直方图第 index 个区间的名字，区间为左闭右开：<a、a~b、>=b
+func bucketName(index int, count int, boundary func(i int) string) string {
+	switch {
+	case count == 0:
+		return "all"
+	case index == 0:
+		return "<" + boundary(0)
+	case index == count:
+		return ">=" + boundary(count-1)
+	default:
+		return boundary(index-1) + "~" + boundary(index)
+	}
+}
+
+func sizeName(size int64) string {
+	for _, u := range []struct {
+		name string
+		size int64
+	}{{"TB", utils.TB}, {"GB", utils.GB}, {"MB", utils.MB}, {"KB", utils.KB}} {
+		if size >= u.size && size%u.size == 0 {
+			return fmt.Sprintf("%d%s", size/u.size, u.name)
+		}
+	}
+	if size >= utils.KB {
+		return utils.FormatFileSize(size)
+	}
+	return fmt.Sprintf("%dB", size)
+}
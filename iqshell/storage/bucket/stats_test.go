@@ -0,0 +1,77 @@
+package bucket
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStatsCollector(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	putTime := func(days int) int64 {
+		return now.Add(-time.Duration(days)*24*time.Hour).UnixNano() / 100
+	}
+
+	collector := NewStatsCollector(StatsConfig{
+		PrefixDepth: 1,
+		Top:         1,
+		SizeBuckets: []int64{1024 * 1024, 1024},
+		AgeBuckets:  []int{90},
+		Now:         now,
+	})
+	for _, object := range []ListObject{
+		{Key: "logs/a.gz", Fsize: 100, PutTime: putTime(100), MimeType: "application/gzip"},
+		{Key: "logs/b.gz", Fsize: 2048, PutTime: putTime(10), MimeType: "application/gzip"},
+		{Key: "img/c.png", Fsize: 2 * 1024 * 1024, PutTime: putTime(200), MimeType: "image/png", Type: 2},
+		{Key: "d.txt", Fsize: 10, PutTime: putTime(90)},
+	} {
+		collector.Add(object)
+	}
+
+	report := collector.Report("bucket", "")
+	if report.Total.Count != 4 || report.Total.Bytes != 100+2048+2*1024*1024+10 {
+		t.Fatalf("total error:%+v", report.Total)
+	}
+
+	sections := make(map[string]map[string]StatsGroup)
+	for _, section := range report.Sections {
+		sections[section.Name] = make(map[string]StatsGroup)
+		for _, g := range section.Groups {
+			sections[section.Name][g.Name] = *g
+		}
+	}
+
+	for section, expect := range map[string]map[string]int64{
+		StatsSectionFileType:    {"0:标准存储": 3, "2:归档存储": 1},
+		StatsSectionMimeType:    {"image/png": 1, statsGroupOthers: 3},
+		StatsSectionPrefix:      {"img/": 1, statsGroupOthers: 3},
+		StatsSectionSize:        {"<1KB": 2, "1KB~1MB": 1, ">=1MB": 1},
+		StatsSectionAge:         {"<90d": 1, ">=90d": 3},
+		StatsSectionFileTypeAge: {"0:标准存储 <90d": 1, "0:标准存储 >=90d": 2, "2:归档存储 >=90d": 1},
+	} {
+		if len(sections[section]) != len(expect) {
+			t.Fatalf("section:%s groups:%v should be:%v", section, sections[section], expect)
+		}
+		for name, count := range expect {
+			if g, ok := sections[section][name]; !ok || g.Count != count {
+				t.Fatalf("section:%s group:%s should count:%d, groups:%v", section, name, count, sections[section])
+			}
+		}
+	}
+}
+
+func TestKeyPrefix(t *testing.T) {
+	for _, c := range []struct {
+		key    string
+		depth  int
+		expect string
+	}{
+		{"a/b/c.txt", 1, "a/"},
+		{"a/b/c.txt", 2, "a/b/"},
+		{"a/b/c.txt", 3, "a/b/"},
+		{"c.txt", 1, statsGroupNoPrefix},
+	} {
+		if p := keyPrefix(c.key, "/", c.depth); p != c.expect {
+			t.Fatalf("key:%s depth:%d prefix:%s should be:%s", c.key, c.depth, p, c.expect)
+		}
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/common/flow"
 	"github.com/qiniu/qshell/v2/iqshell/common/locker"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
 	"github.com/qiniu/qshell/v2/iqshell/common/utils"
 	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
 	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
@@ -33,6 +34,9 @@ type Info struct {
 	EnableRecord             bool // 是否开启 record
 	RecordRedoWhileError     bool // 重新执行任务时，如果任务已执行但是失败，则再重新执行一次。
 	OperationCountPerRequest int  // 每批操作最大的子任务数
+
+	DryRun   bool   // 仅生成执行计划，不执行任何修改操作
+	PlanFile string // DryRun 时执行计划保存的文件路径，默认保存在 job 目录下
 }
 
 func (info *Info) Check() *data.CodeError {
@@ -53,6 +57,11 @@ func (info *Info) Check() *data.CodeError {
 		info.ItemSeparate = "\t"
 	}
 
+	// DryRun 不会修改数据，无需验证
+	if info.DryRun {
+		info.Force = true
+	}
+
 	return nil
 }
 
@@ -61,6 +70,7 @@ type Handler interface {
 	SetFileExport(exporter *export.FileExporter) Handler
 	ItemsToOperation(func(items []string) (operation Operation, err *data.CodeError)) Handler
 	OnResult(func(operationInfo string, operation Operation, result *OperationResult)) Handler
+	OnWorkSkip(func(operationInfo string, operation Operation, result *OperationResult, err *data.CodeError)) Handler
 	OnError(func(err *data.CodeError)) Handler
 	Start()
 }
@@ -80,6 +90,7 @@ type handler struct {
 	operationItemsCreator func(items []string) (operation Operation, err *data.CodeError)
 	onError               func(err *data.CodeError)
 	onResult              func(operationInfo string, operation Operation, result *OperationResult)
+	onWorkSkip            func(operationInfo string, operation Operation, result *OperationResult, err *data.CodeError)
 }
 
 func (h *handler) EmptyOperation(emptyOperation func() flow.Work) Handler {
@@ -102,13 +113,43 @@ func (h *handler) OnResult(handler func(operationInfo string, operation Operatio
 	return h
 }
 
+// OnWorkSkip 操作被跳过时回调，如：操作在之前的执行中已完成（err.Code 为 data.ErrorCodeAlreadyDone，result 为之前执行的结果）
+func (h *handler) OnWorkSkip(handler func(operationInfo string, operation Operation, result *OperationResult, err *data.CodeError)) Handler {
+	h.onWorkSkip = handler
+	return h
+}
+
 func (h *handler) OnError(handler func(err *data.CodeError)) Handler {
 	h.onError = handler
 	return h
 }
 
+// result 文件数据源时，结构化输出每个操作的结果；数组数据源由调用方输出
+func (h *handler) result(operationInfo string, operation Operation, result *OperationResult) {
+	if !h.isArraySource() {
+		output.Result(&OperationRecord{
+			Line:      operationInfo,
+			Operation: operation,
+			Result:    result,
+		})
+	}
+	h.onResult(operationInfo, operation, result)
+}
+
+// 开启自适应并发控制时并发上限为 MaxWorkerCount
+func (h *handler) maxWorkerCount() int {
+	if h.info.MaxWorkerCount > h.info.WorkerCount {
+		return h.info.MaxWorkerCount
+	}
+	return h.info.WorkerCount
+}
+
+func (h *handler) isArraySource() bool {
+	return h.info.WorkList != nil && len(h.info.WorkList) > 0
+}
+
 func (h *handler) Start() {
-	isArraySource := h.info.WorkList != nil && len(h.info.WorkList) > 0
+	isArraySource := h.isArraySource()
 	if !isArraySource {
 		if e := locker.TryLock(); e != nil {
 			log.ErrorF("batch job, %v", e)
@@ -162,6 +203,20 @@ func (h *handler) Start() {
 		}
 	}
 
+	var planner *planWriter
+	if h.info.DryRun {
+		if planner, err = newPlanWriter(h.info.PlanFile); err != nil {
+			h.onError(err)
+			return
+		}
+	}
+
+	blockLimit := flow.NewBlockLimit(h.info.WorkerCount*h.info.OperationCountPerRequest,
+		flow.MaxLimitCount(h.maxWorkerCount()*h.info.OperationCountPerRequest),
+		flow.MinLimitCount(h.info.MinWorkerCount*h.info.OperationCountPerRequest),
+		flow.IncreaseLimitCount(h.info.OperationCountPerRequest),
+		flow.IncreaseLimitCountPeriod(time.Duration(h.info.WorkerCountIncreasePeriod)*time.Second))
+
 	metric := &Metric{}
 	if isArraySource {
 		metric.DisablePrintProgress()
@@ -200,6 +255,11 @@ func (h *handler) Start() {
 					return nil, cErr
 				}
 
+				if planner != nil {
+					planRecordList, pErr := planOperations(bucketManager, operationWorkInfoList, operationStringList)
+					return append(recordList, planRecordList...), pErr
+				}
+
 				resultList, e := bucketManager.Batch(operationStringList)
 				if len(resultList) != len(operationStringList) {
 					return recordList, data.ConvertError(e)
@@ -215,6 +275,12 @@ func (h *handler) Start() {
 						Type:     r.Data.Type,
 						Error:    r.Data.Error,
 					}
+					if r.Data.RestoreStatus != nil {
+						result.RestoreStatus = *r.Data.RestoreStatus
+					}
+					if r.Data.Status != nil {
+						result.Status = *r.Data.Status
+					}
 					record := &flow.WorkRecord{
 						WorkInfo: operationWorkInfoList[i],
 						Result:   result,
@@ -228,7 +294,7 @@ func (h *handler) Start() {
 			}), nil
 		})).
 		DoWorkListMaxCount(h.info.OperationCountPerRequest).
-		SetOverseerEnable(h.info.EnableRecord).
+		SetOverseerEnable(h.info.EnableRecord && !h.info.DryRun).
 		SetDBOverseer(dbPath, func() *flow.WorkRecord {
 			return &flow.WorkRecord{
 				WorkInfo: &flow.WorkInfo{
@@ -239,11 +305,7 @@ func (h *handler) Start() {
 				Err:    nil,
 			}
 		}).
-		SetLimit(flow.NewBlockLimit(h.info.WorkerCount*h.info.OperationCountPerRequest,
-			flow.MaxLimitCount(h.info.WorkerCount*h.info.OperationCountPerRequest),
-			flow.MinLimitCount(h.info.MinWorkerCount*h.info.OperationCountPerRequest),
-			flow.IncreaseLimitCount(h.info.OperationCountPerRequest),
-			flow.IncreaseLimitCountPeriod(time.Duration(h.info.WorkerCountIncreasePeriod)*time.Second))).
+		SetLimit(blockLimit).
 		FlowWillStartFunc(func(flow *flow.Flow) (err *data.CodeError) {
 			metric.AddTotalCount(flow.WorkProvider.WorkTotalCount())
 			return nil
@@ -276,6 +338,10 @@ func (h *handler) Start() {
 			metric.PrintProgress("Batching:" + work.Data)
 
 			operationResult, _ := result.(*OperationResult)
+			if h.onWorkSkip != nil {
+				operation, _ := work.Work.(Operation)
+				h.onWorkSkip(work.Data, operation, operationResult, err)
+			}
 			if err != nil && err.Code == data.ErrorCodeAlreadyDone {
 				if operationResult != nil && operationResult.IsValid() {
 					metric.AddSuccessCount(1)
@@ -290,11 +356,19 @@ func (h *handler) Start() {
 					log.InfoF("Skip line:%s because have done and failure, %v%s", work.Data, err, errDesc)
 					h.exporter.Fail().ExportF("%s%s-%s", work.Data, flow.ErrorSeparate, errDesc)
 				}
+			} else if planner != nil {
+				metric.AddSkippedCount(1)
+				planner.addOperation(&PlanOperation{
+					Type:   PlanRecordTypeOperation,
+					Line:   work.Data,
+					Status: PlanStatusFail,
+					Reason: fmt.Sprintf("%v", err),
+				})
 			} else {
 				metric.AddSkippedCount(1)
 
 				operation, _ := work.Work.(Operation)
-				h.onResult(work.Data, operation, &OperationResult{
+				h.result(work.Data, operation, &OperationResult{
 					Code:  data.ErrorCodeUnknown,
 					Error: fmt.Sprintf("%v", err),
 				})
@@ -310,6 +384,25 @@ func (h *handler) Start() {
 			metric.AddCurrentCount(1)
 			metric.PrintProgress("Batching:" + work.Data)
 
+			if planner != nil {
+				planOperation, _ := result.(*PlanOperation)
+				if planOperation == nil {
+					planOperation = &PlanOperation{
+						Type:   PlanRecordTypeOperation,
+						Line:   work.Data,
+						Status: PlanStatusFail,
+						Reason: "no result",
+					}
+				}
+				if planOperation.Status == PlanStatusFail {
+					metric.AddFailureCount(1)
+				} else {
+					metric.AddSuccessCount(1)
+				}
+				planner.addOperation(planOperation)
+				return
+			}
+
 			operation, _ := work.Work.(Operation)
 			operationResult, _ := result.(*OperationResult)
 			if operationResult != nil && operationResult.IsSuccess() {
@@ -323,16 +416,30 @@ func (h *handler) Start() {
 					h.exporter.Fail().ExportF("%s%s[%d]%s", work.Data, flow.ErrorSeparate, operationResult.Code, operationResult.Error)
 				}
 			}
-			h.onResult(work.Data, operation, operationResult)
+			h.result(work.Data, operation, operationResult)
+		}).
+		OnConcurrencyChange(func(change *flow.ConcurrencyChange) {
+			// 请求数限制随自适应并发控制器调整的并发数同步调整，避免限制并发数的增长
+			metric.OnConcurrencyChange(change)
+			blockLimit.AddLimitCount((change.Concurrency - change.PreConcurrency) * h.info.OperationCountPerRequest)
 		}).
 		OnWorkFail(func(work *flow.WorkInfo, err *data.CodeError) {
 			metric.AddCurrentCount(1)
 			metric.AddFailureCount(1)
 			metric.PrintProgress("Batching:" + work.Data)
+			if planner != nil {
+				planner.addOperation(&PlanOperation{
+					Type:   PlanRecordTypeOperation,
+					Line:   work.Data,
+					Status: PlanStatusFail,
+					Reason: err.Error(),
+				})
+				return
+			}
 			h.exporter.Fail().ExportF("%s%s[%d]%s", work.Data, flow.ErrorSeparate, err.Code, err.Desc)
 
 			operation, _ := work.Work.(Operation)
-			h.onResult(work.Data, operation, &OperationResult{
+			h.result(work.Data, operation, &OperationResult{
 				Code:  err.Code,
 				Error: err.Desc,
 			})
@@ -343,6 +450,24 @@ func (h *handler) Start() {
 		metric.TotalCount = metric.SuccessCount + metric.FailureCount + metric.SkippedCount
 	}
 
+	if planner != nil {
+		if e := planner.close(); e != nil {
+			h.onError(e)
+		}
+		summary := planner.summary
+		log.Alert("-------------- Dry Run Result --------------")
+		log.AlertF("%20s%10d", "Total:", summary.Total)
+		log.AlertF("%20s%10d", "Change:", summary.Change)
+		log.AlertF("%20s%10d", "Unchanged:", summary.Unchanged)
+		log.AlertF("%20s%10d", "Fail:", summary.Fail)
+		log.AlertF("%20s%10s", "ChangeBytes:", utils.FormatFileSize(summary.ChangeBytes))
+		log.AlertF("%20s%10s", "FailBytes:", utils.FormatFileSize(summary.FailBytes))
+		log.AlertF("%20s%10ds", "Duration:", metric.Duration)
+		log.AlertF("--------------------------------------------")
+		log.AlertF("plan file:%s", planner.path)
+		return
+	}
+
 	if !isArraySource {
 		log.InfoF("job dir:%s, there is a cache related to this command in this folder, which will also be used next time the same command is executed. If you are sure that you don’t need it, you can delete this folder.", workspace.GetJobDir())
 
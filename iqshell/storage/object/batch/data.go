@@ -62,6 +62,11 @@ type OperationResult struct {
 	EndUser  string  `json:"endUser"`
 	Error    string  `json:"error"`
 	Parts    []int64 `json:"parts"`
+	MD5      string  `json:"md5"`
+	Status   int     `json:"status"`
+
+	// 归档/深度归档存储文件的解冻状态，仅 stat 操作返回；1：解冻中，2：解冻完成，冻结时为 0
+	RestoreStatus int `json:"restoreStatus,omitempty"`
 }
 
 var _ flow.Result = (*OperationResult)(nil)
@@ -84,3 +89,10 @@ func (r *OperationResult) ErrorDescription() string {
 	}
 	return fmt.Sprintf("Code:%d Error:%s", r.Code, r.Error)
 }
+
+// OperationRecord 结构化输出（--output json/jsonl）时操作的结果记录，Line 为批量操作时输入的行
+type OperationRecord struct {
+	Line      string      `json:"line,omitempty"`
+	Operation interface{} `json:"operation"`
+	Result    interface{} `json:"result"`
+}
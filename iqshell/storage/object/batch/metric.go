@@ -1,9 +1,12 @@
 package batch
 
 import (
-	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"fmt"
 	"sync"
 	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
 )
 
 type Metric struct {
@@ -17,6 +20,9 @@ type Metric struct {
 	SuccessCount int64 `json:"success_count"`
 	FailureCount int64 `json:"failure_count"`
 	SkippedCount int64 `json:"skipped_count"`
+
+	WorkerCount            int   `json:"worker_count,omitempty"`             // 开启自适应并发控制时当前的并发数
+	ConcurrencyChangeCount int64 `json:"concurrency_change_count,omitempty"` // 开启自适应并发控制时并发数调整的次数
 }
 
 func (m *Metric) Start() {
@@ -77,6 +83,17 @@ func (m *Metric) AddSkippedCount(count int64) {
 	m.mu.Unlock()
 }
 
+// OnConcurrencyChange 记录自适应并发控制器调整后的并发数，进度信息中会展示当前并发数
+func (m *Metric) OnConcurrencyChange(change *flow.ConcurrencyChange) {
+	if m == nil || change == nil {
+		return
+	}
+	m.mu.Lock()
+	m.WorkerCount = change.Concurrency
+	m.ConcurrencyChangeCount++
+	m.mu.Unlock()
+}
+
 func (m *Metric) DisablePrintProgress() {
 	m.disablePrintProgress = true
 }
@@ -86,14 +103,19 @@ func (m *Metric) PrintProgress(tag string) {
 		return
 	}
 
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	worker := ""
+	if m.WorkerCount > 0 {
+		worker = fmt.Sprintf(", worker:%d", m.WorkerCount)
+	}
 	if m.TotalCount <= 0 {
-		log.InfoF("%s [%d/-, -] ...", tag, m.CurrentCount)
+		log.InfoF("%s [%d/-, -%s] ...", tag, m.CurrentCount, worker)
 		return
 	}
-	m.mu.Lock()
-	log.InfoF("%s [%d/%d, %.1f%%] ...", tag, m.CurrentCount, m.TotalCount,
-		float32(m.CurrentCount)*100/float32(m.TotalCount))
-	m.mu.Unlock()
+	log.InfoF("%s [%d/%d, %.1f%%%s] ...", tag, m.CurrentCount, m.TotalCount,
+		float32(m.CurrentCount)*100/float32(m.TotalCount), worker)
 }
 
 func (m *Metric) IsCompletedSuccessfully() bool {
@@ -0,0 +1,551 @@
+package batch
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qiniu/go-sdk/v7/storage"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+)
+
+const (
+	PlanRecordTypeHeader    = "header"
+	PlanRecordTypeOperation = "operation"
+	PlanRecordTypeSummary   = "summary"
+
+	PlanStatusChange    = "change"    // 执行后会修改对象
+	PlanStatusUnchanged = "unchanged" // 对象已是目标状态，执行后不会有变化
+	PlanStatusFail      = "fail"      // 执行时会失败
+
+	planVersion = 1
+
+	// 对象不存在
+	statusCodeNoSuchEntry = 612
+)
+
+// PlanHeader plan 文件首行，记录生成 plan 的命令及账号
+type PlanHeader struct {
+	Type        string `json:"type"`
+	Version     int    `json:"version"`
+	CmdId       string `json:"cmd_id"`
+	AccountName string `json:"account_name"`
+	AccessKey   string `json:"access_key"`
+	CreateTime  string `json:"create_time"`
+}
+
+// PlanOperation plan 中的一个操作，Operation 为 rs batch 的操作指令，Source 及 Dest 相关字段为 dry-run 时 stat 的对象状态
+type PlanOperation struct {
+	Type       string `json:"type"`
+	Line       string `json:"line"`
+	Operation  string `json:"operation"`
+	Command    string `json:"command"`
+	Bucket     string `json:"bucket"`
+	Key        string `json:"key"`
+	DestBucket string `json:"dest_bucket,omitempty"`
+	DestKey    string `json:"dest_key,omitempty"`
+	Force      bool   `json:"force,omitempty"`
+	Status     string `json:"status"`
+	Reason     string `json:"reason,omitempty"`
+
+	SourceExist    bool   `json:"source_exist"`
+	SourceHash     string `json:"source_hash,omitempty"`
+	SourceFSize    int64  `json:"source_fsize,omitempty"`
+	SourcePutTime  int64  `json:"source_put_time,omitempty"`
+	SourceMimeType string `json:"source_mime_type,omitempty"`
+	SourceType     int    `json:"source_type"`
+	DestExist      bool   `json:"dest_exist,omitempty"`
+	DestHash       string `json:"dest_hash,omitempty"`
+	DestPutTime    int64  `json:"dest_put_time,omitempty"`
+
+	// 解析 Operation 得到的操作参数，如：chtype 的 type
+	params map[string]string
+}
+
+var _ flow.Result = (*PlanOperation)(nil)
+
+func (p *PlanOperation) IsValid() bool {
+	return p != nil && len(p.Status) > 0
+}
+
+func (p *PlanOperation) hasDest() bool {
+	return p.Command == "copy" || p.Command == "move"
+}
+
+// PlanSummary plan 文件末行，统计 plan 中操作的数量及涉及对象的字节数
+type PlanSummary struct {
+	Type           string `json:"type"`
+	Total          int64  `json:"total"`
+	Change         int64  `json:"change"`
+	Unchanged      int64  `json:"unchanged"`
+	Fail           int64  `json:"fail"`
+	ChangeBytes    int64  `json:"change_bytes"`
+	UnchangedBytes int64  `json:"unchanged_bytes"`
+	FailBytes      int64  `json:"fail_bytes"`
+}
+
+func (s *PlanSummary) add(operation *PlanOperation) {
+	s.Total += 1
+	switch operation.Status {
+	case PlanStatusChange:
+		s.Change += 1
+		s.ChangeBytes += operation.SourceFSize
+	case PlanStatusUnchanged:
+		s.Unchanged += 1
+		s.UnchangedBytes += operation.SourceFSize
+	default:
+		s.Fail += 1
+		s.FailBytes += operation.SourceFSize
+	}
+}
+
+// ParseOperation 解析 rs batch 的操作指令，如：/move/<EncodedEntryURI>/<EncodedEntryURI>/force/true
+func ParseOperation(operation string) (*PlanOperation, *data.CodeError) {
+	items := strings.Split(strings.TrimPrefix(operation, "/"), "/")
+	if len(items) < 2 {
+		return nil, alert.Error("invalid operation:"+operation, "")
+	}
+
+	p := &PlanOperation{
+		Type:      PlanRecordTypeOperation,
+		Operation: operation,
+		Command:   items[0],
+		params:    make(map[string]string),
+	}
+	var err *data.CodeError
+	if p.Bucket, p.Key, err = decodeEntry(items[1]); err != nil {
+		return nil, err
+	}
+
+	paramsIndex := 2
+	if p.hasDest() {
+		if len(items) < 3 {
+			return nil, alert.Error("invalid operation, dest entry missing:"+operation, "")
+		}
+		if p.DestBucket, p.DestKey, err = decodeEntry(items[2]); err != nil {
+			return nil, err
+		}
+		paramsIndex = 3
+	} else if p.Command == "deleteAfterDays" {
+		// /deleteAfterDays/<EncodedEntryURI>/<days>
+		if len(items) < 3 {
+			return nil, alert.Error("invalid operation, days missing:"+operation, "")
+		}
+		p.params["days"] = items[2]
+		paramsIndex = 3
+	}
+
+	for i := paramsIndex; i+1 < len(items); i += 2 {
+		p.params[items[i]] = items[i+1]
+	}
+	p.Force = p.params["force"] == "true"
+	return p, nil
+}
+
+// Param 获取操作指令中的参数，如：chtype 的 type
+func (p *PlanOperation) Param(name string) string {
+	if p == nil || p.params == nil {
+		return ""
+	}
+	return p.params[name]
+}
+
+func decodeEntry(encodedEntry string) (bucket string, key string, err *data.CodeError) {
+	entry, dErr := base64.URLEncoding.DecodeString(encodedEntry)
+	if dErr != nil {
+		return "", "", data.NewEmptyError().AppendDescF("decode entry:%s error:%v", encodedEntry, dErr)
+	}
+	items := strings.SplitN(string(entry), ":", 2)
+	bucket = items[0]
+	if len(items) > 1 {
+		key = items[1]
+	}
+	return
+}
+
+// planOperations 仅通过 stat 获取对象状态，推断操作执行后的结果，不会执行任何修改操作
+func planOperations(bucketManager *storage.BucketManager, workInfoList []*flow.WorkInfo, operations []string) ([]*flow.WorkRecord, *data.CodeError) {
+	recordList := make([]*flow.WorkRecord, 0, len(workInfoList))
+	planList := make([]*PlanOperation, 0, len(operations))
+	planWorkInfoList := make([]*flow.WorkInfo, 0, len(operations))
+	statOperations := make([]string, 0, len(operations)*2)
+	for i, operation := range operations {
+		p, err := ParseOperation(operation)
+		if err != nil {
+			recordList = append(recordList, &flow.WorkRecord{
+				WorkInfo: workInfoList[i],
+				Err:      err,
+			})
+			continue
+		}
+		p.Line = workInfoList[i].Data
+		planList = append(planList, p)
+		planWorkInfoList = append(planWorkInfoList, workInfoList[i])
+		statOperations = append(statOperations, storage.URIStat(p.Bucket, p.Key))
+		if p.hasDest() {
+			statOperations = append(statOperations, storage.URIStat(p.DestBucket, p.DestKey))
+		}
+	}
+
+	if len(statOperations) == 0 {
+		return recordList, nil
+	}
+
+	resultList, err := batchStat(bucketManager, statOperations)
+	if err != nil {
+		return recordList, err
+	}
+
+	resultIndex := 0
+	for i, p := range planList {
+		source := fillPlanOperationState(p, resultList, &resultIndex)
+		p.Status, p.Reason = planOperationStatus(p, source)
+		recordList = append(recordList, &flow.WorkRecord{
+			WorkInfo: planWorkInfoList[i],
+			Result:   p,
+		})
+	}
+	return recordList, nil
+}
+
+// batchStat 按 rs batch 单次请求的操作数限制分批 stat，copy/move 会 stat 源对象及目标对象，stat 数可能是操作数的 2 倍
+func batchStat(bucketManager *storage.BucketManager, statOperations []string) ([]storage.BatchOpRet, *data.CodeError) {
+	resultList := make([]storage.BatchOpRet, 0, len(statOperations))
+	for start := 0; start < len(statOperations); start += defaultOperationCountPerRequest {
+		end := start + defaultOperationCountPerRequest
+		if end > len(statOperations) {
+			end = len(statOperations)
+		}
+		results, e := bucketManager.Batch(statOperations[start:end])
+		if len(results) != end-start {
+			return nil, data.ConvertError(e)
+		}
+		resultList = append(resultList, results...)
+	}
+	return resultList, nil
+}
+
+// fillPlanOperationState 使用 stat 的结果填充操作涉及对象的状态，返回源对象的 stat 结果
+func fillPlanOperationState(p *PlanOperation, resultList []storage.BatchOpRet, resultIndex *int) storage.BatchOpRet {
+	source := resultList[*resultIndex]
+	*resultIndex++
+	p.SourceExist = source.Code == 200
+	if p.SourceExist {
+		p.SourceHash = source.Data.Hash
+		p.SourceFSize = source.Data.Fsize
+		p.SourcePutTime = source.Data.PutTime
+		p.SourceMimeType = source.Data.MimeType
+		p.SourceType = source.Data.Type
+	}
+
+	if p.hasDest() {
+		dest := resultList[*resultIndex]
+		*resultIndex++
+		p.DestExist = dest.Code == 200
+		if p.DestExist {
+			p.DestHash = dest.Data.Hash
+			p.DestPutTime = dest.Data.PutTime
+		}
+	}
+	return source
+}
+
+func planOperationStatus(p *PlanOperation, source storage.BatchOpRet) (status string, reason string) {
+	if source.Code == statusCodeNoSuchEntry {
+		return PlanStatusFail, "source object not found"
+	}
+	if !p.SourceExist {
+		return PlanStatusFail, fmt.Sprintf("stat source object error, code:%d error:%s", source.Code, source.Data.Error)
+	}
+
+	if reason := planConditionMismatch(p, source); len(reason) > 0 {
+		return PlanStatusFail, reason
+	}
+
+	switch p.Command {
+	case "copy", "move":
+		if p.DestExist && !p.Force {
+			return PlanStatusFail, "destination object exists and overwrite is not set"
+		}
+		if p.Bucket == p.DestBucket && p.Key == p.DestKey {
+			return PlanStatusUnchanged, "source and destination are the same object"
+		}
+	case "chtype":
+		if fileType, err := strconv.Atoi(p.params["type"]); err == nil && fileType == p.SourceType {
+			return PlanStatusUnchanged, "object is already the file type"
+		}
+	case "chgm":
+		if mime, ok := p.params["mime"]; ok {
+			if m, err := base64.URLEncoding.DecodeString(mime); err == nil && string(m) == p.SourceMimeType {
+				return PlanStatusUnchanged, "object is already the mime type"
+			}
+		}
+	case "chstatus":
+		if status, err := strconv.Atoi(p.params["status"]); err == nil {
+			sourceStatus := 0
+			if source.Data.Status != nil {
+				sourceStatus = *source.Data.Status
+			}
+			if status == sourceStatus {
+				return PlanStatusUnchanged, "object is already the status"
+			}
+		}
+	case "restoreAr":
+		if p.SourceType != 2 && p.SourceType != 3 {
+			return PlanStatusFail, "object is not archive or deep archive file type"
+		}
+	}
+	return PlanStatusChange, ""
+}
+
+// planConditionMismatch 检查操作指令的条件（如：delete 的 putTime 条件）与源对象的状态是否匹配，不匹配时返回原因
+func planConditionMismatch(p *PlanOperation, source storage.BatchOpRet) string {
+	cond, ok := p.params["cond"]
+	if !ok {
+		return ""
+	}
+	c, err := base64.URLEncoding.DecodeString(cond)
+	if err != nil {
+		return fmt.Sprintf("decode condition:%s error:%v", cond, err)
+	}
+
+	for _, item := range strings.Split(string(c), "&") {
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		current := ""
+		switch kv[0] {
+		case "hash":
+			current = source.Data.Hash
+		case "mime":
+			current = source.Data.MimeType
+		case "fsize":
+			current = strconv.FormatInt(source.Data.Fsize, 10)
+		case "putTime":
+			current = strconv.FormatInt(source.Data.PutTime, 10)
+		default:
+			continue
+		}
+		if current != kv[1] {
+			return fmt.Sprintf("condition %s not match, object %s is %s", item, kv[0], current)
+		}
+	}
+	return ""
+}
+
+type planWriter struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	writer  *bufio.Writer
+	summary *PlanSummary
+}
+
+func newPlanWriter(path string) (*planWriter, *data.CodeError) {
+	if len(path) == 0 {
+		path = filepath.Join(workspace.GetJobDir(), "plan.jsonl")
+	}
+	if err := utils.CreateFileDirIfNotExist(path); err != nil {
+		return nil, err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, data.NewEmptyError().AppendDescF("create plan file:%s error", path).AppendError(err)
+	}
+
+	w := &planWriter{
+		path:   path,
+		file:   f,
+		writer: bufio.NewWriter(f),
+		summary: &PlanSummary{
+			Type: PlanRecordTypeSummary,
+		},
+	}
+
+	header := &PlanHeader{
+		Type:       PlanRecordTypeHeader,
+		Version:    planVersion,
+		CmdId:      workspace.GetConfig().CmdId,
+		CreateTime: time.Now().Format(time.RFC3339),
+	}
+	if acc, aErr := workspace.GetAccount(); aErr == nil {
+		header.AccountName = acc.Name
+		header.AccessKey = acc.AccessKey
+	}
+	if e := w.write(header); e != nil {
+		_ = f.Close()
+		return nil, e
+	}
+	return w, nil
+}
+
+func (w *planWriter) write(record interface{}) *data.CodeError {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.writeWithoutLock(record)
+}
+
+func (w *planWriter) writeWithoutLock(record interface{}) *data.CodeError {
+	d, err := json.Marshal(record)
+	if err != nil {
+		return data.ConvertError(err)
+	}
+	if _, err = w.writer.Write(append(d, '\n')); err != nil {
+		return data.NewEmptyError().AppendDescF("write plan file:%s error", w.path).AppendError(err)
+	}
+	return nil
+}
+
+func (w *planWriter) addOperation(operation *PlanOperation) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.summary.add(operation)
+	output.Result(operation)
+	if err := w.writeWithoutLock(operation); err != nil {
+		log.Error(err)
+	}
+}
+
+func (w *planWriter) close() *data.CodeError {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	output.Result(w.summary)
+	err := w.writeWithoutLock(w.summary)
+	if fErr := w.writer.Flush(); fErr != nil && err == nil {
+		err = data.ConvertError(fErr)
+	}
+	if cErr := w.file.Close(); cErr != nil && err == nil {
+		err = data.ConvertError(cErr)
+	}
+	return err
+}
+
+// Plan dry-run 生成的执行计划
+type Plan struct {
+	Header     *PlanHeader
+	Operations []*PlanOperation
+	Summary    *PlanSummary
+}
+
+// LoadPlan 加载执行计划文件，执行计划文件必须完整（包含 header 及 summary）且未被篡改
+func LoadPlan(path string) (*Plan, *data.CodeError) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, data.NewEmptyError().AppendDescF("open plan file:%s error", path).AppendError(err)
+	}
+	defer f.Close()
+
+	plan := &Plan{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+
+		record := &struct {
+			Type string `json:"type"`
+		}{}
+		if e := json.Unmarshal(line, record); e != nil {
+			return nil, data.NewEmptyError().AppendDescF("plan file line:%d invalid", lineNumber).AppendError(e)
+		}
+
+		if plan.Summary != nil {
+			return nil, data.NewEmptyError().AppendDescF("plan file line:%d invalid, record after summary", lineNumber)
+		}
+
+		switch record.Type {
+		case PlanRecordTypeHeader:
+			if plan.Header != nil {
+				return nil, data.NewEmptyError().AppendDescF("plan file line:%d invalid, duplicate header", lineNumber)
+			}
+			plan.Header = &PlanHeader{}
+			if e := json.Unmarshal(line, plan.Header); e != nil {
+				return nil, data.NewEmptyError().AppendDescF("plan file line:%d invalid", lineNumber).AppendError(e)
+			}
+			if plan.Header.Version != planVersion {
+				return nil, data.NewEmptyError().AppendDescF("plan file version:%d not support", plan.Header.Version)
+			}
+		case PlanRecordTypeOperation:
+			if plan.Header == nil {
+				return nil, data.NewEmptyError().AppendDescF("plan file line:%d invalid, header missing", lineNumber)
+			}
+			operation, e := loadPlanOperation(line)
+			if e != nil {
+				return nil, data.NewEmptyError().AppendDescF("plan file line:%d invalid", lineNumber).AppendError(e)
+			}
+			plan.Operations = append(plan.Operations, operation)
+		case PlanRecordTypeSummary:
+			plan.Summary = &PlanSummary{}
+			if e := json.Unmarshal(line, plan.Summary); e != nil {
+				return nil, data.NewEmptyError().AppendDescF("plan file line:%d invalid", lineNumber).AppendError(e)
+			}
+		default:
+			return nil, data.NewEmptyError().AppendDescF("plan file line:%d invalid, unknown record type:%s", lineNumber, record.Type)
+		}
+	}
+	if e := scanner.Err(); e != nil {
+		return nil, data.NewEmptyError().AppendDescF("read plan file:%s error", path).AppendError(e)
+	}
+
+	if plan.Header == nil {
+		return nil, data.NewEmptyError().AppendDescF("plan file:%s invalid, header missing", path)
+	}
+	if plan.Summary == nil {
+		return nil, data.NewEmptyError().AppendDescF("plan file:%s is incomplete, summary missing", path)
+	}
+	if plan.Summary.Total != int64(len(plan.Operations)) {
+		return nil, data.NewEmptyError().AppendDescF("plan file:%s invalid, operation count:%d is not equal to summary total:%d",
+			path, len(plan.Operations), plan.Summary.Total)
+	}
+	return plan, nil
+}
+
+func loadPlanOperation(line []byte) (*PlanOperation, *data.CodeError) {
+	operation := &PlanOperation{}
+	if e := json.Unmarshal(line, operation); e != nil {
+		return nil, data.ConvertError(e)
+	}
+
+	// 解析失败的操作无操作指令
+	if len(operation.Operation) == 0 {
+		if operation.Status != PlanStatusFail {
+			return nil, data.NewEmptyError().AppendDescF("operation of %s can't be empty", operation.Line)
+		}
+		return operation, nil
+	}
+
+	// 操作对象必须与操作指令一致
+	parsed, err := ParseOperation(operation.Operation)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Command != operation.Command ||
+		parsed.Bucket != operation.Bucket || parsed.Key != operation.Key ||
+		parsed.DestBucket != operation.DestBucket || parsed.DestKey != operation.DestKey ||
+		parsed.Force != operation.Force {
+		return nil, data.NewEmptyError().AppendDescF("operation:%s is not match the object of the record", operation.Operation)
+	}
+	operation.params = parsed.params
+	return operation, nil
+}
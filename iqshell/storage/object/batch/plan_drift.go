@@ -0,0 +1,143 @@
+package batch
+
+import (
+	"fmt"
+
+	"github.com/qiniu/go-sdk/v7/storage"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
+)
+
+const (
+	// 空间不存在
+	statusCodeNoSuchBucket = 631
+)
+
+// PlanDrift 执行计划中记录的对象状态与当前对象状态不一致
+type PlanDrift struct {
+	Operation *PlanOperation
+	Reason    string
+}
+
+func (d *PlanDrift) String() string {
+	return fmt.Sprintf("%s, %s", d.Operation.Operation, d.Reason)
+}
+
+// CheckPlanDrift 重新 stat 执行计划中操作涉及的对象，并与计划中记录的状态对比，返回状态发生变化的操作
+func CheckPlanDrift(operations []*PlanOperation) ([]*PlanDrift, *data.CodeError) {
+	bucketManager, err := bucket.GetBucketManager()
+	if err != nil {
+		return nil, err
+	}
+
+	// 按源空间分组，每组使用对应区域的 rs 服务
+	bucketOperations := make(map[string][]*PlanOperation)
+	bucketList := make([]string, 0)
+	for _, operation := range operations {
+		if _, ok := bucketOperations[operation.Bucket]; !ok {
+			bucketList = append(bucketList, operation.Bucket)
+		}
+		bucketOperations[operation.Bucket] = append(bucketOperations[operation.Bucket], operation)
+	}
+
+	driftList := make([]*PlanDrift, 0)
+	for _, bucketName := range bucketList {
+		if cErr := bucket.CompleteBucketManagerRegion(bucketManager, bucketName); cErr != nil {
+			return nil, data.NewEmptyError().AppendDescF("get region of bucket:%s error", bucketName).AppendError(cErr)
+		}
+
+		list := bucketOperations[bucketName]
+		// 带目标对象的操作需 stat 两次，所以每批数量减半
+		batchCount := defaultOperationCountPerRequest / 2
+		for start := 0; start < len(list); start += batchCount {
+			end := start + batchCount
+			if end > len(list) {
+				end = len(list)
+			}
+			drifts, sErr := checkPlanOperationsDrift(bucketManager, list[start:end])
+			if sErr != nil {
+				return nil, sErr
+			}
+			driftList = append(driftList, drifts...)
+		}
+	}
+	return driftList, nil
+}
+
+func checkPlanOperationsDrift(bucketManager *storage.BucketManager, operations []*PlanOperation) ([]*PlanDrift, *data.CodeError) {
+	statOperations := make([]string, 0, len(operations)*2)
+	for _, p := range operations {
+		statOperations = append(statOperations, storage.URIStat(p.Bucket, p.Key))
+		if p.hasDest() {
+			statOperations = append(statOperations, storage.URIStat(p.DestBucket, p.DestKey))
+		}
+	}
+
+	resultList, e := bucketManager.Batch(statOperations)
+	if len(resultList) != len(statOperations) {
+		return nil, data.NewEmptyError().AppendDesc("stat objects of plan error").AppendError(e)
+	}
+
+	driftList := make([]*PlanDrift, 0)
+	resultIndex := 0
+	for _, planned := range operations {
+		current := &PlanOperation{
+			Command:    planned.Command,
+			Bucket:     planned.Bucket,
+			Key:        planned.Key,
+			DestBucket: planned.DestBucket,
+			DestKey:    planned.DestKey,
+		}
+		if planned.hasDest() && resultList[resultIndex+1].Code == statusCodeNoSuchBucket {
+			driftList = append(driftList, &PlanDrift{
+				Operation: planned,
+				Reason:    fmt.Sprintf("bucket:%s not found", planned.DestBucket),
+			})
+			resultIndex += 2
+			continue
+		}
+		source := fillPlanOperationState(current, resultList, &resultIndex)
+		if reason := planOperationDrift(planned, current, source); len(reason) > 0 {
+			driftList = append(driftList, &PlanDrift{
+				Operation: planned,
+				Reason:    reason,
+			})
+		}
+	}
+	return driftList, nil
+}
+
+// planOperationDrift 对比计划中记录的状态及当前状态，对象存在性、hash 及 put time 任一不一致即认为发生变化
+func planOperationDrift(planned *PlanOperation, current *PlanOperation, source storage.BatchOpRet) string {
+	if source.Code == statusCodeNoSuchBucket {
+		return fmt.Sprintf("bucket:%s not found", planned.Bucket)
+	}
+	if !current.SourceExist && source.Code != statusCodeNoSuchEntry {
+		return fmt.Sprintf("stat source object error, code:%d error:%s", source.Code, source.Data.Error)
+	}
+
+	if planned.SourceExist != current.SourceExist {
+		return fmt.Sprintf("source object exist changed, plan:%v now:%v", planned.SourceExist, current.SourceExist)
+	}
+	if planned.SourceHash != current.SourceHash {
+		return fmt.Sprintf("source object hash changed, plan:%s now:%s", planned.SourceHash, current.SourceHash)
+	}
+	if planned.SourcePutTime != current.SourcePutTime {
+		return fmt.Sprintf("source object put time changed, plan:%d now:%d", planned.SourcePutTime, current.SourcePutTime)
+	}
+
+	if !planned.hasDest() {
+		return ""
+	}
+	if planned.DestExist != current.DestExist {
+		return fmt.Sprintf("destination object exist changed, plan:%v now:%v", planned.DestExist, current.DestExist)
+	}
+	if planned.DestHash != current.DestHash {
+		return fmt.Sprintf("destination object hash changed, plan:%s now:%s", planned.DestHash, current.DestHash)
+	}
+	if planned.DestPutTime != current.DestPutTime {
+		return fmt.Sprintf("destination object put time changed, plan:%d now:%d", planned.DestPutTime, current.DestPutTime)
+	}
+	return ""
+}
@@ -0,0 +1,207 @@
+package batch
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/qiniu/go-sdk/v7/auth"
+	"github.com/qiniu/go-sdk/v7/storage"
+)
+
+func TestParseOperation(t *testing.T) {
+	p, err := ParseOperation(storage.URIMove("src", "a:b", "dest", "c", true))
+	if err != nil {
+		t.Fatal("parse move operation error:", err)
+	}
+	if p.Command != "move" || p.Bucket != "src" || p.Key != "a:b" ||
+		p.DestBucket != "dest" || p.DestKey != "c" || !p.Force {
+		t.Fatalf("parse move operation error:%+v", p)
+	}
+
+	p, err = ParseOperation(storage.URIChangeType("bucket", "key", 1))
+	if err != nil {
+		t.Fatal("parse chtype operation error:", err)
+	}
+	if p.Command != "chtype" || p.Bucket != "bucket" || p.Key != "key" || p.params["type"] != "1" {
+		t.Fatalf("parse chtype operation error:%+v", p)
+	}
+
+	p, err = ParseOperation(storage.URIDelete("bucket", "key") + OperationConditionURI(OperationCondition{PutTime: "1"}))
+	if err != nil {
+		t.Fatal("parse delete operation error:", err)
+	}
+	if p.Command != "delete" || p.Key != "key" || len(p.params["cond"]) == 0 {
+		t.Fatalf("parse delete operation error:%+v", p)
+	}
+
+	if _, err = ParseOperation("/delete"); err == nil {
+		t.Fatal("parse invalid operation should error")
+	}
+}
+
+func TestPlanOperationStatus(t *testing.T) {
+	exist := storage.BatchOpRet{Code: 200}
+	notExist := storage.BatchOpRet{Code: statusCodeNoSuchEntry}
+
+	p, _ := ParseOperation(storage.URIDelete("bucket", "key"))
+	if status, _ := planOperationStatus(p, notExist); status != PlanStatusFail {
+		t.Fatal("delete not exist object should fail, but:", status)
+	}
+
+	p, _ = ParseOperation(storage.URICopy("bucket", "a", "bucket", "b", false))
+	p.SourceExist = true
+	p.DestExist = true
+	if status, _ := planOperationStatus(p, exist); status != PlanStatusFail {
+		t.Fatal("copy to exist object without force should fail, but:", status)
+	}
+
+	p, _ = ParseOperation(storage.URICopy("bucket", "a", "bucket", "b", true))
+	p.SourceExist = true
+	p.DestExist = true
+	if status, _ := planOperationStatus(p, exist); status != PlanStatusChange {
+		t.Fatal("copy to exist object with force should change, but:", status)
+	}
+
+	p, _ = ParseOperation(storage.URIChangeType("bucket", "key", 1))
+	p.SourceExist = true
+	p.SourceType = 1
+	if status, _ := planOperationStatus(p, exist); status != PlanStatusUnchanged {
+		t.Fatal("chtype to same type should unchanged, but:", status)
+	}
+
+	p, _ = ParseOperation(storage.URIChangeMime("bucket", "key", "text/plain"))
+	p.SourceExist = true
+	p.SourceMimeType = "image/png"
+	if status, _ := planOperationStatus(p, exist); status != PlanStatusChange {
+		t.Fatal("chgm to other mime should change, but:", status)
+	}
+
+	putTime := storage.BatchOpRet{Code: 200}
+	putTime.Data.PutTime = 16445676785097143
+	p, _ = ParseOperation(storage.URIDelete("bucket", "key") + OperationConditionURI(OperationCondition{PutTime: "16445676785097143"}))
+	p.SourceExist = true
+	if status, _ := planOperationStatus(p, putTime); status != PlanStatusChange {
+		t.Fatal("delete with matched condition should change, but:", status)
+	}
+
+	p, _ = ParseOperation(storage.URIDelete("bucket", "key") + OperationConditionURI(OperationCondition{PutTime: "16445676785097144"}))
+	p.SourceExist = true
+	if status, _ := planOperationStatus(p, putTime); status != PlanStatusFail {
+		t.Fatal("delete with mismatched condition should fail, but:", status)
+	}
+}
+
+func TestLoadPlan(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.jsonl")
+	operation := storage.URIMove("bucket", "a", "bucket", "b", false)
+	content := `{"type":"header","version":1,"cmd_id":"batchmove","access_key":"ak"}
+{"type":"operation","operation":"` + operation + `","command":"move","bucket":"bucket","key":"a","dest_bucket":"bucket","dest_key":"b","status":"change"}
+{"type":"summary","total":1,"change":1}
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	plan, err := LoadPlan(path)
+	if err != nil {
+		t.Fatal("load plan error:", err)
+	}
+	if plan.Header.AccessKey != "ak" || len(plan.Operations) != 1 || plan.Summary.Change != 1 {
+		t.Fatalf("load plan error:%+v", plan)
+	}
+
+	// 记录的对象与操作指令不一致
+	tampered := strings.Replace(content, `"dest_key":"b"`, `"dest_key":"c"`, 1)
+	if err := os.WriteFile(path, []byte(tampered), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = LoadPlan(path); err == nil {
+		t.Fatal("load tampered plan should error")
+	}
+
+	// 缺少 summary
+	incomplete := content[:strings.LastIndex(content, `{"type":"summary"`)]
+	if err := os.WriteFile(path, []byte(incomplete), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = LoadPlan(path); err == nil {
+		t.Fatal("load incomplete plan should error")
+	}
+}
+
+func TestPlanOperationDrift(t *testing.T) {
+	planned, _ := ParseOperation(storage.URICopy("bucket", "a", "bucket", "b", true))
+	planned.SourceExist = true
+	planned.SourceHash = "hash"
+	planned.SourcePutTime = 1
+
+	current := *planned
+	exist := storage.BatchOpRet{Code: 200}
+	if reason := planOperationDrift(planned, &current, exist); len(reason) > 0 {
+		t.Fatal("same object should not drift, but:", reason)
+	}
+
+	current.SourcePutTime = 2
+	if reason := planOperationDrift(planned, &current, exist); len(reason) == 0 {
+		t.Fatal("put time changed should drift")
+	}
+
+	current = *planned
+	current.DestExist = true
+	if reason := planOperationDrift(planned, &current, exist); len(reason) == 0 {
+		t.Fatal("dest created should drift")
+	}
+
+	current = *planned
+	current.SourceExist = false
+	if reason := planOperationDrift(planned, &current, storage.BatchOpRet{Code: statusCodeNoSuchBucket}); len(reason) == 0 {
+		t.Fatal("bucket not found should drift")
+	}
+}
+
+func TestBatchStatSplit(t *testing.T) {
+	requestCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parse batch request error:%v", err)
+		}
+		operations := r.Form["op"]
+		if len(operations) > defaultOperationCountPerRequest {
+			t.Errorf("batch operation count:%d exceed limit:%d", len(operations), defaultOperationCountPerRequest)
+		}
+		requestCount++
+		ret := make([]map[string]interface{}, 0, len(operations))
+		for range operations {
+			ret = append(ret, map[string]interface{}{"code": 200, "data": map[string]interface{}{"fsize": 1}})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Reqid", "test")
+		json.NewEncoder(w).Encode(ret)
+	}))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	region := &storage.Region{RsHost: host, RsfHost: host, ApiHost: host, IovipHost: host}
+	bucketManager := storage.NewBucketManager(auth.New("ak", "sk"), &storage.Config{
+		Region:        region,
+		Zone:          region,
+		CentralRsHost: host,
+	})
+
+	statOperations := make([]string, 0)
+	for i := 0; i < defaultOperationCountPerRequest*2+1; i++ {
+		statOperations = append(statOperations, storage.URIStat("bucket", strconv.Itoa(i)))
+	}
+	results, err := batchStat(bucketManager, statOperations)
+	if err != nil {
+		t.Fatal("batch stat error:", err)
+	}
+	if len(results) != len(statOperations) || requestCount != 3 {
+		t.Fatalf("batch stat results:%d requests:%d", len(results), requestCount)
+	}
+}
@@ -31,6 +31,7 @@ type DownloadActionInfo struct {
 	ServerFilePutTime      int64             `json:"server_file_put_time"` // 文件修改时间 【选填】
 	ServerFileSize         int64             `json:"server_file_size"`     // 文件大小，有值则会检测文件大小 【选填】
 	ServerFileHash         string            `json:"server_file_hash"`     // 文件 hash，有值则会检测 hash 【选填】
+	ServerFileMd5          string            `json:"server_file_md5"`      // 文件 md5，下载后校验时使用 【选填】
 	DownloadFileSize       int64             `json:"download_file_size"`   // 下载的文件大小，下载整个文件时，等于 ServerFileSize；切片下载则为切片大小；有值则会检测文件大小【选填】
 	CheckSize              bool              `json:"-"`                    // 是否检测文件大小 【选填】
 	CheckHash              bool              `json:"-"`                    // 是否检测文件 hash 【选填】
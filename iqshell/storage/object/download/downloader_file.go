@@ -86,7 +86,7 @@ func (d *downloaderFile) download(info *DownloadApiInfo) (response *http.Respons
 	if workspace.IsCmdInterrupt() {
 		return nil, data.CancelError
 	}
-	response, rErr := client.DefaultStorageClient().DoRequest(workspace.GetContext(), "GET", info.downloadUrl, headers)
+	response, rErr := client.TransferStorageClient().DoRequest(workspace.GetContext(), "GET", info.downloadUrl, headers)
 	if info.CheckHash && len(info.FileHash) != 0 && response != nil && response.Header != nil {
 		etag := fmt.Sprintf(response.Header.Get("Etag"))
 		etag = utils.ParseEtag(etag)
@@ -10,11 +10,11 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/export"
 	"github.com/qiniu/qshell/v2/iqshell/common/flow"
-	"github.com/qiniu/qshell/v2/iqshell/common/host"
 	"github.com/qiniu/qshell/v2/iqshell/common/locker"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
 	"github.com/qiniu/qshell/v2/iqshell/common/utils"
 	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object/download"
 )
 
@@ -152,6 +152,16 @@ func BatchDownload(cfg *iqshell.Config, info BatchDownloadInfo) {
 	metric := &Metric{}
 	metric.Start()
 
+	verifier, err := newFileVerifier(&info.DownloadCfg, metric)
+	if err != nil {
+		log.Error(err)
+		data.SetCmdStatusError()
+		return
+	}
+	if verifier != nil {
+		defer verifier.close()
+	}
+
 	hasPrefixes := len(info.Prefix) > 0
 	prefixes := strings.Split(info.Prefix, ",")
 	filterPrefix := func(name string) bool {
@@ -182,15 +192,18 @@ func BatchDownload(cfg *iqshell.Config, info BatchDownloadInfo) {
 		return true
 	}
 
-	var savePathTemplate *utils.Template
-	if len(info.SavePathHandler) > 0 {
-		if t, tErr := utils.NewTemplate(info.SavePathHandler); tErr != nil {
-			data.SetCmdStatusError()
-			log.ErrorF("create save path template fail, %v", savePathTemplate)
-			return
-		} else {
-			savePathTemplate = t
-		}
+	buildApiInfo, err := newDownloadActionInfoBuilder(&info.DownloadCfg, hosts)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.Error(err)
+		return
+	}
+
+	listFilter, err := bucket.NewListObjectFilter(info.Filter)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("create download filter error:%v", err)
+		return
 	}
 
 	apiPrefix := ""
@@ -200,44 +213,25 @@ func BatchDownload(cfg *iqshell.Config, info BatchDownloadInfo) {
 	}
 
 	flow.New(info.Info).
-		WorkProvider(NewWorkProvider(info.Bucket, apiPrefix, info.InputFile, info.ItemSeparate, func(apiInfo *download.DownloadActionInfo) *data.CodeError {
-			apiInfo.Bucket = info.Bucket
-			apiInfo.IsPublic = info.Public
-			apiInfo.HostProvider = host.NewListProvider(hosts)
-			apiInfo.Referer = info.Referer
-			apiInfo.FileEncoding = info.FileEncoding
-			apiInfo.CheckHash = info.CheckHash
-			apiInfo.CheckSize = info.CheckSize
-			apiInfo.RemoveTempWhileError = info.RemoveTempWhileError
-			apiInfo.UseGetFileApi = info.GetFileApi
-			apiInfo.EnableSlice = info.EnableSlice
-			apiInfo.SliceSize = info.SliceSize
-			apiInfo.SliceConcurrentCount = info.SliceConcurrentCount
-			apiInfo.SliceFileSizeThreshold = info.SliceFileSizeThreshold
-
-			apiInfo.DestDir = info.DestDir
-			apiInfo.ToFile = filepath.Join(info.DestDir, apiInfo.Key)
-			if savePathTemplate != nil {
-				if path, rErr := savePathTemplate.Run(apiInfo); rErr != nil {
-					return rErr
-				} else {
-					apiInfo.ToFile = path
-				}
-			}
-			return nil
-		})).
+		WorkProvider(NewWorkProvider(info.Bucket, apiPrefix, listFilter, info.InputFile, info.RetryFailedFrom, info.ItemSeparate, buildApiInfo)).
 		WorkerProvider(flow.NewWorkerProvider(func() (flow.Worker, *data.CodeError) {
 			return flow.NewSimpleWorker(func(workInfo *flow.WorkInfo) (flow.Result, *data.CodeError) {
 				apiInfo := workInfo.Work.(*download.DownloadActionInfo)
 				metric.AddCurrentCount(1)
 				metric.PrintProgress("Downloading: " + workInfo.Data)
 
-				if file, e := downloadFile(apiInfo); e != nil {
+				file, e := downloadFile(apiInfo)
+				if e != nil {
 					return nil, e
-				} else {
-					log.DebugF("Download Result:%+v", file)
-					return file, nil
 				}
+				log.DebugF("Download Result:%+v", file)
+
+				if verifier != nil {
+					if vErr := verifier.verify(apiInfo, file); vErr != nil {
+						return nil, vErr
+					}
+				}
+				return file, nil
 			}), nil
 		})).
 		DoWorkListMaxCount(1).
@@ -329,6 +323,7 @@ func BatchDownload(cfg *iqshell.Config, info BatchDownloadInfo) {
 
 			exporter.Success().Export(workInfo.Data)
 		}).
+		OnConcurrencyChange(metric.OnConcurrencyChange).
 		OnWorkFail(func(workInfo *flow.WorkInfo, err *data.CodeError) {
 			metric.AddFailureCount(1)
 
@@ -358,6 +353,10 @@ func BatchDownload(cfg *iqshell.Config, info BatchDownloadInfo) {
 	log.InfoF("%10s%10d", "Success:", metric.SuccessCount)
 	log.InfoF("%10s%10d", "Update:", metric.UpdateCount)
 	log.InfoF("%10s%10d", "Failure:", metric.FailureCount)
+	if verifier != nil {
+		log.InfoF("%10s%10d", "Verified:", metric.VerifiedCount)
+		log.InfoF("%10s%10d", "Mismatch:", metric.MismatchCount)
+	}
 	log.InfoF("%10s%10ds", "Duration:", metric.Duration)
 	log.InfoF("-----------------------------")
 	if workspace.GetConfig().Log.Enable() {
@@ -15,6 +15,7 @@ type DownloadCfg struct {
 	Prefix                 string `json:"prefix,omitempty"`
 	SavePathHandler        string `json:"save_path_handler"`
 	Suffixes               string `json:"suffixes,omitempty"`
+	Filter                 string `json:"filter,omitempty"`
 	IoHost                 string `json:"io_host,omitempty"`
 	Public                 bool   `json:"public,omitempty"`
 	CheckSize              bool   `json:"check_size,omitempty"`
@@ -37,6 +38,13 @@ type DownloadCfg struct {
 
 	// 下载状态保存路径
 	RecordRoot string `json:"record_root,omitempty"`
+
+	// 默认下载完成后使用 qetag 校验文件，服务端记录了 md5 时同时校验 md5；DisableVerify 为 true 时不校验
+	DisableVerify bool `json:"disable_verify,omitempty"`
+	// 校验不通过的文件的隔离目录，默认为任务目录下的 quarantine 目录
+	QuarantineDir string `json:"quarantine_dir,omitempty"`
+	// 校验结果导出文件，每行一个文件的校验结果
+	VerifyExportFilePath string `json:"verify_export_file,omitempty"`
 }
 
 func DefaultDownloadCfg() DownloadCfg {
@@ -7,10 +7,16 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/host"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
 	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object/download"
 )
 
+// GetDownloadHosts 获取下载使用的域名，供其他需要下载的命令（如：sync）使用
+func GetDownloadHosts(downloadCfg *DownloadCfg) []*host.Host {
+	return getDownloadHosts(workspace.GetConfig(), downloadCfg)
+}
+
 func getDownloadHostProvider(cfg *config.Config, downloadCfg *DownloadCfg) host.Provider {
 	hosts := getDownloadHosts(cfg, downloadCfg)
 	return host.NewListProvider(hosts)
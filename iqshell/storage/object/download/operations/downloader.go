@@ -0,0 +1,128 @@
+package operations
+
+import (
+	"path/filepath"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/export"
+	"github.com/qiniu/qshell/v2/iqshell/common/host"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/download"
+)
+
+// newDownloadActionInfoBuilder 返回按下载配置填充下载信息的方法，调用前下载信息的 Key 需已设置
+func newDownloadActionInfoBuilder(cfg *DownloadCfg, hosts []*host.Host) (func(apiInfo *download.DownloadActionInfo) *data.CodeError, *data.CodeError) {
+	var savePathTemplate *utils.Template
+	if len(cfg.SavePathHandler) > 0 {
+		t, tErr := utils.NewTemplate(cfg.SavePathHandler)
+		if tErr != nil {
+			return nil, data.NewEmptyError().AppendDesc("create save path template fail").AppendError(tErr)
+		}
+		savePathTemplate = t
+	}
+
+	return func(apiInfo *download.DownloadActionInfo) *data.CodeError {
+		apiInfo.Bucket = cfg.Bucket
+		apiInfo.IsPublic = cfg.Public
+		apiInfo.HostProvider = host.NewListProvider(hosts)
+		apiInfo.Referer = cfg.Referer
+		apiInfo.FileEncoding = cfg.FileEncoding
+		apiInfo.CheckHash = cfg.CheckHash
+		apiInfo.CheckSize = cfg.CheckSize
+		apiInfo.RemoveTempWhileError = cfg.RemoveTempWhileError
+		apiInfo.UseGetFileApi = cfg.GetFileApi
+		apiInfo.EnableSlice = cfg.EnableSlice
+		apiInfo.SliceSize = cfg.SliceSize
+		apiInfo.SliceConcurrentCount = cfg.SliceConcurrentCount
+		apiInfo.SliceFileSizeThreshold = cfg.SliceFileSizeThreshold
+
+		apiInfo.DestDir = cfg.DestDir
+		apiInfo.ToFile = filepath.Join(cfg.DestDir, apiInfo.Key)
+		if savePathTemplate != nil {
+			if path, rErr := savePathTemplate.Run(apiInfo); rErr != nil {
+				return rErr
+			} else {
+				apiInfo.ToFile = path
+			}
+		}
+		return nil
+	}, nil
+}
+
+// Downloader 按 qdownload2 的下载配置下载指定的文件，保存路径及下载后的校验与 qdownload2 一致，供其他需要下载的命令（如：batchrestorear）使用
+type Downloader struct {
+	buildApiInfo func(apiInfo *download.DownloadActionInfo) *data.CodeError
+	verifier     *fileVerifier
+	Metric       *Metric
+}
+
+func NewDownloader(cfg *DownloadCfg) (*Downloader, *data.CodeError) {
+	hosts := GetDownloadHosts(cfg)
+	if len(hosts) == 0 {
+		return nil, data.NewEmptyError().AppendDescF("get download domain error: not find in config and can't get bucket(%s) domain, you can set domain or bind domain to bucket", cfg.Bucket)
+	}
+
+	buildApiInfo, err := newDownloadActionInfoBuilder(cfg, hosts)
+	if err != nil {
+		return nil, err
+	}
+
+	metric := &Metric{}
+	verifier, err := newFileVerifier(cfg, metric)
+	if err != nil {
+		return nil, err
+	}
+	return &Downloader{
+		buildApiInfo: buildApiInfo,
+		verifier:     verifier,
+		Metric:       metric,
+	}, nil
+}
+
+// Download 下载文件，apiInfo 中仅需设置 Key 及服务端文件的信息，其他信息由下载配置填充
+func (d *Downloader) Download(apiInfo *download.DownloadActionInfo) (*download.DownloadActionResult, *data.CodeError) {
+	if err := d.buildApiInfo(apiInfo); err != nil {
+		return nil, err
+	}
+
+	res, err := downloadFile(apiInfo)
+	if err != nil {
+		return res, err
+	}
+	if d.verifier != nil {
+		if vErr := d.verifier.verify(apiInfo, res); vErr != nil {
+			return res, vErr
+		}
+	}
+	return res, nil
+}
+
+// Close 下载结束后调用
+func (d *Downloader) Close() {
+	if d.verifier != nil {
+		d.verifier.close()
+	}
+}
+
+// newFileVerifier 下载配置关闭校验时返回 nil
+func newFileVerifier(cfg *DownloadCfg, metric *Metric) (*fileVerifier, *data.CodeError) {
+	if cfg.DisableVerify {
+		return nil, nil
+	}
+
+	if len(cfg.QuarantineDir) == 0 {
+		cfg.QuarantineDir = filepath.Join(workspace.GetJobDir(), "quarantine")
+	}
+	exporter, err := export.New(cfg.VerifyExportFilePath)
+	if err != nil {
+		return nil, err
+	}
+	log.InfoF("download verify enabled, quarantine dir:%s", cfg.QuarantineDir)
+	return &fileVerifier{
+		quarantineDir: cfg.QuarantineDir,
+		exporter:      exporter,
+		metric:        metric,
+	}, nil
+}
@@ -0,0 +1,28 @@
+package operations
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestNewFileVerifier(t *testing.T) {
+	quarantineDir := filepath.Join(t.TempDir(), "quarantine")
+
+	// 默认开启校验
+	verifier, err := newFileVerifier(&DownloadCfg{QuarantineDir: quarantineDir}, &Metric{})
+	if err != nil {
+		t.Fatal("new file verifier error:", err)
+	}
+	if verifier == nil {
+		t.Fatal("verify should be enabled by default")
+	}
+	verifier.close()
+
+	verifier, err = newFileVerifier(&DownloadCfg{QuarantineDir: quarantineDir, DisableVerify: true}, &Metric{})
+	if err != nil {
+		t.Fatal("new file verifier error:", err)
+	}
+	if verifier != nil {
+		t.Fatal("verify should be disabled by disable_verify")
+	}
+}
@@ -7,6 +7,9 @@ type Metric struct {
 
 	ExistCount  int64 `json:"exist_count"`
 	UpdateCount int64 `json:"update_count"`
+
+	VerifiedCount int64 `json:"verified_count"`
+	MismatchCount int64 `json:"mismatch_count"`
 }
 
 func (m *Metric) AddExistCount(count int64) {
@@ -23,3 +26,15 @@ func (m *Metric) AddUpdateCount(count int64) {
 	m.UpdateCount += count
 	m.Unlock()
 }
+
+func (m *Metric) AddVerifiedCount(count int64) {
+	m.Lock()
+	m.VerifiedCount += count
+	m.Unlock()
+}
+
+func (m *Metric) AddMismatchCount(count int64) {
+	m.Lock()
+	m.MismatchCount += count
+	m.Unlock()
+}
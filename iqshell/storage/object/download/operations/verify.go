@@ -0,0 +1,67 @@
+package operations
+
+import (
+	"strings"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/export"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/download"
+)
+
+// fileVerifier 下载完成后校验文件，校验不通过的文件移动至隔离目录，所有校验结果输出至 exporter
+// 输出格式：Key\tStatus\tLocalFile\tFileSize\tHash\tServerFileHash\tMd5\tServerFileMd5\tCrc32\tDesc
+type fileVerifier struct {
+	quarantineDir string
+	exporter      export.Exporter
+	metric        *Metric
+}
+
+func (v *fileVerifier) verify(info *download.DownloadActionInfo, res *download.DownloadActionResult) *data.CodeError {
+	// 文件夹不需要校验
+	if strings.HasSuffix(info.Key, "/") {
+		return nil
+	}
+
+	result, err := download.Verify(download.VerifyApiInfo{
+		Bucket:         info.Bucket,
+		Key:            info.Key,
+		LocalFile:      res.FileAbsPath,
+		ServerFileHash: info.ServerFileHash,
+		ServerFileMd5:  info.ServerFileMd5,
+		ServerFileSize: info.ServerFileSize,
+	})
+	v.metric.AddVerifiedCount(1)
+	v.export(info, res, result)
+	if err != nil {
+		return data.NewEmptyError().AppendDescF("verify [%s:%s] => %s error", info.Bucket, info.Key, res.FileAbsPath).AppendError(err)
+	}
+	if result.IsMatch() {
+		log.DebugF("Verify Success, [%s:%s] => %s", info.Bucket, info.Key, res.FileAbsPath)
+		return nil
+	}
+
+	v.metric.AddMismatchCount(1)
+	quarantineFile, qErr := download.Quarantine(res.FileAbsPath, v.quarantineDir, info.Key)
+	if qErr != nil {
+		log.ErrorF("Verify Failed, [%s:%s] => %s quarantine error:%v", info.Bucket, info.Key, res.FileAbsPath, qErr)
+		return data.NewEmptyError().AppendDescF("verify [%s:%s] => %s mismatch, %s", info.Bucket, info.Key, res.FileAbsPath, result.Desc)
+	}
+	log.ErrorF("Verify Failed, [%s:%s] => %s %s, quarantined to:%s", info.Bucket, info.Key, res.FileAbsPath, result.Desc, quarantineFile)
+	return data.NewEmptyError().AppendDescF("verify [%s:%s] mismatch, %s, quarantined to:%s", info.Bucket, info.Key, result.Desc, quarantineFile)
+}
+
+func (v *fileVerifier) export(info *download.DownloadActionInfo, res *download.DownloadActionResult, result *download.VerifyResult) {
+	if result == nil {
+		return
+	}
+	v.exporter.ExportF("%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s",
+		info.Key, result.Status, res.FileAbsPath, result.FileSize,
+		result.Hash, result.ServerFileHash,
+		result.Md5, result.ServerFileMd5,
+		result.Crc32, result.Desc)
+}
+
+func (v *fileVerifier) close() {
+	_ = v.exporter.Close()
+}
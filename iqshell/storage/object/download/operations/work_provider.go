@@ -15,17 +15,21 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/storage/object/download"
 )
 
-func NewWorkProvider(bucket, keyPrefix, inputFile, itemSeparate string, infoResetHandler apiInfoResetHandler) flow.WorkProvider {
+// NewWorkProvider 下载 work 的提供者；retryFailedFrom 不为空时仅下载之前失败的文件，否则优先从 inputFile 中读取，最后列举 bucket；
+// filter 不为空时仅下载满足过滤条件的文件
+func NewWorkProvider(bucket, keyPrefix string, filter *bucket.ListObjectFilter, inputFile, retryFailedFrom, itemSeparate string, infoResetHandler apiInfoResetHandler) flow.WorkProvider {
 	provider := &workProvider{
 		totalCount:       0,
 		bucket:           bucket,
 		keyPrefix:        keyPrefix,
+		filter:           filter,
 		inputFile:        inputFile,
+		retryFailedFrom:  retryFailedFrom,
 		itemSeparate:     itemSeparate,
 		infoResetHandler: infoResetHandler,
 		downloadItemChan: make(chan *downloadItem),
 	}
-	if len(inputFile) > 0 {
+	if len(inputFile) > 0 || len(retryFailedFrom) > 0 {
 		provider.getWorkInfoFromFile()
 	} else {
 		provider.getWorkInfoFromBucket()
@@ -39,8 +43,10 @@ type workProvider struct {
 	totalCount       int64
 	itemSeparate     string
 	inputFile        string
+	retryFailedFrom  string
 	bucket           string
 	keyPrefix        string
+	filter           *bucket.ListObjectFilter
 	infoResetHandler apiInfoResetHandler
 	downloadItemChan chan *downloadItem
 }
@@ -60,46 +66,55 @@ func (w *workProvider) Provide() (hasMore bool, workInfo *flow.WorkInfo, err *da
 }
 
 func (w *workProvider) getWorkInfoFromFile() {
-	if len(w.inputFile) == 0 {
+	if len(w.inputFile) == 0 && len(w.retryFailedFrom) == 0 {
 		return
 	}
 
-	w.totalCount = utils.GetFileLineCount(w.inputFile)
+	lineParser := bucket.NewListLineParser()
+	workPro, err := w.newFileWorkProvider(flow.NewItemsWorkCreator(w.itemSeparate,
+		1,
+		func(items []string) (work flow.Work, err *data.CodeError) {
+			listObject, e := lineParser.Parse(items)
+			if e != nil {
+				return nil, e
+			}
 
-	go func() {
-		lineParser := bucket.NewListLineParser()
-		workPro, err := flow.NewWorkProviderOfFile(w.inputFile, false, flow.NewItemsWorkCreator(w.itemSeparate,
-			1,
-			func(items []string) (work flow.Work, err *data.CodeError) {
-				listObject, e := lineParser.Parse(items)
-				if e != nil {
-					return nil, e
-				}
+			if len(listObject.Key) == 0 {
+				return nil, alert.Error("key invalid", "")
+			}
 
-				if len(listObject.Key) == 0 {
-					return nil, alert.Error("key invalid", "")
-				}
+			// 有完整的文件信息时直接过滤，否则获取文件信息后再过滤
+			if listObject.PutTime > 0 && !w.filter.Match(*listObject) {
+				log.DebugF("download skip [%s:%s], filter not match", w.bucket, listObject.Key)
+				return nil, nil
+			}
 
-				info := &download.DownloadActionInfo{
-					Key:               listObject.Key,
-					ServerFileSize:    listObject.Fsize,
-					ServerFileHash:    listObject.Hash,
-					ServerFilePutTime: listObject.PutTime,
-				}
-				if w.infoResetHandler != nil {
-					if e = w.infoResetHandler(info); e != nil {
-						return nil, e
-					}
+			info := &download.DownloadActionInfo{
+				Key:               listObject.Key,
+				ServerFileSize:    listObject.Fsize,
+				ServerFileHash:    listObject.Hash,
+				ServerFilePutTime: listObject.PutTime,
+			}
+			if w.infoResetHandler != nil {
+				if e = w.infoResetHandler(info); e != nil {
+					return nil, e
 				}
-				return info, nil
-			}))
+			}
+			return info, nil
+		}))
+	if err != nil {
+		log.ErrorF("download create work provider error:%v", err)
+		close(w.downloadItemChan)
+		return
+	}
 
-		if err != nil {
-			log.ErrorF("download create work provider error:%v", err)
-			close(w.downloadItemChan)
-			return
-		}
+	if len(w.retryFailedFrom) > 0 {
+		w.totalCount = workPro.WorkTotalCount()
+	} else {
+		w.totalCount = utils.GetFileLineCount(w.inputFile)
+	}
 
+	go func() {
 		var keys []string
 		for {
 			if len(keys) == 300 {
@@ -140,6 +155,14 @@ func (w *workProvider) getWorkInfoFromFile() {
 	}()
 }
 
+func (w *workProvider) newFileWorkProvider(creator flow.WorkCreator) (flow.WorkProvider, *data.CodeError) {
+	if len(w.retryFailedFrom) == 0 {
+		return flow.NewWorkProviderOfFile(w.inputFile, false, creator)
+	}
+
+	return flow.NewRetryFailedWorkProvider(w.retryFailedFrom, creator)
+}
+
 func (w *workProvider) getWorkInfoOfKeys(keys []string) {
 	if len(keys) == 0 {
 		return
@@ -166,10 +189,26 @@ func (w *workProvider) getWorkInfoOfKeys(keys []string) {
 				}
 				downItem.err = data.NewError(result.Code, result.Error)
 			} else {
+				if !w.filter.Match(bucket.ListObject{
+					Key:      item.Key,
+					PutTime:  result.PutTime,
+					Hash:     result.Hash,
+					Fsize:    result.FSize,
+					MimeType: result.MimeType,
+					EndUser:  result.EndUser,
+					Type:     result.Type,
+					Md5:      result.MD5,
+					Status:   result.Status,
+				}) {
+					log.DebugF("download skip [%s:%s], filter not match", w.bucket, item.Key)
+					continue
+				}
+
 				info := &download.DownloadActionInfo{
 					Bucket:            w.bucket,
 					Key:               item.Key,
 					ServerFileHash:    result.Hash,
+					ServerFileMd5:     result.MD5,
 					ServerFileSize:    result.FSize,
 					ServerFilePutTime: result.PutTime,
 				}
@@ -215,12 +254,14 @@ func (w *workProvider) getWorkInfoFromBucket() {
 			StartTime: time.Time{},
 			EndTime:   time.Time{},
 			Suffixes:  nil,
+			Filter:    w.filter,
 			MaxRetry:  20,
 		}, func(marker string, object bucket.ListObject) (bool, *data.CodeError) {
 			info := &download.DownloadActionInfo{
 				Bucket:            w.bucket,
 				Key:               object.Key,
 				ServerFileHash:    object.Hash,
+				ServerFileMd5:     object.Md5,
 				ServerFileSize:    object.Fsize,
 				ServerFilePutTime: object.PutTime,
 			}
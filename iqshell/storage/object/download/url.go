@@ -32,12 +32,12 @@ func PublicUrl(info UrlApiInfo) (fileUrl string) {
 
 // PublicUrlToPrivateApiInfo 私有下载链接
 type PublicUrlToPrivateApiInfo struct {
-	PublicUrl string
-	Deadline  int64
+	PublicUrl string `json:"public_url"`
+	Deadline  int64  `json:"deadline"`
 }
 
 type PublicUrlToPrivateApiResult struct {
-	Url string
+	Url string `json:"url"`
 }
 
 var _ flow.Result = (*PublicUrlToPrivateApiResult)(nil)
@@ -0,0 +1,187 @@
+package download
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object"
+)
+
+const (
+	VerifyStatusOK       = "ok"
+	VerifyStatusMismatch = "mismatch"
+	VerifyStatusError    = "error"
+)
+
+type VerifyApiInfo struct {
+	Bucket         string // 文件所在 bucket 【必填】
+	Key            string // 文件的 key 【必填】
+	LocalFile      string // 下载后的本地文件 【必填】
+	ServerFileHash string // 服务端文件 qetag，可以是 etagV1, 也可以是 etagV2；为空时会从服务获取 【选填】
+	ServerFileMd5  string // 服务端文件 md5，为空时不校验 md5 【选填】
+	ServerFileSize int64  // 服务端文件大小，<= 0 时不校验大小 【选填】
+}
+
+type VerifyResult struct {
+	Status         string `json:"status"`           // 校验结果：ok、mismatch、error
+	FileSize       int64  `json:"file_size"`        // 本地文件大小
+	Hash           string `json:"hash"`             // 本地文件 qetag
+	ServerFileHash string `json:"server_file_hash"` // 服务端文件 qetag
+	Md5            string `json:"md5"`              // 本地文件 md5
+	ServerFileMd5  string `json:"server_file_md5"`  // 服务端文件 md5，为空表示服务端未记录 md5
+	Crc32          string `json:"crc32"`            // 本地文件 crc32，仅用于记录
+	Desc           string `json:"desc"`             // 不匹配或出错的原因
+}
+
+func (r *VerifyResult) IsMatch() bool {
+	return r != nil && r.Status == VerifyStatusOK
+}
+
+// Verify 校验下载后的本地文件：一次读取同时计算 qetag、md5 及 crc32，qetag 与服务端 Hash 比较，服务端有 md5 时同时比较 md5；
+// 校验过程出错时返回 error，文件不匹配时 error 为空，通过 VerifyResult.Status 区分
+func Verify(info VerifyApiInfo) (*VerifyResult, *data.CodeError) {
+	result := &VerifyResult{
+		Status:         VerifyStatusError,
+		ServerFileHash: info.ServerFileHash,
+		ServerFileMd5:  info.ServerFileMd5,
+	}
+
+	var parts []int64
+	if len(info.ServerFileHash) == 0 || utils.IsSignByEtagV2(info.ServerFileHash) {
+		// etag v2 需要分片信息
+		stat, sErr := object.Status(object.StatusApiInfo{
+			Bucket:   info.Bucket,
+			Key:      info.Key,
+			NeedPart: true,
+		})
+		if sErr != nil {
+			result.Desc = fmt.Sprintf("get file status error:%v", sErr)
+			return result, data.NewEmptyError().AppendDesc("verify, get file status").AppendError(sErr)
+		}
+		info.ServerFileHash = stat.Hash
+		if len(info.ServerFileMd5) == 0 {
+			info.ServerFileMd5 = stat.MD5
+		}
+		if info.ServerFileSize <= 0 {
+			info.ServerFileSize = stat.FSize
+		}
+		parts = stat.Parts
+		result.ServerFileHash = info.ServerFileHash
+		result.ServerFileMd5 = info.ServerFileMd5
+	}
+
+	f, oErr := os.Open(info.LocalFile)
+	if oErr != nil {
+		result.Desc = fmt.Sprintf("open local file error:%v", oErr)
+		return result, data.NewEmptyError().AppendDesc("verify, open local file").AppendError(oErr)
+	}
+	defer f.Close()
+
+	md5Hash := md5.New()
+	crc32Hash := crc32.NewIEEE()
+	counter := &verifyCounter{}
+	reader := io.TeeReader(f, io.MultiWriter(md5Hash, crc32Hash, counter))
+
+	var hash string
+	var hErr *data.CodeError
+	if utils.IsSignByEtagV2(info.ServerFileHash) {
+		hash, hErr = utils.EtagV2(reader, parts)
+	} else {
+		hash, hErr = utils.EtagV1(reader)
+	}
+	if hErr != nil {
+		result.Desc = fmt.Sprintf("get local file qetag error:%v", hErr)
+		return result, data.NewEmptyError().AppendDesc("verify, get local file qetag").AppendError(hErr)
+	}
+	// 确保 md5 及 crc32 覆盖整个文件
+	if _, cErr := io.Copy(io.Discard, reader); cErr != nil {
+		result.Desc = fmt.Sprintf("read local file error:%v", cErr)
+		return result, data.NewEmptyError().AppendDesc("verify, read local file").AppendError(cErr)
+	}
+
+	result.FileSize = counter.size
+	result.Hash = hash
+	result.Md5 = hex.EncodeToString(md5Hash.Sum(nil))
+	result.Crc32 = fmt.Sprintf("%d", crc32Hash.Sum32())
+
+	mismatches := make([]string, 0)
+	if info.ServerFileSize > 0 && info.ServerFileSize != result.FileSize {
+		mismatches = append(mismatches, fmt.Sprintf("size:%d except:%d", result.FileSize, info.ServerFileSize))
+	}
+	if result.Hash != info.ServerFileHash {
+		mismatches = append(mismatches, fmt.Sprintf("qetag:%s except:%s", result.Hash, info.ServerFileHash))
+	}
+	if len(info.ServerFileMd5) > 0 && !strings.EqualFold(result.Md5, info.ServerFileMd5) {
+		mismatches = append(mismatches, fmt.Sprintf("md5:%s except:%s", result.Md5, info.ServerFileMd5))
+	}
+
+	if len(mismatches) > 0 {
+		result.Status = VerifyStatusMismatch
+		result.Desc = strings.Join(mismatches, ", ")
+		log.DebugF("verify [%s:%s] => %s, %s", info.Bucket, info.Key, info.LocalFile, result.Desc)
+	} else {
+		result.Status = VerifyStatusOK
+	}
+	return result, nil
+}
+
+type verifyCounter struct {
+	size int64
+}
+
+func (c *verifyCounter) Write(p []byte) (int, error) {
+	c.size += int64(len(p))
+	return len(p), nil
+}
+
+// Quarantine 将校验不通过的文件移动至隔离目录，隔离后的文件路径为：quarantineDir + key，返回隔离后的路径
+func Quarantine(localFile, quarantineDir, key string) (string, *data.CodeError) {
+	name := strings.TrimLeft(filepath.Clean(string(filepath.Separator)+key), string(filepath.Separator))
+	if len(name) == 0 {
+		name = filepath.Base(localFile)
+	}
+	toFile := filepath.Join(quarantineDir, name)
+	if err := utils.CreateFileDirIfNotExist(toFile); err != nil {
+		return "", data.NewEmptyError().AppendDesc("quarantine, create dir").AppendError(err)
+	}
+
+	if err := os.Rename(localFile, toFile); err == nil {
+		return toFile, nil
+	}
+
+	// 跨设备时无法重命名，复制后删除
+	if err := copyFile(localFile, toFile); err != nil {
+		return "", data.NewEmptyError().AppendDesc("quarantine, copy file").AppendError(err)
+	}
+	if err := os.Remove(localFile); err != nil {
+		return toFile, data.NewEmptyError().AppendDesc("quarantine, remove file").AppendError(err)
+	}
+	return toFile, nil
+}
+
+func copyFile(from, to string) error {
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(to)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(dst, src); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	return dst.Close()
+}
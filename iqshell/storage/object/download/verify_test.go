@@ -0,0 +1,86 @@
+package download
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+)
+
+func TestVerify(t *testing.T) {
+	dir := t.TempDir()
+	localFile := filepath.Join(dir, "a.txt")
+	content := []byte("qshell download verify")
+	if err := os.WriteFile(localFile, content, 0644); err != nil {
+		t.Fatal("write file error:", err)
+	}
+
+	hash, err := utils.GetEtag(localFile)
+	if err != nil {
+		t.Fatal("get etag error:", err)
+	}
+	md5Sum := md5.Sum(content)
+	md5Hex := hex.EncodeToString(md5Sum[:])
+
+	result, err := Verify(VerifyApiInfo{
+		Key:            "a.txt",
+		LocalFile:      localFile,
+		ServerFileHash: hash,
+		ServerFileMd5:  md5Hex,
+		ServerFileSize: int64(len(content)),
+	})
+	if err != nil || !result.IsMatch() {
+		t.Fatalf("verify should match, result:%+v error:%v", result, err)
+	}
+	if result.Md5 != md5Hex || result.FileSize != int64(len(content)) || len(result.Crc32) == 0 {
+		t.Fatalf("verify result error:%+v", result)
+	}
+
+	result, err = Verify(VerifyApiInfo{
+		Key:            "a.txt",
+		LocalFile:      localFile,
+		ServerFileHash: hash,
+		ServerFileMd5:  "d41d8cd98f00b204e9800998ecf8427e",
+	})
+	if err != nil || result.Status != VerifyStatusMismatch {
+		t.Fatalf("verify should mismatch by md5, result:%+v error:%v", result, err)
+	}
+
+	result, err = Verify(VerifyApiInfo{
+		Key:            "a.txt",
+		LocalFile:      localFile,
+		ServerFileHash: "FgAgNanfbszl6CSk8MEyKDDXvpgG",
+	})
+	if err != nil || result.Status != VerifyStatusMismatch {
+		t.Fatalf("verify should mismatch by qetag, result:%+v error:%v", result, err)
+	}
+}
+
+func TestQuarantine(t *testing.T) {
+	dir := t.TempDir()
+	localFile := filepath.Join(dir, "download", "a.txt")
+	if err := os.MkdirAll(filepath.Dir(localFile), 0755); err != nil {
+		t.Fatal("create dir error:", err)
+	}
+	if err := os.WriteFile(localFile, []byte("data"), 0644); err != nil {
+		t.Fatal("write file error:", err)
+	}
+
+	quarantineDir := filepath.Join(dir, "quarantine")
+	toFile, err := Quarantine(localFile, quarantineDir, "../x/a.txt")
+	if err != nil {
+		t.Fatal("quarantine error:", err)
+	}
+	if toFile != filepath.Join(quarantineDir, "x", "a.txt") {
+		t.Fatal("quarantine file path error:", toFile)
+	}
+	if exist, _ := utils.ExistFile(localFile); exist {
+		t.Fatal("local file should be moved")
+	}
+	if exist, _ := utils.ExistFile(toFile); !exist {
+		t.Fatal("quarantine file should exist")
+	}
+}
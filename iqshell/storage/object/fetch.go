@@ -96,6 +96,11 @@ func AsyncFetch(info AsyncFetchApiInfo) (result *AsyncFetchApiResult, err *data.
 	if err != nil {
 		return result, err
 	}
+	return AsyncFetchWithManager(bm, info)
+}
+
+// AsyncFetchWithManager 使用指定的 BucketManager 发起异步抓取，用于同时操作多个账户的命令（如：crosscopy）
+func AsyncFetchWithManager(bm *storage.BucketManager, info AsyncFetchApiInfo) (result *AsyncFetchApiResult, err *data.CodeError) {
 	reqUrl, e := bm.ApiReqHost(info.Bucket)
 	if e != nil {
 		return result, data.ConvertError(e)
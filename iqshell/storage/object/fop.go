@@ -4,15 +4,14 @@ import (
 	"context"
 
 	"github.com/qiniu/go-sdk/v7/storage"
-	"github.com/qiniu/qshell/v2/iqshell/common/account"
 	"github.com/qiniu/qshell/v2/iqshell/common/alert"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
 )
 
 type PreFopStatusApiInfo struct {
-	Id     string
-	Bucket string // 用于查询 region，私有云必须，公有云可选
+	Id     string `json:"id"`
+	Bucket string `json:"bucket"` // 用于查询 region，私有云必须，公有云可选
 }
 
 func PreFopStatus(info PreFopStatusApiInfo) (storage.PrefopRet, *data.CodeError) {
@@ -29,14 +28,14 @@ func PreFopStatus(info PreFopStatusApiInfo) (storage.PrefopRet, *data.CodeError)
 }
 
 type PfopApiInfo struct {
-	Bucket             string
-	Key                string
-	Fops               string
-	Pipeline           string
-	NotifyURL          string
-	Force              bool
-	Type               int64
-	WorkflowTemplateID string
+	Bucket             string `json:"bucket"`
+	Key                string `json:"key"`
+	Fops               string `json:"fops"`
+	Pipeline           string `json:"pipeline"`
+	NotifyURL          string `json:"notify_url"`
+	Force              bool   `json:"force"`
+	Type               int64  `json:"type"`
+	WorkflowTemplateID string `json:"workflow_template_id"`
 }
 
 func Pfop(info PfopApiInfo) (string, *data.CodeError) {
@@ -78,7 +77,7 @@ func Pfop(info PfopApiInfo) (string, *data.CodeError) {
 }
 
 func getOperationManager(bucket string) (*storage.OperationManager, *data.CodeError) {
-	mac, err := account.GetMac()
+	mac, err := workspace.GetMac()
 	if err != nil {
 		return nil, err
 	}
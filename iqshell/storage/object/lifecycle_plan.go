@@ -0,0 +1,400 @@
+package object
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+const (
+	lifecycleIndexIA = iota
+	lifecycleIndexArchiveIR
+	lifecycleIndexArchive
+	lifecycleIndexDeepArchive
+	lifecycleIndexDelete
+	lifecycleIndexCount
+)
+
+// 生命周期动作的名字及转换后的存储类型，转换的顺序为：低频 < 归档直读 < 归档 < 深度归档
+var (
+	lifecycleActionNames     = [lifecycleIndexCount]string{"ia", "archive_ir", "archive", "deep_archive", "delete"}
+	lifecycleActionFileTypes = [lifecycleIndexCount]int{1, 4, 2, 3, -1}
+	lifecycleActionFlags     = [lifecycleIndexCount]string{"--to-ia-after-days", "--to-archive-ir-after-days",
+		"--to-archive-after-days", "--to-deep-archive-after-days", "--delete-after-days"}
+)
+
+// DefaultLifecyclePrices 各存储类型存储费用的参考单价，单位：元/GB/月，仅用于估算，请以官网价格为准
+const DefaultLifecyclePrices = "standard=0.098,ia=0.06,archive_ir=0.045,archive=0.028,deep_archive=0.012"
+
+// lifecycleFileTypeRank 存储类型的冷热程度，越大越冷：标准 < 低频 < 归档直读 < 归档 < 深度归档
+func lifecycleFileTypeRank(fileType int) int {
+	switch fileType {
+	case 1:
+		return 1
+	case 4:
+		return 2
+	case 2:
+		return 3
+	case 3:
+		return 4
+	default:
+		return 0
+	}
+}
+
+// LifecycleRule 生命周期规则：Key 以 Prefix 开头的文件在上传指定天数后转换存储类型或删除
+type LifecycleRule struct {
+	Prefix string
+	days   [lifecycleIndexCount]int // 0：未设置
+	index  int                      // 规则的序号，从 1 开始
+}
+
+// ParseLifecycleRule 解析生命周期规则，格式：<Prefix>:<Action>=<Days>[,<Action>=<Days>...]
+// Action 可选：ia、archive_ir、archive、deep_archive 及 delete，例：logs/:ia=30,archive=180
+func ParseLifecycleRule(rule string) (*LifecycleRule, *data.CodeError) {
+	index := strings.LastIndex(rule, ":")
+	if index < 0 {
+		return nil, data.NewEmptyError().AppendDescF("lifecycle rule:%s format error, should be <Prefix>:<Action>=<Days>[,<Action>=<Days>...]", rule)
+	}
+
+	r := &LifecycleRule{Prefix: rule[:index]}
+	for _, action := range strings.Split(rule[index+1:], ",") {
+		action = strings.TrimSpace(action)
+		if len(action) == 0 {
+			continue
+		}
+		items := strings.SplitN(action, "=", 2)
+		if len(items) != 2 {
+			return nil, data.NewEmptyError().AppendDescF("lifecycle rule:%s action:%s format error, should be <Action>=<Days>", rule, action)
+		}
+
+		actionIndex := -1
+		for i, name := range lifecycleActionNames {
+			if strings.EqualFold(strings.TrimSpace(items[0]), name) {
+				actionIndex = i
+			}
+		}
+		if actionIndex < 0 {
+			return nil, data.NewEmptyError().AppendDescF("lifecycle rule:%s action:%s not support, should be one of %s",
+				rule, items[0], strings.Join(lifecycleActionNames[:], ", "))
+		}
+		days, e := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(items[1]), "d"))
+		if e != nil || days <= 0 {
+			return nil, data.NewEmptyError().AppendDescF("lifecycle rule:%s action:%s days should be greater than 0", rule, action)
+		}
+		if r.days[actionIndex] > 0 {
+			return nil, data.NewEmptyError().AppendDescF("lifecycle rule:%s action:%s is repeated", rule, items[0])
+		}
+		r.days[actionIndex] = days
+	}
+
+	lastIndex := -1
+	for i, days := range r.days {
+		if days <= 0 {
+			continue
+		}
+		if lastIndex >= 0 && days <= r.days[lastIndex] {
+			return nil, data.NewEmptyError().AppendDescF("lifecycle rule:%s days of %s should be greater than days of %s",
+				rule, lifecycleActionNames[i], lifecycleActionNames[lastIndex])
+		}
+		lastIndex = i
+	}
+	if lastIndex < 0 {
+		return nil, data.NewEmptyError().AppendDescF("lifecycle rule:%s must set at least one action", rule)
+	}
+	return r, nil
+}
+
+func (r *LifecycleRule) String() string {
+	var actions []string
+	for i, days := range r.days {
+		if days > 0 {
+			actions = append(actions, fmt.Sprintf("%s=%d", lifecycleActionNames[i], days))
+		}
+	}
+	return r.Prefix + ":" + strings.Join(actions, ",")
+}
+
+// ParseLifecyclePrices 解析各存储类型的单价，格式：<Type>=<Price>[,<Type>=<Price>...]，Type 可选：standard、ia、archive_ir、archive 及 deep_archive；
+// 返回存储类型（FileType）到单价的映射，未指定的存储类型使用 DefaultLifecyclePrices 中的单价
+func ParseLifecyclePrices(value string) (map[int]float64, *data.CodeError) {
+	prices := make(map[int]float64)
+	for _, v := range []string{DefaultLifecyclePrices, value} {
+		for _, item := range strings.Split(v, ",") {
+			item = strings.TrimSpace(item)
+			if len(item) == 0 {
+				continue
+			}
+			items := strings.SplitN(item, "=", 2)
+			if len(items) != 2 {
+				return nil, data.NewEmptyError().AppendDescF("price:%s format error, should be <Type>=<Price>", item)
+			}
+
+			fileType := -1
+			name := strings.TrimSpace(items[0])
+			if strings.EqualFold(name, "standard") {
+				fileType = 0
+			}
+			for i, n := range lifecycleActionNames[:lifecycleIndexDelete] {
+				if strings.EqualFold(name, n) {
+					fileType = lifecycleActionFileTypes[i]
+				}
+			}
+			if fileType < 0 {
+				return nil, data.NewEmptyError().AppendDescF("price:%s type not support, should be one of standard, %s",
+					item, strings.Join(lifecycleActionNames[:lifecycleIndexDelete], ", "))
+			}
+			price, e := strconv.ParseFloat(strings.TrimSpace(items[1]), 64)
+			if e != nil || price < 0 {
+				return nil, data.NewEmptyError().AppendDescF("price:%s should be a number not less than 0", item)
+			}
+			prices[fileType] = price
+		}
+	}
+	return prices, nil
+}
+
+// LifecycleSetting 需要设置的生命周期，与 batchchlifecycle 的选项对应，0 表示不设置
+type LifecycleSetting struct {
+	ToIAAfterDays          int `json:"to_ia_after_days,omitempty"`
+	ToArchiveIRAfterDays   int `json:"to_archive_ir_after_days,omitempty"`
+	ToArchiveAfterDays     int `json:"to_archive_after_days,omitempty"`
+	ToDeepArchiveAfterDays int `json:"to_deep_archive_after_days,omitempty"`
+	DeleteAfterDays        int `json:"delete_after_days,omitempty"`
+}
+
+func newLifecycleSetting(days [lifecycleIndexCount]int) LifecycleSetting {
+	return LifecycleSetting{
+		ToIAAfterDays:          days[lifecycleIndexIA],
+		ToArchiveIRAfterDays:   days[lifecycleIndexArchiveIR],
+		ToArchiveAfterDays:     days[lifecycleIndexArchive],
+		ToDeepArchiveAfterDays: days[lifecycleIndexDeepArchive],
+		DeleteAfterDays:        days[lifecycleIndexDelete],
+	}
+}
+
+func (s LifecycleSetting) days() [lifecycleIndexCount]int {
+	return [lifecycleIndexCount]int{s.ToIAAfterDays, s.ToArchiveIRAfterDays, s.ToArchiveAfterDays, s.ToDeepArchiveAfterDays, s.DeleteAfterDays}
+}
+
+// Name 生命周期的简称，例：ia30-archive180
+func (s LifecycleSetting) Name() string {
+	var names []string
+	for i, days := range s.days() {
+		if days > 0 {
+			names = append(names, fmt.Sprintf("%s%d", strings.ReplaceAll(lifecycleActionNames[i], "_", ""), days))
+		}
+	}
+	return strings.Join(names, "-")
+}
+
+// Flags 生命周期对应的 batchchlifecycle 选项，例：--to-ia-after-days 30 --to-archive-after-days 180
+func (s LifecycleSetting) Flags() string {
+	var flags []string
+	for i, days := range s.days() {
+		if days > 0 {
+			flags = append(flags, fmt.Sprintf("%s %d", lifecycleActionFlags[i], days))
+		}
+	}
+	return strings.Join(flags, " ")
+}
+
+// LifecyclePlanObject 待规划的文件
+type LifecyclePlanObject struct {
+	Key      string
+	Fsize    int64
+	PutTime  int64 // 单位：100ns
+	FileType int
+}
+
+// LifecyclePlanCount 文件数量及大小
+type LifecyclePlanCount struct {
+	Count int64 `json:"count"`
+	Bytes int64 `json:"bytes"`
+}
+
+func (c *LifecyclePlanCount) add(fsize int64) {
+	c.Count++
+	c.Bytes += fsize
+}
+
+// LifecyclePlanGroup 生命周期设置相同的一组文件，对应一个 batchchlifecycle 的输入文件
+type LifecyclePlanGroup struct {
+	Id      string           `json:"id"`
+	Rule    string           `json:"rule"`
+	Setting LifecycleSetting `json:"setting"`
+	File    string           `json:"file,omitempty"`
+	LifecyclePlanCount
+}
+
+// LifecyclePlanRuleSummary 一个规则的规划统计
+type LifecyclePlanRuleSummary struct {
+	Rule        string                         `json:"rule"`
+	Matched     LifecyclePlanCount             `json:"matched"`   // 匹配规则的文件
+	Satisfied   LifecyclePlanCount             `json:"satisfied"` // 存储类型已满足规则，无需设置的文件
+	Planned     LifecyclePlanCount             `json:"planned"`   // 需要设置生命周期的文件
+	Due         map[string]*LifecyclePlanCount `json:"due"`       // 按上传时间计算，设置后立即转换（或删除）的文件，key 为动作名
+	CurrentCost float64                        `json:"current_monthly_cost"`
+	PlannedCost float64                        `json:"planned_monthly_cost"`
+}
+
+// LifecyclePlanSummary 规划统计
+type LifecyclePlanSummary struct {
+	Total       LifecyclePlanCount          `json:"total"`
+	Unmatched   LifecyclePlanCount          `json:"unmatched"`
+	Rules       []*LifecyclePlanRuleSummary `json:"rules"`
+	Groups      []*LifecyclePlanGroup       `json:"groups"`
+	CurrentCost float64                     `json:"current_monthly_cost"` // 匹配规则的文件当前的存储费用，单位：元/月
+	PlannedCost float64                     `json:"planned_monthly_cost"` // 匹配规则的文件设置生命周期后立即转换部分完成后的存储费用，单位：元/月
+	CostDelta   float64                     `json:"monthly_cost_delta"`
+}
+
+// LifecyclePlanResult 一个文件的规划结果
+type LifecyclePlanResult struct {
+	Rule      *LifecycleRule      // 匹配的规则，为 nil 时表示没有匹配的规则
+	Satisfied bool                // 存储类型是否已满足规则
+	Group     *LifecyclePlanGroup // 需要设置生命周期时文件所属的组
+}
+
+// LifecyclePlanner 根据生命周期规则规划文件的生命周期，非并发安全
+type LifecyclePlanner struct {
+	rules     []*LifecycleRule // 按前缀长度降序，匹配最长的前缀
+	summaries map[*LifecycleRule]*LifecyclePlanRuleSummary
+	groups    map[string]*LifecyclePlanGroup
+	prices    map[int]float64
+	now       time.Time
+	summary   *LifecyclePlanSummary
+}
+
+// NewLifecyclePlanner 创建规划器；多个规则匹配同一个文件时使用前缀最长的规则
+func NewLifecyclePlanner(rules []*LifecycleRule, prices map[int]float64, now time.Time) *LifecyclePlanner {
+	p := &LifecyclePlanner{
+		summaries: make(map[*LifecycleRule]*LifecyclePlanRuleSummary),
+		groups:    make(map[string]*LifecyclePlanGroup),
+		prices:    prices,
+		now:       now,
+		summary:   &LifecyclePlanSummary{},
+	}
+	for i, r := range rules {
+		r.index = i + 1
+		summary := &LifecyclePlanRuleSummary{
+			Rule: r.String(),
+			Due:  make(map[string]*LifecyclePlanCount),
+		}
+		p.summaries[r] = summary
+		p.summary.Rules = append(p.summary.Rules, summary)
+		p.rules = append(p.rules, r)
+	}
+	sort.SliceStable(p.rules, func(i, j int) bool {
+		return len(p.rules[i].Prefix) > len(p.rules[j].Prefix)
+	})
+	return p
+}
+
+// Plan 规划一个文件：只设置比文件当前存储类型更冷的转换，没有需要设置的转换时文件已满足规则
+func (p *LifecyclePlanner) Plan(object LifecyclePlanObject) (result LifecyclePlanResult) {
+	p.summary.Total.add(object.Fsize)
+	for _, r := range p.rules {
+		if strings.HasPrefix(object.Key, r.Prefix) {
+			result.Rule = r
+			break
+		}
+	}
+	if result.Rule == nil {
+		p.summary.Unmatched.add(object.Fsize)
+		return
+	}
+
+	summary := p.summaries[result.Rule]
+	summary.Matched.add(object.Fsize)
+
+	rank := lifecycleFileTypeRank(object.FileType)
+	var days [lifecycleIndexCount]int
+	for i, d := range result.Rule.days {
+		if d > 0 && (i == lifecycleIndexDelete || lifecycleFileTypeRank(lifecycleActionFileTypes[i]) > rank) {
+			days[i] = d
+		}
+	}
+
+	// 生命周期的天数从上传时间开始计算，上传时间已超过天数的转换会在设置后立即执行
+	age := p.now.Sub(time.Unix(0, object.PutTime*100))
+	targetFileType := object.FileType
+	deleted := false
+	for i, d := range days {
+		if d <= 0 || age < time.Duration(d)*24*time.Hour {
+			continue
+		}
+		if i == lifecycleIndexDelete {
+			deleted = true
+		} else {
+			targetFileType = lifecycleActionFileTypes[i]
+		}
+	}
+
+	currentCost := p.cost(object.FileType, object.Fsize)
+	plannedCost := currentCost
+	if deleted {
+		plannedCost = 0
+		summary.dueAdd(lifecycleActionNames[lifecycleIndexDelete], object.Fsize)
+	} else if targetFileType != object.FileType {
+		plannedCost = p.cost(targetFileType, object.Fsize)
+		for i, fileType := range lifecycleActionFileTypes {
+			if fileType == targetFileType {
+				summary.dueAdd(lifecycleActionNames[i], object.Fsize)
+			}
+		}
+	}
+	summary.CurrentCost += currentCost
+	summary.PlannedCost += plannedCost
+	p.summary.CurrentCost += currentCost
+	p.summary.PlannedCost += plannedCost
+
+	if days == [lifecycleIndexCount]int{} {
+		result.Satisfied = true
+		summary.Satisfied.add(object.Fsize)
+		return
+	}
+
+	setting := newLifecycleSetting(days)
+	id := fmt.Sprintf("rule%d-%s", result.Rule.index, setting.Name())
+	group, ok := p.groups[id]
+	if !ok {
+		group = &LifecyclePlanGroup{
+			Id:      id,
+			Rule:    result.Rule.String(),
+			Setting: setting,
+		}
+		p.groups[id] = group
+		p.summary.Groups = append(p.summary.Groups, group)
+	}
+	group.add(object.Fsize)
+	summary.Planned.add(object.Fsize)
+	result.Group = group
+	return
+}
+
+func (s *LifecyclePlanRuleSummary) dueAdd(action string, fsize int64) {
+	due, ok := s.Due[action]
+	if !ok {
+		due = &LifecyclePlanCount{}
+		s.Due[action] = due
+	}
+	due.add(fsize)
+}
+
+func (p *LifecyclePlanner) cost(fileType int, fsize int64) float64 {
+	return float64(fsize) / float64(1024*1024*1024) * p.prices[fileType]
+}
+
+// Summary 规划统计，组按 Id 排序
+func (p *LifecyclePlanner) Summary() *LifecyclePlanSummary {
+	sort.Slice(p.summary.Groups, func(i, j int) bool {
+		return p.summary.Groups[i].Id < p.summary.Groups[j].Id
+	})
+	p.summary.CostDelta = p.summary.PlannedCost - p.summary.CurrentCost
+	return p.summary
+}
@@ -0,0 +1,85 @@
+package object
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseLifecycleRule(t *testing.T) {
+	r, err := ParseLifecycleRule("logs/a:b/:ia=30,archive=180d")
+	if err != nil {
+		t.Fatal("parse rule error:", err)
+	}
+	if r.Prefix != "logs/a:b/" || r.String() != "logs/a:b/:ia=30,archive=180" {
+		t.Fatalf("rule parse error:%s", r)
+	}
+
+	for _, rule := range []string{
+		"logs/",
+		"logs/:",
+		"logs/:ia",
+		"logs/:ia=0",
+		"logs/:cold=30",
+		"logs/:ia=30,ia=60",
+		"logs/:ia=180,archive=30",
+		"logs/:archive=30,delete=30",
+	} {
+		if _, err = ParseLifecycleRule(rule); err == nil {
+			t.Fatalf("parse rule:%s should error", rule)
+		}
+	}
+}
+
+func TestLifecyclePlanner(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	putTime := func(days int) int64 {
+		return now.Add(-time.Duration(days)*24*time.Hour).UnixNano() / 100
+	}
+	logs, _ := ParseLifecycleRule("logs/:ia=30,archive=180")
+	all, _ := ParseLifecycleRule(":ia=90")
+	prices, err := ParseLifecyclePrices("standard=1,ia=0.5,archive=0.1")
+	if err != nil {
+		t.Fatal("parse prices error:", err)
+	}
+	planner := NewLifecyclePlanner([]*LifecycleRule{logs, all}, prices, now)
+
+	const gb = 1024 * 1024 * 1024
+	for _, c := range []struct {
+		object    LifecyclePlanObject
+		satisfied bool
+		group     string
+	}{
+		{LifecyclePlanObject{Key: "logs/a", Fsize: gb, PutTime: putTime(10)}, false, "rule1-ia30-archive180"},
+		{LifecyclePlanObject{Key: "logs/b", Fsize: gb, PutTime: putTime(200)}, false, "rule1-ia30-archive180"},
+		{LifecyclePlanObject{Key: "logs/c", Fsize: gb, PutTime: putTime(40), FileType: 1}, false, "rule1-archive180"},
+		{LifecyclePlanObject{Key: "logs/d", Fsize: gb, PutTime: putTime(40), FileType: 2}, true, ""},
+		{LifecyclePlanObject{Key: "img/e", Fsize: gb, PutTime: putTime(100)}, false, "rule2-ia90"},
+		{LifecyclePlanObject{Key: "img/f", Fsize: gb, PutTime: putTime(100), FileType: 3}, true, ""},
+	} {
+		result := planner.Plan(c.object)
+		if result.Satisfied != c.satisfied {
+			t.Fatalf("key:%s satisfied:%v should be:%v", c.object.Key, result.Satisfied, c.satisfied)
+		}
+		group := ""
+		if result.Group != nil {
+			group = result.Group.Id
+		}
+		if group != c.group {
+			t.Fatalf("key:%s group:%s should be:%s", c.object.Key, group, c.group)
+		}
+	}
+
+	summary := planner.Summary()
+	if len(summary.Groups) != 3 || summary.Groups[0].Id != "rule1-archive180" || summary.Groups[1].Count != 2 {
+		t.Fatalf("summary groups error:%+v", summary.Groups)
+	}
+	rule1 := summary.Rules[0]
+	if rule1.Matched.Count != 4 || rule1.Satisfied.Count != 1 || rule1.Planned.Count != 3 ||
+		rule1.Due["archive"].Count != 1 || rule1.Due["ia"] != nil {
+		t.Fatalf("rule summary error:%+v", rule1)
+	}
+	// logs/b 1 => 0.1，img/e 1 => 0.5
+	if d := summary.CostDelta; d < -1.41 || d > -1.39 {
+		t.Fatalf("cost delta:%f should be -1.4", d)
+	}
+}
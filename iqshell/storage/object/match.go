@@ -32,8 +32,8 @@ func (m *MatchApiInfo) CheckModeHash() bool {
 }
 
 type MatchResult struct {
-	Exist bool
-	Match bool
+	Exist bool `json:"exist"`
+	Match bool `json:"match"`
 }
 
 var _ flow.Result = (*MatchResult)(nil)
@@ -0,0 +1,319 @@
+package operations
+
+import (
+	"encoding/base64"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/export"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
+)
+
+type BatchApplyInfo struct {
+	BatchInfo batch.Info
+	PlanFile  string
+}
+
+func (info *BatchApplyInfo) Check() *data.CodeError {
+	if err := info.BatchInfo.Check(); err != nil {
+		return err
+	}
+
+	if len(info.PlanFile) == 0 {
+		return alert.CannotEmptyError("PlanFile", "")
+	}
+	return nil
+}
+
+// BatchApply 执行 dry-run 生成的执行计划，执行前会检查账号及计划中对象的状态，任一发生变化则拒绝执行
+func BatchApply(cfg *iqshell.Config, info BatchApplyInfo) {
+	cfg.JobPathBuilder = func(cmdPath string) string {
+		jobId := utils.Md5Hex(fmt.Sprintf("%s:%s", cfg.CmdCfg.CmdId, info.PlanFile))
+		return filepath.Join(cmdPath, jobId)
+	}
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	plan, err := batch.LoadPlan(info.PlanFile)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("Batch apply, load plan error:%v", err)
+		return
+	}
+
+	if err = checkPlanAccount(plan.Header); err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("Batch apply refused, %v", err)
+		return
+	}
+
+	// 仅执行会修改对象的操作
+	changeList := make([]*batch.PlanOperation, 0, len(plan.Operations))
+	works := make([]flow.Work, 0, len(plan.Operations))
+	for _, p := range plan.Operations {
+		if p.Status != batch.PlanStatusChange {
+			continue
+		}
+		operation, cErr := planOperationToBatchOperation(p)
+		if cErr != nil {
+			data.SetCmdStatusError()
+			log.ErrorF("Batch apply refused, line:%s %v", p.Line, cErr)
+			return
+		}
+		changeList = append(changeList, p)
+		works = append(works, operation)
+	}
+	log.InfoF("Plan operations total:%d change:%d unchanged:%d fail:%d",
+		plan.Summary.Total, plan.Summary.Change, plan.Summary.Unchanged, plan.Summary.Fail)
+	if len(works) == 0 {
+		log.Alert("No operation need to apply")
+		return
+	}
+
+	driftList, err := batch.CheckPlanDrift(changeList)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("Batch apply, check plan error:%v", err)
+		return
+	}
+	if len(driftList) > 0 {
+		data.SetCmdStatusError()
+		for _, drift := range driftList {
+			log.ErrorF("Object changed since plan was created, line:%s %s", drift.Operation.Line, drift.Reason)
+		}
+		log.ErrorF("Batch apply refused, %d objects changed since plan was created, please dry run again", len(driftList))
+		return
+	}
+
+	exporter, err := export.NewFileExport(info.BatchInfo.FileExporterConfig)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	info.BatchInfo.WorkList = works
+	metric := &batch.Metric{}
+	metric.AddTotalCount(int64(len(works)))
+	metric.Start()
+	batch.NewHandler(info.BatchInfo).
+		SetFileExport(exporter).
+		EmptyOperation(func() flow.Work {
+			return &object.DeleteApiInfo{}
+		}).
+		OnResult(func(operationInfo string, operation batch.Operation, result *batch.OperationResult) {
+			metric.AddCurrentCount(1)
+			operationString := operationInfo
+			if operation != nil {
+				operationString, _ = operation.ToOperation()
+			}
+			metric.PrintProgress("Applying:" + operationString)
+			output.Result(&batch.OperationRecord{
+				Operation: operation,
+				Result:    result,
+			})
+			if result != nil && result.IsSuccess() {
+				metric.AddSuccessCount(1)
+				exporter.Success().Export(operationString)
+				log.InfoF("Apply Success, %s", operationString)
+			} else {
+				metric.AddFailureCount(1)
+				data.SetCmdStatusError()
+				if result == nil {
+					exporter.Fail().ExportF("%s%s-no result", operationString, flow.ErrorSeparate)
+					log.ErrorF("Apply Failed, %s, no result", operationString)
+				} else {
+					exporter.Fail().ExportF("%s%s[%d]%s", operationString, flow.ErrorSeparate, result.Code, result.Error)
+					log.ErrorF("Apply Failed, %s, Code: %d, Error: %s", operationString, result.Code, result.Error)
+				}
+			}
+		}).
+		OnError(func(err *data.CodeError) {
+			data.SetCmdStatusError()
+			log.ErrorF("Batch apply error:%v:", err)
+		}).Start()
+	metric.End()
+
+	log.Alert("------------ Batch Apply Result ------------")
+	log.AlertF("%20s%10d", "Total:", metric.TotalCount)
+	log.AlertF("%20s%10d", "Success:", metric.SuccessCount)
+	log.AlertF("%20s%10d", "Failure:", metric.FailureCount)
+	log.AlertF("%20s%10ds", "Duration:", metric.Duration)
+	log.AlertF("--------------------------------------------")
+}
+
+func checkPlanAccount(header *batch.PlanHeader) *data.CodeError {
+	if len(header.AccessKey) == 0 {
+		return alert.Error("account of plan is empty", "")
+	}
+	acc, err := workspace.GetAccount()
+	if err != nil {
+		return data.NewEmptyError().AppendDesc("get current account error").AppendError(err)
+	}
+	if acc.AccessKey != header.AccessKey {
+		return data.NewEmptyError().AppendDescF("account changed, plan:%s(%s) current:%s(%s)",
+			header.AccountName, header.AccessKey, acc.Name, acc.AccessKey)
+	}
+	return nil
+}
+
+// planOperationToBatchOperation 将计划中的操作转换为对应的 batch.Operation，转换后的操作指令必须与计划中记录的一致
+func planOperationToBatchOperation(p *batch.PlanOperation) (batch.Operation, *data.CodeError) {
+	var operation batch.Operation
+	switch p.Command {
+	case "delete":
+		condition, err := parseOperationCondition(p.Param("cond"))
+		if err != nil {
+			return nil, err
+		}
+		operation = &object.DeleteApiInfo{
+			Bucket:    p.Bucket,
+			Key:       p.Key,
+			Condition: condition,
+		}
+	case "deleteAfterDays":
+		days, err := strconv.Atoi(p.Param("days"))
+		if err != nil {
+			return nil, data.NewEmptyError().AppendDescF("invalid days of operation:%s", p.Operation).AppendError(err)
+		}
+		operation = &object.DeleteApiInfo{
+			Bucket:          p.Bucket,
+			Key:             p.Key,
+			DeleteAfterDays: days,
+			IsDeleteAfter:   true,
+		}
+	case "copy":
+		operation = &object.CopyApiInfo{
+			SourceBucket: p.Bucket,
+			SourceKey:    p.Key,
+			DestBucket:   p.DestBucket,
+			DestKey:      p.DestKey,
+			Force:        p.Force,
+		}
+	case "move":
+		operation = &object.MoveApiInfo{
+			SourceBucket: p.Bucket,
+			SourceKey:    p.Key,
+			DestBucket:   p.DestBucket,
+			DestKey:      p.DestKey,
+			Force:        p.Force,
+		}
+	case "chgm":
+		mime, err := base64.URLEncoding.DecodeString(p.Param("mime"))
+		if err != nil {
+			return nil, data.NewEmptyError().AppendDescF("invalid mime of operation:%s", p.Operation).AppendError(err)
+		}
+		operation = &object.ChangeMimeApiInfo{
+			Bucket: p.Bucket,
+			Key:    p.Key,
+			Mime:   string(mime),
+		}
+	case "chtype":
+		fileType, err := strconv.Atoi(p.Param("type"))
+		if err != nil {
+			return nil, data.NewEmptyError().AppendDescF("invalid type of operation:%s", p.Operation).AppendError(err)
+		}
+		operation = &object.ChangeTypeApiInfo{
+			Bucket: p.Bucket,
+			Key:    p.Key,
+			Type:   fileType,
+		}
+	case "chstatus":
+		status, err := strconv.Atoi(p.Param("status"))
+		if err != nil {
+			return nil, data.NewEmptyError().AppendDescF("invalid status of operation:%s", p.Operation).AppendError(err)
+		}
+		operation = &object.ChangeStatusApiInfo{
+			Bucket: p.Bucket,
+			Key:    p.Key,
+			Status: status,
+		}
+	case "restoreAr":
+		freezeAfterDays, err := strconv.Atoi(p.Param("freezeAfterDays"))
+		if err != nil {
+			return nil, data.NewEmptyError().AppendDescF("invalid freezeAfterDays of operation:%s", p.Operation).AppendError(err)
+		}
+		operation = &object.RestoreArchiveApiInfo{
+			Bucket:          p.Bucket,
+			Key:             p.Key,
+			FreezeAfterDays: freezeAfterDays,
+		}
+	case "lifecycle":
+		lifecycle := &object.ChangeLifecycleApiInfo{
+			Bucket: p.Bucket,
+			Key:    p.Key,
+		}
+		for name, value := range map[string]*int{
+			"toIAAfterDays":          &lifecycle.ToIAAfterDays,
+			"toArchiveIRAfterDays":   &lifecycle.ToArchiveIRAfterDays,
+			"toArchiveAfterDays":     &lifecycle.ToArchiveAfterDays,
+			"toDeepArchiveAfterDays": &lifecycle.ToDeepArchiveAfterDays,
+			"deleteAfterDays":        &lifecycle.DeleteAfterDays,
+		} {
+			if v := p.Param(name); len(v) > 0 {
+				days, err := strconv.Atoi(v)
+				if err != nil {
+					return nil, data.NewEmptyError().AppendDescF("invalid %s of operation:%s", name, p.Operation).AppendError(err)
+				}
+				*value = days
+			}
+		}
+		operation = lifecycle
+	default:
+		return nil, alert.Error("operation not support:"+p.Operation, "")
+	}
+
+	operationString, err := operation.ToOperation()
+	if err != nil {
+		return nil, err
+	}
+	if operationString != p.Operation {
+		return nil, data.NewEmptyError().AppendDescF("operation:%s not support, it will be executed as:%s", p.Operation, operationString)
+	}
+	return operation, nil
+}
+
+// parseOperationCondition 解析 batch.OperationConditionURI 生成的 cond 参数
+func parseOperationCondition(encodedCondition string) (batch.OperationCondition, *data.CodeError) {
+	condition := batch.OperationCondition{}
+	if len(encodedCondition) == 0 {
+		return condition, nil
+	}
+
+	cond, err := base64.URLEncoding.DecodeString(encodedCondition)
+	if err != nil {
+		return condition, data.NewEmptyError().AppendDescF("invalid cond:%s", encodedCondition).AppendError(err)
+	}
+	for _, item := range strings.Split(string(cond), "&") {
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 {
+			return condition, data.NewEmptyError().AppendDescF("invalid cond:%s", string(cond))
+		}
+		switch kv[0] {
+		case "hash":
+			condition.FileHash = kv[1]
+		case "mime":
+			condition.FileMime = kv[1]
+		case "fsize":
+			condition.FileSize = kv[1]
+		case "putTime":
+			condition.PutTime = kv[1]
+		default:
+			return condition, data.NewEmptyError().AppendDescF("cond:%s not support", string(cond))
+		}
+	}
+	return condition, nil
+}
@@ -0,0 +1,48 @@
+package operations
+
+import (
+	"testing"
+
+	"github.com/qiniu/go-sdk/v7/storage"
+
+	"github.com/qiniu/qshell/v2/iqshell/storage/object"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
+)
+
+func TestPlanOperationToBatchOperation(t *testing.T) {
+	lifecycle, _ := (&object.ChangeLifecycleApiInfo{
+		Bucket:          "bucket",
+		Key:             "key",
+		ToIAAfterDays:   1,
+		DeleteAfterDays: 10,
+	}).ToOperation()
+	operations := []string{
+		storage.URIDelete("bucket", "key"),
+		storage.URIDelete("bucket", "key") + batch.OperationConditionURI(batch.OperationCondition{PutTime: "16445676785097143"}),
+		storage.URIDeleteAfterDays("bucket", "key", 3),
+		storage.URICopy("bucket", "a", "dest", "b", true),
+		storage.URIMove("bucket", "a", "dest", "b", false),
+		storage.URIChangeMime("bucket", "key", "image/png"),
+		storage.URIChangeType("bucket", "key", 2),
+		storage.URIRestoreAr("bucket", "key", 5),
+		lifecycle,
+	}
+	for _, operation := range operations {
+		p, err := batch.ParseOperation(operation)
+		if err != nil {
+			t.Fatal("parse operation error:", err)
+		}
+		o, err := planOperationToBatchOperation(p)
+		if err != nil {
+			t.Fatalf("convert operation:%s error:%v", operation, err)
+		}
+		if s, _ := o.ToOperation(); s != operation {
+			t.Fatalf("convert operation error, expected:%s but:%s", operation, s)
+		}
+	}
+
+	p, _ := batch.ParseOperation(storage.URIChangeMimeAndMeta("bucket", "key", "image/png", map[string]string{"x-qn-meta-a": "b"}))
+	if _, err := planOperationToBatchOperation(p); err == nil {
+		t.Fatal("operation with meta should not support")
+	}
+}
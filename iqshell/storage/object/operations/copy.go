@@ -5,11 +5,13 @@ import (
 	"path/filepath"
 
 	"github.com/qiniu/qshell/v2/iqshell"
+	cdnOperations "github.com/qiniu/qshell/v2/iqshell/cdn/operations"
 	"github.com/qiniu/qshell/v2/iqshell/common/alert"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/export"
 	"github.com/qiniu/qshell/v2/iqshell/common/flow"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
 	"github.com/qiniu/qshell/v2/iqshell/common/utils"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
@@ -51,6 +53,11 @@ func Copy(cfg *iqshell.Config, info CopyInfo) {
 		return
 	}
 
+	output.Result(&batch.OperationRecord{
+		Operation: (*object.CopyApiInfo)(&info),
+		Result:    result,
+	})
+
 	if result.IsSuccess() {
 		log.InfoF("Copy Success, [%s:%s] => [%s:%s]",
 			info.SourceBucket, info.SourceKey,
@@ -68,6 +75,7 @@ type BatchCopyInfo struct {
 	BatchInfo    batch.Info
 	SourceBucket string
 	DestBucket   string
+	CdnRefresh   cdnOperations.AutoRefreshInfo // 复制成功后刷新目标文件的 CDN 缓存
 }
 
 func (info *BatchCopyInfo) Check() *data.CodeError {
@@ -83,7 +91,7 @@ func (info *BatchCopyInfo) Check() *data.CodeError {
 		return alert.CannotEmptyError("DestBucket", "")
 	}
 
-	return nil
+	return info.CdnRefresh.Check()
 }
 
 func BatchCopy(cfg *iqshell.Config, info BatchCopyInfo) {
@@ -104,6 +112,9 @@ func BatchCopy(cfg *iqshell.Config, info BatchCopyInfo) {
 		return
 	}
 
+	refresher := cdnOperations.NewAutoRefresher(info.CdnRefresh)
+	defer refresher.Close()
+
 	batch.NewHandler(info.BatchInfo).
 		EmptyOperation(func() flow.Work {
 			return &object.CopyApiInfo{}
@@ -137,6 +148,7 @@ func BatchCopy(cfg *iqshell.Config, info BatchCopyInfo) {
 
 			in := (*CopyInfo)(apiInfo)
 			if result.IsSuccess() {
+				refresher.AddKeys(in.DestKey)
 				log.InfoF("Copy Success, '%s:%s' => '%s:%s'",
 					in.SourceBucket, in.SourceKey,
 					in.DestBucket, in.DestKey)
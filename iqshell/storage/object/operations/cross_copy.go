@@ -0,0 +1,457 @@
+package operations
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/qiniu/go-sdk/v7/auth"
+	"github.com/qiniu/go-sdk/v7/storage"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/account"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/export"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
+)
+
+// CrossCopyLocation crosscopy 的源或目标，格式：<Account>:<Bucket>[/<Prefix>]，Account 为空时使用当前账户
+type CrossCopyLocation struct {
+	Account string
+	Bucket  string
+	Prefix  string
+}
+
+func (l CrossCopyLocation) String() string {
+	return fmt.Sprintf("%s:%s/%s", l.Account, l.Bucket, l.Prefix)
+}
+
+func ParseCrossCopyLocation(location string) (CrossCopyLocation, *data.CodeError) {
+	l := CrossCopyLocation{}
+	bucketAndPrefix := location
+	if index := strings.Index(location, ":"); index >= 0 {
+		l.Account = location[:index]
+		bucketAndPrefix = location[index+1:]
+	}
+	if index := strings.Index(bucketAndPrefix, "/"); index >= 0 {
+		l.Bucket = bucketAndPrefix[:index]
+		l.Prefix = bucketAndPrefix[index+1:]
+	} else {
+		l.Bucket = bucketAndPrefix
+	}
+	if len(l.Bucket) == 0 {
+		return l, data.NewEmptyError().AppendDescF("location:%s invalid, bucket can't be empty, format: <Account>:<Bucket>[/<Prefix>]", location)
+	}
+	return l, nil
+}
+
+// DestKey 源文件在目标空间中的 Key：将源前缀替换为目标前缀
+func (l CrossCopyLocation) DestKey(src CrossCopyLocation, srcKey string) string {
+	return l.Prefix + strings.TrimPrefix(srcKey, src.Prefix)
+}
+
+type CrossCopyInfo struct {
+	BatchInfo    batch.Info
+	Src          string // 源，格式：<Account>:<Bucket>[/<Prefix>] 【必选】
+	Dst          string // 目标，格式：<Account>:<Bucket>[/<Prefix>] 【必选】
+	SrcDomain    string // 源空间的下载域名，为空时使用源空间绑定的域名 【可选】
+	FromListFile string // 只拷贝 listbucket2 输出文件中的文件，不指定时列举源空间 【可选】
+	UrlExpires   int    // 源文件签名链接的有效期，单位：秒 【可选】
+	Async        bool   // 使用异步抓取，适合大文件 【可选】
+	Overwrite    bool   // 目标文件已存在且 hash 不同时是否覆盖 【可选】
+	MaxRetry     int    // 列举源空间出错时的最大重试次数，-1: 无限重试 【可选】
+
+	src        CrossCopyLocation
+	dst        CrossCopyLocation
+	srcAccount account.Account
+	dstAccount account.Account
+}
+
+func (info *CrossCopyInfo) Check() *data.CodeError {
+	if err := info.BatchInfo.Check(); err != nil {
+		return err
+	}
+	if len(info.Src) == 0 {
+		return alert.CannotEmptyError("Src", "")
+	}
+	if len(info.Dst) == 0 {
+		return alert.CannotEmptyError("Dst", "")
+	}
+
+	var err *data.CodeError
+	if info.src, err = ParseCrossCopyLocation(info.Src); err != nil {
+		return err
+	}
+	if info.dst, err = ParseCrossCopyLocation(info.Dst); err != nil {
+		return err
+	}
+	if info.src.Bucket == info.dst.Bucket && info.src.Account == info.dst.Account && info.src.Prefix == info.dst.Prefix {
+		return alert.Error("src and dst can't be the same", "")
+	}
+	if info.UrlExpires <= 0 {
+		info.UrlExpires = 3600
+	}
+	return nil
+}
+
+func getCrossCopyAccount(name string) (account.Account, *data.CodeError) {
+	if len(name) == 0 {
+		return workspace.GetAccount()
+	}
+	return account.GetUser(name)
+}
+
+// CrossCopy 跨账户、跨区域拷贝文件：使用源账户签名源文件的下载链接，目标账户抓取（fetch）后校验 hash
+func CrossCopy(cfg *iqshell.Config, info CrossCopyInfo) {
+	cfg.JobPathBuilder = func(cmdPath string) string {
+		jobId := utils.Md5Hex(fmt.Sprintf("%s:%s:%s:%s", cfg.CmdCfg.CmdId, info.Src, info.Dst, info.FromListFile))
+		return filepath.Join(cmdPath, jobId)
+	}
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	var err *data.CodeError
+	if info.srcAccount, err = getCrossCopyAccount(info.src.Account); err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("crosscopy: get src account error:%v", err)
+		return
+	}
+	if info.dstAccount, err = getCrossCopyAccount(info.dst.Account); err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("crosscopy: get dst account error:%v", err)
+		return
+	}
+
+	copier, err := newCrossCopier(&info)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("crosscopy: %v", err)
+		return
+	}
+
+	exporter, err := export.NewFileExport(info.BatchInfo.FileExporterConfig)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.Error(err)
+		return
+	}
+
+	dbPath := filepath.Join(workspace.GetJobDir(), ".recorder")
+	if info.BatchInfo.EnableRecord {
+		log.DebugF("crosscopy recorder:%s", dbPath)
+	} else {
+		log.Debug("crosscopy recorder:Not Enable")
+	}
+
+	// 边列举源空间边拷贝
+	works := make(chan flow.Work, info.BatchInfo.WorkerCount*10)
+	var listErr *data.CodeError
+	go func() {
+		defer close(works)
+		listErr = bucket.RangeObjects(bucket.ListApiInfo{
+			Bucket:   info.src.Bucket,
+			Prefix:   info.src.Prefix,
+			MaxRetry: info.MaxRetry,
+			V1Limit:  1000,
+			Account:  &info.srcAccount,
+		}, info.FromListFile, info.BatchInfo.ItemSeparate, func(o bucket.ListObject) *data.CodeError {
+			if !strings.HasPrefix(o.Key, info.src.Prefix) {
+				return nil
+			}
+			works <- &crossCopyWork{
+				SrcBucket: info.src.Bucket,
+				SrcKey:    o.Key,
+				DstBucket: info.dst.Bucket,
+				DstKey:    info.dst.DestKey(info.src, o.Key),
+				Hash:      o.Hash,
+				FileSize:  o.Fsize,
+			}
+			return nil
+		})
+	}()
+
+	metric := &batch.Metric{}
+	metric.Start()
+	flow.New(info.BatchInfo.Info).
+		WorkProviderWithChan(works).
+		WorkerProvider(flow.NewWorkerProvider(func() (flow.Worker, *data.CodeError) {
+			return flow.NewSimpleWorker(func(workInfo *flow.WorkInfo) (flow.Result, *data.CodeError) {
+				in := workInfo.Work.(*crossCopyWork)
+				return copier.copy(in)
+			}), nil
+		})).
+		SetOverseerEnable(info.BatchInfo.EnableRecord).
+		SetDBOverseer(dbPath, func() *flow.WorkRecord {
+			return &flow.WorkRecord{
+				WorkInfo: &flow.WorkInfo{
+					Data: "",
+					Work: &crossCopyWork{},
+				},
+				Result: &crossCopyResult{},
+				Err:    nil,
+			}
+		}).
+		ShouldRedo(func(workInfo *flow.WorkInfo, workRecord *flow.WorkRecord) (shouldRedo bool, cause *data.CodeError) {
+			if workRecord.Err == nil {
+				return false, nil
+			}
+
+			if !info.BatchInfo.RecordRedoWhileError {
+				return false, workRecord.Err
+			}
+
+			result, _ := workRecord.Result.(*crossCopyResult)
+			if result == nil {
+				return true, data.NewEmptyError().AppendDesc("no result found")
+			}
+			if !result.IsValid() {
+				return true, data.NewEmptyError().AppendDesc("result is invalid")
+			}
+			return false, nil
+		}).
+		OnWorkSkip(func(work *flow.WorkInfo, result flow.Result, err *data.CodeError) {
+			metric.AddCurrentCount(1)
+			metric.PrintProgress("Copying:" + work.Data)
+
+			operationResult, _ := result.(*crossCopyResult)
+			if err != nil && err.Code == data.ErrorCodeAlreadyDone {
+				if operationResult != nil && operationResult.IsValid() {
+					metric.AddSuccessCount(1)
+					exporter.Success().ExportF("%s", work.Data)
+					log.InfoF("Skip line:%s because have done and success", work.Data)
+				} else {
+					metric.AddFailureCount(1)
+					exporter.Fail().ExportF("%s%s%v", work.Data, flow.ErrorSeparate, err)
+					log.InfoF("Skip line:%s because have done and failure, %v", work.Data, err)
+				}
+			} else {
+				metric.AddSkippedCount(1)
+				exporter.Fail().ExportF("%s%s%v", work.Data, flow.ErrorSeparate, err)
+				log.InfoF("Skip line:%s because:%v", work.Data, err)
+			}
+		}).
+		OnWorkSuccess(func(workInfo *flow.WorkInfo, result flow.Result) {
+			metric.AddCurrentCount(1)
+			metric.AddSuccessCount(1)
+			metric.PrintProgress("Copying:" + workInfo.Data)
+
+			in, _ := workInfo.Work.(*crossCopyWork)
+			res, _ := result.(*crossCopyResult)
+			output.Result(&batch.OperationRecord{
+				Line:      workInfo.Data,
+				Operation: in,
+				Result:    result,
+			})
+			exporter.Success().ExportF("%s\t%s", in.SrcKey, in.DstKey)
+			if res != nil && res.IsExist {
+				log.InfoF("Cross copy Skip, [%s:%s] => [%s:%s], dest file exists and hash match", in.SrcBucket, in.SrcKey, in.DstBucket, in.DstKey)
+			} else {
+				log.InfoF("Cross copy Success, [%s:%s] => [%s:%s]", in.SrcBucket, in.SrcKey, in.DstBucket, in.DstKey)
+			}
+		}).
+		OnWorkFail(func(workInfo *flow.WorkInfo, err *data.CodeError) {
+			metric.AddCurrentCount(1)
+			metric.AddFailureCount(1)
+			metric.PrintProgress("Copying:" + workInfo.Data)
+
+			exporter.Fail().ExportF("%s%s%v", workInfo.Data, flow.ErrorSeparate, err)
+			output.Result(&batch.OperationRecord{
+				Line:      workInfo.Data,
+				Operation: workInfo.Work,
+				Result: &batch.OperationResult{
+					Code:  err.Code,
+					Error: err.Desc,
+				},
+			})
+			if in, ok := workInfo.Work.(*crossCopyWork); ok {
+				log.ErrorF("Cross copy Failed, [%s:%s] => [%s:%s], Error: %v", in.SrcBucket, in.SrcKey, in.DstBucket, in.DstKey, err)
+			} else {
+				log.ErrorF("Cross copy Failed, %s, Error: %s", workInfo.Data, err)
+			}
+		}).Build().Start()
+
+	metric.End()
+	metric.TotalCount = metric.SuccessCount + metric.FailureCount + metric.SkippedCount
+
+	log.InfoF("job dir:%s, there is a cache related to this command in this folder, which will also be used next time the same command is executed. If you are sure that you don’t need it, you can delete this folder.", workspace.GetJobDir())
+
+	resultPath := filepath.Join(workspace.GetJobDir(), ".result")
+	if e := utils.MarshalToFile(resultPath, metric); e != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("save crosscopy result to path:%s error:%v", resultPath, e)
+	} else {
+		log.DebugF("save crosscopy result to path:%s", resultPath)
+	}
+
+	log.Info("--------------- Cross Copy Result ---------------")
+	log.InfoF("%20s%10d", "Total:", metric.TotalCount)
+	log.InfoF("%20s%10d", "Success:", metric.SuccessCount)
+	log.InfoF("%20s%10d", "Failure:", metric.FailureCount)
+	log.InfoF("%20s%10d", "Skipped:", metric.SkippedCount)
+	log.InfoF("%20s%10ds", "Duration:", metric.Duration)
+	log.InfoF("-------------------------------------------------")
+
+	if listErr != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("crosscopy: list src error:%v", listErr)
+	}
+	if !metric.IsCompletedSuccessfully() {
+		data.SetCmdStatusError()
+	}
+}
+
+type crossCopyWork struct {
+	SrcBucket string `json:"src_bucket"`
+	SrcKey    string `json:"src_key"`
+	DstBucket string `json:"dst_bucket"`
+	DstKey    string `json:"dst_key"`
+	Hash      string `json:"hash"`
+	FileSize  int64  `json:"fsize"`
+}
+
+var _ flow.Work = (*crossCopyWork)(nil)
+
+// WorkId 包含源文件列表中的 hash，源文件变化后会重新拷贝；列表中没有 hash 时 WorkId 中的 hash 为空
+func (w *crossCopyWork) WorkId() string {
+	return fmt.Sprintf("%s:%s:%s:%s:%s", w.SrcBucket, w.SrcKey, w.DstBucket, w.DstKey, w.Hash)
+}
+
+func (w *crossCopyWork) String() string {
+	return fmt.Sprintf("[%s:%s] => [%s:%s]", w.SrcBucket, w.SrcKey, w.DstBucket, w.DstKey)
+}
+
+type crossCopyResult struct {
+	Hash     string `json:"hash"`
+	FileSize int64  `json:"fsize"`
+	IsExist  bool   `json:"is_exist"` // 目标文件已存在且 hash 相同
+}
+
+var _ flow.Result = (*crossCopyResult)(nil)
+
+func (r *crossCopyResult) IsValid() bool {
+	return r != nil && len(r.Hash) > 0
+}
+
+type crossCopier struct {
+	info       *CrossCopyInfo
+	srcMac     *auth.Credentials
+	srcDomain  string
+	srcManager *storage.BucketManager
+	dstManager *storage.BucketManager
+}
+
+func newCrossCopier(info *CrossCopyInfo) (*crossCopier, *data.CodeError) {
+	srcDomain := info.SrcDomain
+	if len(srcDomain) == 0 {
+		domains, err := bucket.AllDomainsOfBucketWithAccount(info.srcAccount, info.src.Bucket)
+		if err != nil || len(domains) == 0 {
+			return nil, data.NewEmptyError().AppendDescF("get domain of src bucket:%s error:%v, you can set --src-domain", info.src.Bucket, err)
+		}
+		srcDomain = domains[0].Domain.Value()
+	}
+	if !strings.HasPrefix(srcDomain, "http://") && !strings.HasPrefix(srcDomain, "https://") {
+		srcDomain = utils.Endpoint(workspace.GetConfig().IsUseHttps(), srcDomain)
+	}
+	log.DebugF("crosscopy src domain:%s", srcDomain)
+
+	return &crossCopier{
+		info:       info,
+		srcMac:     auth.New(info.srcAccount.AccessKey, info.srcAccount.SecretKey),
+		srcDomain:  srcDomain,
+		srcManager: bucket.GetBucketManagerWithAccount(info.srcAccount),
+		dstManager: bucket.GetBucketManagerWithAccount(info.dstAccount),
+	}, nil
+}
+
+func (c *crossCopier) copy(w *crossCopyWork) (*crossCopyResult, *data.CodeError) {
+	// --from-list 的文件列表中可能没有 hash，从源文件获取用于比较及校验；
+	// 不修改原 work，WorkId 需与记录中的一致，否则断点续传时无法匹配
+	if len(w.Hash) == 0 {
+		stat, sErr := c.srcManager.Stat(w.SrcBucket, w.SrcKey)
+		if sErr != nil {
+			return nil, data.NewEmptyError().AppendDesc("stat src file").AppendError(data.ConvertError(sErr))
+		}
+		statWork := *w
+		statWork.Hash = stat.Hash
+		statWork.FileSize = stat.Fsize
+		w = &statWork
+	}
+
+	// 目标文件已存在且 hash 相同时无需拷贝
+	if stat, sErr := c.dstManager.Stat(w.DstBucket, w.DstKey); sErr == nil {
+		if stat.Hash == w.Hash {
+			return &crossCopyResult{Hash: stat.Hash, FileSize: stat.Fsize, IsExist: true}, nil
+		}
+		if !c.info.Overwrite {
+			return nil, data.NewEmptyError().AppendDescF("dest file exists and hash not match, src hash:%s, dest hash:%s, you can use --overwrite to overwrite it", w.Hash, stat.Hash)
+		}
+	} else if e, ok := sErr.(*storage.ErrorInfo); !ok || e.Code != 612 {
+		return nil, data.NewEmptyError().AppendDesc("stat dest file").AppendError(data.ConvertError(sErr))
+	}
+
+	deadline := time.Now().Add(time.Duration(c.info.UrlExpires) * time.Second).Unix()
+	srcUrl := storage.MakePrivateURLv2(c.srcMac, c.srcDomain, w.SrcKey, deadline)
+	if c.info.Async {
+		return c.asyncFetch(w, srcUrl)
+	}
+
+	ret, fErr := c.dstManager.Fetch(srcUrl, w.DstBucket, w.DstKey)
+	if fErr != nil {
+		return nil, data.NewEmptyError().AppendDesc("fetch").AppendError(data.ConvertError(fErr))
+	}
+	return verifyCrossCopy(w, ret.Hash, ret.Fsize)
+}
+
+// asyncFetch 发起异步抓取后轮询目标文件，直到目标文件的 hash 与源文件一致或超时
+func (c *crossCopier) asyncFetch(w *crossCopyWork, srcUrl string) (*crossCopyResult, *data.CodeError) {
+	ret, err := object.AsyncFetchWithManager(c.dstManager, object.AsyncFetchApiInfo{
+		Url:           srcUrl,
+		Bucket:        w.DstBucket,
+		Key:           w.DstKey,
+		Etag:          w.Hash,
+		IgnoreSameKey: !c.info.Overwrite,
+	})
+	if err != nil {
+		return nil, data.NewEmptyError().AppendDesc("async fetch").AppendError(err)
+	}
+	log.DebugF("crosscopy async fetch %s, id:%s", w, ret.Id)
+
+	// 排队等因素可能导致抓取较慢，最长等待抓取所需时间的 6 倍
+	timeout := time.Duration(asyncFetchCheckMaxDuration(uint64(w.FileSize))*6) * time.Second
+	deadline := time.Now().Add(timeout)
+	interval := 3 * time.Second
+	for {
+		if !sleepUntilInterrupt(interval) {
+			return nil, data.NewEmptyError().AppendDescF("interrupted, async fetch id:%s", ret.Id)
+		}
+		if stat, sErr := c.dstManager.Stat(w.DstBucket, w.DstKey); sErr == nil && stat.Hash == w.Hash {
+			return verifyCrossCopy(w, stat.Hash, stat.Fsize)
+		}
+		if time.Now().After(deadline) {
+			return nil, data.NewEmptyError().AppendDescF("async fetch timeout after %s, id:%s, you can check it by `qshell acheck %s %s`",
+				timeout, ret.Id, w.DstBucket, ret.Id)
+		}
+		if interval *= 2; interval > 30*time.Second {
+			interval = 30 * time.Second
+		}
+	}
+}
+
+func verifyCrossCopy(w *crossCopyWork, hash string, fileSize int64) (*crossCopyResult, *data.CodeError) {
+	if hash != w.Hash || fileSize != w.FileSize {
+		return nil, data.NewEmptyError().AppendDescF("verify failed, src hash:%s size:%d, dest hash:%s size:%d", w.Hash, w.FileSize, hash, fileSize)
+	}
+	return &crossCopyResult{Hash: hash, FileSize: fileSize}, nil
+}
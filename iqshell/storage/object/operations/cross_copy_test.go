@@ -0,0 +1,94 @@
+package operations
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/qiniu/go-sdk/v7/auth"
+	"github.com/qiniu/go-sdk/v7/storage"
+)
+
+func TestParseCrossCopyLocation(t *testing.T) {
+	cases := []struct {
+		location string
+		want     CrossCopyLocation
+		hasErr   bool
+	}{
+		{"alice:bucket/logs/", CrossCopyLocation{Account: "alice", Bucket: "bucket", Prefix: "logs/"}, false},
+		{"alice:bucket", CrossCopyLocation{Account: "alice", Bucket: "bucket"}, false},
+		{":bucket/a/b", CrossCopyLocation{Bucket: "bucket", Prefix: "a/b"}, false},
+		{"bucket/a", CrossCopyLocation{Bucket: "bucket", Prefix: "a"}, false},
+		{"alice:", CrossCopyLocation{}, true},
+		{"alice:/logs", CrossCopyLocation{}, true},
+	}
+	for _, c := range cases {
+		got, err := ParseCrossCopyLocation(c.location)
+		if c.hasErr {
+			if err == nil {
+				t.Errorf("%s: should return error", c.location)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error:%v", c.location, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("%s: want %+v, got %+v", c.location, c.want, got)
+		}
+	}
+}
+
+func TestCrossCopyDestKey(t *testing.T) {
+	src := CrossCopyLocation{Account: "alice", Bucket: "src", Prefix: "logs/"}
+	dst := CrossCopyLocation{Account: "bob", Bucket: "dst", Prefix: "backup/"}
+	if key := dst.DestKey(src, "logs/2022/a.log"); key != "backup/2022/a.log" {
+		t.Fatalf("dest key error:%s", key)
+	}
+
+	dst.Prefix = ""
+	if key := dst.DestKey(src, "logs/a.log"); key != "a.log" {
+		t.Fatalf("dest key error:%s", key)
+	}
+}
+
+func TestCrossCopyKeepWorkId(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/stat/") {
+			t.Errorf("unexpected request:%s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Reqid", "test")
+		_, _ = w.Write([]byte(`{"hash":"src-hash","fsize":10}`))
+	}))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	region := &storage.Region{RsHost: host, RsfHost: host, ApiHost: host, IovipHost: host}
+	manager := storage.NewBucketManager(auth.New("ak", "sk"), &storage.Config{
+		Region:        region,
+		Zone:          region,
+		CentralRsHost: host,
+	})
+	c := &crossCopier{
+		info:       &CrossCopyInfo{},
+		srcManager: manager,
+		dstManager: manager,
+	}
+
+	// --from-list 中没有 hash 的文件，拷贝时 stat 源文件，但 WorkId 需与记录时一致
+	work := &crossCopyWork{SrcBucket: "src", SrcKey: "a", DstBucket: "dst", DstKey: "a"}
+	workId := work.WorkId()
+	result, err := c.copy(work)
+	if err != nil {
+		t.Fatal("copy error:", err)
+	}
+	if !result.IsExist || result.Hash != "src-hash" {
+		t.Fatalf("dest file with the same hash should be skipped, result:%+v", result)
+	}
+	if work.WorkId() != workId {
+		t.Fatalf("work id changed after copy, before:%s after:%s", workId, work.WorkId())
+	}
+}
@@ -3,11 +3,13 @@ package operations
 import (
 	"fmt"
 	"github.com/qiniu/qshell/v2/iqshell"
+	cdnOperations "github.com/qiniu/qshell/v2/iqshell/cdn/operations"
 	"github.com/qiniu/qshell/v2/iqshell/common/alert"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/export"
 	"github.com/qiniu/qshell/v2/iqshell/common/flow"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
 	"github.com/qiniu/qshell/v2/iqshell/common/utils"
 	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object"
@@ -38,12 +40,13 @@ func Delete(cfg *iqshell.Config, info DeleteInfo) {
 		return
 	}
 
-	result, err := object.Delete(&object.DeleteApiInfo{
+	apiInfo := &object.DeleteApiInfo{
 		Bucket:          info.Bucket,
 		Key:             info.Key,
 		DeleteAfterDays: 0,
 		IsDeleteAfter:   false,
-	})
+	}
+	result, err := object.Delete(apiInfo)
 
 	if err != nil || result == nil {
 		data.SetCmdStatusError()
@@ -52,6 +55,11 @@ func Delete(cfg *iqshell.Config, info DeleteInfo) {
 		return
 	}
 
+	output.Result(&batch.OperationRecord{
+		Operation: apiInfo,
+		Result:    result,
+	})
+
 	if result.IsSuccess() {
 		log.InfoF("Delete Success, [%s:%s]", info.Bucket, info.Key)
 	} else {
@@ -62,8 +70,9 @@ func Delete(cfg *iqshell.Config, info DeleteInfo) {
 }
 
 type BatchDeleteInfo struct {
-	BatchInfo batch.Info
-	Bucket    string
+	BatchInfo  batch.Info
+	Bucket     string
+	CdnRefresh cdnOperations.AutoRefreshInfo // 删除成功后刷新文件的 CDN 缓存
 }
 
 func (info *BatchDeleteInfo) Check() *data.CodeError {
@@ -74,7 +83,7 @@ func (info *BatchDeleteInfo) Check() *data.CodeError {
 	if len(info.Bucket) == 0 {
 		return alert.CannotEmptyError("Bucket", "")
 	}
-	return nil
+	return info.CdnRefresh.Check()
 }
 
 // BatchDelete 批量删除，由于和批量删除的输入读取逻辑不同，所以分开
@@ -95,6 +104,9 @@ func BatchDelete(cfg *iqshell.Config, info BatchDeleteInfo) {
 		return
 	}
 
+	refresher := cdnOperations.NewAutoRefresher(info.CdnRefresh)
+	defer refresher.Close()
+
 	lineParser := bucket.NewListLineParser()
 	batch.NewHandler(info.BatchInfo).
 		SetFileExport(exporter).
@@ -128,6 +140,7 @@ func BatchDelete(cfg *iqshell.Config, info BatchDeleteInfo) {
 				return
 			}
 			if result.IsSuccess() {
+				refresher.AddKeys(apiInfo.Key)
 				if len(apiInfo.Condition.PutTime) == 0 {
 					log.InfoF("Delete Success, [%s:%s]", apiInfo.Bucket, apiInfo.Key)
 				} else {
@@ -190,12 +203,13 @@ func DeleteAfter(cfg *iqshell.Config, info DeleteAfterInfo) {
 		return
 	}
 
-	result, err := object.Delete(&object.DeleteApiInfo{
+	apiInfo := &object.DeleteApiInfo{
 		Bucket:          info.Bucket,
 		Key:             info.Key,
 		DeleteAfterDays: afterDays,
 		IsDeleteAfter:   true,
-	})
+	}
+	result, err := object.Delete(apiInfo)
 
 	if err != nil || result == nil {
 		data.SetCmdStatusError()
@@ -204,6 +218,11 @@ func DeleteAfter(cfg *iqshell.Config, info DeleteAfterInfo) {
 		return
 	}
 
+	output.Result(&batch.OperationRecord{
+		Operation: apiInfo,
+		Result:    result,
+	})
+
 	if result.IsSuccess() {
 		if afterDays == 0 {
 			log.InfoF("Expire Success, [%s:%s], cancel expiration time", info.Bucket, info.Key)
@@ -8,6 +8,7 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/common/export"
 	"github.com/qiniu/qshell/v2/iqshell/common/flow"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
 	"github.com/qiniu/qshell/v2/iqshell/common/utils"
 	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object"
@@ -40,6 +41,10 @@ func Fetch(cfg *iqshell.Config, info FetchInfo) {
 		log.ErrorF("Fetch Failed, '%s' => [%s:%s], Error:%v",
 			info.FromUrl, info.Bucket, info.Key, err)
 	} else {
+		output.Result(&batch.OperationRecord{
+			Operation: object.FetchApiInfo(info),
+			Result:    result,
+		})
 		log.InfoF("Fetch Success, '%s' => [%s:%s]", info.FromUrl, info.Bucket, info.Key)
 		log.AlertF("Key:%s", result.Key)
 		log.AlertF("FileHash:%s", result.Hash)
@@ -179,15 +184,29 @@ func BatchFetch(cfg *iqshell.Config, info BatchFetchInfo) {
 			metric.PrintProgress("Batching:" + workInfo.Data)
 
 			in, _ := workInfo.Work.(*object.FetchApiInfo)
+			output.Result(&batch.OperationRecord{
+				Line:      workInfo.Data,
+				Operation: in,
+				Result:    result,
+			})
 			exporter.Success().ExportF("%s\t%s", in.FromUrl, in.Key)
 			log.InfoF("Fetch Success, '%s' => [%s:%s]", in.FromUrl, info.Bucket, in.Key)
 		}).
+		OnConcurrencyChange(metric.OnConcurrencyChange).
 		OnWorkFail(func(workInfo *flow.WorkInfo, err *data.CodeError) {
 			metric.AddCurrentCount(1)
 			metric.AddFailureCount(1)
 			metric.PrintProgress("Batching:" + workInfo.Data)
 
 			exporter.Fail().ExportF("%s%s%v", workInfo.Data, flow.ErrorSeparate, err)
+			output.Result(&batch.OperationRecord{
+				Line:      workInfo.Data,
+				Operation: workInfo.Work,
+				Result: &batch.OperationResult{
+					Code:  err.Code,
+					Error: err.Desc,
+				},
+			})
 			if in, ok := workInfo.Work.(*object.FetchApiInfo); ok {
 				log.ErrorF("Fetch Failed, '%s' => [%s:%s], Error: %v", in.FromUrl, in.Bucket, in.Key, err)
 			} else {
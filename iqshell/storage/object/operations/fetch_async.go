@@ -13,6 +13,7 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/common/export"
 	"github.com/qiniu/qshell/v2/iqshell/common/flow"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
 	"github.com/qiniu/qshell/v2/iqshell/common/utils"
 	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object"
@@ -46,6 +47,7 @@ func CheckAsyncFetchStatus(cfg *iqshell.Config, info CheckAsyncFetchStatusInfo)
 		data.SetCmdStatusError()
 		log.ErrorF("CheckAsyncFetchStatus error: %v", err)
 	} else {
+		output.Result(ret)
 		log.Alert(ret)
 	}
 }
@@ -254,17 +256,31 @@ func batchAsyncFetch(cfg *iqshell.Config, info BatchAsyncFetchInfo,
 			res := result.(*asyncFetchResult)
 			if info.DisableCheckFetchResult {
 				exporter.Success().ExportF("%s", workInfo.Data)
+				output.Result(&batch.OperationRecord{
+					Line:      workInfo.Data,
+					Operation: in.info,
+					Result:    res,
+				})
 			}
 			fetchResultChan <- res
 			log.InfoF("Fetch Response, '%s' => [%s:%s] id:%s wait:%d",
 				in.info.Url, in.info.Bucket, in.info.Key, res.Info.Id, res.Info.Wait)
 		}).
+		OnConcurrencyChange(metric.OnConcurrencyChange).
 		OnWorkFail(func(workInfo *flow.WorkInfo, err *data.CodeError) {
 			metric.AddFailureCount(1)
 			metric.AddCurrentCount(1)
 			metric.PrintProgress("Batching:" + workInfo.Data)
 
 			if in, ok := workInfo.Work.(*asyncFetchItem); ok {
+				output.Result(&batch.OperationRecord{
+					Line:      workInfo.Data,
+					Operation: in.info,
+					Result: &batch.OperationResult{
+						Code:  err.Code,
+						Error: err.Desc,
+					},
+				})
 				exporter.Fail().ExportF("%s%s%v", in.info.Url, flow.ErrorSeparate, err)
 				log.ErrorF("Fetch Failed, '%s' => [%s:%s], Error: %v", in.info.Url, in.info.Bucket, in.info.Key, err)
 			} else {
@@ -424,14 +440,26 @@ func batchAsyncFetchCheck(cfg *iqshell.Config, info BatchAsyncFetchInfo,
 			metric.PrintProgress("Batching:" + workInfo.Data)
 
 			in := workInfo.Work.(*asyncFetchResult)
+			output.Result(&batch.OperationRecord{
+				Operation: in,
+				Result:    &batch.OperationResult{},
+			})
 			exporter.Success().ExportF("%s\t%s", in.Url, in.Key)
 			log.InfoF("Fetch Success, %s => [%s:%s]", in.Url, in.Bucket, in.Key)
 		}).
+		OnConcurrencyChange(metric.OnConcurrencyChange).
 		OnWorkFail(func(workInfo *flow.WorkInfo, err *data.CodeError) {
 			metric.AddFailureCount(1)
 			metric.PrintProgress("Batching:" + workInfo.Data)
 
 			if in, ok := workInfo.Work.(*asyncFetchResult); ok {
+				output.Result(&batch.OperationRecord{
+					Operation: in,
+					Result: &batch.OperationResult{
+						Code:  err.Code,
+						Error: err.Desc,
+					},
+				})
 				exporter.Fail().ExportF("%s%s%v", in.Url, flow.ErrorSeparate, err)
 				log.ErrorF("Fetch Failed, '%s' => [%s:%s], Error: %v", in.Url, in.Bucket, in.Key, err)
 			} else {
@@ -5,7 +5,9 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/common/alert"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
 )
 
 type PreFopStatusInfo struct {
@@ -27,16 +29,21 @@ func PreFopStatus(cfg *iqshell.Config, info PreFopStatusInfo) {
 		return
 	}
 
-	ret, err := object.PreFopStatus(object.PreFopStatusApiInfo{
+	apiInfo := object.PreFopStatusApiInfo{
 		Id:     info.Id,
 		Bucket: info.Bucket,
-	})
+	}
+	ret, err := object.PreFopStatus(apiInfo)
 	if err != nil {
 		data.SetCmdStatusError()
 		log.ErrorF("prefop status error:%v", err)
 		return
 	}
 
+	output.Result(&batch.OperationRecord{
+		Operation: apiInfo,
+		Result:    ret,
+	})
 	log.Alert(ret.String())
 }
 
@@ -70,5 +77,11 @@ func Pfop(cfg *iqshell.Config, info PfopInfo) {
 		log.ErrorF("pfop error:%v", err)
 		return
 	}
+	output.Result(&batch.OperationRecord{
+		Operation: object.PfopApiInfo(info),
+		Result: map[string]string{
+			"persistent_id": persistentId,
+		},
+	})
 	log.Alert(persistentId)
 }
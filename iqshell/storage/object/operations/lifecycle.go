@@ -10,6 +10,7 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/common/export"
 	"github.com/qiniu/qshell/v2/iqshell/common/flow"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
 	"github.com/qiniu/qshell/v2/iqshell/common/utils"
 	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object"
@@ -52,6 +53,11 @@ func ChangeLifecycle(cfg *iqshell.Config, info *ChangeLifecycleInfo) {
 		return
 	}
 
+	output.Result(&batch.OperationRecord{
+		Operation: (*object.ChangeLifecycleApiInfo)(info),
+		Result:    result,
+	})
+
 	if result.IsSuccess() {
 		lifecycleValues := []int{info.ToIAAfterDays, info.ToArchiveIRAfterDays, info.ToArchiveAfterDays,
 			info.ToDeepArchiveAfterDays, info.DeleteAfterDays}
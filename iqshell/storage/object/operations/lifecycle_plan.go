@@ -0,0 +1,214 @@
+package operations
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object"
+)
+
+const (
+	lifecyclePlanSatisfiedFile = "satisfied.txt"
+	lifecyclePlanSummaryFile   = "summary.json"
+)
+
+type LifecyclePlanInfo struct {
+	Bucket       string   // 空间名 【必选】
+	Rules        []string // 生命周期规则，参考 object.ParseLifecycleRule 【必选】
+	Prices       string   // 各存储类型的单价，参考 object.ParseLifecyclePrices 【可选】
+	FromListFile string   // 从 listbucket2 的输出文件规划，不指定时列举空间 【可选】
+	ItemSeparate string   // FromListFile 每行中各字段的分隔符，默认：\t 【可选】
+	OutputDir    string   // batchchlifecycle 输入文件及规划统计的保存目录 【必选】
+	MaxRetry     int      // 列举出错时的最大重试次数，-1: 无限重试 【可选】
+
+	rules  []*object.LifecycleRule
+	prices map[int]float64
+}
+
+func (info *LifecyclePlanInfo) Check() *data.CodeError {
+	if len(info.Bucket) == 0 {
+		return alert.CannotEmptyError("Bucket", "")
+	}
+	if len(info.Rules) == 0 {
+		return alert.CannotEmptyError("rule (--rule)", "")
+	}
+	if len(info.OutputDir) == 0 {
+		return alert.CannotEmptyError("output dir (--output-dir)", "")
+	}
+	if len(info.ItemSeparate) == 0 {
+		info.ItemSeparate = "\t"
+	}
+
+	info.rules = nil
+	for _, rule := range info.Rules {
+		r, err := object.ParseLifecycleRule(rule)
+		if err != nil {
+			return err
+		}
+		info.rules = append(info.rules, r)
+	}
+
+	prices, err := object.ParseLifecyclePrices(info.Prices)
+	if err != nil {
+		return err
+	}
+	info.prices = prices
+	return nil
+}
+
+// listPrefix 所有规则前缀的公共前缀，列举空间时只需列举此前缀下的文件
+func (info *LifecyclePlanInfo) listPrefix() string {
+	prefix := info.rules[0].Prefix
+	for _, r := range info.rules[1:] {
+		for !strings.HasPrefix(r.Prefix, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+// LifecyclePlan 根据生命周期规则模拟文件的生命周期设置：生成 batchchlifecycle 的输入文件，并估算存储费用的变化
+func LifecyclePlan(cfg *iqshell.Config, info LifecyclePlanInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	if err := os.MkdirAll(info.OutputDir, os.ModePerm); err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("lifecycle plan: create output dir:%s error:%v", info.OutputDir, err)
+		return
+	}
+
+	writers := &lifecyclePlanWriters{
+		dir:     info.OutputDir,
+		writers: make(map[string]*bufio.Writer),
+	}
+	defer writers.close()
+
+	planner := object.NewLifecyclePlanner(info.rules, info.prices, time.Now())
+	err := bucket.RangeObjects(bucket.ListApiInfo{
+		Bucket:   info.Bucket,
+		Prefix:   info.listPrefix(),
+		MaxRetry: info.MaxRetry,
+		V1Limit:  1000,
+	}, info.FromListFile, info.ItemSeparate, func(o bucket.ListObject) *data.CodeError {
+		result := planner.Plan(object.LifecyclePlanObject{
+			Key:      o.Key,
+			Fsize:    o.Fsize,
+			PutTime:  o.PutTime,
+			FileType: o.Type,
+		})
+		if result.Satisfied {
+			return writers.write(lifecyclePlanSatisfiedFile, fmt.Sprintf("%s\t%d\t%s", o.Key, o.Type, result.Rule))
+		}
+		if result.Group != nil {
+			return writers.write(result.Group.Id+".txt", o.Key)
+		}
+		return nil
+	})
+	if fErr := writers.close(); fErr != nil && err == nil {
+		err = fErr
+	}
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("lifecycle plan error:%v", err)
+		return
+	}
+
+	summary := planner.Summary()
+	for _, group := range summary.Groups {
+		group.File = filepath.Join(info.OutputDir, group.Id+".txt")
+	}
+	summaryFile := filepath.Join(info.OutputDir, lifecyclePlanSummaryFile)
+	if err = utils.MarshalToFile(summaryFile, summary); err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("lifecycle plan: save summary to %s error:%v", summaryFile, err)
+		return
+	}
+	output.Result(summary)
+
+	log.AlertF("Total: %d files, %s; Unmatched: %d files, %s", summary.Total.Count, utils.FormatFileSize(summary.Total.Bytes),
+		summary.Unmatched.Count, utils.FormatFileSize(summary.Unmatched.Bytes))
+	for _, r := range summary.Rules {
+		log.AlertF("Rule %s", r.Rule)
+		log.AlertF("  Matched: %d files, %s; Satisfied: %d files, %s; Planned: %d files, %s",
+			r.Matched.Count, utils.FormatFileSize(r.Matched.Bytes), r.Satisfied.Count, utils.FormatFileSize(r.Satisfied.Bytes),
+			r.Planned.Count, utils.FormatFileSize(r.Planned.Bytes))
+		actions := make([]string, 0, len(r.Due))
+		for action := range r.Due {
+			actions = append(actions, action)
+		}
+		sort.Strings(actions)
+		for _, action := range actions {
+			log.AlertF("  Due now to %s: %d files, %s", action, r.Due[action].Count, utils.FormatFileSize(r.Due[action].Bytes))
+		}
+		log.AlertF("  Monthly storage cost: %.2f => %.2f", r.CurrentCost, r.PlannedCost)
+	}
+	log.AlertF("Monthly storage cost of matched files: %.2f => %.2f, delta: %+.2f (estimated, excluding minimum storage duration and retrieval fees)",
+		summary.CurrentCost, summary.PlannedCost, summary.CostDelta)
+	satisfiedCount := int64(0)
+	for _, r := range summary.Rules {
+		satisfiedCount += r.Satisfied.Count
+	}
+	if satisfiedCount > 0 {
+		log.AlertF("Files which already satisfy the rules: %s", filepath.Join(info.OutputDir, lifecyclePlanSatisfiedFile))
+	}
+	for _, group := range summary.Groups {
+		log.AlertF("qshell batchchlifecycle %s -i %s %s  # %d files, %s", info.Bucket, group.File, group.Setting.Flags(),
+			group.Count, utils.FormatFileSize(group.Bytes))
+	}
+	log.AlertF("Summary: %s", summaryFile)
+}
+
+// lifecyclePlanWriters 规划结果文件，按文件名懒创建
+type lifecyclePlanWriters struct {
+	dir     string
+	files   []*os.File
+	writers map[string]*bufio.Writer
+}
+
+func (w *lifecyclePlanWriters) write(name, line string) *data.CodeError {
+	writer, ok := w.writers[name]
+	if !ok {
+		file, e := os.Create(filepath.Join(w.dir, name))
+		if e != nil {
+			return data.NewEmptyError().AppendDescF("create file:%s", name).AppendError(e)
+		}
+		writer = bufio.NewWriter(file)
+		w.files = append(w.files, file)
+		w.writers[name] = writer
+	}
+	if _, e := writer.WriteString(line + "\n"); e != nil {
+		return data.NewEmptyError().AppendDescF("write file:%s", name).AppendError(e)
+	}
+	return nil
+}
+
+func (w *lifecyclePlanWriters) close() *data.CodeError {
+	var err *data.CodeError
+	for _, writer := range w.writers {
+		if e := writer.Flush(); e != nil && err == nil {
+			err = data.ConvertError(e)
+		}
+	}
+	for _, file := range w.files {
+		_ = file.Close()
+	}
+	w.files = nil
+	w.writers = make(map[string]*bufio.Writer)
+	return err
+}
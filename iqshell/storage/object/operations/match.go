@@ -8,6 +8,7 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/common/export"
 	"github.com/qiniu/qshell/v2/iqshell/common/flow"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
 	"github.com/qiniu/qshell/v2/iqshell/common/utils"
 	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
 	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
@@ -38,12 +39,16 @@ func Match(cfg *iqshell.Config, info MatchInfo) {
 		return
 	}
 
-	_, err := object.Match(object.MatchApiInfo(info))
+	result, err := object.Match(object.MatchApiInfo(info))
 	if err != nil {
 		data.SetCmdStatusError()
 		log.ErrorF("Match  Failed, [%s:%s] => '%s', Error:%v",
 			info.Bucket, info.Key, info.LocalFile, err)
 	} else {
+		output.Result(&batch.OperationRecord{
+			Operation: object.MatchApiInfo(info),
+			Result:    result,
+		})
 		log.InfoF("Match Success, [%s:%s] => '%s'",
 			info.Bucket, info.Key, info.LocalFile)
 	}
@@ -191,15 +196,29 @@ func BatchMatch(cfg *iqshell.Config, info BatchMatchInfo) {
 			metric.PrintProgress("Batching:" + workInfo.Data)
 
 			in, _ := workInfo.Work.(*object.MatchApiInfo)
+			output.Result(&batch.OperationRecord{
+				Line:      workInfo.Data,
+				Operation: in,
+				Result:    result,
+			})
 			exporter.Success().ExportF("%s\t \t%s", in.Key, in.ServerFileHash)
 			log.InfoF("Match Success, [%s:%s] => '%s'", info.Bucket, in.Key, in.LocalFile)
 		}).
+		OnConcurrencyChange(metric.OnConcurrencyChange).
 		OnWorkFail(func(workInfo *flow.WorkInfo, err *data.CodeError) {
 			metric.AddCurrentCount(1)
 			metric.AddFailureCount(1)
 			metric.PrintProgress("Batching:" + workInfo.Data)
 
 			exporter.Fail().ExportF("%s%s%v", workInfo.Data, flow.ErrorSeparate, err)
+			output.Result(&batch.OperationRecord{
+				Line:      workInfo.Data,
+				Operation: workInfo.Work,
+				Result: &batch.OperationResult{
+					Code:  err.Code,
+					Error: err.Desc,
+				},
+			})
 			if in, ok := workInfo.Work.(*object.MatchApiInfo); ok {
 				log.ErrorF("Match Failed, [%s:%s] => '%s', Error: %s", info.Bucket, in.Key, in.LocalFile, err)
 			} else {
@@ -8,6 +8,7 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/common/export"
 	"github.com/qiniu/qshell/v2/iqshell/common/flow"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
 	"github.com/qiniu/qshell/v2/iqshell/common/utils"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
@@ -43,6 +44,11 @@ func ChangeMime(cfg *iqshell.Config, info ChangeMimeInfo) {
 		return
 	}
 
+	output.Result(&batch.OperationRecord{
+		Operation: (*object.ChangeMimeApiInfo)(&info),
+		Result:    result,
+	})
+
 	if result.IsSuccess() {
 		log.InfoF("Change mimetype Success, [%s:%s] => '%s'", info.Bucket, info.Key, info.Mime)
 	} else {
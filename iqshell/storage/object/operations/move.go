@@ -3,11 +3,13 @@ package operations
 import (
 	"fmt"
 	"github.com/qiniu/qshell/v2/iqshell"
+	cdnOperations "github.com/qiniu/qshell/v2/iqshell/cdn/operations"
 	"github.com/qiniu/qshell/v2/iqshell/common/alert"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/export"
 	"github.com/qiniu/qshell/v2/iqshell/common/flow"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
 	"github.com/qiniu/qshell/v2/iqshell/common/utils"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
@@ -49,6 +51,11 @@ func Move(cfg *iqshell.Config, info MoveInfo) {
 		return
 	}
 
+	output.Result(&batch.OperationRecord{
+		Operation: (*object.MoveApiInfo)(&info),
+		Result:    result,
+	})
+
 	if result.IsSuccess() {
 		log.InfoF("Move Success, [%s:%s] => [%s:%s]",
 			info.SourceBucket, info.SourceKey,
@@ -66,6 +73,7 @@ type BatchMoveInfo struct {
 	BatchInfo    batch.Info
 	SourceBucket string
 	DestBucket   string
+	CdnRefresh   cdnOperations.AutoRefreshInfo // 移动成功后刷新源文件的 CDN 缓存，源空间与目标空间相同时也刷新目标文件
 }
 
 func (info *BatchMoveInfo) Check() *data.CodeError {
@@ -81,7 +89,7 @@ func (info *BatchMoveInfo) Check() *data.CodeError {
 		return alert.CannotEmptyError("DestBucket", "")
 	}
 
-	return nil
+	return info.CdnRefresh.Check()
 }
 
 func BatchMove(cfg *iqshell.Config, info BatchMoveInfo) {
@@ -102,6 +110,9 @@ func BatchMove(cfg *iqshell.Config, info BatchMoveInfo) {
 		return
 	}
 
+	refresher := cdnOperations.NewAutoRefresher(info.CdnRefresh)
+	defer refresher.Close()
+
 	batch.NewHandler(info.BatchInfo).
 		SetFileExport(exporter).
 		EmptyOperation(func() flow.Work {
@@ -132,6 +143,10 @@ func BatchMove(cfg *iqshell.Config, info BatchMoveInfo) {
 			}
 
 			if result.IsSuccess() {
+				refresher.AddKeys(apiInfo.SourceKey)
+				if apiInfo.DestBucket == apiInfo.SourceBucket {
+					refresher.AddKeys(apiInfo.DestKey)
+				}
 				log.InfoF("Move Success, [%s:%s] => [%s:%s]",
 					apiInfo.SourceBucket, apiInfo.SourceKey,
 					apiInfo.DestBucket, apiInfo.DestKey)
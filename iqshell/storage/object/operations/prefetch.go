@@ -5,7 +5,9 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/common/alert"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
 	"github.com/qiniu/qshell/v2/iqshell/storage"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
 )
 
 type MirrorUpdateInfo storage.PrefetchApiInfo
@@ -32,6 +34,10 @@ func MirrorUpdate(cfg *iqshell.Config, info MirrorUpdateInfo) {
 		data.SetCmdStatusError()
 		log.ErrorF("Mirror update Failed, [%s:%s], Error: %v", info.Bucket, info.Key, err)
 	} else {
+		output.Result(&batch.OperationRecord{
+			Operation: storage.PrefetchApiInfo(info),
+			Result:    &batch.OperationResult{},
+		})
 		log.InfoF("Mirror update Success, [%s:%s]", info.Bucket, info.Key)
 	}
 }
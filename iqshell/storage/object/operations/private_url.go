@@ -13,6 +13,7 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/common/export"
 	"github.com/qiniu/qshell/v2/iqshell/common/flow"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
 	"github.com/qiniu/qshell/v2/iqshell/common/utils"
 	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
@@ -65,16 +66,21 @@ func PrivateUrl(cfg *iqshell.Config, info PrivateUrlInfo) {
 		return
 	}
 
-	url, err := download.PublicUrlToPrivate(download.PublicUrlToPrivateApiInfo{
+	apiInfo := download.PublicUrlToPrivateApiInfo{
 		PublicUrl: info.PublicUrl,
 		Deadline:  deadline,
-	})
+	}
+	url, err := download.PublicUrlToPrivate(apiInfo)
 	if err != nil {
 		log.Error(err)
 		data.SetCmdStatusError()
 		return
 	}
 
+	output.Result(&batch.OperationRecord{
+		Operation: apiInfo,
+		Result:    url,
+	})
 	log.Alert(url.Url)
 }
 
@@ -212,6 +218,11 @@ func BatchPrivateUrl(cfg *iqshell.Config, info BatchPrivateUrlInfo) {
 			metric.PrintProgress("Batching:" + work.Data)
 
 			r, _ := result.(*download.PublicUrlToPrivateApiResult)
+			output.Result(&batch.OperationRecord{
+				Line:      work.Data,
+				Operation: work.Work,
+				Result:    r,
+			})
 			exporter.Success().Export(work.Data)
 			exporter.Result().ExportF(r.Url)
 			log.Alert(r.Url)
@@ -222,6 +233,14 @@ func BatchPrivateUrl(cfg *iqshell.Config, info BatchPrivateUrlInfo) {
 			metric.PrintProgress("Batching:" + work.Data)
 
 			exporter.Fail().ExportF("%s%s%v", work.Data, flow.ErrorSeparate, err)
+			output.Result(&batch.OperationRecord{
+				Line:      work.Data,
+				Operation: work.Work,
+				Result: &batch.OperationResult{
+					Code:  err.Code,
+					Error: err.Desc,
+				},
+			})
 			log.Error(err)
 		}).Build().Start()
 
@@ -3,11 +3,13 @@ package operations
 import (
 	"fmt"
 	"github.com/qiniu/qshell/v2/iqshell"
+	cdnOperations "github.com/qiniu/qshell/v2/iqshell/cdn/operations"
 	"github.com/qiniu/qshell/v2/iqshell/common/alert"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/export"
 	"github.com/qiniu/qshell/v2/iqshell/common/flow"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
 	"github.com/qiniu/qshell/v2/iqshell/common/utils"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
@@ -51,6 +53,11 @@ func Rename(cfg *iqshell.Config, info RenameInfo) {
 		return
 	}
 
+	output.Result(&batch.OperationRecord{
+		Operation: (*object.MoveApiInfo)(&info),
+		Result:    result,
+	})
+
 	if result.IsSuccess() {
 		log.InfoF("Rename '%s:%s' => '%s:%s' success",
 			info.SourceBucket, info.SourceKey,
@@ -65,8 +72,9 @@ func Rename(cfg *iqshell.Config, info RenameInfo) {
 }
 
 type BatchRenameInfo struct {
-	BatchInfo batch.Info
-	Bucket    string
+	BatchInfo  batch.Info
+	Bucket     string
+	CdnRefresh cdnOperations.AutoRefreshInfo // 重命名成功后刷新源文件及目标文件的 CDN 缓存
 }
 
 func (info *BatchRenameInfo) Check() *data.CodeError {
@@ -77,7 +85,7 @@ func (info *BatchRenameInfo) Check() *data.CodeError {
 	if len(info.Bucket) == 0 {
 		return alert.CannotEmptyError("Bucket", "")
 	}
-	return nil
+	return info.CdnRefresh.Check()
 }
 
 func BatchRename(cfg *iqshell.Config, info BatchRenameInfo) {
@@ -98,6 +106,9 @@ func BatchRename(cfg *iqshell.Config, info BatchRenameInfo) {
 		return
 	}
 
+	refresher := cdnOperations.NewAutoRefresher(info.CdnRefresh)
+	defer refresher.Close()
+
 	batch.NewHandler(info.BatchInfo).
 		EmptyOperation(func() flow.Work {
 			return &object.MoveApiInfo{}
@@ -129,6 +140,7 @@ func BatchRename(cfg *iqshell.Config, info BatchRenameInfo) {
 			}
 			in := (*RenameInfo)(apiInfo)
 			if result.IsSuccess() {
+				refresher.AddKeys(in.SourceKey, in.DestKey)
 				log.InfoF("Rename Success, [%s:%s] => [%s:%s]",
 					in.SourceBucket, in.SourceKey,
 					in.DestBucket, in.DestKey)
@@ -2,17 +2,23 @@ package operations
 
 import (
 	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+
 	"github.com/qiniu/qshell/v2/iqshell"
 	"github.com/qiniu/qshell/v2/iqshell/common/alert"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/export"
 	"github.com/qiniu/qshell/v2/iqshell/common/flow"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
 	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
-	"path/filepath"
-	"strconv"
+	downloadOperations "github.com/qiniu/qshell/v2/iqshell/storage/object/download/operations"
 )
 
 func convertFreezeAfterDaysToInt(freezeAfterDays string) (int, *data.CodeError) {
@@ -64,11 +70,12 @@ func RestoreArchive(cfg *iqshell.Config, info RestoreArchiveInfo) {
 		return
 	}
 
-	result, err := object.RestoreArchive(&object.RestoreArchiveApiInfo{
+	apiInfo := &object.RestoreArchiveApiInfo{
 		Bucket:          info.Bucket,
 		Key:             info.Key,
 		FreezeAfterDays: info.freezeAfterDaysInt,
-	})
+	}
+	result, err := object.RestoreArchive(apiInfo)
 	if err != nil || result == nil {
 		data.SetCmdStatusError()
 		log.ErrorF("Restore archive Failed, [%s:%s], FreezeAfterDays:%s, Error: %v",
@@ -76,6 +83,11 @@ func RestoreArchive(cfg *iqshell.Config, info RestoreArchiveInfo) {
 		return
 	}
 
+	output.Result(&batch.OperationRecord{
+		Operation: apiInfo,
+		Result:    result,
+	})
+
 	if result.IsSuccess() {
 		log.InfoF("Restore archive Success, [%s:%s], FreezeAfterDays:%s",
 			info.Bucket, info.Key, info.FreezeAfterDays)
@@ -92,6 +104,16 @@ type BatchRestoreArchiveInfo struct {
 	Bucket             string
 	FreezeAfterDays    string
 	freezeAfterDaysInt int
+
+	Wait            bool   // 等待解冻完成 【可选】
+	WaitInterval    int    // 首次查询解冻状态的间隔，之后按 2 倍退避，单位：秒 【可选】
+	WaitMaxInterval int    // 查询解冻状态的最大间隔，单位：秒 【可选】
+	WaitTimeout     int    // 等待的最长时间，单位：秒，0：不限制 【可选】
+	ReadyListFile   string // 解冻完成的文件列表导出路径，格式同 listbucket2 的输出 【可选】
+	DownloadConfig  string // qdownload2 的配置文件，配置后解冻完成的文件会被立即下载，隐含 Wait 【可选】
+	DownloadWorker  int    // 下载的并发数 【可选】
+
+	downloadCfg *downloadOperations.DownloadCfg
 }
 
 func (info *BatchRestoreArchiveInfo) Check() *data.CodeError {
@@ -108,6 +130,39 @@ func (info *BatchRestoreArchiveInfo) Check() *data.CodeError {
 	} else {
 		info.freezeAfterDaysInt = freezeAfterDaysInt
 	}
+
+	if len(info.DownloadConfig) > 0 {
+		info.Wait = true
+		downloadCfg := downloadOperations.DefaultDownloadCfg()
+		if err := utils.UnMarshalFromFile(info.DownloadConfig, &downloadCfg); err != nil {
+			return data.NewEmptyError().AppendDescF("read download config:%s error:%v", info.DownloadConfig, err)
+		}
+		// 只下载本次解冻的文件
+		downloadCfg.Bucket = info.Bucket
+		if err := downloadCfg.Check(); err != nil {
+			return err
+		}
+		info.downloadCfg = &downloadCfg
+	}
+	if !info.Wait {
+		return nil
+	}
+
+	if info.BatchInfo.DryRun {
+		return alert.Error("--wait can't be used with --dry-run", "")
+	}
+	if info.WaitInterval <= 0 {
+		info.WaitInterval = defaultRestoreWaitInterval
+	}
+	if info.WaitMaxInterval <= 0 {
+		info.WaitMaxInterval = defaultRestoreWaitMaxInterval
+	}
+	if info.WaitMaxInterval < info.WaitInterval {
+		info.WaitMaxInterval = info.WaitInterval
+	}
+	if info.WaitTimeout < 0 {
+		return alert.Error("wait timeout can't be negative", "")
+	}
 	return nil
 }
 
@@ -130,6 +185,24 @@ func BatchRestoreArchive(cfg *iqshell.Config, info BatchRestoreArchiveInfo) {
 		return
 	}
 
+	// 解冻请求成功或已在解冻、已解冻的文件，--wait 时等待这些文件解冻完成；
+	// 解冻请求失败的文件可能已在解冻中或已解冻，--wait 时通过 stat 的解冻状态确认
+	restoredKeysLocker := sync.Mutex{}
+	var restoredKeys []string
+	failedResults := make(map[string]*batch.OperationResult)
+	addRestoredKey := func(key string) {
+		if info.Wait {
+			restoredKeysLocker.Lock()
+			restoredKeys = append(restoredKeys, key)
+			restoredKeysLocker.Unlock()
+		}
+	}
+	addFailedResult := func(key string, result *batch.OperationResult) {
+		restoredKeysLocker.Lock()
+		failedResults[key] = result
+		restoredKeysLocker.Unlock()
+	}
+
 	batch.NewHandler(info.BatchInfo).
 		EmptyOperation(func() flow.Work {
 			return &object.RestoreArchiveApiInfo{}
@@ -157,6 +230,9 @@ func BatchRestoreArchive(cfg *iqshell.Config, info BatchRestoreArchiveInfo) {
 			if result.IsSuccess() {
 				log.InfoF("Restore archive Success, [%s:%s], FreezeAfterDays:%d",
 					apiInfo.Bucket, apiInfo.Key, apiInfo.FreezeAfterDays)
+				addRestoredKey(apiInfo.Key)
+			} else if info.Wait {
+				addFailedResult(apiInfo.Key, result)
 			} else {
 				data.SetCmdStatusError()
 				log.ErrorF("Restore archive Failed, [%s:%s], FreezeAfterDays:%d, Code: %d, Error: %s",
@@ -164,8 +240,67 @@ func BatchRestoreArchive(cfg *iqshell.Config, info BatchRestoreArchiveInfo) {
 					result.Code, result.Error)
 			}
 		}).
+		OnWorkSkip(func(operationInfo string, operation batch.Operation, result *batch.OperationResult, err *data.CodeError) {
+			// 之前的执行中已发送过解冻请求的文件也需要等待
+			apiInfo, ok := operation.(*object.RestoreArchiveApiInfo)
+			if !ok || err == nil || err.Code != data.ErrorCodeAlreadyDone || result == nil {
+				return
+			}
+			if result.IsSuccess() {
+				addRestoredKey(apiInfo.Key)
+			} else if info.Wait {
+				addFailedResult(apiInfo.Key, result)
+			}
+		}).
 		OnError(func(err *data.CodeError) {
 			data.SetCmdStatusError()
 			log.ErrorF("Batch restore archive error:%v:", err)
 		}).Start()
+
+	if info.Wait && !workspace.IsCmdInterrupt() {
+		restoredKeys = append(restoredKeys, checkRestoreArchiveFailedKeys(info.Bucket, failedResults)...)
+		waitRestoreArchive(&info, restoredKeys)
+	}
+}
+
+// checkRestoreArchiveFailedKeys 解冻请求失败的文件如果已在解冻中或已解冻，也需要等待，返回需要等待的文件
+func checkRestoreArchiveFailedKeys(bucket string, failedResults map[string]*batch.OperationResult) []string {
+	if len(failedResults) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(failedResults))
+	for key := range failedResults {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	statResults, err := statRestoreFiles(bucket, keys)
+	if err != nil {
+		log.WarningF("Restore archive: stat failed files error:%v", err)
+	}
+
+	waitKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		result := failedResults[key]
+		if isRestoreArchiveWaitable(statResults[key]) {
+			log.InfoF("Restore archive already in progress or done, [%s:%s], Code: %d, Error: %s",
+				bucket, key, result.Code, result.Error)
+			waitKeys = append(waitKeys, key)
+		} else {
+			data.SetCmdStatusError()
+			log.ErrorF("Restore archive Failed, [%s:%s], Code: %d, Error: %s",
+				bucket, key, result.Code, result.Error)
+		}
+	}
+	return waitKeys
+}
+
+// isRestoreArchiveWaitable 根据 stat 的解冻状态判断文件是否已在解冻中或已解冻
+func isRestoreArchiveWaitable(statResult *batch.OperationResult) bool {
+	if statResult == nil || !statResult.IsSuccess() {
+		return false
+	}
+	// 1：解冻中，2：已解冻
+	return statResult.RestoreStatus == 1 || statResult.RestoreStatus == 2
 }
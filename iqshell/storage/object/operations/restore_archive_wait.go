@@ -0,0 +1,276 @@
+package operations
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/export"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/download"
+	downloadOperations "github.com/qiniu/qshell/v2/iqshell/storage/object/download/operations"
+)
+
+const (
+	defaultRestoreWaitInterval    = 30
+	defaultRestoreWaitMaxInterval = 600
+	defaultRestoreDownloadWorker  = 5
+
+	restoreStatOperationCountPerRequest = 250 // 每次 batch stat 的文件数
+)
+
+type restoreWaitState int
+
+const (
+	restoreWaitStateFrozen  restoreWaitState = iota // 仍为冻结状态，解冻请求可能还未生效
+	restoreWaitStateThawing                         // 解冻中
+	restoreWaitStateReady                           // 解冻完成，或者文件不是归档/深度归档存储，可以直接下载
+	restoreWaitStateFailed                          // 文件不存在等无法再等待的错误
+)
+
+// getRestoreWaitState 根据 stat 的结果判断文件的解冻状态；服务端错误等可重试的错误按冻结处理，下一轮重新查询
+func getRestoreWaitState(result *batch.OperationResult) restoreWaitState {
+	if result == nil {
+		return restoreWaitStateFrozen
+	}
+	if !result.IsSuccess() {
+		// 612：文件不存在，631：空间不存在
+		if (result.Code >= 400 && result.Code < 500) || result.Code == 612 || result.Code == 631 {
+			return restoreWaitStateFailed
+		}
+		return restoreWaitStateFrozen
+	}
+
+	switch result.RestoreStatus {
+	case 2:
+		return restoreWaitStateReady
+	case 1:
+		return restoreWaitStateThawing
+	}
+	// 归档存储：2，深度归档存储：3，其他存储类型无需解冻
+	if result.Type != 2 && result.Type != 3 {
+		return restoreWaitStateReady
+	}
+	return restoreWaitStateFrozen
+}
+
+// nextRestoreWaitInterval 查询间隔按 2 倍退避，最大为 maxInterval
+func nextRestoreWaitInterval(interval, maxInterval time.Duration) time.Duration {
+	interval *= 2
+	if interval > maxInterval {
+		interval = maxInterval
+	}
+	return interval
+}
+
+// waitRestoreArchive 周期性 stat 文件直到所有文件解冻完成、超时或被中断；
+// 解冻完成的文件会导出到 ReadyListFile，配置了 DownloadConfig 时会立即开始下载
+func waitRestoreArchive(info *BatchRestoreArchiveInfo, keys []string) {
+	if len(keys) == 0 {
+		log.Alert("Restore wait: no file to wait")
+		return
+	}
+
+	readyExporter, err := export.New(info.ReadyListFile)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("Restore wait: create ready list error:%v", err)
+		return
+	}
+	defer readyExporter.Close()
+
+	var downloader *restoreDownloader
+	if info.downloadCfg != nil {
+		if downloader, err = newRestoreDownloader(info.downloadCfg, info.DownloadWorker); err != nil {
+			data.SetCmdStatusError()
+			log.ErrorF("Restore wait: %v", err)
+			return
+		}
+		defer downloader.wait()
+	}
+
+	total := len(keys)
+	pending := keys
+	readyCount, failedCount := 0, 0
+	interval := time.Duration(info.WaitInterval) * time.Second
+	maxInterval := time.Duration(info.WaitMaxInterval) * time.Second
+	timeout := time.Duration(info.WaitTimeout) * time.Second
+	startTime := time.Now()
+	for {
+		results, sErr := statRestoreFiles(info.Bucket, pending)
+		if sErr != nil {
+			log.WarningF("Restore wait: stat files error:%v, will retry later", sErr)
+		}
+
+		thawingCount, frozenCount := 0, 0
+		stillPending := make([]string, 0, len(pending))
+		for _, key := range pending {
+			result := results[key]
+			switch getRestoreWaitState(result) {
+			case restoreWaitStateReady:
+				readyCount++
+				readyExporter.ExportF("%s\t%d\t%s\t%d\t%s\t%d", key, result.FSize, result.Hash, result.PutTime, result.MimeType, result.Type)
+				log.InfoF("Restore archive ready, [%s:%s]", info.Bucket, key)
+				if downloader != nil {
+					downloader.add(info.Bucket, key, result)
+				}
+			case restoreWaitStateFailed:
+				failedCount++
+				data.SetCmdStatusError()
+				log.ErrorF("Restore wait failed, [%s:%s], Code: %d, Error: %s", info.Bucket, key, result.Code, result.Error)
+			case restoreWaitStateThawing:
+				thawingCount++
+				stillPending = append(stillPending, key)
+			default:
+				frozenCount++
+				stillPending = append(stillPending, key)
+			}
+		}
+		pending = stillPending
+
+		elapsed := time.Since(startTime).Truncate(time.Second)
+		log.AlertF("Restore wait: %d/%d ready, %d thawing, %d frozen, %d failed, elapsed: %s",
+			readyCount, total, thawingCount, frozenCount, failedCount, elapsed)
+		if len(pending) == 0 {
+			break
+		}
+
+		if timeout > 0 && time.Since(startTime)+interval > timeout {
+			data.SetCmdStatusError()
+			log.ErrorF("Restore wait timeout after %s, %d files are not ready", elapsed, len(pending))
+			break
+		}
+		if !sleepUntilInterrupt(interval) {
+			data.SetCmdStatusError()
+			log.ErrorF("Restore wait interrupted, %d files are not ready", len(pending))
+			break
+		}
+		interval = nextRestoreWaitInterval(interval, maxInterval)
+	}
+
+	if len(info.ReadyListFile) > 0 {
+		log.AlertF("Ready files: %s", info.ReadyListFile)
+	}
+}
+
+// statRestoreFiles 使用 batch stat 查询文件的状态，返回 key 到结果的映射
+func statRestoreFiles(bucket string, keys []string) (map[string]*batch.OperationResult, *data.CodeError) {
+	works := make([]flow.Work, 0, len(keys))
+	for _, key := range keys {
+		works = append(works, object.StatusApiInfo{
+			Bucket: bucket,
+			Key:    key,
+		})
+	}
+
+	locker := sync.Mutex{}
+	results := make(map[string]*batch.OperationResult, len(keys))
+	var err *data.CodeError
+	batch.NewHandler(batch.Info{
+		Info: flow.Info{
+			Force:       true,
+			WorkerCount: 1,
+		},
+		WorkList:                 works,
+		OperationCountPerRequest: restoreStatOperationCountPerRequest,
+	}).OnResult(func(operationInfo string, operation batch.Operation, result *batch.OperationResult) {
+		if apiInfo, ok := operation.(object.StatusApiInfo); ok {
+			locker.Lock()
+			results[apiInfo.Key] = result
+			locker.Unlock()
+		}
+	}).OnError(func(e *data.CodeError) {
+		err = e
+	}).Start()
+	return results, err
+}
+
+// sleepUntilInterrupt 等待 duration，被中断时返回 false
+func sleepUntilInterrupt(duration time.Duration) bool {
+	deadline := time.Now().Add(duration)
+	for time.Now().Before(deadline) {
+		if workspace.IsCmdInterrupt() {
+			return false
+		}
+		step := time.Until(deadline)
+		if step > time.Second {
+			step = time.Second
+		}
+		time.Sleep(step)
+	}
+	return !workspace.IsCmdInterrupt()
+}
+
+// restoreDownloader 使用 qdownload2 的配置下载解冻完成的文件，保存路径及下载后的校验与 qdownload2 一致
+type restoreDownloader struct {
+	downloader   *downloadOperations.Downloader
+	files        chan *download.DownloadActionInfo
+	waitGroup    sync.WaitGroup
+	successCount int64
+	failureCount int64
+}
+
+func newRestoreDownloader(cfg *downloadOperations.DownloadCfg, workerCount int) (*restoreDownloader, *data.CodeError) {
+	downloader, err := downloadOperations.NewDownloader(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if workerCount <= 0 {
+		workerCount = defaultRestoreDownloadWorker
+	}
+
+	d := &restoreDownloader{
+		downloader: downloader,
+		files:      make(chan *download.DownloadActionInfo, workerCount),
+	}
+	for i := 0; i < workerCount; i++ {
+		d.waitGroup.Add(1)
+		go func() {
+			defer d.waitGroup.Done()
+			for apiInfo := range d.files {
+				d.download(apiInfo)
+			}
+		}()
+	}
+	return d, nil
+}
+
+func (d *restoreDownloader) add(bucket, key string, stat *batch.OperationResult) {
+	d.files <- &download.DownloadActionInfo{
+		Bucket:            bucket,
+		Key:               key,
+		ServerFilePutTime: stat.PutTime,
+		ServerFileSize:    stat.FSize,
+		ServerFileHash:    stat.Hash,
+		ServerFileMd5:     stat.MD5,
+		DownloadFileSize:  stat.FSize,
+	}
+}
+
+func (d *restoreDownloader) download(apiInfo *download.DownloadActionInfo) {
+	if workspace.IsCmdInterrupt() {
+		return
+	}
+	if _, err := d.downloader.Download(apiInfo); err != nil {
+		atomic.AddInt64(&d.failureCount, 1)
+		data.SetCmdStatusError()
+		log.ErrorF("Download Failed, [%s:%s] => %s, Error: %v", apiInfo.Bucket, apiInfo.Key, apiInfo.ToFile, err)
+		return
+	}
+	atomic.AddInt64(&d.successCount, 1)
+}
+
+// wait 等待所有文件下载结束
+func (d *restoreDownloader) wait() {
+	close(d.files)
+	d.waitGroup.Wait()
+	d.downloader.Close()
+	log.AlertF("Restore download: %d success, %d failure, %d verified, %d mismatch",
+		atomic.LoadInt64(&d.successCount), atomic.LoadInt64(&d.failureCount),
+		d.downloader.Metric.VerifiedCount, d.downloader.Metric.MismatchCount)
+}
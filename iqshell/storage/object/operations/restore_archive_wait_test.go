@@ -0,0 +1,166 @@
+package operations
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/account"
+	"github.com/qiniu/qshell/v2/iqshell/common/config"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
+)
+
+func TestGetRestoreWaitState(t *testing.T) {
+	cases := []struct {
+		name   string
+		result *batch.OperationResult
+		want   restoreWaitState
+	}{
+		{"no result", nil, restoreWaitStateFrozen},
+		{"frozen", &batch.OperationResult{Code: 200, Type: 2}, restoreWaitStateFrozen},
+		{"thawing", &batch.OperationResult{Code: 200, Type: 3, RestoreStatus: 1}, restoreWaitStateThawing},
+		{"thawed", &batch.OperationResult{Code: 200, Type: 2, RestoreStatus: 2}, restoreWaitStateReady},
+		{"not archive", &batch.OperationResult{Code: 200, Type: 1}, restoreWaitStateReady},
+		{"not exist", &batch.OperationResult{Code: 612, Error: "no such file or directory"}, restoreWaitStateFailed},
+		{"no such bucket", &batch.OperationResult{Code: 631, Error: "no such bucket"}, restoreWaitStateFailed},
+		{"server error", &batch.OperationResult{Code: 599, Error: "server error"}, restoreWaitStateFrozen},
+	}
+	for _, c := range cases {
+		if got := getRestoreWaitState(c.result); got != c.want {
+			t.Errorf("%s: want state %d, got %d", c.name, c.want, got)
+		}
+	}
+}
+
+func TestIsRestoreArchiveWaitable(t *testing.T) {
+	cases := []struct {
+		name   string
+		result *batch.OperationResult
+		want   bool
+	}{
+		{"no result", nil, false},
+		{"frozen", &batch.OperationResult{Code: 200, Type: 2}, false},
+		{"thawing", &batch.OperationResult{Code: 200, Type: 2, RestoreStatus: 1}, true},
+		{"thawed", &batch.OperationResult{Code: 200, Type: 3, RestoreStatus: 2}, true},
+		{"not archive", &batch.OperationResult{Code: 200, Type: 1}, false},
+		{"not exist", &batch.OperationResult{Code: 612, Error: "no such file or directory"}, false},
+	}
+	for _, c := range cases {
+		if got := isRestoreArchiveWaitable(c.result); got != c.want {
+			t.Errorf("%s: want %t, got %t", c.name, c.want, got)
+		}
+	}
+}
+
+// newRestoreStatTestServer 模拟 rs batch stat 接口，statuses 为 key 对应的解冻状态，不存在的 key 返回 612
+func newRestoreStatTestServer(t *testing.T, statuses map[string]int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parse batch request error:%v", err)
+		}
+		ret := make([]map[string]interface{}, 0)
+		for _, op := range r.Form["op"] {
+			items := strings.Split(op, "/")
+			entry, _ := base64.URLEncoding.DecodeString(items[len(items)-1])
+			key := strings.SplitN(string(entry), ":", 2)[1]
+			if status, ok := statuses[key]; ok {
+				ret = append(ret, map[string]interface{}{
+					"code": 200,
+					"data": map[string]interface{}{"fsize": 1, "type": 2, "restoreStatus": status},
+				})
+			} else {
+				ret = append(ret, map[string]interface{}{
+					"code": 612,
+					"data": map[string]interface{}{"error": "no such file or directory"},
+				})
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Reqid", "test")
+		json.NewEncoder(w).Encode(ret)
+	}))
+}
+
+func TestStatRestoreFiles(t *testing.T) {
+	statuses := map[string]int{}
+	keys := make([]string, 0)
+	for i := 0; i < 600; i++ {
+		key := fmt.Sprintf("file-%d", i)
+		statuses[key] = i % 3
+		keys = append(keys, key)
+	}
+	keys = append(keys, "not-exist")
+	server := newRestoreStatTestServer(t, statuses)
+	defer server.Close()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(account.EnvAccessKey, "ak")
+	t.Setenv(account.EnvSecretKey, "sk")
+	host := strings.TrimPrefix(server.URL, "http://")
+	hosts := []string{host}
+	if err := workspace.Load(workspace.LoadInfo{
+		WorkspacePath: filepath.Join(home, ".qshell"),
+		CmdConfig: &config.Config{
+			CmdId:    "batchrestorear",
+			UseHttps: data.NewBool(false),
+			Hosts: &config.Hosts{
+				UC:  hosts,
+				Api: hosts,
+				Rs:  hosts,
+				Rsf: hosts,
+				Io:  hosts,
+				Up:  hosts,
+			},
+		},
+	}); err != nil {
+		t.Fatal("load workspace error:", err)
+	}
+
+	done := make(chan struct{})
+	var results map[string]*batch.OperationResult
+	var err *data.CodeError
+	go func() {
+		results, err = statRestoreFiles("bucket", keys)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("stat restore files timeout")
+	}
+
+	if err != nil {
+		t.Fatal("stat restore files error:", err)
+	}
+	if len(results) != len(keys) {
+		t.Fatalf("want %d results, got %d", len(keys), len(results))
+	}
+	for key, status := range statuses {
+		if r := results[key]; r == nil || !r.IsSuccess() || r.RestoreStatus != status {
+			t.Fatalf("%s: want restore status %d, got %+v", key, status, r)
+		}
+	}
+	if r := results["not-exist"]; r == nil || r.Code != 612 || getRestoreWaitState(r) != restoreWaitStateFailed {
+		t.Fatalf("not-exist: want code 612, got %+v", r)
+	}
+}
+
+func TestNextRestoreWaitInterval(t *testing.T) {
+	interval := 30 * time.Second
+	want := []time.Duration{60 * time.Second, 120 * time.Second, 240 * time.Second, 300 * time.Second, 300 * time.Second}
+	for i, w := range want {
+		interval = nextRestoreWaitInterval(interval, 300*time.Second)
+		if interval != w {
+			t.Fatalf("round %d: want %s, got %s", i, w, interval)
+		}
+	}
+}
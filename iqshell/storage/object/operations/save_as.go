@@ -5,7 +5,9 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/common/alert"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
 )
 
 type SaveAsInfo object.SaveAsApiInfo
@@ -35,6 +37,12 @@ func SaveAs(cfg *iqshell.Config, info SaveAsInfo) {
 		data.SetCmdStatusError()
 		log.ErrorF("Save as Failed, Error: %v", err)
 	} else {
+		output.Result(&batch.OperationRecord{
+			Operation: object.SaveAsApiInfo(info),
+			Result: map[string]string{
+				"url": url,
+			},
+		})
 		log.Alert(url)
 	}
 }
@@ -23,6 +23,7 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/common/alert"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
 	"github.com/qiniu/qshell/v2/iqshell/common/utils"
 	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
 )
@@ -97,6 +98,7 @@ func createShare(cfg *iqshell.Config, info *CreateShareInfo) error {
 			return err
 		}
 	} else {
+		output.Result(&body)
 		log.AlertF("Link:\n%s", body.Link)
 		log.AlertF("Extract Code:\n%s", body.ExtractCode)
 		log.AlertF("Expire:\n%s", body.WillExpireAt.Local().Format(time.DateTime+" -0700"))
@@ -259,11 +261,30 @@ func listShare(cfg *iqshell.Config, info *ListShareInfo) error {
 	return nil
 }
 
+// listedShareObject 结构化输出时分享中列举的文件或目录
+type listedShareObject struct {
+	Key          string    `json:"key"`
+	IsDir        bool      `json:"is_dir"`
+	Size         int64     `json:"size,omitempty"`
+	StorageClass string    `json:"storage_class,omitempty"`
+	LastModified time.Time `json:"last_modified,omitempty"`
+}
+
 func printListedS3Object(object *s3.Object) {
+	output.Result(&listedShareObject{
+		Key:          aws.StringValue(object.Key),
+		Size:         aws.Int64Value(object.Size),
+		StorageClass: aws.StringValue(object.StorageClass),
+		LastModified: aws.TimeValue(object.LastModified),
+	})
 	log.AlertF("%s\t%d\t%s\t%s", aws.StringValue(object.Key), aws.Int64Value(object.Size), aws.StringValue(object.StorageClass), aws.TimeValue(object.LastModified))
 }
 
 func printListedS3Directory(object *s3.Object) {
+	output.Result(&listedShareObject{
+		Key:   aws.StringValue(object.Key),
+		IsDir: true,
+	})
 	log.AlertF("%s", aws.StringValue(object.Key))
 }
 
@@ -275,6 +296,12 @@ type listedStats struct {
 }
 
 func printListedStats(info *listedStats) {
+	output.Result(map[string]interface{}{
+		"prefix":            info.prefix,
+		"total_size":        info.totalSize,
+		"directory_numbers": info.directoryNumbers,
+		"object_numbers":    info.objectNumbers,
+	})
 	if info.prefix == "" {
 		log.AlertF("Total size: %s", utils.FormatFileSize(info.totalSize))
 	} else {
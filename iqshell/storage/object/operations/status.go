@@ -11,6 +11,7 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/common/export"
 	"github.com/qiniu/qshell/v2/iqshell/common/flow"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
 	"github.com/qiniu/qshell/v2/iqshell/common/utils"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
@@ -43,6 +44,11 @@ func Status(cfg *iqshell.Config, info StatusInfo) {
 		return
 	}
 
+	output.Result(&batch.OperationRecord{
+		Operation: object.StatusApiInfo(info),
+		Result:    result,
+	})
+
 	if result.IsSuccess() {
 		log.Alert(getResultInfo(info.Bucket, info.Key, result))
 	}
@@ -8,6 +8,7 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/common/export"
 	"github.com/qiniu/qshell/v2/iqshell/common/flow"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
 	"github.com/qiniu/qshell/v2/iqshell/common/utils"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
@@ -55,11 +56,12 @@ func ForbiddenObject(cfg *iqshell.Config, info ForbiddenInfo) {
 		return
 	}
 
-	result, err := object.ChangeStatus(&object.ChangeStatusApiInfo{
+	apiInfo := &object.ChangeStatusApiInfo{
 		Bucket: info.Bucket,
 		Key:    info.Key,
 		Status: info.getStatus(),
-	})
+	}
+	result, err := object.ChangeStatus(apiInfo)
 
 	statusDesc := info.getStatusDesc()
 	if err != nil || result == nil {
@@ -69,6 +71,11 @@ func ForbiddenObject(cfg *iqshell.Config, info ForbiddenInfo) {
 		return
 	}
 
+	output.Result(&batch.OperationRecord{
+		Operation: apiInfo,
+		Result:    result,
+	})
+
 	if result.IsSuccess() {
 		log.InfoF("Change status Success, [%s:%s] => %s",
 			info.Bucket, info.Key, statusDesc)
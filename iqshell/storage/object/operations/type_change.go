@@ -11,6 +11,7 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/common/export"
 	"github.com/qiniu/qshell/v2/iqshell/common/flow"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
 	"github.com/qiniu/qshell/v2/iqshell/common/utils"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
@@ -62,11 +63,12 @@ func ChangeType(cfg *iqshell.Config, info ChangeTypeInfo) {
 		return
 	}
 
-	result, err := object.ChangeType(&object.ChangeTypeApiInfo{
+	apiInfo := &object.ChangeTypeApiInfo{
 		Bucket: info.Bucket,
 		Key:    info.Key,
 		Type:   t,
-	})
+	}
+	result, err := object.ChangeType(apiInfo)
 
 	if err != nil || result == nil {
 		data.SetCmdStatusError()
@@ -75,6 +77,11 @@ func ChangeType(cfg *iqshell.Config, info ChangeTypeInfo) {
 		return
 	}
 
+	output.Result(&batch.OperationRecord{
+		Operation: apiInfo,
+		Result:    result,
+	})
+
 	if result.IsSuccess() {
 		log.InfoF("Change Type Success, [%s:%s] => '%d'(%s)", info.Bucket, info.Key, t, getFileTypeDescription(t))
 	} else {
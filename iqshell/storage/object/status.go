@@ -83,9 +83,9 @@ func Status(info StatusApiInfo) (res StatusResult, err *data.CodeError) {
 
 // ChangeStatusApiInfo 修改 status
 type ChangeStatusApiInfo struct {
-	Bucket string
-	Key    string
-	Status int
+	Bucket string `json:"bucket"`
+	Key    string `json:"key"`
+	Status int    `json:"status"`
 }
 
 func (c *ChangeStatusApiInfo) GetBucket() string {
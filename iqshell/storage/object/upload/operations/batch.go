@@ -10,6 +10,7 @@ import (
 	"github.com/qiniu/go-sdk/v7/storage"
 
 	"github.com/qiniu/qshell/v2/iqshell"
+	cdnOperations "github.com/qiniu/qshell/v2/iqshell/cdn/operations"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/export"
 	"github.com/qiniu/qshell/v2/iqshell/common/flow"
@@ -35,6 +36,7 @@ type BatchUploadInfo struct {
 	UploadConfigFile string
 	CallbackHost     string
 	CallbackUrl      string
+	CdnRefresh       cdnOperations.AutoRefreshInfo // 配置时覆盖配置文件中的 cdn_refresh_domain、cdn_refresh_qps
 }
 
 func (info *BatchUploadInfo) Check() *data.CodeError {
@@ -90,6 +92,12 @@ func BatchUpload(cfg *iqshell.Config, info BatchUploadInfo) {
 		log.ErrorF("UnMarshal: read log setting error:%v config file:%s", err, info.UploadConfigFile)
 		return
 	}
+	if info.CdnRefresh.Enable() {
+		upload2Info.UploadConfig.CdnRefreshDomain = info.CdnRefresh.Domain
+	}
+	if info.CdnRefresh.QpsLimit > 0 {
+		upload2Info.UploadConfig.CdnRefreshQps = info.CdnRefresh.QpsLimit
+	}
 
 	BatchUpload2(cfg, upload2Info)
 }
@@ -167,6 +175,12 @@ func batchUpload(info BatchUpload2Info) {
 	dbPath := filepath.Join(workspace.GetJobDir(), ".ldb")
 	log.InfoF("upload status db file path:%s", dbPath)
 
+	// 重试失败的文件时，待上传的文件来自失败列表或 job 记录，无需扫描本地文件
+	if len(info.RetryFailedFrom) > 0 {
+		batchUploadFlow(info, info.UploadConfig, dbPath)
+		return
+	}
+
 	// 扫描本地文件
 	needScanLocal := false
 	if data.Empty(info.FileList) {
@@ -224,6 +238,11 @@ func batchUploadFlow(info BatchUpload2Info, uploadConfig UploadConfig, dbPath st
 		return
 	}
 
+	// 未检查文件是否存在时无法判断是否覆盖了已有的文件，开启覆盖时刷新所有上传成功的文件
+	refresher := cdnOperations.NewAutoRefresher(uploadConfig.CdnRefreshInfo())
+	refreshAll := uploadConfig.Overwrite && !uploadConfig.CheckExists
+	defer refresher.Close()
+
 	metric := &Metric{}
 	metric.Start()
 
@@ -443,7 +462,12 @@ func batchUploadFlow(info BatchUpload2Info, uploadConfig UploadConfig, dbPath st
 				metric.AddSuccessCount(1)
 				exporter.Success().Export(workInfo.Data)
 			}
+			if res.IsOverwrite || (refreshAll && !res.IsNotOverwrite && !res.IsSkip) {
+				uploadInfo, _ := workInfo.Work.(*UploadInfo)
+				refresher.AddKeys(uploadInfo.SaveKey)
+			}
 		}).
+		OnConcurrencyChange(metric.OnConcurrencyChange).
 		OnWorkFail(func(workInfo *flow.WorkInfo, err *data.CodeError) {
 			metric.AddFailureCount(1)
 			exporter.Fail().ExportF("%s%s%%s", workInfo.Data, flow.ErrorSeparate, err)
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	cdnOperations "github.com/qiniu/qshell/v2/iqshell/cdn/operations"
 	"github.com/qiniu/qshell/v2/iqshell/common/alert"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/utils"
@@ -41,8 +42,10 @@ type UploadConfig struct {
 	DisableForm            bool   `json:"disable_form,omitempty"`
 	WorkerCount            int    `json:"work_count,omitempty"` // 分片上传并发数
 	RecordRoot             string `json:"record_root,omitempty"`
-	SequentialReadFile     bool   `json:"sequential_read_file"`   // 文件顺序读
-	Accelerate             bool   `json:"uploading_acceleration"` // 开启上传加速
+	SequentialReadFile     bool   `json:"sequential_read_file"`         // 文件顺序读
+	Accelerate             bool   `json:"uploading_acceleration"`       // 开启上传加速
+	CdnRefreshDomain       string `json:"cdn_refresh_domain,omitempty"` // 上传覆盖空间中已有的文件后，使用此 CDN 域名刷新文件的缓存
+	CdnRefreshQps          int    `json:"cdn_refresh_qps,omitempty"`    // CDN 刷新请求的 qps 限制
 
 	// 唯一属主标识。特殊场景下非常有用，例如根据 App-Client 标识给图片或视频打水印。
 	EndUser string `json:"end_user,omitempty"`
@@ -182,6 +185,14 @@ func (up *UploadConfig) JobId() string {
 	return utils.Md5Hex(fmt.Sprintf("%s:%s:%s", up.SrcDir, up.Bucket, up.FileList))
 }
 
+// CdnRefreshInfo 上传覆盖空间中已有的文件后自动刷新 CDN 缓存的配置
+func (up *UploadConfig) CdnRefreshInfo() cdnOperations.AutoRefreshInfo {
+	return cdnOperations.AutoRefreshInfo{
+		Domain:   up.CdnRefreshDomain,
+		QpsLimit: up.CdnRefreshQps,
+	}
+}
+
 func (up *UploadConfig) Check() *data.CodeError {
 	// 验证大小
 	if up.ResumableAPIV2PartSize == 0 {
@@ -196,6 +207,11 @@ func (up *UploadConfig) Check() *data.CodeError {
 		return alert.CannotEmptyError("Bucket", "")
 	}
 
+	refreshInfo := up.CdnRefreshInfo()
+	if err := refreshInfo.Check(); err != nil {
+		return err
+	}
+
 	if len(up.SrcDir) == 0 {
 		return alert.CannotEmptyError("SrcDir", "")
 	}
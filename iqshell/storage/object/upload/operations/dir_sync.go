@@ -0,0 +1,660 @@
+package operations
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qiniu/go-sdk/v7/storage"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	cdnOperations "github.com/qiniu/qshell/v2/iqshell/cdn/operations"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/export"
+	"github.com/qiniu/qshell/v2/iqshell/common/filter"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/common/host"
+	"github.com/qiniu/qshell/v2/iqshell/common/locker"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/download"
+	downloadOperations "github.com/qiniu/qshell/v2/iqshell/storage/object/download/operations"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/upload"
+)
+
+const (
+	DirSyncDirectionUpload   = "up"   // 本地 => 空间
+	DirSyncDirectionDownload = "down" // 空间 => 本地
+)
+
+const (
+	dirSyncActionUpload       = "upload"
+	dirSyncActionDownload     = "download"
+	dirSyncActionDeleteRemote = "delete_remote"
+	dirSyncActionDeleteLocal  = "delete_local"
+)
+
+type DirSyncInfo struct {
+	flow.Info
+	export.FileExporterConfig
+
+	LocalDir  string // 本地文件夹
+	Bucket    string // 空间名
+	Prefix    string // 空间中文件的前缀，本地文件的相对路径拼接在 Prefix 后即为文件的 key
+	Direction string // 同步方向：up 本地 => 空间，down 空间 => 本地
+	Delete    bool   // 是否删除目标端多余的文件
+	CheckHash bool   // 文件大小一致时是否使用 qetag 判断文件是否一致，否则使用本地文件修改时间和服务端 put time 判断
+	Filter    string // 过滤表达式，仅同步满足条件的文件；本地文件仅支持 key、size、putTime（修改时间）字段
+
+	// 上传相关
+	FileType    int    // 上传文件的存储类型
+	UpHost      string // 上传使用的域名
+	UseResumeV2 bool   // 分片上传是否使用分片 v2
+	ChunkSize   int64  // 分片 v2 的分片大小
+
+	// 下载相关
+	Domain        string // 下载使用的域名
+	UseGetFileApi bool   // 是否使用 get file api 下载
+	IsPublic      bool   // 是否为公开空间
+
+	// 上传同步时，上传及删除空间中的文件后刷新 CDN 缓存
+	CdnRefresh cdnOperations.AutoRefreshInfo
+}
+
+func (info *DirSyncInfo) Check() *data.CodeError {
+	if info.WorkerCount < 1 || info.WorkerCount > 2000 {
+		log.WarningF("Tip: %d is out of range, you can set <ThreadCount> value between 1 and 200 to improve speed, and now ThreadCount change to: 5", info.WorkerCount)
+		info.WorkerCount = 5
+	}
+	if err := info.Info.Check(); err != nil {
+		return err
+	}
+	if len(info.LocalDir) == 0 {
+		return alert.CannotEmptyError("LocalDir", "")
+	}
+	if len(info.Bucket) == 0 {
+		return alert.CannotEmptyError("Bucket", "")
+	}
+	if info.Direction != DirSyncDirectionUpload && info.Direction != DirSyncDirectionDownload {
+		return alert.Error("sync direction should be up or down", "")
+	}
+	if _, err := bucket.NewListObjectFilter(info.Filter); err != nil {
+		return err
+	}
+	if info.Direction == DirSyncDirectionUpload && len(info.Filter) > 0 {
+		// 本地文件只有 key、size 及 putTime，使用其他字段过滤时本地新增的文件永远不会被上传
+		if _, err := filter.Parse(info.Filter, dirSyncUploadFilterSchema); err != nil {
+			return data.NewEmptyError().AppendDesc("upload sync filter only supports key, size and putTime").AppendError(err)
+		}
+	}
+	if err := info.CdnRefresh.Check(); err != nil {
+		return err
+	}
+	if info.Direction == DirSyncDirectionUpload {
+		if exist, _ := utils.ExistDir(info.LocalDir); !exist {
+			return data.NewEmptyError().AppendDescF("local dir:%s is not exist", info.LocalDir)
+		}
+	}
+	// 不删除文件时无需验证
+	if !info.Delete {
+		info.Force = true
+	}
+	return nil
+}
+
+func (info *DirSyncInfo) JobId() string {
+	return utils.Md5Hex(fmt.Sprintf("%s:%s:%s:%s:%t:%t:%s", info.LocalDir, info.Bucket, info.Prefix, info.Direction,
+		info.Delete, info.CheckHash, info.Filter))
+}
+
+// dirSyncUploadFilterSchema 上传同步时过滤表达式支持的字段，本地文件仅有这些信息
+var dirSyncUploadFilterSchema = filter.Schema{
+	"key":     filter.KindString,
+	"size":    filter.KindNumber,
+	"fsize":   filter.KindNumber,
+	"putTime": filter.KindTime,
+}
+
+// dirSyncLocalFile 本地文件信息，ModifyTime 单位为 100ns，和服务端 PutTime 单位一致
+type dirSyncLocalFile struct {
+	RelativePath string
+	FileSize     int64
+	ModifyTime   int64
+}
+
+// dirSyncWork 同步操作，一次同步中的上传、下载和删除均为 dirSyncWork
+type dirSyncWork struct {
+	Action     string `json:"action"`
+	Bucket     string `json:"bucket"`
+	Key        string `json:"key"`
+	LocalPath  string `json:"local_path"`
+	FileSize   int64  `json:"file_size"`
+	ModifyTime int64  `json:"modify_time"`
+	PutTime    int64  `json:"put_time"`
+	Hash       string `json:"hash"`
+}
+
+var _ flow.Work = (*dirSyncWork)(nil)
+
+func (w *dirSyncWork) WorkId() string {
+	return fmt.Sprintf("%s|%s|%s|%s", w.Action, w.Bucket, w.Key, w.LocalPath)
+}
+
+func (w *dirSyncWork) String() string {
+	switch w.Action {
+	case dirSyncActionUpload:
+		return fmt.Sprintf("upload %s => [%s:%s]", w.LocalPath, w.Bucket, w.Key)
+	case dirSyncActionDownload:
+		return fmt.Sprintf("download [%s:%s] => %s", w.Bucket, w.Key, w.LocalPath)
+	case dirSyncActionDeleteRemote:
+		return fmt.Sprintf("delete [%s:%s]", w.Bucket, w.Key)
+	case dirSyncActionDeleteLocal:
+		return fmt.Sprintf("delete %s", w.LocalPath)
+	default:
+		return fmt.Sprintf("%s [%s:%s] %s", w.Action, w.Bucket, w.Key, w.LocalPath)
+	}
+}
+
+type dirSyncResult struct {
+	Action string `json:"action"`
+	Key    string `json:"key"`
+}
+
+var _ flow.Result = (*dirSyncResult)(nil)
+
+func (r *dirSyncResult) IsValid() bool {
+	return len(r.Action) > 0 && len(r.Key) > 0
+}
+
+type dirSyncDiffInfo struct {
+	Direction string
+	LocalDir  string
+	Bucket    string
+	Prefix    string
+	Delete    bool
+	// 文件大小一致时用来获取本地文件的 qetag，为空时使用修改时间判断
+	LocalFileHash func(localPath string) (string, *data.CodeError)
+}
+
+// dirSyncDiff 对比本地文件和空间文件，生成需要执行的同步操作；
+// 文件大小不同则需要同步，大小相同时优先对比 qetag，不对比 qetag 时源端更新时间晚于目标端则需要同步。
+func dirSyncDiff(info dirSyncDiffInfo, localFiles map[string]*dirSyncLocalFile, remoteFiles map[string]bucket.ListObject) []*dirSyncWork {
+	keys := make([]string, 0, len(localFiles)+len(remoteFiles))
+	for key := range remoteFiles {
+		keys = append(keys, key)
+	}
+	for key := range localFiles {
+		if _, ok := remoteFiles[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	works := make([]*dirSyncWork, 0)
+	for _, key := range keys {
+		local := localFiles[key]
+		remote, hasRemote := remoteFiles[key]
+		work := &dirSyncWork{
+			Bucket: info.Bucket,
+			Key:    key,
+		}
+		if local != nil {
+			work.LocalPath = filepath.Join(info.LocalDir, local.RelativePath)
+			work.FileSize = local.FileSize
+			work.ModifyTime = local.ModifyTime
+		} else {
+			work.LocalPath = filepath.Join(info.LocalDir, filepath.FromSlash(strings.TrimPrefix(key, info.Prefix)))
+		}
+		if hasRemote {
+			work.PutTime = remote.PutTime
+			work.Hash = remote.Hash
+			if local == nil {
+				work.FileSize = remote.Fsize
+			}
+		}
+
+		if info.Direction == DirSyncDirectionUpload {
+			if local == nil {
+				if info.Delete {
+					work.Action = dirSyncActionDeleteRemote
+					works = append(works, work)
+				}
+				continue
+			}
+			if !hasRemote || dirSyncFileChanged(info, work.LocalPath, local, remote, local.ModifyTime > remote.PutTime) {
+				work.Action = dirSyncActionUpload
+				works = append(works, work)
+			}
+		} else {
+			if !hasRemote {
+				if info.Delete {
+					work.Action = dirSyncActionDeleteLocal
+					works = append(works, work)
+				}
+				continue
+			}
+			if local == nil || dirSyncFileChanged(info, work.LocalPath, local, remote, remote.PutTime > local.ModifyTime) {
+				work.Action = dirSyncActionDownload
+				work.FileSize = remote.Fsize
+				works = append(works, work)
+			}
+		}
+	}
+	return works
+}
+
+func dirSyncFileChanged(info dirSyncDiffInfo, localPath string, local *dirSyncLocalFile, remote bucket.ListObject, sourceIsNewer bool) bool {
+	if local.FileSize != remote.Fsize {
+		return true
+	}
+	if info.LocalFileHash == nil {
+		return sourceIsNewer
+	}
+	hash, err := info.LocalFileHash(localPath)
+	if err != nil {
+		log.WarningF("get local file:%s hash error:%v", localPath, err)
+		return true
+	}
+	return hash != remote.Hash
+}
+
+// DirSync 同步本地文件夹和空间中的某个前缀，可以选择同步的方向以及是否删除目标端多余的文件
+func DirSync(cfg *iqshell.Config, info DirSyncInfo) {
+	cfg.JobPathBuilder = func(cmdPath string) string {
+		return filepath.Join(cmdPath, info.JobId())
+	}
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	if e := locker.Lock(); e != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("Sync, %v", e)
+		return
+	}
+
+	unlockHandler := func() {
+		if e := locker.TryUnlock(); e != nil {
+			data.SetCmdStatusError()
+			log.ErrorF("Sync, %v", e)
+		}
+	}
+	workspace.AddCancelObserver(func(s os.Signal) {
+		unlockHandler()
+	})
+	defer unlockHandler()
+
+	localFiles, err := dirSyncScanLocal(info.LocalDir, filepath.Join(workspace.GetJobDir(), ".cache"), info.Prefix)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("Sync, scan local dir:%s error:%v", info.LocalDir, err)
+		return
+	}
+
+	remoteFiles, err := dirSyncListRemote(info.Bucket, info.Prefix)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("Sync, list bucket:%s prefix:%s error:%v", info.Bucket, info.Prefix, err)
+		return
+	}
+
+	if info.Direction == DirSyncDirectionDownload {
+		for _, key := range dirSyncRemoveUnsafeKeys(info.Prefix, remoteFiles) {
+			data.SetCmdStatusError()
+			log.ErrorF("Sync, skip [%s:%s] because the local path of the key is outside the local dir", info.Bucket, key)
+		}
+	}
+
+	// 两端均需要过滤，否则不满足条件的文件会被当做目标端多余的文件
+	listFilter, _ := bucket.NewListObjectFilter(info.Filter)
+	dirSyncFilter(listFilter, localFiles, remoteFiles)
+
+	diffInfo := dirSyncDiffInfo{
+		Direction: info.Direction,
+		LocalDir:  info.LocalDir,
+		Bucket:    info.Bucket,
+		Prefix:    info.Prefix,
+		Delete:    info.Delete,
+	}
+	if info.CheckHash {
+		diffInfo.LocalFileHash = utils.GetEtag
+	}
+	works := dirSyncDiff(diffInfo, localFiles, remoteFiles)
+	log.InfoF("Sync, local files:%d, remote files:%d, works:%d", len(localFiles), len(remoteFiles), len(works))
+	if len(works) == 0 {
+		log.Alert("Sync, nothing to do, local dir and bucket are already in sync")
+		return
+	}
+
+	dirSyncFlow(info, works)
+}
+
+// dirSyncFilter 过滤本地文件和空间文件，任意一端满足条件的 key 两端均保留；
+// 本地文件没有 mime 等信息，只要求一端满足可以避免空间中满足条件的文件因本地文件不满足而被当做多余的文件
+func dirSyncFilter(listFilter *bucket.ListObjectFilter, localFiles map[string]*dirSyncLocalFile, remoteFiles map[string]bucket.ListObject) {
+	if listFilter == nil {
+		return
+	}
+
+	matchedKeys := make(map[string]bool)
+	for key, local := range localFiles {
+		if listFilter.Match(bucket.ListObject{
+			Key:     key,
+			Fsize:   local.FileSize,
+			PutTime: local.ModifyTime,
+		}) {
+			matchedKeys[key] = true
+		}
+	}
+	for key, remote := range remoteFiles {
+		if listFilter.Match(remote) {
+			matchedKeys[key] = true
+		}
+	}
+
+	for key := range localFiles {
+		if !matchedKeys[key] {
+			delete(localFiles, key)
+		}
+	}
+	for key := range remoteFiles {
+		if !matchedKeys[key] {
+			delete(remoteFiles, key)
+		}
+	}
+}
+
+func dirSyncScanLocal(localDir string, cacheFile string, prefix string) (map[string]*dirSyncLocalFile, *data.CodeError) {
+	files := make(map[string]*dirSyncLocalFile)
+	if exist, _ := utils.ExistDir(localDir); !exist {
+		// 下载时本地文件夹可以不存在
+		return files, nil
+	}
+
+	if _, err := utils.DirCache(localDir, cacheFile); err != nil {
+		return nil, err
+	}
+
+	f, oErr := os.Open(cacheFile)
+	if oErr != nil {
+		return nil, data.NewEmptyError().AppendDesc("open dir cache file").AppendError(oErr)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		items := strings.Split(scanner.Text(), "\t")
+		if len(items) < 3 {
+			continue
+		}
+		fileSize, _ := strconv.ParseInt(items[1], 10, 64)
+		modifyTime, _ := strconv.ParseInt(items[2], 10, 64)
+		key := prefix + filepath.ToSlash(items[0])
+		files[key] = &dirSyncLocalFile{
+			RelativePath: items[0],
+			FileSize:     fileSize,
+			ModifyTime:   modifyTime,
+		}
+	}
+	if sErr := scanner.Err(); sErr != nil {
+		return nil, data.NewEmptyError().AppendDesc("read dir cache file").AppendError(sErr)
+	}
+	return files, nil
+}
+
+func dirSyncListRemote(bucketName string, prefix string) (map[string]bucket.ListObject, *data.CodeError) {
+	var listErr *data.CodeError
+	files := make(map[string]bucket.ListObject)
+	bucket.List(bucket.ListApiInfo{
+		Bucket:   bucketName,
+		Prefix:   prefix,
+		MaxRetry: 20,
+	}, func(marker string, object bucket.ListObject) (bool, *data.CodeError) {
+		// 文件夹不参与同步
+		if strings.HasSuffix(object.Key, "/") || object.Key == prefix {
+			return true, nil
+		}
+		files[object.Key] = object
+		return true, nil
+	}, func(marker string, err *data.CodeError) {
+		listErr = err
+	})
+	return files, listErr
+}
+
+// dirSyncRemoveUnsafeKeys 移除并返回不能安全映射到本地路径的 key：去除前缀后不是规范的相对路径，
+// 如包含 ..、. 或连续的 /，这些 key 下载时会写到本地文件夹之外或覆盖其他 key 对应的本地文件
+func dirSyncRemoveUnsafeKeys(prefix string, remoteFiles map[string]bucket.ListObject) []string {
+	unsafeKeys := make([]string, 0)
+	for key := range remoteFiles {
+		relativePath := strings.TrimPrefix(key, prefix)
+		if path.Clean(relativePath) != relativePath || !filepath.IsLocal(filepath.FromSlash(relativePath)) {
+			unsafeKeys = append(unsafeKeys, key)
+			delete(remoteFiles, key)
+		}
+	}
+	sort.Strings(unsafeKeys)
+	return unsafeKeys
+}
+
+func dirSyncFlow(info DirSyncInfo, works []*dirSyncWork) {
+	exporter, err := export.NewFileExport(info.FileExporterConfig)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.Error(err)
+		return
+	}
+
+	mac, err := workspace.GetMac()
+	if err != nil {
+		data.SetCmdStatusError()
+		log.Error("get mac error:" + err.Error())
+		return
+	}
+
+	var downloadHosts []*host.Host
+	for _, w := range works {
+		if w.Action == dirSyncActionDownload {
+			downloadHosts = downloadOperations.GetDownloadHosts(&downloadOperations.DownloadCfg{
+				Bucket:     info.Bucket,
+				Domain:     info.Domain,
+				GetFileApi: info.UseGetFileApi,
+			})
+			if len(downloadHosts) == 0 {
+				data.SetCmdStatusError()
+				log.ErrorF("get download domain error: not find in config and can't get bucket(%s) domain, you can set --domain or bind domain to bucket", info.Bucket)
+				return
+			}
+			break
+		}
+	}
+
+	workList := make([]flow.Work, 0, len(works))
+	for _, w := range works {
+		workList = append(workList, w)
+	}
+
+	dbPath := filepath.Join(workspace.GetJobDir(), ".ldb")
+	log.InfoF("sync status db file path:%s", dbPath)
+
+	refresher := cdnOperations.NewAutoRefresher(info.CdnRefresh)
+	defer refresher.Close()
+
+	metric := &DirSyncMetric{}
+	metric.Start()
+
+	flow.New(info.Info).
+		WorkProviderWithArray(workList).
+		WorkerProvider(flow.NewWorkerProvider(func() (flow.Worker, *data.CodeError) {
+			return flow.NewSimpleWorker(func(workInfo *flow.WorkInfo) (flow.Result, *data.CodeError) {
+				work, _ := workInfo.Work.(*dirSyncWork)
+				metric.AddCurrentCount(1)
+				metric.PrintProgress("Syncing: " + work.String())
+
+				switch work.Action {
+				case dirSyncActionUpload:
+					uploadInfo := &UploadInfo{
+						ApiInfo: upload.ApiInfo{
+							FilePath:            work.LocalPath,
+							ToBucket:            work.Bucket,
+							SaveKey:             work.Key,
+							FileType:            info.FileType,
+							Overwrite:           true,
+							UpHost:              info.UpHost,
+							TryTimes:            3,
+							TryInterval:         500 * time.Millisecond,
+							LocalFileSize:       work.FileSize,
+							LocalFileModifyTime: work.ModifyTime,
+							UseResumeV2:         info.UseResumeV2,
+							ChunkSize:           info.ChunkSize,
+							CacheDir:            workspace.GetJobDir(),
+						},
+						Policy: storage.PutPolicy{},
+					}
+					uploadInfo.TokenProvider = createTokenProviderWithMac(mac, uploadInfo)
+					if _, e := uploadFile(uploadInfo); e != nil {
+						return nil, e
+					}
+				case dirSyncActionDownload:
+					if _, e := download.Download(&download.DownloadActionInfo{
+						Bucket:            work.Bucket,
+						Key:               work.Key,
+						IsPublic:          info.IsPublic,
+						HostProvider:      host.NewListProvider(downloadHosts),
+						ToFile:            work.LocalPath,
+						ServerFilePutTime: work.PutTime,
+						ServerFileSize:    work.FileSize,
+						ServerFileHash:    work.Hash,
+						DownloadFileSize:  work.FileSize,
+						CheckHash:         true,
+						UseGetFileApi:     info.UseGetFileApi,
+					}); e != nil {
+						return nil, e
+					}
+				case dirSyncActionDeleteRemote:
+					result, e := object.Delete(&object.DeleteApiInfo{
+						Bucket: work.Bucket,
+						Key:    work.Key,
+					})
+					if e != nil {
+						return nil, e
+					}
+					if !result.IsSuccess() {
+						return nil, data.NewError(result.Code, result.Error)
+					}
+				case dirSyncActionDeleteLocal:
+					if e := os.Remove(work.LocalPath); e != nil && !os.IsNotExist(e) {
+						return nil, data.NewEmptyError().AppendDescF("delete local file:%s error", work.LocalPath).AppendError(e)
+					}
+				default:
+					return nil, alert.Error("unknown sync action:"+work.Action, "")
+				}
+				return &dirSyncResult{
+					Action: work.Action,
+					Key:    work.Key,
+				}, nil
+			}), nil
+		})).
+		DoWorkListMaxCount(1).
+		DoWorkListMinCount(1).
+		SetOverseerEnable(true).
+		SetDBOverseer(dbPath, func() *flow.WorkRecord {
+			return &flow.WorkRecord{
+				WorkInfo: &flow.WorkInfo{
+					Data: "",
+					Work: &dirSyncWork{},
+				},
+				Result: &dirSyncResult{},
+				Err:    nil,
+			}
+		}).
+		ShouldRedo(func(workInfo *flow.WorkInfo, workRecord *flow.WorkRecord) (shouldRedo bool, cause *data.CodeError) {
+			if workRecord.Err != nil {
+				return true, workRecord.Err
+			}
+			work, _ := workInfo.Work.(*dirSyncWork)
+			recordWork, _ := workRecord.Work.(*dirSyncWork)
+			if recordWork == nil {
+				return true, data.NewEmptyError().AppendDesc("no work record found")
+			}
+			// 同步操作由对比生成，文件信息和记录一致说明此操作已执行过，但由于 list 等原因再次出现，无需重做
+			if *work == *recordWork {
+				return false, nil
+			}
+			return true, data.NewEmptyError().AppendDesc("file has change since last sync")
+		}).
+		FlowWillStartFunc(func(flow *flow.Flow) (err *data.CodeError) {
+			metric.AddTotalCount(flow.WorkProvider.WorkTotalCount())
+			return nil
+		}).
+		OnWorkSkip(func(workInfo *flow.WorkInfo, result flow.Result, err *data.CodeError) {
+			metric.AddCurrentCount(1)
+			work, _ := workInfo.Work.(*dirSyncWork)
+			if err != nil && err.Code == data.ErrorCodeAlreadyDone {
+				if result != nil && result.IsValid() {
+					metric.AddActionCount(work.Action)
+					log.InfoF("Skip %s because have done and success", work)
+				} else {
+					metric.AddFailureCount(1)
+					log.InfoF("Skip %s because have done and failure, %v", work, err)
+				}
+			} else {
+				metric.AddSkippedCount(1)
+				log.InfoF("Skip %s because:%v", work, err)
+				exporter.Skip().Export(work.String())
+			}
+		}).
+		OnWorkSuccess(func(workInfo *flow.WorkInfo, result flow.Result) {
+			work, _ := workInfo.Work.(*dirSyncWork)
+			metric.AddActionCount(work.Action)
+			if work.Action == dirSyncActionUpload || work.Action == dirSyncActionDeleteRemote {
+				refresher.AddKeys(work.Key)
+			}
+			log.InfoF("Sync Success, %s", work)
+			exporter.Success().Export(work.String())
+		}).
+		OnWorkFail(func(workInfo *flow.WorkInfo, err *data.CodeError) {
+			work, _ := workInfo.Work.(*dirSyncWork)
+			metric.AddFailureCount(1)
+			log.ErrorF("Sync Failed, %s error:%v", work, err)
+			exporter.Fail().ExportF("%s%s%v", work, flow.ErrorSeparate, err)
+		}).Build().Start()
+
+	metric.End()
+
+	log.InfoF("job dir:%s, there is a cache related to this command in this folder, which will also be used next time the same command is executed. If you are sure that you don’t need it, you can delete this folder.", workspace.GetJobDir())
+
+	resultPath := filepath.Join(workspace.GetJobDir(), ".result")
+	if e := utils.MarshalToFile(resultPath, metric); e != nil {
+		log.ErrorF("save sync result to path:%s error:%v", resultPath, e)
+	} else {
+		log.DebugF("save sync result to path:%s", resultPath)
+	}
+
+	log.Alert("--------------- Sync Result ----------------")
+	log.AlertF("%20s%10d", "Total:", metric.TotalCount)
+	log.AlertF("%20s%10d", "Upload:", metric.UploadCount)
+	log.AlertF("%20s%10d", "Download:", metric.DownloadCount)
+	log.AlertF("%20s%10d", "DeleteRemote:", metric.DeleteRemoteCount)
+	log.AlertF("%20s%10d", "DeleteLocal:", metric.DeleteLocalCount)
+	log.AlertF("%20s%10d", "Skipped:", metric.SkippedCount)
+	log.AlertF("%20s%10d", "Failure:", metric.FailureCount)
+	log.AlertF("%20s%10ds", "Duration:", metric.Duration)
+	log.AlertF("--------------------------------------------")
+
+	if !metric.IsCompletedSuccessfully() {
+		data.SetCmdStatusError()
+	}
+}
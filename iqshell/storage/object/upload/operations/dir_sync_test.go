@@ -0,0 +1,225 @@
+package operations
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
+)
+
+func TestDirSyncDiffUpload(t *testing.T) {
+	localFiles := map[string]*dirSyncLocalFile{
+		"p/new.txt":    {RelativePath: "new.txt", FileSize: 10, ModifyTime: 100},
+		"p/same.txt":   {RelativePath: "same.txt", FileSize: 10, ModifyTime: 100},
+		"p/size.txt":   {RelativePath: "size.txt", FileSize: 11, ModifyTime: 100},
+		"p/newer.txt":  {RelativePath: "newer.txt", FileSize: 10, ModifyTime: 300},
+		"p/a/deep.txt": {RelativePath: filepath.Join("a", "deep.txt"), FileSize: 1, ModifyTime: 100},
+	}
+	remoteFiles := map[string]bucket.ListObject{
+		"p/same.txt":   {Key: "p/same.txt", Fsize: 10, PutTime: 200},
+		"p/size.txt":   {Key: "p/size.txt", Fsize: 10, PutTime: 200},
+		"p/newer.txt":  {Key: "p/newer.txt", Fsize: 10, PutTime: 200},
+		"p/a/deep.txt": {Key: "p/a/deep.txt", Fsize: 1, PutTime: 200},
+		"p/remote.txt": {Key: "p/remote.txt", Fsize: 1, PutTime: 200},
+	}
+
+	info := dirSyncDiffInfo{
+		Direction: DirSyncDirectionUpload,
+		LocalDir:  "dir",
+		Bucket:    "bucket",
+		Prefix:    "p/",
+	}
+	actions := dirSyncActions(dirSyncDiff(info, localFiles, remoteFiles))
+	expected := map[string]string{
+		"p/new.txt":   dirSyncActionUpload,
+		"p/size.txt":  dirSyncActionUpload,
+		"p/newer.txt": dirSyncActionUpload,
+	}
+	checkDirSyncActions(t, expected, actions)
+
+	info.Delete = true
+	actions = dirSyncActions(dirSyncDiff(info, localFiles, remoteFiles))
+	expected["p/remote.txt"] = dirSyncActionDeleteRemote
+	checkDirSyncActions(t, expected, actions)
+}
+
+func TestDirSyncDiffDownload(t *testing.T) {
+	localFiles := map[string]*dirSyncLocalFile{
+		"same.txt":  {RelativePath: "same.txt", FileSize: 10, ModifyTime: 300},
+		"older.txt": {RelativePath: "older.txt", FileSize: 10, ModifyTime: 100},
+		"local.txt": {RelativePath: "local.txt", FileSize: 10, ModifyTime: 100},
+	}
+	remoteFiles := map[string]bucket.ListObject{
+		"same.txt":   {Key: "same.txt", Fsize: 10, PutTime: 200},
+		"older.txt":  {Key: "older.txt", Fsize: 10, PutTime: 200},
+		"remote.txt": {Key: "remote.txt", Fsize: 1, PutTime: 200},
+	}
+
+	works := dirSyncDiff(dirSyncDiffInfo{
+		Direction: DirSyncDirectionDownload,
+		LocalDir:  "dir",
+		Bucket:    "bucket",
+		Delete:    true,
+	}, localFiles, remoteFiles)
+	checkDirSyncActions(t, map[string]string{
+		"older.txt":  dirSyncActionDownload,
+		"remote.txt": dirSyncActionDownload,
+		"local.txt":  dirSyncActionDeleteLocal,
+	}, dirSyncActions(works))
+
+	for _, w := range works {
+		if w.Key == "remote.txt" && w.LocalPath != filepath.Join("dir", "remote.txt") {
+			t.Fatalf("remote.txt local path error:%s", w.LocalPath)
+		}
+	}
+}
+
+func TestDirSyncDiffCheckHash(t *testing.T) {
+	localFiles := map[string]*dirSyncLocalFile{
+		"same.txt":  {RelativePath: "same.txt", FileSize: 10, ModifyTime: 300},
+		"other.txt": {RelativePath: "other.txt", FileSize: 10, ModifyTime: 100},
+	}
+	remoteFiles := map[string]bucket.ListObject{
+		"same.txt":  {Key: "same.txt", Fsize: 10, PutTime: 200, Hash: "hash"},
+		"other.txt": {Key: "other.txt", Fsize: 10, PutTime: 200, Hash: "hash"},
+	}
+
+	works := dirSyncDiff(dirSyncDiffInfo{
+		Direction: DirSyncDirectionUpload,
+		LocalDir:  "dir",
+		Bucket:    "bucket",
+		LocalFileHash: func(localPath string) (string, *data.CodeError) {
+			if localPath == filepath.Join("dir", "same.txt") {
+				return "hash", nil
+			}
+			return "other", nil
+		},
+	}, localFiles, remoteFiles)
+	checkDirSyncActions(t, map[string]string{
+		"other.txt": dirSyncActionUpload,
+	}, dirSyncActions(works))
+}
+
+func TestDirSyncFilter(t *testing.T) {
+	localFiles := map[string]*dirSyncLocalFile{
+		"a.png":   {RelativePath: "a.png", FileSize: 10},
+		"b.txt":   {RelativePath: "b.txt", FileSize: 10},
+		"c.png":   {RelativePath: "c.png", FileSize: 10},
+		"big.txt": {RelativePath: "big.txt", FileSize: 2048},
+	}
+	remoteFiles := map[string]bucket.ListObject{
+		"a.png": {Key: "a.png", Fsize: 10, MimeType: "image/png"},
+		"b.txt": {Key: "b.txt", Fsize: 10, MimeType: "text/plain"},
+		"d.png": {Key: "d.png", Fsize: 10, MimeType: "image/png"},
+	}
+
+	listFilter, err := bucket.NewListObjectFilter(`mime == "image/png" || size > 1KB`)
+	if err != nil {
+		t.Fatal("create filter error:", err)
+	}
+	dirSyncFilter(listFilter, localFiles, remoteFiles)
+
+	// 本地文件没有 mime 信息，空间文件满足条件时本地文件也保留
+	for _, key := range []string{"a.png", "big.txt"} {
+		if localFiles[key] == nil {
+			t.Fatalf("local file:%s should be kept", key)
+		}
+	}
+	if len(localFiles) != 2 {
+		t.Fatalf("local files should be filtered, but:%v", localFiles)
+	}
+	for _, key := range []string{"a.png", "d.png"} {
+		if _, ok := remoteFiles[key]; !ok {
+			t.Fatalf("remote file:%s should be kept", key)
+		}
+	}
+	if len(remoteFiles) != 2 {
+		t.Fatalf("remote files should be filtered, but:%v", remoteFiles)
+	}
+}
+
+func TestDirSyncRemoveUnsafeKeys(t *testing.T) {
+	remoteFiles := map[string]bucket.ListObject{
+		"p/a.txt":     {Key: "p/a.txt"},
+		"p/dir/b.txt": {Key: "p/dir/b.txt"},
+		"p/../c.txt":  {Key: "p/../c.txt"},
+		"p/dir/../d":  {Key: "p/dir/../d"},
+		"p//e.txt":    {Key: "p//e.txt"},
+		"p/./f.txt":   {Key: "p/./f.txt"},
+		"p/..":        {Key: "p/.."},
+		"p/..g/h.txt": {Key: "p/..g/h.txt"},
+	}
+	unsafeKeys := dirSyncRemoveUnsafeKeys("p/", remoteFiles)
+	expected := []string{"p/..", "p/../c.txt", "p/./f.txt", "p//e.txt", "p/dir/../d"}
+	if len(unsafeKeys) != len(expected) {
+		t.Fatalf("unsafe keys invalid:%v", unsafeKeys)
+	}
+	for i, key := range expected {
+		if unsafeKeys[i] != key {
+			t.Fatalf("unsafe keys invalid:%v", unsafeKeys)
+		}
+		if _, ok := remoteFiles[key]; ok {
+			t.Fatalf("unsafe key:%s should be removed", key)
+		}
+	}
+	if len(remoteFiles) != 3 {
+		t.Fatalf("safe keys should be kept, but:%v", remoteFiles)
+	}
+}
+
+func TestDirSyncJobId(t *testing.T) {
+	info := DirSyncInfo{LocalDir: "/data", Bucket: "b", Direction: DirSyncDirectionDownload}
+	jobId := info.JobId()
+	info.Delete = true
+	if info.JobId() == jobId {
+		t.Fatal("job id should be different with delete")
+	}
+	info.Delete = false
+	info.CheckHash = true
+	if info.JobId() == jobId {
+		t.Fatal("job id should be different with check hash")
+	}
+	info.CheckHash = false
+	info.Filter = "size > 1KB"
+	if info.JobId() == jobId {
+		t.Fatal("job id should be different with filter")
+	}
+}
+
+func TestDirSyncCheckUploadFilter(t *testing.T) {
+	info := DirSyncInfo{LocalDir: t.TempDir(), Bucket: "b", Direction: DirSyncDirectionUpload, Filter: `key glob "*.png" && size > 1KB`}
+	if err := info.Check(); err != nil {
+		t.Fatal("upload sync filter on key and size should be valid, but:", err)
+	}
+
+	// 本地文件没有 mime 信息，上传同步不支持
+	info.Filter = `mime == "image/png"`
+	if err := info.Check(); err == nil {
+		t.Fatal("upload sync filter on mime should be rejected")
+	}
+
+	info.Direction = DirSyncDirectionDownload
+	if err := info.Check(); err != nil {
+		t.Fatal("download sync filter on mime should be valid, but:", err)
+	}
+}
+
+func dirSyncActions(works []*dirSyncWork) map[string]string {
+	actions := make(map[string]string)
+	for _, w := range works {
+		actions[w.Key] = w.Action
+	}
+	return actions
+}
+
+func checkDirSyncActions(t *testing.T, expected map[string]string, actions map[string]string) {
+	if len(expected) != len(actions) {
+		t.Fatalf("action count error, expected:%v but:%v", expected, actions)
+	}
+	for key, action := range expected {
+		if actions[key] != action {
+			t.Fatalf("action of %s error, expected:%s but:%s", key, action, actions[key])
+		}
+	}
+}
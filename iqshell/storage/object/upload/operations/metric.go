@@ -23,3 +23,31 @@ func (m *Metric) AddNotOverwriteCount(count int64) {
 	m.NotOverwriteCount += count
 	m.Unlock()
 }
+
+type DirSyncMetric struct {
+	batch.Metric
+
+	UploadCount       int64 `json:"upload_count"`
+	DownloadCount     int64 `json:"download_count"`
+	DeleteRemoteCount int64 `json:"delete_remote_count"`
+	DeleteLocalCount  int64 `json:"delete_local_count"`
+}
+
+func (m *DirSyncMetric) AddActionCount(action string) {
+	if m == nil {
+		return
+	}
+	m.Lock()
+	switch action {
+	case dirSyncActionUpload:
+		m.UploadCount += 1
+	case dirSyncActionDownload:
+		m.DownloadCount += 1
+	case dirSyncActionDeleteRemote:
+		m.DeleteRemoteCount += 1
+	case dirSyncActionDeleteLocal:
+		m.DeleteLocalCount += 1
+	}
+	m.SuccessCount += 1
+	m.Unlock()
+}
@@ -4,6 +4,7 @@ import (
 	"path/filepath"
 
 	"github.com/qiniu/qshell/v2/iqshell"
+	cdnOperations "github.com/qiniu/qshell/v2/iqshell/cdn/operations"
 	"github.com/qiniu/qshell/v2/iqshell/common/alert"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
@@ -12,7 +13,11 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
 )
 
-type SyncInfo UploadInfo
+type SyncInfo struct {
+	UploadInfo
+
+	CdnRefresh cdnOperations.AutoRefreshInfo // 覆盖上传成功后刷新文件的 CDN 缓存
+}
 
 func (info *SyncInfo) Check() *data.CodeError {
 	if len(info.FilePath) == 0 {
@@ -27,6 +32,9 @@ func (info *SyncInfo) Check() *data.CodeError {
 	if info.Overwrite && len(info.SaveKey) == 0 {
 		return alert.CannotEmptyError("Overwrite mode and Key", "")
 	}
+	if err := info.CdnRefresh.Check(); err != nil {
+		return err
+	}
 	return checkPolicy(&info.Policy)
 }
 
@@ -49,7 +57,7 @@ func SyncFile(cfg *iqshell.Config, info SyncInfo) {
 
 	info.CacheDir = workspace.GetJobDir()
 	info.Progress = progress.NewPrintProgress(" 进度")
-	ret, err := uploadFile((*UploadInfo)(&info))
+	ret, err := uploadFile(&info.UploadInfo)
 	if err != nil {
 		data.SetCmdStatusError()
 		log.ErrorF("Sync file error %v", err)
@@ -60,5 +68,11 @@ func SyncFile(cfg *iqshell.Config, info SyncInfo) {
 		log.AlertF("%10s%s", "Hash: ", ret.ServerFileHash)
 		log.AlertF("%10s%d%s", "Fsize: ", ret.ServerFileSize, "("+utils.FormatFileSize(ret.ServerFileSize)+")")
 		log.AlertF("%10s%s", "MimeType: ", ret.MimeType)
+
+		if ret.IsOverwrite || (info.Overwrite && !ret.IsSkip) {
+			refresher := cdnOperations.NewAutoRefresher(info.CdnRefresh)
+			refresher.AddKeys(ret.Key)
+			refresher.Close()
+		}
 	}
 }
@@ -50,7 +50,7 @@ func (f *formUploader) upload(info *ApiInfo) (ret *ApiResult, err *data.CodeErro
 		}
 	}
 
-	c := client.DefaultStorageClient()
+	c := client.TransferStorageClient()
 	up := storage.NewFormUploaderEx(f.cfg, &c)
 	if e := up.Put(workspace.GetContext(), &ret, token, info.SaveKey, file, fileStatus.Size(), f.ext); e != nil {
 		err = data.NewEmptyError().AppendDesc("form upload").AppendError(e)
@@ -47,7 +47,7 @@ func (r *resumeV1Uploader) upload(info *ApiInfo) (*ApiResult, *data.CodeError) {
 
 	var progress int64 = 0
 	ret := &ApiResult{}
-	c := client.DefaultStorageClient()
+	c := client.TransferStorageClient()
 	up := storage.NewResumeUploaderEx(r.cfg, &c)
 	extra := &storage.RputExtra{
 		Recorder:   recorder,
@@ -47,7 +47,7 @@ func (r *resumeV2Uploader) upload(info *ApiInfo) (*ApiResult, *data.CodeError) {
 
 	var progress int64 = 0
 	ret := &ApiResult{}
-	c := client.DefaultStorageClient()
+	c := client.TransferStorageClient()
 	up := storage.NewResumeUploaderV2Ex(r.cfg, &c)
 	extra := &storage.RputV2Extra{
 		Recorder:   recorder,
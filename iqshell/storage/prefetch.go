@@ -7,8 +7,8 @@ import (
 )
 
 type PrefetchApiInfo struct {
-	Bucket string
-	Key    string
+	Bucket string `json:"bucket"`
+	Key    string `json:"key"`
 }
 
 func Prefetch(info PrefetchApiInfo) *data.CodeError {
@@ -4,6 +4,7 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/output"
 	"github.com/qiniu/qshell/v2/iqshell/storage/servers"
 )
 
@@ -35,6 +36,10 @@ func List(cfg *iqshell.Config, info ListInfo) {
 		return
 	}
 
+	for _, b := range buckets {
+		output.Result(b)
+	}
+
 	if info.Detail {
 		log.AlertF("%s", servers.BucketInfoDetailDescriptionStringFormat())
 		for _, b := range buckets {